@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/services"
+)
+
+// mailedToken matches the token in a verification or password reset email
+var mailedToken = regexp.MustCompile(`(?:address|password): (\S+)`)
+
+// mailedTokenFor returns the token in the last email sent to address
+func (s *testServer) mailedTokenFor(address string) string {
+	s.t.Helper()
+	msg, ok := s.mail.Last(address)
+	if !ok {
+		s.t.Fatalf("no email was sent to %s", address)
+	}
+	match := mailedToken.FindStringSubmatch(msg.Body)
+	if match == nil {
+		s.t.Fatalf("no token in the email to %s: %q", address, msg.Body)
+	}
+	return match[1]
+}
+
+// register signs a user up through the API
+func (s *testServer) register(email, password string) models.User {
+	s.t.Helper()
+	var user models.User
+	s.expect(http.StatusCreated, "POST", "/api/users/register", "",
+		map[string]any{"name": "New User", "email": email, "password": password}, &user)
+	return user
+}
+
+func TestRegistrationIgnoresPrivilegedFields(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	s.expect(http.StatusCreated, "POST", "/api/users/register", "", map[string]any{
+		"name": "Mallory", "email": "mallory@example.com", "password": testPassword,
+		"verified": true, "mfa_enabled": true, "role": authz.RoleAdmin, "permissions": []string{authz.All},
+	}, nil)
+
+	user, err := s.store.Users().GetByEmail(context.Background(), "mallory@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Verified || user.MFAEnabled || user.MFASecret != "" || user.Role != authz.DefaultRole || len(user.Permissions) != 0 {
+		t.Fatalf("registered user = %+v, want an unverified viewer without MFA", user)
+	}
+
+	// Unverified users cannot log in under the default policy
+	s.expect(http.StatusForbidden, "POST", "/api/users/login", "",
+		map[string]string{"email": "mallory@example.com", "password": testPassword}, nil)
+}
+
+func TestRegistrationNeedsAStrongPassword(t *testing.T) {
+	s := newTestServer(t, testConfig{verification: "off"})
+	for _, body := range []map[string]any{
+		{"email": "weak@example.com"},
+		{"email": "weak@example.com", "password": ""},
+		{"email": "weak@example.com", "password": "short1"},
+		{"email": "weak@example.com", "password": "lettersonlylong"},
+		{"email": "weak@example.com", "password": "weak@example.com1"},
+		{"password": testPassword},
+	} {
+		s.expect(http.StatusBadRequest, "POST", "/api/users/register", "", body, nil)
+	}
+
+	// Nobody could register with an empty password, so it logs no one in
+	s.expect(http.StatusUnauthorized, "POST", "/api/users/login", "",
+		map[string]string{"email": "weak@example.com", "password": ""}, nil)
+
+	s.register("strong@example.com", testPassword)
+	s.expect(http.StatusConflict, "POST", "/api/users/register", "",
+		map[string]any{"email": "strong@example.com", "password": testPassword}, nil)
+	s.login("strong@example.com")
+}
+
+func TestEmailVerification(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	s.register("verify@example.com", testPassword)
+	token := s.mailedTokenFor("verify@example.com")
+
+	s.expect(http.StatusBadRequest, "POST", "/api/users/verification/confirm", "", map[string]string{"token": token + "x"}, nil)
+	s.expect(http.StatusNoContent, "POST", "/api/users/verification/confirm", "", map[string]string{"token": token}, nil)
+	s.login("verify@example.com")
+
+	// Confirming again changes nothing
+	s.expect(http.StatusNoContent, "POST", "/api/users/verification/confirm", "", map[string]string{"token": token}, nil)
+}
+
+func TestVerificationLinkOnlyWorksForItsAddress(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	user := s.register("old@example.com", testPassword)
+	token := s.mailedTokenFor("old@example.com")
+
+	stored, err := s.store.Users().Get(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored.Email = "new@example.com"
+	if err := s.store.Users().Save(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+	s.expect(http.StatusBadRequest, "POST", "/api/users/verification/confirm", "", map[string]string{"token": token}, nil)
+}
+
+// Every resend gets 202, so the answer does not tell which accounts exist,
+// are verified or were mailed recently
+func TestResendVerificationRevealsNothing(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	s.createUser("verified@example.com", authz.RoleViewer)
+	user := s.register("pending@example.com", testPassword)
+	sent := len(s.mail.Messages())
+
+	resend := func(email string) {
+		t.Helper()
+		rec := s.expect(http.StatusAccepted, "POST", "/api/users/verification/resend", "", map[string]string{"email": email}, nil)
+		if rec.Header().Get("Retry-After") != "" {
+			t.Errorf("resend to %s has a Retry-After header", email)
+		}
+	}
+	resend("nobody@example.com")
+	resend("verified@example.com")
+	resend("pending@example.com") // too soon after registering
+	if got := len(s.mail.Messages()); got != sent {
+		t.Fatalf("%d emails sent by ignored resends", got-sent)
+	}
+
+	// Once the interval has passed a new link goes out
+	earlier := time.Now().Add(-time.Hour)
+	if err := s.store.Users().Update(context.Background(), user.ID, map[string]interface{}{"verification_sent_at": earlier}); err != nil {
+		t.Fatal(err)
+	}
+	resend("pending@example.com")
+	if got := len(s.mail.Messages()); got != sent+1 {
+		t.Fatalf("%d emails sent by the resend, want 1", got-sent)
+	}
+	s.expect(http.StatusNoContent, "POST", "/api/users/verification/confirm", "",
+		map[string]string{"token": s.mailedTokenFor("pending@example.com")}, nil)
+
+	s.expect(http.StatusBadRequest, "POST", "/api/users/verification/resend", "", map[string]string{}, nil)
+}
+
+func TestPasswordReset(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	s.createUser("reset@example.com", authz.RoleViewer)
+	session := s.login("reset@example.com")
+
+	// Unknown addresses get the same answer and no email
+	s.expect(http.StatusAccepted, "POST", "/api/users/password-reset/request", "", map[string]string{"email": "nobody@example.com"}, nil)
+	if len(s.mail.Messages()) != 0 {
+		t.Fatal("an email was sent to an unknown address")
+	}
+
+	s.expect(http.StatusAccepted, "POST", "/api/users/password-reset/request", "", map[string]string{"email": "reset@example.com"}, nil)
+	token := s.mailedTokenFor("reset@example.com")
+
+	const newPassword = "new-password-2026"
+	s.expect(http.StatusBadRequest, "POST", "/api/users/password-reset/confirm", "",
+		map[string]string{"token": token, "password": "weak"}, nil)
+	s.expect(http.StatusNoContent, "POST", "/api/users/password-reset/confirm", "",
+		map[string]string{"token": token, "password": newPassword}, nil)
+
+	// The reset ends existing sessions, and the token works once
+	s.expect(http.StatusUnauthorized, "GET", "/api/profile", session, nil, nil)
+	s.expect(http.StatusBadRequest, "POST", "/api/users/password-reset/confirm", "",
+		map[string]string{"token": token, "password": "another-password-1"}, nil)
+
+	s.expect(http.StatusUnauthorized, "POST", "/api/users/login", "",
+		map[string]string{"email": "reset@example.com", "password": testPassword}, nil)
+	s.expect(http.StatusOK, "POST", "/api/users/login", "",
+		map[string]string{"email": "reset@example.com", "password": newPassword}, nil)
+}
+
+func TestPasswordResetReplacesEarlierTokens(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	s.createUser("twice@example.com", authz.RoleViewer)
+
+	s.expect(http.StatusAccepted, "POST", "/api/users/password-reset/request", "", map[string]string{"email": "twice@example.com"}, nil)
+	first := s.mailedTokenFor("twice@example.com")
+	s.expect(http.StatusAccepted, "POST", "/api/users/password-reset/request", "", map[string]string{"email": "twice@example.com"}, nil)
+	second := s.mailedTokenFor("twice@example.com")
+
+	s.expect(http.StatusBadRequest, "POST", "/api/users/password-reset/confirm", "",
+		map[string]string{"token": first, "password": "new-password-2026"}, nil)
+	s.expect(http.StatusNoContent, "POST", "/api/users/password-reset/confirm", "",
+		map[string]string{"token": second, "password": "new-password-2026"}, nil)
+}
+
+// Changing a password ends every session, refresh tokens included
+func TestPasswordChangeEndsSessions(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	s.createUser("change@example.com", authz.RoleViewer)
+	var other services.TokenPair
+	s.expect(http.StatusOK, "POST", "/api/users/login", "",
+		map[string]string{"email": "change@example.com", "password": testPassword}, &other)
+	session := s.login("change@example.com")
+
+	const newPassword = "new-password-2026"
+	s.expect(http.StatusBadRequest, "PUT", "/api/profile/password", session, map[string]string{"password": "weak"}, nil)
+	s.expect(http.StatusOK, "PUT", "/api/profile/password", session, map[string]string{"password": newPassword}, nil)
+
+	s.expect(http.StatusUnauthorized, "GET", "/api/profile", session, nil, nil)
+	s.expect(http.StatusUnauthorized, "GET", "/api/profile", other.AccessToken, nil, nil)
+	s.expect(http.StatusUnauthorized, "POST", "/api/users/refresh", "", map[string]string{"refresh_token": other.RefreshToken}, nil)
+
+	s.expect(http.StatusUnauthorized, "POST", "/api/users/login", "",
+		map[string]string{"email": "change@example.com", "password": testPassword}, nil)
+	s.expect(http.StatusOK, "POST", "/api/users/login", "",
+		map[string]string{"email": "change@example.com", "password": newPassword}, nil)
+}
+
+// A profile update changes only the fields sent, and a new email address
+// keeps the user out until it is confirmed
+func TestProfileEmailChangeNeedsVerification(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	user := s.createUser("before@example.com", authz.RoleViewer)
+	s.createUser("taken@example.com", authz.RoleViewer)
+	session := s.login("before@example.com")
+
+	var profile models.User
+	s.expect(http.StatusOK, "PUT", "/api/profile", session, map[string]string{"phone": "555-0100"}, &profile)
+	if profile.Name != user.Name || profile.Email != "before@example.com" || profile.Phone != "555-0100" || !profile.Verified {
+		t.Fatalf("profile after a phone change = %+v, want only the phone changed", profile)
+	}
+
+	s.expect(http.StatusBadRequest, "PUT", "/api/profile", session, map[string]string{"email": ""}, nil)
+	s.expect(http.StatusConflict, "PUT", "/api/profile", session, map[string]string{"email": "taken@example.com"}, nil)
+
+	s.expect(http.StatusOK, "PUT", "/api/profile", session, map[string]string{"email": "after@example.com"}, &profile)
+	if profile.Email != "after@example.com" || profile.Verified || profile.Phone != "555-0100" {
+		t.Fatalf("profile after an email change = %+v, want the new address unverified", profile)
+	}
+
+	// The change ends the session, and the block policy refuses the login
+	s.expect(http.StatusUnauthorized, "GET", "/api/profile", session, nil, nil)
+	s.expect(http.StatusForbidden, "POST", "/api/users/login", "",
+		map[string]string{"email": "after@example.com", "password": testPassword}, nil)
+
+	token := s.mailedTokenFor("after@example.com")
+	s.expect(http.StatusNoContent, "POST", "/api/users/verification/confirm", "", map[string]string{"token": token}, nil)
+	s.login("after@example.com")
+}
+
+// An administrator changing a user's email leaves it unverified too
+func TestAdminEmailChangeNeedsVerification(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	user := s.createUser("staff@example.com", authz.RoleStaff)
+
+	s.expect(http.StatusBadRequest, "PUT", "/api/users/"+itoa(user.ID), admin, map[string]string{"name": "Staff"}, nil)
+	s.expect(http.StatusNotFound, "PUT", "/api/users/999", admin, map[string]string{"email": "ghost@example.com"}, nil)
+	s.expect(http.StatusConflict, "PUT", "/api/users/"+itoa(user.ID), admin, map[string]string{"email": "admin@example.com"}, nil)
+	s.expect(http.StatusOK, "PUT", "/api/users/"+itoa(user.ID), admin,
+		map[string]string{"name": "Staff", "email": "moved@example.com"}, nil)
+
+	s.expect(http.StatusForbidden, "POST", "/api/users/login", "",
+		map[string]string{"email": "moved@example.com", "password": testPassword}, nil)
+}
+
+// An account flagged for MFA without a secret has no second factor to
+// check, so it logs in with its password and can enroll properly
+func TestMFAFlagWithoutSecretIsNotEnrolled(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	user := s.createUser("half@example.com", authz.RoleViewer)
+	if err := s.db.Model(&models.User{}).Where("id = ?", user.ID).Update("mfa_enabled", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+		MFARequired bool   `json:"mfa_required"`
+	}
+	s.expect(http.StatusOK, "POST", "/api/users/login", "",
+		map[string]string{"email": "half@example.com", "password": testPassword}, &login)
+	if login.MFARequired || login.AccessToken == "" {
+		t.Fatalf("login = %+v, want tokens without an MFA challenge", login)
+	}
+
+	// Starting enrollment does not turn MFA on before a code confirms it
+	s.expect(http.StatusOK, "POST", "/api/users/mfa/enroll", login.AccessToken, nil, nil)
+	if s.login("half@example.com") == "" {
+		t.Fatal("login after starting enrollment asked for an MFA code")
+	}
+}
+
+// An API key acts as its owner, so the block policy turns it away while the
+// owner's email address is unverified, as it would the owner's login
+func TestAPIKeysOfUnverifiedUsersAreBlocked(t *testing.T) {
+	for _, test := range []struct {
+		verification string
+		unverified   int
+	}{
+		{"block", http.StatusUnauthorized},
+		{"off", http.StatusOK},
+	} {
+		s := newTestServer(t, testConfig{verification: test.verification})
+		admin := s.adminToken()
+		user := s.createUser("keyed@example.com", authz.RoleStaff)
+		session := s.login("keyed@example.com")
+
+		var issued services.IssuedAPIKey
+		s.expect(http.StatusCreated, "POST", "/api/api-keys", admin, map[string]any{
+			"name": "sync", "user_id": user.ID, "permissions": []string{authz.InventoryRead},
+		}, &issued)
+		s.expect(http.StatusOK, "GET", "/api/inventory", issued.Key, nil, nil)
+
+		s.expect(http.StatusOK, "PUT", "/api/profile", session, map[string]string{"email": "rekeyed@example.com"}, nil)
+		rec := s.expect(test.unverified, "GET", "/api/inventory", issued.Key, nil, nil)
+		if test.unverified == http.StatusUnauthorized && !strings.Contains(rec.Body.String(), services.ErrEmailNotVerified.Error()) {
+			t.Errorf("%s: response to an unverified owner's key = %q, want it to say why", test.verification, rec.Body.String())
+		}
+
+		token := s.mailedTokenFor("rekeyed@example.com")
+		s.expect(http.StatusNoContent, "POST", "/api/users/verification/confirm", "", map[string]string{"token": token}, nil)
+		s.expect(http.StatusOK, "GET", "/api/inventory", issued.Key, nil, nil)
+	}
+}
@@ -6,19 +6,20 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	// Import the docs generated by Swag
 	_ "inventory-supply-chain-system/cmd/docs"
 
-	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
-	httpSwagger "github.com/swaggo/http-swagger"
 
-	"inventory-supply-chain-system/controllers"
 	"inventory-supply-chain-system/db"
-	"inventory-supply-chain-system/internal/middlewares"
-	"inventory-supply-chain-system/routes"
+	"inventory-supply-chain-system/pkg/notifier"
+	"inventory-supply-chain-system/pkg/oidc"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
 )
 
 // @title Enterprise Inventory and Supply Chain Management System API
@@ -33,59 +34,83 @@ func main() {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
+	// "migrate <command>" manages the database schema instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("Error migrating the database: %v", err)
+		}
+		return
+	}
+
 	// Log environment variables for debugging
 	log.Println("DB_HOST:", os.Getenv("DB_HOST"))
 	log.Println("DB_USER:", os.Getenv("DB_USER"))
 	log.Println("DB_NAME:", os.Getenv("DB_NAME"))
 	log.Println("DB_PORT:", os.Getenv("DB_PORT"))
 
+	// Load the token signing and verification keys
+	if err := utils.LoadKeys(); err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+
 	// Initialize the database connection
-	db.ConnectDB()
-
-	// Create a new router
-	r := mux.NewRouter()
-
-	// Public routes
-	// r.HandleFunc("/api/auth/register", controllers.RegisterUser).Methods("POST")
-	r.HandleFunc("/api/users/login", controllers.LoginUser).Methods("POST")
-	r.HandleFunc("/api/users/register", controllers.RegisterUser).Methods("POST")
-
-	// Swagger route for API docs
-	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
-
-	// Protected routes (Require authentication)
-	api := r.PathPrefix("/api").Subrouter()
-	api.Use(middlewares.AuthMiddleware)
-
-	// Register protected routes
-	routes.RegisterItemRoutes(api)
-	routes.RegisterProductRoutes(api)
-	routes.RegisterProfileRoutes(api)
-	routes.RegisterSupplierRoutes(api)
-	routes.RegisterOrderRoutes(api)
-	routes.RegisterInventoryRoutes(api)
-	routes.RegisterShipmentRoutes(api)
-	routes.RegisterVendorRoutes(api)
-	routes.RegisterUserRoutes(api)
-
-	// Serve static files
-	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
-	r.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "static/index.html")
-	})
-
-	// Handle 404
-	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "static/404.html")
-	})
-
-	// Handle 405
-	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "static/405.html")
-	})
-
-	// Middleware to handle panics and recover with error pages
-	r.Use(recoverMiddleware)
+	// Refuse to serve from an outdated schema unless asked to migrate it first
+	database, err := db.ConnectDB(os.Getenv("MIGRATE_ON_START") == "true")
+	if err != nil {
+		log.Fatalf("Error opening the database: %v", err)
+	}
+	log.Println("Connected to the database and verified the schema successfully!")
+	store := repository.NewStore(database)
+
+	// Deliver password reset and verification emails through the configured notifier
+	n, err := notifier.FromEnv()
+	if err != nil {
+		log.Fatalf("Error configuring notifier: %v", err)
+	}
+
+	// Decide what users who have not verified their email can do, and which
+	// roles, e.g. admin,manager, must enroll in MFA
+	policy, err := services.NewAccountPolicy(os.Getenv("VERIFICATION_POLICY"), strings.Split(os.Getenv("MFA_REQUIRED_ROLES"), ","))
+	if err != nil {
+		log.Fatalf("Error configuring account policy: %v", err)
+	}
+
+	// Single sign-on through an OpenID Connect provider
+	var provider *oidc.Provider
+	var mapping services.OIDCMapping
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		provider, err = oidc.Discover(ctx, oidc.Config{
+			Issuer:       issuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+			GroupsClaim:  os.Getenv("OIDC_GROUPS_CLAIM"),
+		})
+		cancel()
+		if err != nil {
+			log.Fatalf("Error configuring single sign-on: %v", err)
+		}
+		mapping, err = services.ParseOIDCMapping(os.Getenv("OIDC_ROLE_MAP"), os.Getenv("OIDC_PERMISSION_MAP"))
+		if err != nil {
+			log.Fatalf("Error configuring single sign-on: %v", err)
+		}
+	}
+
+	// Promote or create the bootstrap admin so roles can be assigned on a fresh install
+	if email := os.Getenv("ADMIN_EMAIL"); email != "" {
+		if err := services.NewUserService(store).BootstrapAdmin(context.Background(), email, os.Getenv("ADMIN_PASSWORD")); err != nil {
+			log.Fatalf("Error bootstrapping admin: %v", err)
+		}
+		log.Println("Admin account ready:", email)
+	}
+
+	// Assemble the services and controllers and register the routes
+	r, err := newRouter(store, n, policy, provider, mapping)
+	if err != nil {
+		log.Fatalf("Error verifying route policies: %v", err)
+	}
 
 	// Create the server
 	srv := &http.Server{
@@ -117,16 +142,3 @@ func main() {
 
 	log.Println("ISCS Server exiting")
 }
-
-// recoverMiddleware handles panics and recovers with appropriate error pages.
-func recoverMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				log.Printf("I have recovered from panic: %v", rec)
-				http.ServeFile(w, r, "static/500.html")
-			}
-		}()
-		next.ServeHTTP(w, r)
-	})
-}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"inventory-supply-chain-system/db"
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/pkg/notifier"
+	"inventory-supply-chain-system/pkg/oidc"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testPassword is the password of every user the tests create
+const testPassword = "correct-horse-42"
+
+func TestMain(m *testing.M) {
+	// Sign test tokens with HS256 and keep the request logs out of test output
+	os.Setenv("JWT_SIGNING_ALG", "HS256")
+	os.Setenv("JWT_SECRET", "integration-test-secret")
+	if err := utils.LoadKeys(); err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testConfig is the part of the server configuration a test can change
+type testConfig struct {
+	verification string
+	mfaRoles     []string
+	provider     *oidc.Provider
+	mapping      services.OIDCMapping
+}
+
+// testServer is the application's router over its own migrated in-memory
+// SQLite database. Emails are kept in mail, and db reaches the tables
+// directly for what the API cannot set up, such as stock that has expired.
+type testServer struct {
+	t      *testing.T
+	db     *gorm.DB
+	store  repository.Store
+	policy *services.AccountPolicy
+	mail   *notifier.Memory
+	router http.Handler
+}
+
+// newTestServer opens sqlite::memory:, applies every migration and builds
+// the router the way main does
+func newTestServer(t *testing.T, config testConfig) *testServer {
+	t.Helper()
+	database, err := db.Open("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("opening the database: %v", err)
+	}
+	database.Logger = logger.Discard
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrating the database: %v", err)
+	}
+
+	policy, err := services.NewAccountPolicy(config.verification, config.mfaRoles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := repository.NewStore(database)
+	mail := &notifier.Memory{}
+	router, err := newRouter(store, mail, policy, config.provider, config.mapping)
+	if err != nil {
+		t.Fatalf("building the router: %v", err)
+	}
+	return &testServer{t: t, db: database, store: store, policy: policy, mail: mail, router: router}
+}
+
+// request builds a request with body encoded as JSON, authenticated with
+// token when it is not empty
+func (s *testServer) request(method, path, token string, body any) *http.Request {
+	s.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// serve runs a request through the router
+func (s *testServer) serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// do sends a JSON request and returns the response
+func (s *testServer) do(method, path, token string, body any) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.serve(s.request(method, path, token, body))
+}
+
+// expect sends a JSON request, fails the test unless it gets status, and
+// decodes the response into out when out is not nil
+func (s *testServer) expect(status int, method, path, token string, body, out any) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.expectRequest(status, s.request(method, path, token, body), out)
+}
+
+// expectRequest is expect for a request the test has built, e.g. to add
+// headers
+func (s *testServer) expectRequest(status int, req *http.Request, out any) *httptest.ResponseRecorder {
+	s.t.Helper()
+	rec := s.serve(req)
+	if rec.Code != status {
+		s.t.Fatalf("%s %s = %d %s, want %d", req.Method, req.URL, rec.Code, bytes.TrimSpace(rec.Body.Bytes()), status)
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decoding %q: %v", req.Method, req.URL, rec.Body.String(), err)
+		}
+	}
+	return rec
+}
+
+// createUser stores a verified user with testPassword and the given role
+func (s *testServer) createUser(email, role string) models.User {
+	s.t.Helper()
+	user := models.User{Name: email, Email: email, Password: testPassword, Role: role, Verified: true}
+	if err := services.NewUserService(s.store).CreateUser(context.Background(), &user); err != nil {
+		s.t.Fatalf("creating %s: %v", email, err)
+	}
+	return user
+}
+
+// login logs a user in with testPassword and returns their access token
+func (s *testServer) login(email string) string {
+	s.t.Helper()
+	var tokens services.TokenPair
+	s.expect(http.StatusOK, "POST", "/api/users/login", "",
+		map[string]string{"email": email, "password": testPassword}, &tokens)
+	return tokens.AccessToken
+}
+
+// sessionToken starts a session for user without checking a password and
+// returns its access token
+func (s *testServer) sessionToken(user models.User) string {
+	s.t.Helper()
+	current, err := s.store.Users().Get(context.Background(), user.ID)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	tokens, err := services.NewSessionService(s.store, s.policy).StartSession(context.Background(), *current, "test", "192.0.2.1")
+	if err != nil {
+		s.t.Fatalf("starting a session for %s: %v", user.Email, err)
+	}
+	return tokens.AccessToken
+}
+
+// userToken creates a user with role and logs them in
+func (s *testServer) userToken(email, role string) string {
+	s.t.Helper()
+	s.createUser(email, role)
+	return s.login(email)
+}
+
+// adminToken creates an admin and logs them in
+func (s *testServer) adminToken() string {
+	s.t.Helper()
+	return s.userToken("admin@example.com", authz.RoleAdmin)
+}
+
+// itoa formats an ID for a URL path
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/pkg/oidc"
+	"inventory-supply-chain-system/pkg/oidc/oidctest"
+	"inventory-supply-chain-system/services"
+)
+
+func TestSingleSignOnThroughTheRouter(t *testing.T) {
+	mock, err := oidctest.NewProvider("iscs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+	mock.SetUser(map[string]interface{}{
+		"sub": "sso-1", "email": "sso@example.com", "email_verified": true, "groups": []string{"warehouse"},
+	})
+
+	provider, err := oidc.Discover(context.Background(), oidc.Config{
+		Issuer:      mock.Issuer(),
+		ClientID:    mock.ClientID,
+		RedirectURL: "http://iscs.test/api/users/oidc/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t, testConfig{
+		provider: provider,
+		mapping:  services.OIDCMapping{Roles: map[string]string{"warehouse": authz.RoleStaff}},
+	})
+
+	rec := s.expect(http.StatusFound, "GET", "/api/users/oidc/login", "", nil, nil)
+	back, err := mock.Login(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tokens services.TokenPair
+	s.expect(http.StatusOK, "GET", "/api/users/oidc/callback?"+back.RawQuery, "", nil, &tokens)
+
+	// The provisioned staff user can place orders but not manage users
+	s.expect(http.StatusOK, "GET", "/api/orders", tokens.AccessToken, nil, nil)
+	s.expect(http.StatusForbidden, "GET", "/api/users", tokens.AccessToken, nil, nil)
+
+	// The state was used up by the first callback
+	s.expect(http.StatusBadRequest, "GET", "/api/users/oidc/callback?"+back.RawQuery, "", nil, nil)
+}
+
+func TestSingleSignOnIsOffWithoutAProvider(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	s.expect(http.StatusNotFound, "GET", "/api/users/oidc/login", "", nil, nil)
+}
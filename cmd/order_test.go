@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+)
+
+// placedOrder places an order for quantity units and backdates it to created
+func (s *testServer) placedOrder(token string, item models.Inventory, quantity int, status string, created time.Time) models.Order {
+	s.t.Helper()
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/api/orders", token,
+		map[string]any{"inventory_id": item.ID, "quantity": quantity}, &order)
+	if err := s.db.Model(&models.Order{}).Where("id = ?", order.ID).
+		Updates(map[string]any{"status": status, "created_at": created}).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return order
+}
+
+// orderIDs lists the IDs of the orders a listing returns, in order
+func (s *testServer) orderIDs(token, path string) []uint {
+	s.t.Helper()
+	var page struct {
+		Data []models.Order `json:"data"`
+	}
+	s.expect(http.StatusOK, "GET", path, token, nil, &page)
+	ids := make([]uint, 0, len(page.Data))
+	for _, order := range page.Data {
+		ids = append(ids, order.ID)
+	}
+	return ids
+}
+
+func TestOrderQueries(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	staffUser := s.createUser("staff@example.com", authz.RoleStaff)
+	staff := s.login("staff@example.com")
+	item, _ := s.stockedItem(admin, "Q-1", 2, 100)
+
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	jan30 := s.placedOrder(admin, item, 1, models.OrderStatusPending, at(time.January, 30, 9))
+	jan31 := s.placedOrder(admin, item, 5, models.OrderStatusCancelled, at(time.January, 31, 23))
+	feb1 := s.placedOrder(staff, item, 10, models.OrderStatusPending, at(time.February, 1, 0))
+	feb2 := s.placedOrder(staff, item, 20, models.OrderStatusShipped, at(time.February, 2, 12))
+
+	for _, test := range []struct {
+		query string
+		want  []uint
+	}{
+		{"/api/orders", []uint{jan30.ID, jan31.ID, feb1.ID, feb2.ID}},
+		{"/api/orders?status=pending,shipped", []uint{jan30.ID, feb1.ID, feb2.ID}},
+		{"/api/orders?status=pending&status=cancelled", []uint{jan30.ID, jan31.ID, feb1.ID}},
+		{"/api/orders/status/pending", []uint{jan30.ID, feb1.ID}},
+		{"/api/orders/status/pending?status=pending,shipped", []uint{jan30.ID, feb1.ID}},
+		{"/api/orders/customer/" + itoa(staffUser.ID), []uint{feb1.ID, feb2.ID}},
+		{"/api/orders/customer/" + itoa(staffUser.ID) + "/status/pending", []uint{feb1.ID}},
+		{"/api/orders?min_total=10&max_total=20", []uint{jan31.ID, feb1.ID}},
+
+		// A date-only end takes in the whole day, up to the next midnight
+		{"/api/orders?end=2026-01-31", []uint{jan30.ID, jan31.ID}},
+		{"/api/orders?start=2026-01-31&end=2026-02-01", []uint{jan31.ID, feb1.ID}},
+		{"/api/orders/date-range?start=2026-02-01&end=2026-02-01", []uint{feb1.ID}},
+		{"/api/orders/status/pending/date-range?start=2026-01-31&end=2026-02-02", []uint{feb1.ID}},
+
+		// A timestamp end is the last instant included
+		{"/api/orders?end=" + url.QueryEscape("2026-02-01T00:00:00Z"), []uint{jan30.ID, jan31.ID, feb1.ID}},
+		{"/api/orders?end=" + url.QueryEscape("2026-01-31T22:59:59Z"), []uint{jan30.ID}},
+		{"/api/orders?start=" + url.QueryEscape("2026-01-31T23:00:00+00:00") + "&end=" + url.QueryEscape("2026-02-01T00:00:00Z"), []uint{jan31.ID, feb1.ID}},
+	} {
+		if got := s.orderIDs(admin, test.query); !slices.Equal(got, test.want) {
+			t.Errorf("GET %s = orders %v, want %v", test.query, got, test.want)
+		}
+	}
+
+	for _, query := range []string{
+		"/api/orders/status/pending?status=shipped",
+		"/api/orders?start=yesterday",
+		"/api/orders?end=2026-02-30",
+		"/api/orders?min_total=lots",
+		"/api/orders?customer_id=-1",
+	} {
+		s.expect(http.StatusBadRequest, "GET", query, admin, nil, nil)
+	}
+}
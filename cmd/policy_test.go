@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/routes"
+	"inventory-supply-chain-system/services"
+)
+
+// pathVariable matches a {name} or {name:pattern} path variable
+var pathVariable = regexp.MustCompile(`\{[^{}:]+(:[^{}]+)?\}`)
+
+// missingID fills every path variable. No record has it, so handlers that
+// are let through answer without changing anything.
+const missingID = "999999"
+
+// policyRoute is a protected route and the permission it declares
+type policyRoute struct {
+	method, path, permission string
+}
+
+// protectedRoutes lists the routes registered with a policy, with their
+// path and query variables filled in
+func protectedRoutes(t *testing.T, s *testServer) []policyRoute {
+	t.Helper()
+	router := s.router.(*mux.Router)
+	policies, _ := routes.RoutePolicies(router)
+
+	var protected []policyRoute
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		url := pathVariable.ReplaceAllString(path, missingID)
+		if queries, err := route.GetQueriesTemplates(); err == nil && len(queries) > 0 {
+			url += "?" + pathVariable.ReplaceAllString(strings.Join(queries, "&"), missingID)
+		}
+		for _, method := range methods {
+			if permission, ok := policies[method+" "+path]; ok {
+				protected = append(protected, policyRoute{method, url, permission})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(protected) < 100 {
+		t.Fatalf("found only %d protected routes", len(protected))
+	}
+	return protected
+}
+
+// permissionUser creates a verified user holding just permission
+func permissionUser(s *testServer, email, permission string) models.User {
+	s.t.Helper()
+	user := s.createUser(email, "")
+	if permission != "" {
+		user.Permissions = models.StringArray{permission}
+		if err := s.store.Users().Save(context.Background(), &user); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	return user
+}
+
+// publicRoutes are the routes anyone may call, as "METHOD path"
+var publicRoutes = map[string]bool{
+	"POST /api/users/login":                  true,
+	"POST /api/users/register":               true,
+	"POST /api/users/login/mfa":              true,
+	"GET /api/users/oidc/login":              true,
+	"GET /api/users/oidc/callback":           true,
+	"POST /api/users/refresh":                true,
+	"POST /api/users/password-reset/request": true,
+	"POST /api/users/password-reset/confirm": true,
+	"POST /api/users/verification/confirm":   true,
+	"POST /api/users/verification/resend":    true,
+	"GET /.well-known/jwks.json":             true,
+	"ANY /swagger/":                          true,
+	"ANY /static/":                           true,
+	"ANY /":                                  true,
+}
+
+// Every route is either public or guarded by a permission the authz
+// package knows
+func TestEveryRouteIsPublicOrGuarded(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	policies, _ := routes.RoutePolicies(s.router.(*mux.Router))
+
+	err := s.router.(*mux.Router).Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"ANY"}
+		}
+		for _, method := range methods {
+			key := method + " " + path
+			permission, guarded := policies[key]
+			switch {
+			case !guarded && !publicRoutes[key]:
+				t.Errorf("%s has no authorization policy", key)
+			case guarded && publicRoutes[key]:
+				t.Errorf("%s is meant to be public", key)
+			case guarded && permission != "" && !authz.IsPermission(permission):
+				t.Errorf("%s requires unknown permission %q", key, permission)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Every protected route answers 401 without credentials, 403 to a user
+// without its permission and lets a user holding it through
+func TestRoutePoliciesAreEnforced(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	nobody := s.sessionToken(permissionUser(s, "nobody@example.com", ""))
+
+	// Routes that only need a signed-in user are closed to API keys instead
+	owner := s.createUser("key-owner@example.com", authz.RoleAdmin)
+	key, err := services.NewAPIKeyService(s.store, s.policy).
+		IssueAPIKey(context.Background(), services.APIKeyRequest{Name: "policy test", Permissions: []string{authz.InventoryRead}}, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	holders := make(map[string]models.User)
+	for _, route := range protectedRoutes(t, s) {
+		name := route.method + " " + route.path
+		if rec := s.do(route.method, route.path, "", nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s without credentials = %d, want 401", name, rec.Code)
+		}
+
+		denied := nobody
+		if route.permission == "" {
+			denied = key.Key
+		}
+		if rec := s.do(route.method, route.path, denied, map[string]any{}); rec.Code != http.StatusForbidden {
+			t.Errorf("%s without %q = %d, want 403", name, route.permission, rec.Code)
+		}
+
+		// A fresh session per request, as some routes end the caller's sessions
+		holder, ok := holders[route.permission]
+		if !ok {
+			holder = permissionUser(s, "holder-"+strings.ReplaceAll(route.permission, ":", "-")+"@example.com", route.permission)
+			holders[route.permission] = holder
+		}
+		rec := s.do(route.method, route.path, s.sessionToken(holder), map[string]any{})
+		if rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+			t.Errorf("%s with %q = %d %s, want it let through", name, route.permission, rec.Code, strings.TrimSpace(rec.Body.String()))
+		}
+	}
+}
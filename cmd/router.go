@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	httpSwagger "github.com/swaggo/http-swagger"
+
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/middlewares"
+	"inventory-supply-chain-system/pkg/notifier"
+	"inventory-supply-chain-system/pkg/oidc"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/routes"
+	"inventory-supply-chain-system/services"
+)
+
+// newRouter assembles the services and controllers over store and registers
+// every route. provider is nil when single sign-on is not configured. It
+// fails if a protected route was registered without an authorization policy.
+func newRouter(store repository.Store, n notifier.Notifier, policy *services.AccountPolicy,
+	provider *oidc.Provider, mapping services.OIDCMapping) (*mux.Router, error) {
+	// Assemble the services
+	users := services.NewUserService(store)
+	logins := services.NewLoginService(store)
+	sessions := services.NewSessionService(store, policy)
+	mfa := services.NewMFAService(store, policy, logins)
+	apiKeys := services.NewAPIKeyService(store, policy)
+
+	// Reject access tokens whose session was revoked or whose user's tokens were invalidated
+	utils.SetRevocationCheck(sessions.CheckTokenRevocation)
+
+	// Assemble the controllers
+	verification := services.NewVerificationService(store, n)
+	auth := controllers.NewAuthController(users, logins, sessions, mfa,
+		services.NewPasswordService(store, n),
+		verification,
+		services.NewOIDCService(store, provider, mapping))
+
+	// Create a new router
+	r := mux.NewRouter()
+
+	// Public routes
+	// r.HandleFunc("/api/auth/register", auth.RegisterUser).Methods("POST")
+	r.HandleFunc("/api/users/login", auth.LoginUser).Methods("POST")
+	r.HandleFunc("/api/users/register", auth.RegisterUser).Methods("POST")
+	r.HandleFunc("/api/users/login/mfa", auth.CompleteMFALogin).Methods("POST")
+	r.HandleFunc("/api/users/oidc/login", auth.OIDCLogin).Methods("GET")
+	r.HandleFunc("/api/users/oidc/callback", auth.OIDCCallback).Methods("GET")
+	r.HandleFunc("/api/users/refresh", auth.RefreshToken).Methods("POST")
+	r.HandleFunc("/api/users/password-reset/request", auth.RequestPasswordReset).Methods("POST")
+	r.HandleFunc("/api/users/password-reset/confirm", auth.ConfirmPasswordReset).Methods("POST")
+	r.HandleFunc("/api/users/verification/confirm", auth.ConfirmEmail).Methods("POST")
+	r.HandleFunc("/api/users/verification/resend", auth.ResendVerification).Methods("POST")
+	r.HandleFunc("/.well-known/jwks.json", auth.JWKS).Methods("GET")
+
+	// Swagger route for API docs
+	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+
+	// Protected routes (Require authentication)
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(middlewares.AuthMiddleware(apiKeyAuthenticator{apiKeys}))
+
+	// Register protected routes
+	routes.RegisterCategoryRoutes(api, controllers.NewCategoryController(services.NewCategoryService(store)))
+	routes.RegisterUnitRoutes(api, controllers.NewUnitController(services.NewUnitService(store)))
+	routes.RegisterSKURoutes(api, controllers.NewSKUController(services.NewSKUService(store)))
+	routes.RegisterProductRoutes(api, controllers.NewProductController(services.NewProductService(store)))
+	routes.RegisterProfileRoutes(api, controllers.NewProfileController(users, verification))
+	routes.RegisterSupplierRoutes(api, controllers.NewSupplierController(services.NewSupplierService(store)))
+	routes.RegisterOrderRoutes(api, controllers.NewOrderController(services.NewOrderService(store)))
+	routes.RegisterInventoryRoutes(api, controllers.NewInventoryController(services.NewInventoryService(store)))
+	routes.RegisterSerialRoutes(api, controllers.NewSerialController(services.NewSerialService(store)))
+	routes.RegisterWarehouseRoutes(api, controllers.NewWarehouseController(services.NewWarehouseService(store)))
+	routes.RegisterTransferRoutes(api, controllers.NewTransferController(services.NewTransferService(store)))
+	routes.RegisterShipmentRoutes(api, controllers.NewShipmentController(services.NewShipmentService(store)))
+	routes.RegisterVendorRoutes(api, controllers.NewVendorController(services.NewVendorService(store.Vendors())))
+	routes.RegisterUserRoutes(api, controllers.NewUserController(users, logins), auth)
+	routes.RegisterAPIKeyRoutes(api, controllers.NewAPIKeyController(apiKeys))
+	routes.RegisterAuditRoutes(api, controllers.NewAuditController(services.NewAuditService(store.AuditLogs())))
+
+	// Refuse to start if any protected route was registered without a policy
+	if err := routes.VerifyPolicies(api); err != nil {
+		return nil, err
+	}
+
+	// Serve static files
+	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+	r.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "static/index.html")
+	})
+
+	// Handle 404
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "static/404.html")
+	})
+
+	// Handle 405
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "static/405.html")
+	})
+
+	// Middleware to handle panics and recover with error pages
+	r.Use(recoverMiddleware)
+
+	// Tag every request with an ID the audit log can tie changes to
+	r.Use(middlewares.RequestID)
+
+	return r, nil
+}
+
+// recoverMiddleware handles panics and recovers with appropriate error pages.
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("I have recovered from panic: %v", rec)
+				http.ServeFile(w, r, "static/500.html")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiKeyAuthenticator checks the API keys presented to the auth middleware
+// with the API key service
+type apiKeyAuthenticator struct {
+	keys *services.APIKeyService
+}
+
+func (a apiKeyAuthenticator) IsAPIKey(credential string) bool {
+	return services.IsAPIKey(credential)
+}
+
+func (a apiKeyAuthenticator) AuthenticateAPIKey(ctx context.Context, key string) (middlewares.Principal, error) {
+	principal, err := a.keys.AuthenticateAPIKey(ctx, key)
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		return middlewares.Principal{}, fmt.Errorf("%w: %w", middlewares.ErrAPIKeyRejected, services.ErrEmailNotVerified)
+	}
+	if errors.Is(err, services.ErrAPIKeyRejected) {
+		return middlewares.Principal{}, middlewares.ErrAPIKeyRejected
+	}
+	if err != nil {
+		return middlewares.Principal{}, err
+	}
+	return middlewares.Principal{
+		KeyID:       principal.KeyID,
+		UserID:      principal.UserID,
+		Role:        principal.Role,
+		Permissions: principal.Permissions,
+	}, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/services"
+)
+
+// serialItem creates a serial-controlled SKU in a new warehouse and
+// receives one unit of it for each serial
+func (s *testServer) serialItem(token, code string, serials ...string) (models.Inventory, models.Warehouse) {
+	s.t.Helper()
+	var warehouse models.Warehouse
+	s.expect(http.StatusCreated, "POST", "/api/warehouses", token,
+		map[string]any{"code": "WH-" + code, "name": "Warehouse " + code}, &warehouse)
+
+	var product models.Product
+	s.expect(http.StatusCreated, "POST", "/api/products", token, map[string]any{
+		"name": "Product " + code,
+		"skus": []map[string]any{{"code": code, "price": 100, "serial_controlled": true}},
+	}, &product)
+
+	var item models.Inventory
+	s.expect(http.StatusCreated, "POST", "/api/inventory", token,
+		map[string]any{"sku_id": product.SKUs[0].ID}, &item)
+	s.expect(http.StatusCreated, "POST", "/api/inventory/"+itoa(item.ID)+"/movements", token, map[string]any{
+		"type": models.MovementTypeReceipt, "quantity": len(serials), "warehouse_id": warehouse.ID, "serials": serials,
+	}, nil)
+	return item, warehouse
+}
+
+// trace fetches the trace of a serial number
+func (s *testServer) trace(token, serial string) services.SerialTrace {
+	s.t.Helper()
+	var trace services.SerialTrace
+	s.expect(http.StatusOK, "GET", "/api/serials/"+serial, token, nil, &trace)
+	return trace
+}
+
+// shipmentIDs lists the IDs of the shipments in a trace
+func shipmentIDs(trace services.SerialTrace) []uint {
+	ids := make([]uint, 0, len(trace.Shipments))
+	for _, shipment := range trace.Shipments {
+		ids = append(ids, shipment.ID)
+	}
+	return ids
+}
+
+// A serial is traced to the shipment that carried it, not to every
+// shipment of its order
+func TestSerialTraceFollowsTheCarryingShipment(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, _ := s.serialItem(admin, "SN-A", "SN-1", "SN-2", "SN-3")
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 2}, &order)
+	req := s.request("PUT", "/api/orders/"+itoa(order.ID), admin,
+		map[string]any{"status": models.OrderStatusProcessing, "serials": []string{"SN-1", "SN-2"}})
+	req.Header.Set("If-Match", `"1"`)
+	s.expectRequest(http.StatusOK, req, nil)
+
+	// The order goes out in two parcels
+	var first, second models.Shipment
+	s.expect(http.StatusCreated, "POST", "/api/shipments", admin,
+		map[string]any{"order_id": order.ID, "carrier": "DHL", "serials": []string{"SN-1"}}, &first)
+	s.expect(http.StatusConflict, "POST", "/api/shipments", admin,
+		map[string]any{"order_id": order.ID, "carrier": "UPS", "serials": []string{"SN-1"}}, nil)
+	s.expect(http.StatusConflict, "POST", "/api/shipments", admin,
+		map[string]any{"order_id": order.ID, "carrier": "UPS", "serials": []string{"SN-3"}}, nil)
+	s.expect(http.StatusCreated, "POST", "/api/shipments", admin,
+		map[string]any{"order_id": order.ID, "carrier": "UPS"}, &second)
+	if len(second.Serials) != 1 || second.Serials[0] != "SN-2" {
+		t.Fatalf("second shipment carries %v, want the serial left over, SN-2", second.Serials)
+	}
+
+	req = s.request("PUT", "/api/orders/"+itoa(order.ID), admin, map[string]any{"status": models.OrderStatusShipped})
+	req.Header.Set("If-Match", `"2"`)
+	s.expectRequest(http.StatusOK, req, nil)
+
+	for serial, want := range map[string]uint{"SN-1": first.ID, "SN-2": second.ID} {
+		trace := s.trace(admin, serial)
+		if ids := shipmentIDs(trace); len(ids) != 1 || ids[0] != want {
+			t.Errorf("%s is traced to shipments %v, want [%d]", serial, ids, want)
+		}
+		if trace.Serial.ShipmentID != want || trace.Serial.Status != models.SerialStatusShipped {
+			t.Errorf("%s is %s on shipment %d, want shipped on %d", serial, trace.Serial.Status, trace.Serial.ShipmentID, want)
+		}
+		if len(trace.Orders) != 1 || trace.Orders[0].ID != order.ID {
+			t.Errorf("%s is traced to orders %v, want order %d", serial, trace.Orders, order.ID)
+		}
+	}
+	if ids := shipmentIDs(s.trace(admin, "SN-3")); len(ids) != 0 {
+		t.Errorf("SN-3 never shipped but is traced to shipments %v", ids)
+	}
+}
+
+// A shipment created before its order was picked takes the serials on its
+// next update
+func TestShipmentUpdateRecordsSerialsPickedLater(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, _ := s.serialItem(admin, "SN-B", "SN-9")
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 1}, &order)
+	var shipment models.Shipment
+	s.expect(http.StatusCreated, "POST", "/api/shipments", admin,
+		map[string]any{"order_id": order.ID, "carrier": "DHL"}, &shipment)
+	if len(shipment.Serials) != 0 {
+		t.Fatalf("shipment carries %v before the order is picked", shipment.Serials)
+	}
+
+	req := s.request("PUT", "/api/orders/"+itoa(order.ID), admin,
+		map[string]any{"status": models.OrderStatusShipped, "serials": []string{"SN-9"}})
+	req.Header.Set("If-Match", `"1"`)
+	s.expectRequest(http.StatusOK, req, nil)
+
+	req = s.request("PUT", "/api/shipments/"+itoa(shipment.ID), admin,
+		map[string]any{"order_id": order.ID, "carrier": "DHL", "shipping_status": "dispatched"})
+	req.Header.Set("If-Match", `"1"`)
+	s.expectRequest(http.StatusOK, req, &shipment)
+	if len(shipment.Serials) != 1 || shipment.Serials[0] != "SN-9" {
+		t.Fatalf("updated shipment carries %v, want SN-9", shipment.Serials)
+	}
+	if ids := shipmentIDs(s.trace(admin, "SN-9")); len(ids) != 1 || ids[0] != shipment.ID {
+		t.Fatalf("SN-9 is traced to shipments %v, want [%d]", ids, shipment.ID)
+	}
+}
+
+// Serial numbers move with the transfer that lists them
+func TestSerialsMoveWithTransfers(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, source := s.serialItem(admin, "SN-C", "SN-20", "SN-21")
+	var destination models.Warehouse
+	s.expect(http.StatusCreated, "POST", "/api/warehouses", admin,
+		map[string]any{"code": "WH-SN-C-B", "name": "Second warehouse"}, &destination)
+
+	s.expect(http.StatusCreated, "POST", "/api/inventory/"+itoa(item.ID)+"/movements", admin, map[string]any{
+		"type": models.MovementTypeTransfer, "quantity": 1, "warehouse_id": source.ID,
+		"to_warehouse_id": destination.ID, "serials": []string{"SN-21"},
+	}, nil)
+
+	for serial, want := range map[string]uint{"SN-20": source.ID, "SN-21": destination.ID} {
+		if got := s.trace(admin, serial).Serial; got.WarehouseID != want || got.Status != models.SerialStatusInStock {
+			t.Errorf("%s is %s in warehouse %d, want in stock in %d", serial, got.Status, got.WarehouseID, want)
+		}
+	}
+}
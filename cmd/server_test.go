@@ -0,0 +1,351 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+// stockedItem creates a warehouse and a SKU priced at price and receives
+// quantity units of it into the warehouse. It returns the inventory item.
+func (s *testServer) stockedItem(token, code string, price float64, quantity int) (models.Inventory, models.Warehouse) {
+	s.t.Helper()
+	var warehouse models.Warehouse
+	s.expect(http.StatusCreated, "POST", "/api/warehouses", token,
+		map[string]any{"code": "WH-" + code, "name": "Warehouse " + code}, &warehouse)
+
+	var product models.Product
+	s.expect(http.StatusCreated, "POST", "/api/products", token, map[string]any{
+		"name": "Product " + code,
+		"skus": []map[string]any{{"code": code, "price": price}},
+	}, &product)
+	if len(product.SKUs) != 1 {
+		s.t.Fatalf("product has %d SKUs, want 1", len(product.SKUs))
+	}
+
+	var item models.Inventory
+	s.expect(http.StatusCreated, "POST", "/api/inventory", token,
+		map[string]any{"sku_id": product.SKUs[0].ID}, &item)
+	s.expect(http.StatusCreated, "POST", "/api/inventory/"+itoa(item.ID)+"/movements", token, map[string]any{
+		"type": models.MovementTypeReceipt, "quantity": quantity, "warehouse_id": warehouse.ID, "reference": "PO-" + code,
+	}, nil)
+	return item, warehouse
+}
+
+// inventory fetches an inventory item
+func (s *testServer) inventory(token string, id uint) models.Inventory {
+	s.t.Helper()
+	var item models.Inventory
+	s.expect(http.StatusOK, "GET", "/api/inventory/"+itoa(id), token, nil, &item)
+	return item
+}
+
+func TestServerPlacesAndShipsOrders(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, _ := s.stockedItem(admin, "W-1", 2.5, 20)
+
+	var order models.Order
+	rec := s.expect(http.StatusCreated, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 5}, &order)
+	if order.Status != models.OrderStatusPending || order.TotalPrice != 12.5 {
+		t.Fatalf("order = %s for %.2f, want pending for 12.50", order.Status, order.TotalPrice)
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("ETag = %s, want \"1\"", etag)
+	}
+	if got := s.inventory(admin, item.ID); got.Quantity != 20 || got.Reserved != 5 {
+		t.Fatalf("after ordering: quantity %d reserved %d, want 20 and 5", got.Quantity, got.Reserved)
+	}
+
+	// Orders are only written with the version they were read at
+	path := "/api/orders/" + itoa(order.ID)
+	shipped := map[string]any{"status": models.OrderStatusShipped}
+	s.expect(http.StatusPreconditionRequired, "PUT", path, admin, shipped, nil)
+
+	req := s.request("PUT", path, admin, shipped)
+	req.Header.Set("If-Match", `"7"`)
+	s.expectRequest(http.StatusPreconditionFailed, req, nil)
+
+	req = s.request("PUT", path, admin, shipped)
+	req.Header.Set("If-Match", `"1"`)
+	s.expectRequest(http.StatusOK, req, nil)
+
+	if got := s.inventory(admin, item.ID); got.Quantity != 15 || got.Reserved != 0 {
+		t.Fatalf("after shipping: quantity %d reserved %d, want 15 and 0", got.Quantity, got.Reserved)
+	}
+
+	// The ledger holds the receipt and the issue for the order
+	var movements repository.Page[models.StockMovement]
+	s.expect(http.StatusOK, "GET", "/api/inventory/sku/W-1/movements", admin, nil, &movements)
+	if len(movements.Data) != 2 || movements.Data[1].Type != models.MovementTypeIssue || movements.Data[1].BalanceAfter != 15 {
+		t.Fatalf("movements = %+v, want a receipt and an issue leaving 15", movements.Data)
+	}
+}
+
+func TestServerRejectsOversoldOrders(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, _ := s.stockedItem(admin, "W-2", 1, 3)
+
+	s.expect(http.StatusConflict, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 4}, nil)
+	s.expect(http.StatusCreated, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 3}, nil)
+	s.expect(http.StatusConflict, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 1}, nil)
+}
+
+// An order that names a warehouse needs unreserved, unexpired stock there
+func TestServerChecksStockInTheOrderedWarehouse(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, first := s.stockedItem(admin, "W-8", 1, 10)
+	var second models.Warehouse
+	s.expect(http.StatusCreated, "POST", "/api/warehouses", admin,
+		map[string]any{"code": "WH-W-8-B", "name": "Second warehouse"}, &second)
+	s.expect(http.StatusCreated, "POST", "/api/inventory/"+itoa(item.ID)+"/movements", admin, map[string]any{
+		"type": models.MovementTypeReceipt, "quantity": 10, "warehouse_id": second.ID,
+	}, nil)
+	s.receiveLot(admin, item, first, "L-GONE", 5, time.Now().AddDate(0, 0, -1))
+
+	order := func(status int, quantity int, warehouseID uint) {
+		t.Helper()
+		s.expect(status, "POST", "/api/orders", admin,
+			map[string]any{"inventory_id": item.ID, "quantity": quantity, "warehouse_id": warehouseID}, nil)
+	}
+	order(http.StatusConflict, 12, first.ID)
+	order(http.StatusCreated, 8, first.ID)
+	order(http.StatusConflict, 3, first.ID)
+	order(http.StatusCreated, 2, first.ID)
+	order(http.StatusCreated, 10, second.ID)
+}
+
+// receiveLot receives quantity units of an item into a warehouse in a new
+// lot expiring on expiresOn
+func (s *testServer) receiveLot(token string, item models.Inventory, warehouse models.Warehouse, number string, quantity int, expiresOn time.Time) {
+	s.t.Helper()
+	s.expect(http.StatusCreated, "POST", "/api/inventory/"+itoa(item.ID)+"/movements", token, map[string]any{
+		"type": models.MovementTypeReceipt, "quantity": quantity, "warehouse_id": warehouse.ID,
+		"lot": map[string]any{"number": number, "expires_on": expiresOn},
+	}, nil)
+}
+
+// Expired lots count as on hand but cannot be ordered
+func TestServerDoesNotOrderExpiredStock(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, warehouse := s.stockedItem(admin, "W-3", 1, 27)
+	s.receiveLot(admin, item, warehouse, "L-OLD", 5, time.Now().AddDate(0, 0, -3))
+	if got := s.inventory(admin, item.ID); got.Quantity != 32 {
+		t.Fatalf("on hand = %d, want 32", got.Quantity)
+	}
+
+	s.expect(http.StatusConflict, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 30}, nil)
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 27}, &order)
+	req := s.request("PUT", "/api/orders/"+itoa(order.ID), admin, map[string]any{"status": models.OrderStatusShipped})
+	req.Header.Set("If-Match", `"1"`)
+	s.expectRequest(http.StatusOK, req, nil)
+
+	if got := s.inventory(admin, item.ID); got.Quantity != 5 || got.Reserved != 0 {
+		t.Fatalf("after shipping: quantity %d reserved %d, want the 5 expired units", got.Quantity, got.Reserved)
+	}
+}
+
+// Stock that expires after an order reserved it makes shipping fail with
+// an explicit error
+func TestServerDoesNotShipStockThatExpiredAfterOrdering(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, warehouse := s.stockedItem(admin, "W-4", 1, 10)
+	s.receiveLot(admin, item, warehouse, "L-SOON", 10, time.Now().AddDate(0, 0, 7))
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "quantity": 15, "warehouse_id": warehouse.ID}, &order)
+
+	// A week passes
+	if err := s.db.Model(&models.Lot{}).Where("number = ?", "L-SOON").
+		Update("expires_on", time.Now().AddDate(0, 0, -1)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	req := s.request("PUT", "/api/orders/"+itoa(order.ID), admin, map[string]any{"status": models.OrderStatusShipped})
+	req.Header.Set("If-Match", `"1"`)
+	rec := s.expectRequest(http.StatusConflict, req, nil)
+	if body := rec.Body.String(); !strings.Contains(body, "stock expired") {
+		t.Fatalf("shipping expired stock = %q, want it to say the stock expired", strings.TrimSpace(body))
+	}
+	if got := s.inventory(admin, item.ID); got.Quantity != 20 || got.Reserved != 15 {
+		t.Fatalf("after the failed shipment: quantity %d reserved %d, want 20 and 15", got.Quantity, got.Reserved)
+	}
+}
+
+// Only a transfer order may move unlotted stock of a lot-controlled SKU
+// into a warehouse; a client cannot book it in by calling its movement a
+// transfer
+func TestServerRequiresLotsOutsideTransferOrders(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, source := s.stockedItem(admin, "W-5", 1, 5)
+	var destination models.Warehouse
+	s.expect(http.StatusCreated, "POST", "/api/warehouses", admin,
+		map[string]any{"code": "WH-W-5-B", "name": "Second warehouse"}, &destination)
+
+	// Lot control is turned on after the stock came in
+	s.expect(http.StatusOK, "PUT", "/api/items/"+itoa(item.SKUID), admin, map[string]any{
+		"product_id": item.SKU.ProductID, "code": "W-5", "price": 1, "lot_controlled": true,
+	}, nil)
+
+	s.expect(http.StatusBadRequest, "POST", "/api/inventory/"+itoa(item.ID)+"/movements", admin, map[string]any{
+		"type": models.MovementTypeTransfer, "quantity": 5, "warehouse_id": destination.ID,
+	}, nil)
+
+	var transfer models.TransferOrder
+	s.expect(http.StatusCreated, "POST", "/api/transfers", admin, map[string]any{
+		"source_warehouse_id": source.ID, "destination_warehouse_id": destination.ID,
+		"lines": []map[string]any{{"inventory_id": item.ID, "quantity": 5}},
+	}, &transfer)
+	path := "/api/transfers/" + itoa(transfer.ID)
+	s.expect(http.StatusOK, "POST", path+"/pick", admin, nil, nil)
+	s.expect(http.StatusOK, "POST", path+"/dispatch", admin, map[string]any{"carrier": "Own fleet"}, nil)
+	s.expect(http.StatusOK, "POST", path+"/receive", admin,
+		map[string]any{"lines": []map[string]any{{"line_id": transfer.Lines[0].ID, "quantity": 5}}}, &transfer)
+	if transfer.Status != models.TransferStatusReceived {
+		t.Fatalf("transfer is %s, want received", transfer.Status)
+	}
+	if got := s.inventory(admin, item.ID); got.Quantity != 5 {
+		t.Fatalf("on hand = %d, want the 5 units moved", got.Quantity)
+	}
+}
+
+// A transfer movement moves stock between locations without changing how
+// much is on hand; a lone leg is refused
+func TestServerRecordsBothLegsOfATransfer(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, source := s.stockedItem(admin, "W-6", 1, 10)
+	var destination models.Warehouse
+	s.expect(http.StatusCreated, "POST", "/api/warehouses", admin,
+		map[string]any{"code": "WH-W-6-B", "name": "Second warehouse"}, &destination)
+
+	path := "/api/inventory/" + itoa(item.ID) + "/movements"
+	for _, body := range []map[string]any{
+		{"type": models.MovementTypeTransfer, "quantity": 4, "warehouse_id": destination.ID},
+		{"type": models.MovementTypeTransfer, "quantity": -4, "warehouse_id": source.ID, "to_warehouse_id": destination.ID},
+		{"type": models.MovementTypeTransfer, "quantity": 4, "warehouse_id": source.ID, "to_warehouse_id": source.ID},
+	} {
+		s.expect(http.StatusBadRequest, "POST", path, admin, body, nil)
+	}
+	s.expect(http.StatusConflict, "POST", path, admin, map[string]any{
+		"type": models.MovementTypeTransfer, "quantity": 11, "warehouse_id": source.ID, "to_warehouse_id": destination.ID,
+	}, nil)
+
+	var movement models.StockMovement
+	s.expect(http.StatusCreated, "POST", path, admin, map[string]any{
+		"type": models.MovementTypeTransfer, "quantity": 4, "warehouse_id": source.ID, "to_warehouse_id": destination.ID,
+	}, &movement)
+	if movement.WarehouseID != destination.ID || movement.Quantity != 4 || movement.BalanceAfter != 10 {
+		t.Fatalf("transfer = %+v, want 4 units into warehouse %d leaving 10 on hand", movement, destination.ID)
+	}
+
+	var balances []models.StockBalance
+	s.expect(http.StatusOK, "GET", "/api/inventory/"+itoa(item.ID)+"/locations", admin, nil, &balances)
+	held := map[uint]int{}
+	for _, balance := range balances {
+		held[balance.WarehouseID] += balance.Quantity
+	}
+	if held[source.ID] != 6 || held[destination.ID] != 4 {
+		t.Fatalf("warehouses hold %v, want 6 in %d and 4 in %d", held, source.ID, destination.ID)
+	}
+	if got := s.inventory(admin, item.ID); got.Quantity != 10 {
+		t.Fatalf("on hand = %d, want 10", got.Quantity)
+	}
+}
+
+// An inventory item can only be deleted once its stock has been booked out
+func TestServerKeepsInventoryThatHoldsStock(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, warehouse := s.stockedItem(admin, "W-7", 1, 10)
+	path := "/api/inventory/" + itoa(item.ID)
+
+	deleteItem := func(status int) {
+		t.Helper()
+		req := s.request("DELETE", path, admin, nil)
+		req.Header.Set("If-Match", `"`+strconv.Itoa(s.inventory(admin, item.ID).Version)+`"`)
+		s.expectRequest(status, req, nil)
+	}
+	deleteItem(http.StatusConflict)
+
+	s.expect(http.StatusCreated, "POST", path+"/movements", admin, map[string]any{
+		"type": models.MovementTypeIssue, "quantity": 10, "warehouse_id": warehouse.ID,
+	}, nil)
+	deleteItem(http.StatusNoContent)
+	s.expect(http.StatusNotFound, "GET", path, admin, nil, nil)
+}
+
+func TestServerAuditsChanges(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+
+	req := s.request("POST", "/api/warehouses", admin, map[string]any{"code": "AUD", "name": "Audited"})
+	req.Header.Set("X-Request-ID", "test-request-1")
+	rec := s.expectRequest(http.StatusCreated, req, nil)
+	if got := rec.Header().Get("X-Request-ID"); got != "test-request-1" {
+		t.Fatalf("X-Request-ID = %q, want test-request-1", got)
+	}
+
+	var page repository.Page[models.AuditLog]
+	s.expect(http.StatusOK, "GET", "/api/audit?request_id=test-request-1", admin, nil, &page)
+	if len(page.Data) != 1 || page.Data[0].EntityType != "warehouses" || page.Data[0].Action != "create" {
+		t.Fatalf("audit entries = %+v, want the warehouse being created", page.Data)
+	}
+}
+
+func TestServerServesListsWithCursors(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	for _, code := range []string{"A", "B", "C"} {
+		s.expect(http.StatusCreated, "POST", "/api/warehouses", admin, map[string]any{"code": code, "name": code}, nil)
+	}
+
+	var first repository.Page[models.Warehouse]
+	s.expect(http.StatusOK, "GET", "/api/warehouses?limit=2&sort=code", admin, nil, &first)
+	if len(first.Data) != 2 || first.Data[0].Code != "A" || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+
+	var second repository.Page[models.Warehouse]
+	s.expect(http.StatusOK, "GET", "/api/warehouses?limit=2&sort=code&cursor="+url.QueryEscape(first.NextCursor), admin, nil, &second)
+	if len(second.Data) != 1 || second.Data[0].Code != "C" || second.NextCursor != "" {
+		t.Fatalf("second page = %+v", second)
+	}
+}
+
+// Orders are placed for the caller, whatever user_id the body names
+func TestServerPlacesOrdersForTheCaller(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, _ := s.stockedItem(admin, "W-5", 1, 10)
+	victim := s.createUser("victim@example.com", authz.RoleViewer)
+	buyer := s.createUser("buyer@example.com", authz.RoleStaff)
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/api/orders", s.sessionToken(buyer),
+		map[string]any{"inventory_id": item.ID, "quantity": 1, "user_id": victim.ID}, &order)
+	if order.UserID != buyer.ID {
+		t.Fatalf("order placed for user %d, want the caller %d", order.UserID, buyer.ID)
+	}
+}
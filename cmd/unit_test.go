@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"inventory-supply-chain-system/models"
+)
+
+// newProduct posts a product with one SKU and returns the response status
+func (s *testServer) newProduct(token, code string, sku map[string]any) int {
+	s.t.Helper()
+	sku["code"] = code
+	return s.do("POST", "/api/products", token, map[string]any{
+		"name": "Product " + code,
+		"skus": []map[string]any{sku},
+	}).Code
+}
+
+// Stock is kept in whole base units, so the base unit has to be the
+// smallest unit a SKU is counted in
+func TestSKUBaseUnitIsTheSmallest(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+
+	for _, sku := range []map[string]any{
+		{"base_unit": "kg"},
+		{"base_unit": "case"},
+		{"base_unit": "dozen"},
+		{"base_unit": "g", "units": []map[string]any{{"unit": "pack", "factor": 0.5}}},
+	} {
+		if status := s.newProduct(admin, "BAD", sku); status != http.StatusBadRequest {
+			t.Errorf("SKU %v = %d, want 400", sku, status)
+		}
+	}
+
+	// A unit smaller than the one with ratio 1 would break that
+	s.expect(http.StatusBadRequest, "POST", "/api/units", admin,
+		map[string]any{"code": "mg", "name": "Milligram", "dimension": models.UnitDimensionMass, "ratio": 0.001}, nil)
+	s.expect(http.StatusCreated, "POST", "/api/units", admin,
+		map[string]any{"code": "crate", "name": "Crate", "dimension": models.UnitDimensionCount, "ratio": 0}, nil)
+
+	// A SKU sold by the kilogram is counted in grams
+	var product models.Product
+	s.expect(http.StatusCreated, "POST", "/api/products", admin, map[string]any{
+		"name": "Flour",
+		"skus": []map[string]any{{"code": "FLOUR", "price": 0.002, "base_unit": "g", "units": []map[string]any{{"unit": "pack", "factor": 2500}}}},
+	}, &product)
+	var item models.Inventory
+	s.expect(http.StatusCreated, "POST", "/api/inventory", admin, map[string]any{"sku_id": product.SKUs[0].ID}, &item)
+	s.expect(http.StatusCreated, "POST", "/api/inventory/"+itoa(item.ID)+"/movements", admin, map[string]any{
+		"type": models.MovementTypeReceipt, "unit": "pack", "unit_quantity": 4,
+	}, nil)
+
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/api/orders", admin,
+		map[string]any{"inventory_id": item.ID, "unit": "kg", "unit_quantity": 1.5}, &order)
+	if order.Quantity != 1500 || order.Unit != "kg" || order.UnitQuantity != 1.5 {
+		t.Fatalf("order for 1.5 kg = %d base units in %g %s, want 1500", order.Quantity, order.UnitQuantity, order.Unit)
+	}
+	if got := s.inventory(admin, item.ID); got.Quantity != 10000 || got.Reserved != 1500 {
+		t.Fatalf("flour: quantity %d reserved %d, want 10000 g with 1500 reserved", got.Quantity, got.Reserved)
+	}
+}
+
+// A SKU given a larger base unit before the rule keeps it through updates
+func TestSKUKeepsAnEarlierLargerBaseUnit(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	var product models.Product
+	s.expect(http.StatusCreated, "POST", "/api/products", admin, map[string]any{
+		"name": "Sugar", "skus": []map[string]any{{"code": "SUGAR", "base_unit": "g"}},
+	}, &product)
+	sku := product.SKUs[0]
+	if err := s.db.Model(&models.SKU{}).Where("id = ?", sku.ID).Update("base_unit", "kg").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/api/items/" + itoa(sku.ID)
+	s.expect(http.StatusOK, "PUT", path, admin,
+		map[string]any{"product_id": product.ID, "code": "SUGAR", "base_unit": "kg", "price": 1.2}, nil)
+	s.expect(http.StatusBadRequest, "PUT", path, admin,
+		map[string]any{"product_id": product.ID, "code": "SUGAR", "base_unit": "t", "price": 1.2}, nil)
+}
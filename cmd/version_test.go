@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"inventory-supply-chain-system/models"
+)
+
+// versionedResource is a record that takes If-Match on PUT and DELETE
+type versionedResource struct {
+	name string
+	path string
+	// race holds PUT bodies that are each valid for the new record; star is
+	// one that is valid after any of them
+	race []map[string]any
+	star map[string]any
+	// field is the JSON field the bodies change
+	field string
+}
+
+// writeIfMatch sends a PUT or DELETE with an If-Match header, or none when
+// ifMatch is empty
+func (s *testServer) writeIfMatch(method, path, token, ifMatch string, body any) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := s.request(method, path, token, body)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	return s.serve(req)
+}
+
+// versionedResources creates one inventory item, order, shipment and vendor
+func (s *testServer) versionedResources(token string) []versionedResource {
+	s.t.Helper()
+	stocked, _ := s.stockedItem(token, "V-1", 1, 10)
+	var order models.Order
+	s.expect(http.StatusCreated, "POST", "/api/orders", token,
+		map[string]any{"inventory_id": stocked.ID, "quantity": 2}, &order)
+	var shipment models.Shipment
+	s.expect(http.StatusCreated, "POST", "/api/shipments", token,
+		map[string]any{"order_id": order.ID, "carrier": "DHL"}, &shipment)
+	var vendor models.Vendor
+	s.expect(http.StatusOK, "POST", "/api/vendors", token, map[string]any{"name": "Acme"}, &vendor)
+
+	// An item without stock, so it can be deleted
+	var product models.Product
+	s.expect(http.StatusCreated, "POST", "/api/products", token, map[string]any{
+		"name": "Product V-2", "skus": []map[string]any{{"code": "V-2", "price": 1}},
+	}, &product)
+	var item models.Inventory
+	s.expect(http.StatusCreated, "POST", "/api/inventory", token, map[string]any{"sku_id": product.SKUs[0].ID}, &item)
+
+	return []versionedResource{
+		{
+			name:  "inventory",
+			path:  "/api/inventory/" + itoa(item.ID),
+			race:  []map[string]any{{"vendor_id": 1}, {"vendor_id": 2}, {"vendor_id": 3}},
+			star:  map[string]any{"vendor_id": 4},
+			field: "vendor_id",
+		},
+		{
+			name:  "order",
+			path:  "/api/orders/" + itoa(order.ID),
+			race:  []map[string]any{{"status": models.OrderStatusProcessing}, {"status": models.OrderStatusCancelled}},
+			star:  map[string]any{"status": models.OrderStatusCancelled},
+			field: "status",
+		},
+		{
+			name: "shipment",
+			path: "/api/shipments/" + itoa(shipment.ID),
+			race: []map[string]any{
+				{"order_id": order.ID, "carrier": "UPS"}, {"order_id": order.ID, "carrier": "FedEx"}, {"order_id": order.ID, "carrier": "TNT"},
+			},
+			star:  map[string]any{"order_id": order.ID, "carrier": "Royal Mail"},
+			field: "carrier",
+		},
+		{
+			name:  "vendor",
+			path:  "/api/vendors/" + itoa(vendor.ID),
+			race:  []map[string]any{{"name": "Acme 1"}, {"name": "Acme 2"}, {"name": "Acme 3"}},
+			star:  map[string]any{"name": "Acme 4"},
+			field: "name",
+		},
+	}
+}
+
+// field fetches a resource and returns one of its JSON fields as text
+func (s *testServer) field(token, path, field string) string {
+	s.t.Helper()
+	var resource map[string]any
+	s.expect(http.StatusOK, "GET", path, token, nil, &resource)
+	return fmt.Sprint(resource[field])
+}
+
+// Writes without If-Match are refused with 428
+func TestWritesRequireIfMatch(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	for _, resource := range s.versionedResources(admin) {
+		for _, method := range []string{"PUT", "DELETE"} {
+			if rec := s.writeIfMatch(method, resource.path, admin, "", resource.race[0]); rec.Code != http.StatusPreconditionRequired {
+				t.Errorf("%s %s without If-Match = %d, want 428", method, resource.name, rec.Code)
+			}
+		}
+	}
+}
+
+// Of several writes based on the same version only one lands; the others
+// get 412 instead of overwriting it
+func TestConcurrentWritesDoNotLoseUpdates(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	for _, resource := range s.versionedResources(admin) {
+		codes := make([]int, len(resource.race))
+		var wg sync.WaitGroup
+		for i, body := range resource.race {
+			req := s.request("PUT", resource.path, admin, body)
+			req.Header.Set("If-Match", `"1"`)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes[i] = s.serve(req).Code
+			}()
+		}
+		wg.Wait()
+
+		winner := -1
+		for i, code := range codes {
+			switch {
+			case code == http.StatusOK && winner < 0:
+				winner = i
+			case code != http.StatusPreconditionFailed:
+				t.Fatalf("%s: concurrent PUTs got %v, want one 200 and 412 for the rest", resource.name, codes)
+			}
+		}
+		if winner < 0 {
+			t.Fatalf("%s: concurrent PUTs got %v, want one 200", resource.name, codes)
+		}
+		if got, want := s.field(admin, resource.path, resource.field), fmt.Sprint(resource.race[winner][resource.field]); got != want {
+			t.Errorf("%s: %s = %s after the race, want the winner's %s", resource.name, resource.field, got, want)
+		}
+	}
+}
+
+// A write based on a version that has since changed gets 412 and changes
+// nothing; If-Match: * writes whatever the version is
+func TestStaleWritesAreRefused(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	for _, resource := range s.versionedResources(admin) {
+		if rec := s.writeIfMatch("PUT", resource.path, admin, `"1"`, resource.race[0]); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+			t.Fatalf("%s: first PUT = %d with ETag %s, want 200 with \"2\"", resource.name, rec.Code, rec.Header().Get("ETag"))
+		}
+		want := s.field(admin, resource.path, resource.field)
+
+		for _, ifMatch := range []string{`"1"`, `"0"`, `"3"`, `W/"2"`, `"1", "2"`} {
+			if rec := s.writeIfMatch("PUT", resource.path, admin, ifMatch, resource.race[1]); rec.Code != http.StatusPreconditionFailed {
+				t.Errorf("%s: PUT with If-Match %s = %d, want 412", resource.name, ifMatch, rec.Code)
+			}
+			if rec := s.writeIfMatch("DELETE", resource.path, admin, ifMatch, nil); rec.Code != http.StatusPreconditionFailed {
+				t.Errorf("%s: DELETE with If-Match %s = %d, want 412", resource.name, ifMatch, rec.Code)
+			}
+		}
+		if got := s.field(admin, resource.path, resource.field); got != want {
+			t.Errorf("%s: %s = %s after stale writes, want %s", resource.name, resource.field, got, want)
+		}
+
+		rec := s.writeIfMatch("PUT", resource.path, admin, "*", resource.star)
+		if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+			t.Fatalf("%s: PUT with If-Match * = %d with ETag %s, want 200 with \"3\"", resource.name, rec.Code, rec.Header().Get("ETag"))
+		}
+		if got, want := s.field(admin, resource.path, resource.field), fmt.Sprint(resource.star[resource.field]); got != want {
+			t.Errorf("%s: %s = %s after If-Match *, want %s", resource.name, resource.field, got, want)
+		}
+		if rec := s.writeIfMatch("DELETE", resource.path, admin, "*", nil); rec.Code != http.StatusNoContent {
+			t.Errorf("%s: DELETE with If-Match * = %d, want 204", resource.name, rec.Code)
+		}
+		if rec := s.writeIfMatch("PUT", resource.path, admin, "*", resource.star); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: PUT with If-Match * after the delete = %d, want 404", resource.name, rec.Code)
+		}
+	}
+}
+
+// An update writes the record's own columns, not records nested in the body
+func TestUpdatesLeaveAssociationsAlone(t *testing.T) {
+	s := newTestServer(t, testConfig{})
+	admin := s.adminToken()
+	item, _ := s.stockedItem(admin, "V-3", 2.5, 1)
+	var sku models.SKU
+	s.expect(http.StatusOK, "GET", "/api/items/"+itoa(item.SKUID), admin, nil, &sku)
+
+	req := s.request("PUT", "/api/inventory/"+itoa(item.ID), admin, map[string]any{
+		"quantity": 1, "sku": map[string]any{"id": 999, "product_id": sku.ProductID, "code": "PLANTED", "price": 0},
+	})
+	req.Header.Set("If-Match", `"`+itoa(uint(s.inventory(admin, item.ID).Version))+`"`)
+	s.expectRequest(http.StatusOK, req, nil)
+
+	s.expect(http.StatusNotFound, "GET", "/api/items/999", admin, nil, nil)
+	s.expect(http.StatusOK, "GET", "/api/items/"+itoa(item.SKUID), admin, nil, &sku)
+	if sku.Code != "V-3" || sku.Price != 2.5 {
+		encoded, _ := json.Marshal(sku)
+		t.Fatalf("SKU after an inventory update = %s, want it unchanged", encoded)
+	}
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+)
+
+// APIKeyController serves the API key endpoints
+type APIKeyController struct {
+	keys *services.APIKeyService
+}
+
+// NewAPIKeyController returns an APIKeyController backed by keys
+func NewAPIKeyController(keys *services.APIKeyService) *APIKeyController {
+	return &APIKeyController{keys: keys}
+}
+
+// CreateAPIKey issues an API key and returns it with its secret, which is
+// not shown again
+func (c *APIKeyController) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var input services.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	key, err := c.keys.IssueAPIKey(r.Context(), input, currentUserID(r))
+	if err != nil {
+		writeAPIKeyError(w, err, "Failed to issue API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+// ListAPIKeys returns a page of API keys filtered by the user_id and active parameters
+func (c *APIKeyController) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	var filter repository.APIKeyFilter
+	var err error
+	if filter.UserID, err = parseUintParam(r, "user_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Active = r.URL.Query().Get("active") == "true"
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	keys, err := c.keys.FindAPIKeys(r.Context(), filter, page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve API keys", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, keys)
+}
+
+// GetAPIKey fetches an API key; its secret is never returned
+func (c *APIKeyController) GetAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	key, err := c.keys.GetAPIKey(r.Context(), id)
+	if err != nil {
+		writeAPIKeyError(w, err, "Failed to retrieve API key")
+		return
+	}
+
+	json.NewEncoder(w).Encode(key)
+}
+
+// RevokeAPIKey stops an API key from being accepted
+func (c *APIKeyController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	key, err := c.keys.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		writeAPIKeyError(w, err, "Failed to revoke API key")
+		return
+	}
+
+	json.NewEncoder(w).Encode(key)
+}
+
+// writeAPIKeyError maps API key service errors to status codes
+func writeAPIKeyError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAPIKey), errors.Is(err, services.ErrUnknownPermission):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "API key or user not found", http.StatusNotFound)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+)
+
+// AuditController serves the audit log
+type AuditController struct {
+	audit *services.AuditService
+}
+
+// NewAuditController returns an AuditController backed by audit
+func NewAuditController(audit *services.AuditService) *AuditController {
+	return &AuditController{audit: audit}
+}
+
+// ListAuditLogs returns a page of audit log entries filtered by the
+// entity_type, entity_id, actor_id, action, request_id, start and end parameters
+func (c *AuditController) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	var filter repository.AuditFilter
+	var err error
+	if filter.ActorID, err = parseUintParam(r, "actor_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.From, err = parseTimeParam(r, "start"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.To, filter.Before, err = parseEndParam(r, "end"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.EntityType = r.URL.Query().Get("entity_type")
+	filter.EntityID = r.URL.Query().Get("entity_id")
+	filter.Actions = queryList(r, "action")
+	filter.RequestID = r.URL.Query().Get("request_id")
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logs, err := c.audit.FindAuditLogs(r.Context(), filter, page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve audit log", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, logs)
+}
@@ -2,51 +2,99 @@ package controllers
 
 import (
 	"encoding/json"
-	"inventory-supply-chain-system/db"
+	"errors"
+	"inventory-supply-chain-system/internal/authz"
 	"inventory-supply-chain-system/models"
 	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/services"
 	"log"
+	"math"
 	"net/http"
-
-	"github.com/lib/pq"
-	"golang.org/x/crypto/bcrypt"
+	"strconv"
 )
 
+// registerInput is what a new user may choose about their own account
+type registerInput struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Phone    string `json:"phone"`
+}
+
+// AuthController serves registration, login, sessions, password resets,
+// email verification, MFA and single sign-on
+type AuthController struct {
+	users        *services.UserService
+	logins       *services.LoginService
+	sessions     *services.SessionService
+	mfa          *services.MFAService
+	passwords    *services.PasswordService
+	verification *services.VerificationService
+	oidc         *services.OIDCService
+}
+
+// NewAuthController returns an AuthController backed by the given services
+func NewAuthController(users *services.UserService, logins *services.LoginService, sessions *services.SessionService,
+	mfa *services.MFAService, passwords *services.PasswordService, verification *services.VerificationService,
+	oidc *services.OIDCService) *AuthController {
+	return &AuthController{
+		users:        users,
+		logins:       logins,
+		sessions:     sessions,
+		mfa:          mfa,
+		passwords:    passwords,
+		verification: verification,
+		oidc:         oidc,
+	}
+}
+
 // RegisterUser registers a new user
-func RegisterUser(w http.ResponseWriter, r *http.Request) {
-	var user models.User
-	err := json.NewDecoder(r.Body).Decode(&user)
+func (c *AuthController) RegisterUser(w http.ResponseWriter, r *http.Request) {
+	var input registerInput
+	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	if input.Email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+	if err := services.ValidatePassword(input.Password, input.Email); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Check if user with the given email already exists
-	var existingUser models.User
-	if db.DB.Where("email = ?", user.Email).First(&existingUser).Error == nil {
+	if _, err := c.users.GetUserByEmail(r.Context(), input.Email); err == nil {
 		http.Error(w, "User with this email already exists", http.StatusConflict)
 		return
 	}
 
-	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		http.Error(w, "Error hashing password", http.StatusInternalServerError)
-		return
+	// Self-registered users get the default role, start unverified and
+	// without MFA; anything more is granted by an admin or enrolled later
+	user := models.User{
+		Name:        input.Name,
+		Email:       input.Email,
+		Password:    input.Password,
+		Phone:       input.Phone,
+		Role:        authz.DefaultRole,
+		Permissions: models.StringArray{},
+		Verified:    false,
+		MFAEnabled:  false,
+		MFASecret:   "",
 	}
-	user.Password = string(hashedPassword)
 
-	// Format the permissions as a PostgreSQL array
-	user.Permissions = pq.StringArray([]string{"view_reports", "approve_transactions", "manage_team"})
-
-	// Save the user to the database
-	if err := db.DB.Create(&user).Error; err != nil {
+	// Save the user to the database with a hashed password
+	if err := c.users.CreateUser(r.Context(), &user); err != nil {
 		http.Error(w, "Error saving user", http.StatusInternalServerError)
 		return
 	}
 
-	// Debugging log: output the hashed password
-	log.Printf("User registered: %s, Hashed Password: %s", user.Email, user.Password)
+	// Send the verification link; the user can ask for another if this one is lost
+	if err := c.verification.SendVerification(r.Context(), &user); err != nil {
+		log.Printf("Error sending verification email to %s: %v", user.Email, err)
+	}
 
 	// Return the created user (without the password)
 	user.Password = ""
@@ -54,8 +102,9 @@ func RegisterUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
-// LoginUser authenticates the user and returns a JWT token
-func LoginUser(w http.ResponseWriter, r *http.Request) {
+// LoginUser authenticates the user and returns a JWT token, or an MFA
+// challenge when the user has enrolled in MFA
+func (c *AuthController) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var loginUser struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
@@ -67,32 +116,220 @@ func LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find the user by email
-	var user models.User
-	if err := db.DB.Where("email = ?", loginUser.Email).First(&user).Error; err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+	// Unknown emails and wrong passwords get the same answer
+	user, err := c.logins.LoginUser(r.Context(), loginUser.Email, loginUser.Password, clientIP(r))
+	if writeRetryAfter(w, err) {
+		return
+	}
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error logging in", http.StatusInternalServerError)
 		return
 	}
 
-	// Debugging log: output the stored hashed password
-	log.Printf("Login attempt for: %s, Stored Hashed Password: %s", user.Email, user.Password)
+	c.completeLogin(w, r, user)
+}
 
-	// Compare the hashed password
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginUser.Password))
-	if err != nil {
-		log.Printf("Password comparison failed for: %s, Provided Password: %s", user.Email, loginUser.Password)
-		http.Error(w, "Invalid password", http.StatusUnauthorized)
+// completeLogin finishes the login of a user who has proven who they are.
+// Users with MFA get a challenge to answer with a code instead of tokens.
+func (c *AuthController) completeLogin(w http.ResponseWriter, r *http.Request, user models.User) {
+	if user.MFAEnrolled() {
+		challenge, err := c.mfa.StartMFAChallenge(user)
+		if err != nil {
+			http.Error(w, "Error generating token", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(challenge)
 		return
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Role)
+	c.startSession(w, r, user)
+}
+
+// startSession starts a session for an authenticated user and writes its
+// first token pair
+func (c *AuthController) startSession(w http.ResponseWriter, r *http.Request, user models.User) {
+	tokens, err := c.sessions.StartSession(r.Context(), user, r.UserAgent(), clientIP(r))
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Error generating token", http.StatusInternalServerError)
 		return
 	}
 
-	// Return the token in the response
+	// Return the tokens in the response; token is kept for older clients
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
+	json.NewEncoder(w).Encode(struct {
+		Token string `json:"token"`
+		*services.TokenPair
+	}{tokens.AccessToken, tokens})
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh token
+func (c *AuthController) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.RefreshToken == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := c.sessions.RefreshSession(r.Context(), input.RefreshToken)
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// RequestPasswordReset sends a password reset token to the given email.
+// It answers the same whether or not the account exists.
+func (c *AuthController) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Email == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	// Failures are only logged, as they happen for existing accounts only
+	if err := c.passwords.RequestPasswordReset(r.Context(), input.Email); err != nil {
+		log.Printf("Error requesting password reset: %v", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmPasswordReset sets a new password with a token from RequestPasswordReset
+func (c *AuthController) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Token == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	err := c.passwords.ConfirmPasswordReset(r.Context(), input.Token, input.Password)
+	if errors.Is(err, services.ErrInvalidResetToken) || errors.Is(err, services.ErrWeakPassword) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error resetting password", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ConfirmEmail verifies the email address a verification token was sent to
+func (c *AuthController) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Token == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	err := c.verification.ConfirmEmail(r.Context(), input.Token)
+	if errors.Is(err, services.ErrInvalidVerificationToken) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error verifying email", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerification sends a new verification link to the given email.
+// It answers 202 whether or not the account exists, is already verified,
+// asked too recently or could not be mailed; failures are only logged.
+func (c *AuthController) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Email == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.verification.ResendVerification(r.Context(), input.Email); err != nil {
+		log.Printf("Error resending verification email: %v", err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// LogoutUser ends the session of the access token used for the request
+func (c *AuthController) LogoutUser(w http.ResponseWriter, r *http.Request) {
+	sessionID, _ := r.Context().Value("sessionID").(string)
+	if err := c.sessions.RevokeSession(r.Context(), currentUserID(r), sessionID); err != nil {
+		http.Error(w, "Error logging out", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAllSessions ends every session of the authenticated user
+func (c *AuthController) LogoutAllSessions(w http.ResponseWriter, r *http.Request) {
+	if err := c.sessions.RevokeAllSessions(r.Context(), currentUserID(r)); err != nil {
+		http.Error(w, "Error logging out", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JWKS publishes the public keys access tokens can be verified with
+func (c *AuthController) JWKS(w http.ResponseWriter, r *http.Request) {
+	jwks, err := utils.PublicJWKS()
+	if err != nil {
+		http.Error(w, "Error loading signing keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(jwks)
+}
+
+// writeRetryAfter answers 429 with a Retry-After header when err asks the
+// client to wait, and reports whether it did
+func writeRetryAfter(w http.ResponseWriter, err error) bool {
+	var retry *services.RetryError
+	if !errors.As(err, &retry) {
+		return false
+	}
+
+	seconds := int(math.Ceil(retry.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, retry.Err.Error(), http.StatusTooManyRequests)
+	return true
 }
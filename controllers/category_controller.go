@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+)
+
+// CategoryController serves the product category tree endpoints
+type CategoryController struct {
+	categories *services.CategoryService
+}
+
+// NewCategoryController returns a CategoryController backed by categories
+func NewCategoryController(categories *services.CategoryService) *CategoryController {
+	return &CategoryController{categories: categories}
+}
+
+// CreateCategory handles the creation of a new category
+func (c *CategoryController) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var category models.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.categories.CreateCategory(r.Context(), &category); err != nil {
+		writeCategoryError(w, err, "Failed to create category")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+// GetCategoryTree fetches the whole category tree, roots first
+func (c *CategoryController) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := c.categories.GetCategoryTree(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tree)
+}
+
+// GetCategoryByID fetches a category and its direct children
+func (c *CategoryController) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	category, err := c.categories.GetCategoryByID(r.Context(), id)
+	if err != nil {
+		writeCategoryError(w, err, "Failed to fetch category")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+// UpdateCategory renames a category, along with the paths below it
+func (c *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var category models.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	category.ID = id
+
+	if err := c.categories.UpdateCategory(r.Context(), &category); err != nil {
+		writeCategoryError(w, err, "Failed to update category")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+// MoveCategory moves a category under the parent_id given, or to the root when it is null
+func (c *CategoryController) MoveCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		ParentID *uint `json:"parent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	category, err := c.categories.MoveCategory(r.Context(), id, body.ParentID)
+	if err != nil {
+		writeCategoryError(w, err, "Failed to move category")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+// MergeCategory merges a category into the one named by into_id and returns the target
+func (c *CategoryController) MergeCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		IntoID uint `json:"into_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.IntoID == 0 {
+		http.Error(w, "into_id is required", http.StatusBadRequest)
+		return
+	}
+
+	category, err := c.categories.MergeCategory(r.Context(), id, body.IntoID)
+	if err != nil {
+		writeCategoryError(w, err, "Failed to merge category")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+// DeleteCategory deletes a category without subcategories or products
+func (c *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.categories.DeleteCategory(r.Context(), id); err != nil {
+		writeCategoryError(w, err, "Failed to delete category")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Category deleted successfully"})
+}
+
+// writeCategoryError answers a failed category request, falling back to a 500 with message
+func writeCategoryError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCategory):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Category not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrDuplicate):
+		http.Error(w, "A category with this path already exists", http.StatusConflict)
+	case errors.Is(err, services.ErrCategoryInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
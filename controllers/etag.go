@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"inventory-supply-chain-system/repository"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setETag advertises the version of the resource in the response
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion reads the version a PUT or DELETE is based on from its
+// If-Match header, which must carry the ETag of a GET, or * to write
+// whatever the current version is. A missing header is answered with 428
+// and one that names no version with 412, and ok is false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		http.Error(w, "If-Match header with the resource's ETag is required", http.StatusPreconditionRequired)
+		return 0, false
+	}
+	if header == "*" {
+		return repository.AnyVersion, true
+	}
+
+	// A weak tag cannot be matched for a write, and nor can a list of tags
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version < 1 {
+		writeVersionConflict(w)
+		return 0, false
+	}
+	return version, true
+}
+
+// writeVersionConflict answers a write whose If-Match no longer matches
+func writeVersionConflict(w http.ResponseWriter) {
+	http.Error(w, "Resource was modified; fetch it again and retry", http.StatusPreconditionFailed)
+}
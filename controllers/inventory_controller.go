@@ -2,27 +2,40 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 	"inventory-supply-chain-system/services"
 
 	"github.com/gorilla/mux"
 )
 
+// InventoryController serves the inventory and stock movement endpoints
+type InventoryController struct {
+	inventory *services.InventoryService
+}
+
+// NewInventoryController returns an InventoryController backed by inventory
+func NewInventoryController(inventory *services.InventoryService) *InventoryController {
+	return &InventoryController{inventory: inventory}
+}
+
 // CreateInventory creates a new inventory item
 // @Summary Create a new inventory item
-// @Description Creates a new inventory item in the system
+// @Description Creates the stock record of a SKU; a SKU has at most one
 // @Tags inventory
 // @Accept json
 // @Produce json
 // @Param inventory body models.Inventory true "Inventory data"
 // @Success 201 {object} models.Inventory
-// @Failure 400 {string} string "Invalid input"
+// @Failure 400 {string} string "Invalid input or unknown SKU"
+// @Failure 409 {string} string "SKU already has a stock record"
 // @Failure 500 {string} string "Failed to create inventory"
 // @Router /inventory [post]
-func CreateInventory(w http.ResponseWriter, r *http.Request) {
+func (c *InventoryController) CreateInventory(w http.ResponseWriter, r *http.Request) {
 	var inventory models.Inventory
 	err := json.NewDecoder(r.Body).Decode(&inventory)
 	if err != nil {
@@ -30,33 +43,54 @@ func CreateInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.CreateInventoryItem(inventory)
-	if err != nil {
+	err = c.inventory.CreateInventoryItem(r.Context(), &inventory, currentUserID(r))
+	switch {
+	case errors.Is(err, services.ErrInvalidMovement), errors.Is(err, services.ErrInvalidSKU), errors.Is(err, services.ErrInvalidSerial):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, repository.ErrDuplicate):
+		http.Error(w, "SKU already has a stock record", http.StatusConflict)
+		return
+	case err != nil:
 		http.Error(w, "Failed to create inventory", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, inventory.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(inventory)
 }
 
-// GetInventoryItems fetches all inventory items
-// @Summary Get all inventory items
-// @Description Retrieves a list of all inventory items
+// GetInventoryItems fetches a page of inventory items
+// @Summary Get inventory items
+// @Description Retrieves a page of inventory items ordered by the sort keys
 // @Tags inventory
 // @Produce json
-// @Success 200 {array} models.Inventory
+// @Param limit query int false "Page size (max 200)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "Sort keys, e.g. -created_at,name"
+// @Success 200 {object} repository.Page[models.Inventory]
+// @Failure 400 {string} string "Invalid pagination parameters"
 // @Failure 500 {string} string "Failed to retrieve inventory items"
 // @Router /inventory [get]
-func GetInventoryItems(w http.ResponseWriter, r *http.Request) {
-	inventoryItems, err := services.GetInventoryItems()
+func (c *InventoryController) GetInventoryItems(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	inventoryItems, err := c.inventory.GetInventoryItems(r.Context(), page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to retrieve inventory items", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(inventoryItems)
+	writePage(w, r, inventoryItems)
 }
 
 // GetInventory fetches an inventory item by its ID
@@ -66,10 +100,11 @@ func GetInventoryItems(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Inventory ID"
 // @Success 200 {object} models.Inventory
+// @Header 200 {string} ETag "Version to send in If-Match when updating or deleting"
 // @Failure 400 {string} string "Invalid ID"
 // @Failure 404 {string} string "Inventory item not found"
 // @Router /inventory/{id} [get]
-func GetInventory(w http.ResponseWriter, r *http.Request) {
+func (c *InventoryController) GetInventory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -77,12 +112,13 @@ func GetInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	inventoryItem, err := services.GetInventoryItemByID(uint(id))
+	inventoryItem, err := c.inventory.GetInventoryItemByID(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, "Inventory item not found", http.StatusNotFound)
 		return
 	}
 
+	setETag(w, inventoryItem.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(inventoryItem)
 }
@@ -94,21 +130,29 @@ func GetInventory(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Inventory ID"
+// @Param If-Match header string true "ETag of the item as last read"
 // @Param inventory body models.Inventory true "Updated Inventory data"
 // @Success 200 {object} models.Inventory
-// @Failure 400 {string} string "Invalid input"
+// @Failure 400 {string} string "Invalid input, or a quantity change the item needs a lot or serial numbers for"
 // @Failure 404 {string} string "Inventory item not found"
+// @Failure 409 {string} string "Quantity below reserved stock"
+// @Failure 412 {string} string "Resource was modified"
+// @Failure 428 {string} string "If-Match header is required"
 // @Failure 500 {string} string "Failed to update inventory"
 // @Router /inventory/{id} [put]
-func UpdateInventory(w http.ResponseWriter, r *http.Request) {
+func (c *InventoryController) UpdateInventory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
 		http.Error(w, "Invalid ID", http.StatusBadRequest)
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	inventoryItem, err := services.GetInventoryItemByID(uint(id))
+	inventoryItem, err := c.inventory.GetInventoryItemByID(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, "Inventory item not found", http.StatusNotFound)
 		return
@@ -119,13 +163,29 @@ func UpdateInventory(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	inventoryItem.ID = uint(id)
+	inventoryItem.Version = version
 
-	err = services.UpdateInventoryItem(inventoryItem)
-	if err != nil {
+	err = c.inventory.UpdateInventoryItem(r.Context(), inventoryItem, currentUserID(r))
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Inventory item not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrInvalidMovement), errors.Is(err, services.ErrInvalidSerial):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrInsufficientStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
 		http.Error(w, "Failed to update inventory", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, inventoryItem.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(inventoryItem)
 }
@@ -135,11 +195,16 @@ func UpdateInventory(w http.ResponseWriter, r *http.Request) {
 // @Description Deletes an inventory item by its ID
 // @Tags inventory
 // @Param id path int true "Inventory ID"
+// @Param If-Match header string true "ETag of the item as last read"
 // @Success 204 {string} string "No Content"
 // @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Inventory item not found"
+// @Failure 409 {string} string "Inventory item still holds stock"
+// @Failure 412 {string} string "Resource was modified"
+// @Failure 428 {string} string "If-Match header is required"
 // @Failure 500 {string} string "Failed to delete inventory"
 // @Router /inventory/{id} [delete]
-func DeleteInventory(w http.ResponseWriter, r *http.Request) {
+func (c *InventoryController) DeleteInventory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -147,8 +212,23 @@ func DeleteInventory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.DeleteInventoryItem(uint(id))
-	if err != nil {
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err = c.inventory.DeleteInventoryItem(r.Context(), uint(id), version)
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w)
+		return
+	case errors.Is(err, services.ErrInventoryInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Inventory item not found", http.StatusNotFound)
+		return
+	case err != nil:
 		http.Error(w, "Failed to delete inventory", http.StatusInternalServerError)
 		return
 	}
@@ -158,7 +238,7 @@ func DeleteInventory(w http.ResponseWriter, r *http.Request) {
 
 // GetInventoryByProductID fetches all inventory items for a given product ID
 // @Summary Get inventory items by product ID
-// @Description Retrieves a list of inventory items by their associated product ID
+// @Description Retrieves the inventory items of every SKU of a product
 // @Tags inventory
 // @Produce json
 // @Param productID path int true "Product ID"
@@ -166,7 +246,7 @@ func DeleteInventory(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {string} string "Invalid product ID"
 // @Failure 500 {string} string "Failed to retrieve inventory items by product ID"
 // @Router /inventory/product/{productID} [get]
-func GetInventoryByProductID(w http.ResponseWriter, r *http.Request) {
+func (c *InventoryController) GetInventoryByProductID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	productID, err := strconv.Atoi(vars["productID"])
 	if err != nil {
@@ -174,7 +254,7 @@ func GetInventoryByProductID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	inventoryItems, err := services.GetInventoryItemsByProductID(uint(productID))
+	inventoryItems, err := c.inventory.GetInventoryItemsByProductID(r.Context(), uint(productID))
 	if err != nil {
 		http.Error(w, "Failed to retrieve inventory items by product ID", http.StatusInternalServerError)
 		return
@@ -184,9 +264,9 @@ func GetInventoryByProductID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(inventoryItems)
 }
 
-// GetInventoryByWarehouseID fetches all inventory items for a given warehouse ID
+// GetInventoryByWarehouseID fetches all inventory items with stock in a given warehouse
 // @Summary Get inventory items by warehouse ID
-// @Description Retrieves a list of inventory items by their associated warehouse ID
+// @Description Retrieves a list of inventory items that have stock in the warehouse
 // @Tags inventory
 // @Produce json
 // @Param warehouseID path int true "Warehouse ID"
@@ -194,7 +274,7 @@ func GetInventoryByProductID(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {string} string "Invalid warehouse ID"
 // @Failure 500 {string} string "Failed to retrieve inventory items by warehouse ID"
 // @Router /inventory/warehouse/{warehouseID} [get]
-func GetInventoryByWarehouseID(w http.ResponseWriter, r *http.Request) {
+func (c *InventoryController) GetInventoryByWarehouseID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	warehouseID, err := strconv.Atoi(vars["warehouseID"])
 	if err != nil {
@@ -202,7 +282,7 @@ func GetInventoryByWarehouseID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	inventoryItems, err := services.GetInventoryItemsByWarehouseID(uint(warehouseID))
+	inventoryItems, err := c.inventory.GetInventoryItemsByWarehouseID(r.Context(), uint(warehouseID))
 	if err != nil {
 		http.Error(w, "Failed to retrieve inventory items by warehouse ID", http.StatusInternalServerError)
 		return
@@ -211,3 +291,208 @@ func GetInventoryByWarehouseID(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(inventoryItems)
 }
+
+// RecordStockMovement books a movement against an inventory item's ledger
+// @Summary Record a stock movement
+// @Description Appends a receipt, issue, adjustment, transfer or return to the ledger of an inventory item and applies it to the on-hand quantity. A warehouse_id, and optionally a bin_id, books the movement against that location's balance. A transfer moves a positive quantity from there to to_warehouse_id and to_bin_id. Movements of serial-controlled SKUs list the serial number of each unit in serials
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "Inventory ID"
+// @Param movement body models.StockMovement true "Movement type, quantity, reason code and reference"
+// @Success 201 {object} models.StockMovement
+// @Failure 400 {string} string "Invalid movement"
+// @Failure 404 {string} string "Inventory item or location not found"
+// @Failure 409 {string} string "Insufficient stock, location unavailable or serial number unavailable"
+// @Failure 500 {string} string "Failed to record stock movement"
+// @Router /inventory/{id}/movements [post]
+func (c *InventoryController) RecordStockMovement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var movement models.StockMovement
+	if err := json.NewDecoder(r.Body).Decode(&movement); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	movement.InventoryID = uint(id)
+	movement.UserID = currentUserID(r)
+
+	err = c.inventory.RecordStockMovement(r.Context(), &movement)
+	switch {
+	case errors.Is(err, services.ErrInvalidMovement), errors.Is(err, services.ErrInvalidUnit), errors.Is(err, services.ErrInvalidSerial):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrInsufficientStock), errors.Is(err, services.ErrLocationUnavailable), errors.Is(err, services.ErrSerialUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Inventory item or location not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "Failed to record stock movement", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+// GetStockMovementsBySKU lists the ledger of a SKU with its running balance
+// @Summary List stock movements for a SKU
+// @Description Retrieves a page of the stock ledger for a SKU, oldest first; balance_after carries the running balance
+// @Tags inventory
+// @Produce json
+// @Param sku path string true "SKU"
+// @Param limit query int false "Page size (max 200)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} repository.Page[models.StockMovement]
+// @Failure 400 {string} string "Invalid pagination parameters"
+// @Failure 500 {string} string "Failed to retrieve stock movements"
+// @Router /inventory/sku/{sku}/movements [get]
+func (c *InventoryController) GetStockMovementsBySKU(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movements, err := c.inventory.GetStockMovementsBySKU(r.Context(), mux.Vars(r)["sku"], page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve stock movements", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, movements)
+}
+
+// GetInventoryReconciliation compares on-hand stock with the ledger
+// @Summary Compare on-hand stock with the ledger
+// @Description Reports the on-hand quantity, the ledger balance and the difference between them
+// @Tags inventory
+// @Produce json
+// @Param id path int true "Inventory ID"
+// @Success 200 {object} services.InventoryReconciliation
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Inventory item not found"
+// @Router /inventory/{id}/reconciliation [get]
+func (c *InventoryController) GetInventoryReconciliation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.inventory.GetInventoryReconciliation(r.Context(), uint(id))
+	if err != nil {
+		http.Error(w, "Inventory item not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// ReconcileInventory resets on-hand stock to the ledger balance
+// @Summary Reconcile on-hand stock against the ledger
+// @Description Sets the on-hand quantity to the ledger balance, or records the current quantity as an opening balance for items without any movements
+// @Tags inventory
+// @Produce json
+// @Param id path int true "Inventory ID"
+// @Success 200 {object} services.InventoryReconciliation
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 404 {string} string "Inventory item not found"
+// @Failure 500 {string} string "Failed to reconcile inventory"
+// @Router /inventory/{id}/reconcile [post]
+func (c *InventoryController) ReconcileInventory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.inventory.ReconcileInventory(r.Context(), uint(id), currentUserID(r))
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Inventory item not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to reconcile inventory", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetExpiringStock lists the lot stock expiring within a number of days
+// @Summary List stock expiring soon
+// @Description Retrieves the stock balances of lots that expire within days (default 30), soonest first, including lots that have already expired. warehouse_id limits the list to one warehouse
+// @Tags inventory
+// @Produce json
+// @Param days query int false "Days ahead to look, default 30"
+// @Param warehouse_id query int false "Warehouse ID"
+// @Success 200 {array} models.StockBalance
+// @Failure 400 {string} string "Invalid days or warehouse_id"
+// @Failure 500 {string} string "Failed to retrieve expiring stock"
+// @Router /inventory/expiring [get]
+func (c *InventoryController) GetExpiringStock(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		var err error
+		days, err = strconv.Atoi(raw)
+		if err != nil || days < 0 {
+			http.Error(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+	}
+	warehouseID, err := parseUintParam(r, "warehouse_id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	balances, err := c.inventory.GetExpiringStock(r.Context(), days, warehouseID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve expiring stock", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(balances)
+}
+
+// GetInventoryLocations lists where an inventory item is held
+// @Summary List the locations of an inventory item
+// @Description Retrieves the non-zero stock balances of an inventory item per warehouse, bin and lot; bin_id 0 is stock not assigned to a bin, lot_id 0 stock without a lot and warehouse_id 0 lot stock outside a warehouse
+// @Tags inventory
+// @Produce json
+// @Param id path int true "Inventory ID"
+// @Success 200 {array} models.StockBalance
+// @Failure 400 {string} string "Invalid ID"
+// @Failure 500 {string} string "Failed to retrieve inventory locations"
+// @Router /inventory/{id}/locations [get]
+func (c *InventoryController) GetInventoryLocations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	balances, err := c.inventory.GetInventoryLocations(r.Context(), uint(id))
+	if err != nil {
+		http.Error(w, "Failed to retrieve inventory locations", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(balances)
+}
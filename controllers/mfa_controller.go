@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+
+	"github.com/gorilla/mux"
+)
+
+// mfaCodeInput is the body of requests that confirm an action with a TOTP or recovery code
+type mfaCodeInput struct {
+	Code string `json:"code"`
+}
+
+// EnrollMFA starts MFA enrollment for the authenticated user and returns
+// the secret and otpauth URI to show as a QR code
+func (c *AuthController) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := c.mfa.BeginMFAEnrollment(r.Context(), currentUserID(r))
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// ConfirmMFA turns MFA on with a code from the newly enrolled app and
+// returns the user's recovery codes
+func (c *AuthController) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	var input mfaCodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := c.mfa.ConfirmMFAEnrollment(r.Context(), currentUserID(r), input.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
+
+// DisableMFA turns MFA off for the authenticated user
+func (c *AuthController) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	var input mfaCodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.mfa.DisableMFA(r.Context(), currentUserID(r), input.Code); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the authenticated user's recovery codes
+func (c *AuthController) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var input mfaCodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := c.mfa.RegenerateRecoveryCodes(r.Context(), currentUserID(r), input.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
+
+// ResetUserMFA turns MFA off for another user who lost their authenticator
+func (c *AuthController) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.mfa.ResetMFA(r.Context(), uint(id)); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompleteMFALogin finishes a login that returned an MFA challenge
+func (c *AuthController) CompleteMFALogin(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.MFAToken == "" || input.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.mfa.CompleteMFAChallenge(r.Context(), input.MFAToken, input.Code, clientIP(r))
+	if writeRetryAfter(w, err) {
+		return
+	}
+	if errors.Is(err, services.ErrInvalidMFAChallenge) || errors.Is(err, services.ErrInvalidMFACode) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error checking authentication code", http.StatusInternalServerError)
+		return
+	}
+
+	c.startSession(w, r, user)
+}
+
+// writeMFAError maps MFA service errors to status codes
+func writeMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidMFACode):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrMFAAlreadyEnabled), errors.Is(err, services.ErrMFANotEnabled),
+		errors.Is(err, services.ErrMFARequired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Error updating multi-factor authentication", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"inventory-supply-chain-system/services"
+)
+
+// OIDCLogin redirects the user to the single sign-on provider
+func (c *AuthController) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, err := c.oidc.BeginOIDCLogin(r.Context())
+	if errors.Is(err, services.ErrOIDCNotConfigured) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error starting single sign-on", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback is where the provider sends the user back. It signs them in
+// and returns the same response as LoginUser.
+func (c *AuthController) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if reason := query.Get("error"); reason != "" {
+		http.Error(w, "Single sign-on failed: "+reason, http.StatusUnauthorized)
+		return
+	}
+	if query.Get("state") == "" || query.Get("code") == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.oidc.CompleteOIDCLogin(r.Context(), query.Get("state"), query.Get("code"))
+	switch {
+	case errors.Is(err, services.ErrOIDCNotConfigured):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrInvalidOIDCLogin):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrOIDCIdentity):
+		log.Printf("Single sign-on rejected: %v", err)
+		http.Error(w, services.ErrOIDCIdentity.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		http.Error(w, "Error completing single sign-on", http.StatusInternalServerError)
+		return
+	}
+
+	c.completeLogin(w, r, user)
+}
@@ -2,17 +2,32 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 	"inventory-supply-chain-system/services"
 	"net/http"
-
+	"slices"
 	"strconv"
 
 	"github.com/gorilla/mux"
 )
 
-// CreateOrder handles the creation of a new order
-func CreateOrder(w http.ResponseWriter, r *http.Request) {
+// OrderController serves the order endpoints
+type OrderController struct {
+	orders *services.OrderService
+}
+
+// NewOrderController returns an OrderController backed by orders
+func NewOrderController(orders *services.OrderService) *OrderController {
+	return &OrderController{orders: orders}
+}
+
+// CreateOrder handles the creation of a new order. The total price and
+// status are set by the server, and the order is always placed for the
+// authenticated user; a user_id in the body is ignored.
+func (c *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var order models.Order
 	err := json.NewDecoder(r.Body).Decode(&order)
 	if err != nil {
@@ -20,34 +35,58 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.CreateOrder(order)
-	if err != nil {
+	order.UserID = currentUserID(r)
+
+	err = c.orders.CreateOrder(r.Context(), &order)
+	switch {
+	case errors.Is(err, services.ErrInsufficientStock):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrInvalidQuantity), errors.Is(err, services.ErrInvalidUnit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Inventory item not found", http.StatusBadRequest)
+		return
+	case err != nil:
 		http.Error(w, "Failed to create order", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, order.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(order)
 }
 
 // GetOrder handles retrieving a single order by ID
-func GetOrder(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	order, err := services.GetOrder(params["id"])
+func (c *OrderController) GetOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	order, err := c.orders.GetOrder(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Order not found", http.StatusNotFound)
 		return
 	}
 
+	setETag(w, order.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(order)
 }
 
-// UpdateOrder handles updating an existing order
-func UpdateOrder(w http.ResponseWriter, r *http.Request) {
+// UpdateOrder handles updating an existing order. The If-Match header must
+// carry the order's current ETag.
+func (c *OrderController) UpdateOrder(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	var order models.Order
 
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	err := json.NewDecoder(r.Body).Decode(&order)
 
 	if err != nil {
@@ -62,396 +101,129 @@ func UpdateOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	order.ID = uint(orderID) // Convert orderID to uint
+	order.Version = version
 
-	err = services.UpdateOrder(order)
-	if err != nil {
-		http.Error(w, "Failed to update order", http.StatusInternalServerError)
+	err = c.orders.UpdateOrder(r.Context(), &order, currentUserID(r))
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w)
 		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(order)
-}
-
-// DeleteOrder handles deleting an order by ID
-func DeleteOrder(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	err := services.DeleteOrder(params["id"])
-	if err != nil {
-		http.Error(w, "Failed to delete order", http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-// CreateOrderHandler handles creating a new order
-func CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
-	var order models.Order
-	err := json.NewDecoder(r.Body).Decode(&order)
-	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
-		return
-	}
-
-	err = services.CreateOrder(order)
-	if err != nil {
-		http.Error(w, "Failed to create order", http.StatusInternalServerError)
-		return
-	}
-
-	json.NewEncoder(w).Encode(order)
-}
-
-// GetAllOrdersHandler returns all orders
-func GetAllOrdersHandler(w http.ResponseWriter, r *http.Request) {
-	orders, err := services.GetAllOrders()
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
-	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrderHandler returns an order by ID
-func GetOrderHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id := params["id"]
-
-	order, err := services.GetOrder(id)
-	if err != nil {
+	case errors.Is(err, repository.ErrNotFound):
 		http.Error(w, "Order not found", http.StatusNotFound)
 		return
-	}
-
-	json.NewEncoder(w).Encode(order)
-}
-
-// UpdateOrderHandler updates an order by ID
-func UpdateOrderHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id := params["id"]
-
-	var order models.Order
-	order, err := services.GetOrder(id)
-	if err != nil {
-		http.Error(w, "Order not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidSerial):
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
-	}
-
-	err = json.NewDecoder(r.Body).Decode(&order)
-	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	case errors.Is(err, services.ErrInvalidStatusTransition), errors.Is(err, services.ErrInsufficientStock), errors.Is(err, services.ErrStockExpired),
+		errors.Is(err, services.ErrLocationUnavailable), errors.Is(err, services.ErrSerialUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
 		return
-	}
-
-	err = services.UpdateOrder(order)
-	if err != nil {
+	case err != nil:
 		http.Error(w, "Failed to update order", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, order.Version)
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(order)
 }
 
-// DeleteOrderHandler deletes an order by ID
-func DeleteOrderHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	id := params["id"]
-
-	err := services.DeleteOrder(id)
-	if err != nil {
-		http.Error(w, "Failed to delete order", http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-// GetOrdersByCustomerIDHandler returns orders by customer ID
-func GetOrdersByCustomerIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	customerID := params["customerID"]
-
-	orders, err := services.GetOrdersByCustomerID(customerID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
+// DeleteOrder handles deleting an order by ID. The If-Match header must
+// carry the order's current ETag.
+func (c *OrderController) DeleteOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
 		return
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByVendorIDHandler returns orders by vendor ID
-func GetOrdersByVendorIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	vendorID := params["vendorID"]
-
-	orders, err := services.GetOrdersByVendorID(vendorID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
 		return
 	}
 
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByProductIDHandler returns orders by product ID
-func GetOrdersByProductIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	productID := params["productID"]
-
-	orders, err := services.GetOrdersByProductID(productID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
-	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByShipmentIDHandler returns orders by shipment ID
-func GetOrdersByShipmentIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	shipmentID := params["shipmentID"]
-
-	orders, err := services.GetOrdersByShipmentID(shipmentID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
+	err := c.orders.DeleteOrder(r.Context(), id, version)
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w)
 		return
-	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByStatusHandler returns orders by status
-func GetOrdersByStatusHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	status := params["status"]
-
-	orders, err := services.GetOrdersByStatus(status)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Order not found", http.StatusNotFound)
 		return
-	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByDateRangeHandler returns orders by date range
-func GetOrdersByDateRangeHandler(w http.ResponseWriter, r *http.Request) {
-	startDate := r.URL.Query().Get("start")
-	endDate := r.URL.Query().Get("end")
-
-	orders, err := services.GetOrdersByDateRange(startDate, endDate)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
+	case err != nil:
+		http.Error(w, "Failed to delete order", http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(orders)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetOrdersByCustomerIDAndStatusHandler returns orders by customer ID and status
-func GetOrdersByCustomerIDAndStatusHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	customerID := params["customerID"]
-	status := params["status"]
-
-	orders, err := services.GetOrdersByCustomerIDAndStatus(customerID, status)
+// ListOrdersHandler returns a page of the orders matching any combination of
+// the customer_id, vendor_id, product_id, shipment_id, status, start, end,
+// min_total and max_total parameters. The legacy filter routes bind the same
+// names as path variables and are served by this handler too.
+func (c *OrderController) ListOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseOrderFilter(r)
 	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByVendorIDAndStatusHandler returns orders by vendor ID and status
-func GetOrdersByVendorIDAndStatusHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	vendorID := params["vendorID"]
-	status := params["status"]
-
-	orders, err := services.GetOrdersByVendorIDAndStatus(vendorID, status)
+	page, err := parsePageRequest(r)
 	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByProductIDAndStatusHandler returns orders by product ID and status
-func GetOrdersByProductIDAndStatusHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	productID := params["productID"]
-	status := params["status"]
-
-	orders, err := services.GetOrdersByProductIDAndStatus(productID, status)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
+	orders, err := c.orders.FindOrders(r.Context(), filter, page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByVendorIDAndProductIDHandler returns orders by vendor ID and product ID
-func GetOrdersByVendorIDAndProductIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	vendorID := params["vendorID"]
-	productID := params["productID"]
-
-	orders, err := services.GetOrdersByVendorIDAndProductID(vendorID, productID)
 	if err != nil {
 		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(orders)
+	writePage(w, r, orders)
 }
 
-// GetOrdersByVendorIDAndShipmentIDHandler returns orders by vendor ID and shipment ID
-func GetOrdersByVendorIDAndShipmentIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	vendorID := params["vendorID"]
-	shipmentID := params["shipmentID"]
+// parseOrderFilter reads an OrderFilter from the route variables and query string
+func parseOrderFilter(r *http.Request) (repository.OrderFilter, error) {
+	var filter repository.OrderFilter
+	var err error
 
-	orders, err := services.GetOrdersByVendorIDAndShipmentID(vendorID, shipmentID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	if filter.CustomerID, err = parseUintParam(r, "customer_id"); err != nil {
+		return filter, err
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByProductIDAndShipmentIDHandler returns orders by product ID and shipment ID
-func GetOrdersByProductIDAndShipmentIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	productID := params["productID"]
-	shipmentID := params["shipmentID"]
-
-	orders, err := services.GetOrdersByProductIDAndShipmentID(productID, shipmentID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	if filter.VendorID, err = parseUintParam(r, "vendor_id"); err != nil {
+		return filter, err
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByCustomerIDAndProductIDHandler returns orders by customer ID and product ID
-func GetOrdersByCustomerIDAndProductIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	customerID := params["customerID"]
-	productID := params["productID"]
-
-	orders, err := services.GetOrdersByCustomerIDAndProductID(customerID, productID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	if filter.ProductID, err = parseUintParam(r, "product_id"); err != nil {
+		return filter, err
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByCustomerIDAndShipmentIDHandler returns orders by customer ID and shipment ID
-func GetOrdersByCustomerIDAndShipmentIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	customerID := params["customerID"]
-	shipmentID := params["shipmentID"]
-
-	orders, err := services.GetOrdersByCustomerIDAndShipmentID(customerID, shipmentID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	if filter.ShipmentID, err = parseUintParam(r, "shipment_id"); err != nil {
+		return filter, err
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByCustomerIDAndProductIDAndShipmentIDHandler returns orders by customer ID, product ID, and shipment ID
-func GetOrdersByCustomerIDAndProductIDAndShipmentIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	customerID := params["customerID"]
-	productID := params["productID"]
-	shipmentID := params["shipmentID"]
-
-	orders, err := services.GetOrdersByCustomerIDAndProductIDAndShipmentID(customerID, productID, shipmentID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	if filter.From, err = parseTimeParam(r, "start"); err != nil {
+		return filter, err
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByVendorIDAndProductIDAndShipmentIDHandler returns orders by vendor ID, product ID, and shipment ID
-func GetOrdersByVendorIDAndProductIDAndShipmentIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	vendorID := params["vendorID"]
-	productID := params["productID"]
-	shipmentID := params["shipmentID"]
-
-	orders, err := services.GetOrdersByVendorIDAndProductIDAndShipmentID(vendorID, productID, shipmentID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	if filter.To, filter.Before, err = parseEndParam(r, "end"); err != nil {
+		return filter, err
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByCustomerIDAndVendorIDAndProductIDAndShipmentIDHandler returns orders by customer ID, vendor ID, product ID, and shipment ID
-func GetOrdersByCustomerIDAndVendorIDAndProductIDAndShipmentIDHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	customerID := params["customerID"]
-	vendorID := params["vendorID"]
-	productID := params["productID"]
-	shipmentID := params["shipmentID"]
-
-	orders, err := services.GetOrdersByCustomerIDAndVendorIDAndProductIDAndShipmentID(customerID, vendorID, productID, shipmentID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	if filter.MinTotal, err = parseFloatParam(r, "min_total"); err != nil {
+		return filter, err
 	}
-
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByStatusAndDateRangeHandler returns orders by status and date range
-func GetOrdersByStatusAndDateRangeHandler(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	status := params["status"]
-	startDate := r.URL.Query().Get("start")
-	endDate := r.URL.Query().Get("end")
-
-	orders, err := services.GetOrdersByStatusAndDateRange(status, startDate, endDate)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	if filter.MaxTotal, err = parseFloatParam(r, "max_total"); err != nil {
+		return filter, err
 	}
 
-	json.NewEncoder(w).Encode(orders)
-}
-
-// GetOrdersByCustomerIDAndStatusAndDateRangeHandler returns orders by customer ID, status, and date range
-func GetOrdersByCustomerIDAndStatusAndDateRangeHandler(w http.ResponseWriter, r *http.Request) {
-
-	params := mux.Vars(r)
-	customerID := params["customerID"]
-	status := params["status"]
-	startDate := r.URL.Query().Get("start")
-	endDate := r.URL.Query().Get("end")
-
-	orders, err := services.GetOrdersByCustomerIDAndStatusAndDateRange(customerID, status, startDate, endDate)
-	if err != nil {
-		http.Error(w, "Failed to retrieve orders", http.StatusInternalServerError)
-		return
+	// A status in the path narrows the ones asked for in the query
+	filter.Statuses = queryList(r, "status")
+	if status, ok := mux.Vars(r)["status"]; ok {
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, status) {
+			return filter, fmt.Errorf("status %s in the path is not among the statuses in the query", status)
+		}
+		filter.Statuses = []string{status}
 	}
 
-	json.NewEncoder(w).Encode(orders)
+	return filter, nil
 }
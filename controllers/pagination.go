@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"inventory-supply-chain-system/repository"
+)
+
+// parsePageRequest reads the limit, cursor and sort query parameters
+func parsePageRequest(r *http.Request) (repository.PageRequest, error) {
+	query := r.URL.Query()
+	page := repository.PageRequest{
+		Cursor: query.Get("cursor"),
+		Sort:   repository.ParseSortKeys(query.Get("sort")),
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return page, errors.New("invalid limit")
+		}
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+// isListRequestError reports whether err was caused by the caller's
+// pagination or sort parameters rather than by the server
+func isListRequestError(err error) bool {
+	return errors.Is(err, repository.ErrInvalidCursor) || errors.Is(err, repository.ErrInvalidSortField)
+}
+
+// writePage encodes page in the list response envelope and advertises the
+// next page through a Link header
+func writePage[T any](w http.ResponseWriter, r *http.Request, page repository.Page[T]) {
+	if page.NextCursor != "" {
+		query := r.URL.Query()
+		query.Set("cursor", page.NextCursor)
+		query.Set("limit", strconv.Itoa(page.Limit))
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
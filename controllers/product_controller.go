@@ -2,25 +2,37 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 	"inventory-supply-chain-system/services"
 
 	"github.com/gorilla/mux"
 )
 
+// ProductController serves the product endpoints
+type ProductController struct {
+	products *services.ProductService
+}
+
+// NewProductController returns a ProductController backed by products
+func NewProductController(products *services.ProductService) *ProductController {
+	return &ProductController{products: products}
+}
+
 // CreateProductHandler handles the creation of a new product
-func CreateProductHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) CreateProductHandler(w http.ResponseWriter, r *http.Request) {
 	var product models.Product
 	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
 
-	if err := services.CreateProduct(product); err != nil {
-		http.Error(w, "Failed to create product", http.StatusInternalServerError)
+	if err := c.products.CreateProduct(r.Context(), &product); err != nil {
+		writeProductError(w, err, "Failed to create product")
 		return
 	}
 
@@ -28,27 +40,40 @@ func CreateProductHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(product)
 }
 
-// GetProductsHandler fetches all products
-func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
-	products, err := services.GetProducts()
+// GetProductsHandler fetches a page of products
+func (c *ProductController) GetProductsHandler(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := c.products.GetProducts(r.Context(), page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(products)
+	writePage(w, r, products)
 }
 
 // GetProductByIDHandler fetches a product by ID
-func GetProductByIDHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductByIDHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
 	if err != nil {
 		http.Error(w, "Invalid product ID", http.StatusBadRequest)
 		return
 	}
 
-	product, err := services.GetProductByID(uint(id))
+	product, err := c.products.GetProductByID(r.Context(), uint(id))
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch product", http.StatusInternalServerError)
 		return
@@ -59,14 +84,14 @@ func GetProductByIDHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateProductHandler handles the update of an existing product
-func UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
 	if err != nil {
 		http.Error(w, "Invalid product ID", http.StatusBadRequest)
 		return
 	}
 
-	product, err := services.GetProductByID(uint(id))
+	product, err := c.products.GetProductByID(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, "Product not found", http.StatusNotFound)
 		return
@@ -76,9 +101,10 @@ func UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	product.ID = uint(id)
 
-	if err := services.UpdateProduct(product); err != nil {
-		http.Error(w, "Failed to update product", http.StatusInternalServerError)
+	if err := c.products.UpdateProduct(r.Context(), product); err != nil {
+		writeProductError(w, err, "Failed to update product")
 		return
 	}
 
@@ -87,15 +113,15 @@ func UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteProductHandler deletes a product by ID
-func DeleteProductHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) DeleteProductHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
 	if err != nil {
 		http.Error(w, "Invalid product ID", http.StatusBadRequest)
 		return
 	}
 
-	if err := services.DeleteProduct(uint(id)); err != nil {
-		http.Error(w, "Failed to delete product", http.StatusInternalServerError)
+	if err := c.products.DeleteProduct(r.Context(), uint(id)); err != nil {
+		writeProductError(w, err, "Failed to delete product")
 		return
 	}
 
@@ -103,10 +129,24 @@ func DeleteProductHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Product deleted successfully"})
 }
 
+// writeProductError answers a failed product write, falling back to a 500 with message
+func writeProductError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidProduct), errors.Is(err, services.ErrInvalidSKU):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrDuplicate):
+		http.Error(w, "SKU code already exists", http.StatusConflict)
+	case errors.Is(err, services.ErrProductHasSKUs):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
 // GetProductsByCategoryHandler fetches products by category
-func GetProductsByCategoryHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByCategoryHandler(w http.ResponseWriter, r *http.Request) {
 	category := mux.Vars(r)["category"]
-	products, err := services.GetProductsByCategory(category)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{Category: category})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -117,11 +157,11 @@ func GetProductsByCategoryHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetProductsByPriceRangeHandler fetches products by price range
-func GetProductsByPriceRangeHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByPriceRangeHandler(w http.ResponseWriter, r *http.Request) {
 	minPrice, _ := strconv.ParseFloat(r.URL.Query().Get("minPrice"), 64)
 	maxPrice, _ := strconv.ParseFloat(r.URL.Query().Get("maxPrice"), 64)
 
-	products, err := services.GetProductsByPriceRange(minPrice, maxPrice)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -132,10 +172,10 @@ func GetProductsByPriceRangeHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetProductsByStockHandler fetches products by stock level
-func GetProductsByStockHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByStockHandler(w http.ResponseWriter, r *http.Request) {
 	stock, _ := strconv.Atoi(mux.Vars(r)["stock"])
 
-	products, err := services.GetProductsByStock(stock)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{Stock: &stock})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -146,11 +186,11 @@ func GetProductsByStockHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetProductsByStockRangeHandler fetches products by stock range
-func GetProductsByStockRangeHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByStockRangeHandler(w http.ResponseWriter, r *http.Request) {
 	minStock, _ := strconv.Atoi(r.URL.Query().Get("minStock"))
 	maxStock, _ := strconv.Atoi(r.URL.Query().Get("maxStock"))
 
-	products, err := services.GetProductsByStockRange(minStock, maxStock)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{MinStock: &minStock, MaxStock: &maxStock})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -160,88 +200,13 @@ func GetProductsByStockRangeHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(products)
 }
 
-// CreateInventoryItemHandler handles the creation of a new inventory item
-func CreateInventoryItemHandler(w http.ResponseWriter, r *http.Request) {
-	var inventory models.Inventory
-	if err := json.NewDecoder(r.Body).Decode(&inventory); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
-		return
-	}
-
-	if err := services.CreateInventoryItem(inventory); err != nil {
-		http.Error(w, "Failed to create inventory", http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(inventory)
-}
-
-// GetInventoryItemsHandler fetches all inventory items
-func GetInventoryItemsHandler(w http.ResponseWriter, r *http.Request) {
-	inventoryItems, err := services.GetInventoryItems()
-	if err != nil {
-		http.Error(w, "Failed to retrieve inventory items", http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(inventoryItems)
-}
-
-// GetInventoryItemHandler fetches an inventory item by its ID
-func GetInventoryItemHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
-	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
-		return
-	}
-
-	inventoryItem, err := services.GetInventoryItemByID(uint(id))
-	if err != nil {
-		http.Error(w, "Inventory item not found", http.StatusNotFound)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(inventoryItem)
-}
-
-// UpdateInventoryItemHandler updates an existing inventory item
-func UpdateInventoryItemHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
-	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
-		return
-	}
-
-	inventoryItem, err := services.GetInventoryItemByID(uint(id))
-	if err != nil {
-		http.Error(w, "Inventory item not found", http.StatusNotFound)
-		return
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&inventoryItem); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
-		return
-	}
-
-	if err := services.UpdateInventoryItem(inventoryItem); err != nil {
-		http.Error(w, "Failed to update inventory item", http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(inventoryItem)
-}
-
 // GetProductsByCategoryAndPriceRangeHandler fetches products by category and price range
-func GetProductsByCategoryAndPriceRangeHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByCategoryAndPriceRangeHandler(w http.ResponseWriter, r *http.Request) {
 	category := mux.Vars(r)["category"]
 	minPrice, _ := strconv.ParseFloat(r.URL.Query().Get("minPrice"), 64)
 	maxPrice, _ := strconv.ParseFloat(r.URL.Query().Get("maxPrice"), 64)
 
-	products, err := services.GetProductsByCategoryAndPriceRange(category, minPrice, maxPrice)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{Category: category, MinPrice: &minPrice, MaxPrice: &maxPrice})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -252,11 +217,11 @@ func GetProductsByCategoryAndPriceRangeHandler(w http.ResponseWriter, r *http.Re
 }
 
 // GetProductsByCategoryAndStockHandler fetches products by category and stock level
-func GetProductsByCategoryAndStockHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByCategoryAndStockHandler(w http.ResponseWriter, r *http.Request) {
 	category := mux.Vars(r)["category"]
 	stock, _ := strconv.Atoi(mux.Vars(r)["stock"])
 
-	products, err := services.GetProductsByCategoryAndStock(category, stock)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{Category: category, Stock: &stock})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -267,12 +232,12 @@ func GetProductsByCategoryAndStockHandler(w http.ResponseWriter, r *http.Request
 }
 
 // GetProductsByCategoryAndStockRangeHandler fetches products by category and stock range
-func GetProductsByCategoryAndStockRangeHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByCategoryAndStockRangeHandler(w http.ResponseWriter, r *http.Request) {
 	category := mux.Vars(r)["category"]
 	minStock, _ := strconv.Atoi(r.URL.Query().Get("minStock"))
 	maxStock, _ := strconv.Atoi(r.URL.Query().Get("maxStock"))
 
-	products, err := services.GetProductsByCategoryAndStockRange(category, minStock, maxStock)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{Category: category, MinStock: &minStock, MaxStock: &maxStock})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -283,12 +248,12 @@ func GetProductsByCategoryAndStockRangeHandler(w http.ResponseWriter, r *http.Re
 }
 
 // GetProductsByPriceRangeAndStockHandler fetches products by price range and stock level
-func GetProductsByPriceRangeAndStockHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByPriceRangeAndStockHandler(w http.ResponseWriter, r *http.Request) {
 	minPrice, _ := strconv.ParseFloat(r.URL.Query().Get("minPrice"), 64)
 	maxPrice, _ := strconv.ParseFloat(r.URL.Query().Get("maxPrice"), 64)
 	stock, _ := strconv.Atoi(mux.Vars(r)["stock"])
 
-	products, err := services.GetProductsByPriceRangeAndStock(minPrice, maxPrice, stock)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice, Stock: &stock})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -299,13 +264,13 @@ func GetProductsByPriceRangeAndStockHandler(w http.ResponseWriter, r *http.Reque
 }
 
 // GetProductsByPriceRangeAndStockRangeHandler fetches products by price range and stock range
-func GetProductsByPriceRangeAndStockRangeHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByPriceRangeAndStockRangeHandler(w http.ResponseWriter, r *http.Request) {
 	minPrice, _ := strconv.ParseFloat(r.URL.Query().Get("minPrice"), 64)
 	maxPrice, _ := strconv.ParseFloat(r.URL.Query().Get("maxPrice"), 64)
 	minStock, _ := strconv.Atoi(r.URL.Query().Get("minStock"))
 	maxStock, _ := strconv.Atoi(r.URL.Query().Get("maxStock"))
 
-	products, err := services.GetProductsByPriceRangeAndStockRange(minPrice, maxPrice, minStock, maxStock)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice, MinStock: &minStock, MaxStock: &maxStock})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -316,13 +281,13 @@ func GetProductsByPriceRangeAndStockRangeHandler(w http.ResponseWriter, r *http.
 }
 
 // GetProductsByCategoryPriceRangeAndStockHandler fetches products by category, price range, and stock level
-func GetProductsByCategoryPriceRangeAndStockHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByCategoryPriceRangeAndStockHandler(w http.ResponseWriter, r *http.Request) {
 	category := mux.Vars(r)["category"]
 	minPrice, _ := strconv.ParseFloat(r.URL.Query().Get("minPrice"), 64)
 	maxPrice, _ := strconv.ParseFloat(r.URL.Query().Get("maxPrice"), 64)
 	stock, _ := strconv.Atoi(mux.Vars(r)["stock"])
 
-	products, err := services.GetProductsByCategoryPriceRangeAndStock(category, minPrice, maxPrice, stock)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{Category: category, MinPrice: &minPrice, MaxPrice: &maxPrice, Stock: &stock})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -333,14 +298,14 @@ func GetProductsByCategoryPriceRangeAndStockHandler(w http.ResponseWriter, r *ht
 }
 
 // GetProductsByCategoryPriceRangeAndStockRangeHandler fetches products by category, price range, and stock range
-func GetProductsByCategoryPriceRangeAndStockRangeHandler(w http.ResponseWriter, r *http.Request) {
+func (c *ProductController) GetProductsByCategoryPriceRangeAndStockRangeHandler(w http.ResponseWriter, r *http.Request) {
 	category := mux.Vars(r)["category"]
 	minPrice, _ := strconv.ParseFloat(r.URL.Query().Get("minPrice"), 64)
 	maxPrice, _ := strconv.ParseFloat(r.URL.Query().Get("maxPrice"), 64)
 	minStock, _ := strconv.Atoi(r.URL.Query().Get("minStock"))
 	maxStock, _ := strconv.Atoi(r.URL.Query().Get("maxStock"))
 
-	products, err := services.GetProductsByCategoryPriceRangeAndStockRange(category, minPrice, maxPrice, minStock, maxStock)
+	products, err := c.products.FindProducts(r.Context(), repository.ProductFilter{Category: category, MinPrice: &minPrice, MaxPrice: &maxPrice, MinStock: &minStock, MaxStock: &maxStock})
 	if err != nil {
 		http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
 		return
@@ -2,77 +2,79 @@ package controllers
 
 import (
 	"encoding/json"
-	"inventory-supply-chain-system/db"
-	"inventory-supply-chain-system/models"
+	"errors"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+	"log"
 	"net/http"
 )
 
-// GetProfileHandler returns the profile of the authenticated user
-func GetProfileHandler(w http.ResponseWriter, r *http.Request) {
-	// Retrieve the user ID from the context (set by the AuthMiddleware)
-	userID := r.Context().Value("userID").(uint)
+// ProfileController serves the authenticated user's own profile
+type ProfileController struct {
+	users        *services.UserService
+	verification *services.VerificationService
+}
+
+// NewProfileController returns a ProfileController backed by the given services
+func NewProfileController(users *services.UserService, verification *services.VerificationService) *ProfileController {
+	return &ProfileController{users: users, verification: verification}
+}
 
-	// Fetch the user information from the database
-	var user models.User
-	if err := db.DB.First(&user, userID).Error; err != nil {
+// GetProfileHandler returns the profile of the authenticated user
+func (c *ProfileController) GetProfileHandler(w http.ResponseWriter, r *http.Request) {
+	// Fetch the user information for the ID set by the AuthMiddleware
+	user, err := c.users.GetUser(r.Context(), currentUserID(r))
+	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
 
-	// Return user information, excluding the password
-	user.Password = ""
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(user)
 }
 
-// UpdateProfileHandler updates the profile of the authenticated user
-func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
-	// Retrieve the user ID from the context (set by the AuthMiddleware)
-	userID := r.Context().Value("userID").(uint)
-
-	// Fetch the user information from the database
-	var user models.User
-	if err := db.DB.First(&user, userID).Error; err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
-		return
-	}
-
+// UpdateProfileHandler updates the profile of the authenticated user. Only
+// the fields sent are changed. A new email address has to be confirmed
+// through the verification link sent to it.
+func (c *ProfileController) UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 	// Decode the updated user information from the request body
-	var updatedUser models.User
-	if err := json.NewDecoder(r.Body).Decode(&updatedUser); err != nil {
+	var changes services.ProfileChanges
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
 
-	// Update the user information
-	user.Name = updatedUser.Name
-	user.Email = updatedUser.Email
-	user.Phone = updatedUser.Phone
-
-	// Save the updated user information
-	if err := db.DB.Save(&user).Error; err != nil {
+	// Update the user information for the ID set by the AuthMiddleware
+	user, emailChanged, err := c.users.UpdateProfile(r.Context(), currentUserID(r), changes)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrEmailRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case errors.Is(err, repository.ErrDuplicate):
+		http.Error(w, "Email is already in use", http.StatusConflict)
+		return
+	default:
 		http.Error(w, "Failed to update user profile", http.StatusInternalServerError)
 		return
 	}
 
-	// Return the updated user information, excluding the password
-	user.Password = ""
+	if emailChanged {
+		// The change stands without the link; the user can ask for another
+		if err := c.verification.SendVerification(r.Context(), &user); err != nil {
+			log.Printf("Error sending verification email to %s: %v", user.Email, err)
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(user)
 }
 
 // UpdatePasswordHandler updates the password of the authenticated user
-func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
-	// Retrieve the user ID from the context (set by the AuthMiddleware)
-	userID := r.Context().Value("userID").(uint)
-
-	// Fetch the user information from the database
-	var user models.User
-	if err := db.DB.First(&user, userID).Error; err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
-		return
-	}
-
+func (c *ProfileController) UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	// Decode the updated password from the request body
 	var passwordUpdate struct {
 		Password string `json:"password"`
@@ -82,56 +84,18 @@ func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update the user's password
-	user.Password = passwordUpdate.Password
-
-	// Save the updated user information
-	if err := db.DB.Save(&user).Error; err != nil {
-		http.Error(w, "Failed to update password", http.StatusInternalServerError)
-		return
-	}
-
-	// Return a success message
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode("Password updated successfully")
-}
-
-// GetAllProfilesHandler returns all the profiles of the users
-func GetAllProfilesHandler(w http.ResponseWriter, r *http.Request) {
-	// Fetch all the user information from the database
-	var users []models.User
-	if err := db.DB.Find(&users).Error; err != nil {
-		http.Error(w, "Failed to fetch user profiles", http.StatusInternalServerError)
-		return
-	}
-
-	// Return user information, excluding the password
-	for i := range users {
-		users[i].Password = ""
-	}
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(users)
-}
-
-// DeleteProfileHandler deletes the profile of the user
-func DeleteProfileHandler(w http.ResponseWriter, r *http.Request) {
-	// Retrieve the user ID from the context (set by the AuthMiddleware)
-	userID := r.Context().Value("userID").(uint)
-
-	// Fetch the user information from the database
-	var user models.User
-	if err := db.DB.First(&user, userID).Error; err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+	// Hash and store the new password, ending the tokens issued with the old one
+	err := c.users.ChangePassword(r.Context(), currentUserID(r), passwordUpdate.Password)
+	if errors.Is(err, services.ErrWeakPassword) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Delete the user from the database
-	if err := db.DB.Delete(&user).Error; err != nil {
-		http.Error(w, "Failed to delete user profile", http.StatusInternalServerError)
+	if err != nil {
+		http.Error(w, "Failed to update password", http.StatusInternalServerError)
 		return
 	}
 
 	// Return a success message
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode("User profile deleted successfully")
+	json.NewEncoder(w).Encode("Password updated successfully")
 }
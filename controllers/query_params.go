@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// queryParam returns a named parameter from the route variables, falling
+// back to the query string, so alias routes and query filters read the same way
+func queryParam(r *http.Request, name string) string {
+	if v, ok := mux.Vars(r)[name]; ok {
+		return v
+	}
+	return r.URL.Query().Get(name)
+}
+
+// queryList returns every value of a query parameter, accepting both
+// repeated keys (?status=a&status=b) and comma-separated values (?status=a,b)
+func queryList(r *http.Request, name string) []string {
+	var values []string
+	for _, raw := range r.URL.Query()[name] {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// parseUintParam parses an optional unsigned integer parameter
+func parseUintParam(r *http.Request, name string) (uint, error) {
+	raw := queryParam(r, name)
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return uint(v), nil
+}
+
+// parseFloatParam parses an optional floating point parameter
+func parseFloatParam(r *http.Request, name string) (*float64, error) {
+	raw := queryParam(r, name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return &v, nil
+}
+
+// dateLayout is the layout of a date given without a time of day
+const dateLayout = "2006-01-02"
+
+// parseTimeParam parses an optional RFC 3339 timestamp or YYYY-MM-DD date
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	raw := queryParam(r, name)
+	if raw == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, dateLayout} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s", name)
+}
+
+// parseEndParam parses the optional end of a time range. A timestamp is the
+// last instant included and is returned as to. A YYYY-MM-DD date includes
+// the whole day, so it is returned as before, the start of the next day,
+// which the range ends just short of.
+func parseEndParam(r *http.Request, name string) (to, before *time.Time, err error) {
+	end, err := parseTimeParam(r, name)
+	if err != nil || end == nil {
+		return nil, nil, err
+	}
+	if _, err := time.Parse(dateLayout, queryParam(r, name)); err == nil {
+		next := end.AddDate(0, 0, 1)
+		return nil, &next, nil
+	}
+	return end, nil, nil
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestQueryList(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?status=pending,%20shipped&status=&status=cancelled,", nil)
+	if got, want := queryList(r, "status"), []string{"pending", "shipped", "cancelled"}; !slices.Equal(got, want) {
+		t.Fatalf("queryList = %q, want %q", got, want)
+	}
+}
+
+func TestParseEndParam(t *testing.T) {
+	for _, test := range []struct {
+		raw        string
+		to, before string
+	}{
+		{raw: ""},
+		{raw: "2026-01-31", before: "2026-02-01T00:00:00Z"},
+		{raw: "2026-12-31", before: "2027-01-01T00:00:00Z"},
+		{raw: "2026-01-31T10:30:00Z", to: "2026-01-31T10:30:00Z"},
+		{raw: "2026-01-31T10:30:00+02:00", to: "2026-01-31T08:30:00Z"},
+	} {
+		r := httptest.NewRequest("GET", "/?end="+url.QueryEscape(test.raw), nil)
+		to, before, err := parseEndParam(r, "end")
+		if err != nil {
+			t.Fatalf("end=%s: %v", test.raw, err)
+		}
+		if got := formatTime(to); got != test.to {
+			t.Errorf("end=%s: to = %s, want %s", test.raw, got, test.to)
+		}
+		if got := formatTime(before); got != test.before {
+			t.Errorf("end=%s: before = %s, want %s", test.raw, got, test.before)
+		}
+	}
+
+	for _, raw := range []string{"yesterday", "2026-02-30", "2026-01-31 10:30"} {
+		r := httptest.NewRequest("GET", "/?end="+url.QueryEscape(raw), nil)
+		if _, _, err := parseEndParam(r, "end"); err == nil {
+			t.Errorf("end=%s was accepted", raw)
+		}
+	}
+}
+
+// formatTime formats an optional time in UTC, or returns "" for nil
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func TestParseOrderFilterStatuses(t *testing.T) {
+	for _, test := range []struct {
+		path, query string
+		want        []string
+		invalid     bool
+	}{
+		{query: "status=pending,shipped", want: []string{"pending", "shipped"}},
+		{path: "pending", want: []string{"pending"}},
+		{path: "pending", query: "status=pending,shipped", want: []string{"pending"}},
+		{path: "pending", query: "status=shipped", invalid: true},
+	} {
+		r := httptest.NewRequest("GET", "/?"+test.query, nil)
+		if test.path != "" {
+			r = mux.SetURLVars(r, map[string]string{"status": test.path})
+		}
+		filter, err := parseOrderFilter(r)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("status %s with %s was accepted", test.path, test.query)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(filter.Statuses, test.want) {
+			t.Errorf("status %s with %s = %q, %v; want %q", test.path, test.query, filter.Statuses, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"net"
+	"net/http"
+)
+
+// currentUserID returns the ID of the authenticated user that AuthMiddleware
+// stored in the request context, or 0 for anonymous requests
+func currentUserID(r *http.Request) uint {
+	userID, _ := r.Context().Value("userID").(uint)
+	return userID
+}
+
+// clientIP returns the address of the client that sent the request, without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
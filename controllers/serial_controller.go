@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+
+	"github.com/gorilla/mux"
+)
+
+// SerialController serves the serial number trace endpoint
+type SerialController struct {
+	serials *services.SerialService
+}
+
+// NewSerialController returns a SerialController backed by serials
+func NewSerialController(serials *services.SerialService) *SerialController {
+	return &SerialController{serials: serials}
+}
+
+// GetSerialTrace fetches a serial number with its history and the orders and shipments it went out on
+func (c *SerialController) GetSerialTrace(w http.ResponseWriter, r *http.Request) {
+	trace, err := c.serials.GetSerialTrace(r.Context(), mux.Vars(r)["serial"])
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Serial number not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch serial number", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trace)
+}
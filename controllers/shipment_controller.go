@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 	"inventory-supply-chain-system/services"
 	"net/http"
 	"strconv"
@@ -10,8 +12,18 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// ShipmentController serves the shipment endpoints
+type ShipmentController struct {
+	shipments *services.ShipmentService
+}
+
+// NewShipmentController returns a ShipmentController backed by shipments
+func NewShipmentController(shipments *services.ShipmentService) *ShipmentController {
+	return &ShipmentController{shipments: shipments}
+}
+
 // CreateShipment handles the creation of a new shipment
-func CreateShipment(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) CreateShipment(w http.ResponseWriter, r *http.Request) {
 	var shipment models.Shipment
 	err := json.NewDecoder(r.Body).Decode(&shipment)
 	if err != nil {
@@ -19,29 +31,47 @@ func CreateShipment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.CreateShipment(shipment)
-	if err != nil {
+	err = c.shipments.CreateShipment(r.Context(), &shipment, currentUserID(r))
+	switch {
+	case errors.Is(err, services.ErrInvalidSerial):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrSerialUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
 		http.Error(w, "Error creating shipment", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, shipment.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(shipment)
 }
 
-// GetShipments fetches all shipments
-func GetShipments(w http.ResponseWriter, r *http.Request) {
-	shipments, err := services.GetShipments()
+// GetShipments fetches a page of shipments
+func (c *ShipmentController) GetShipments(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	shipments, err := c.shipments.GetShipments(r.Context(), page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Error fetching shipments", http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(shipments)
+	writePage(w, r, shipments)
 }
 
 // GetShipmentByID fetches a shipment by its ID
-func GetShipmentByID(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentByID(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["id"])
 	if err != nil {
@@ -49,23 +79,29 @@ func GetShipmentByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shipment, err := services.GetShipmentByID(uint(id))
+	shipment, err := c.shipments.GetShipmentByID(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, "Shipment not found", http.StatusNotFound)
 		return
 	}
 
+	setETag(w, shipment.Version)
 	json.NewEncoder(w).Encode(shipment)
 }
 
-// UpdateShipment updates an existing shipment
-func UpdateShipment(w http.ResponseWriter, r *http.Request) {
+// UpdateShipment updates an existing shipment. The If-Match header must
+// carry the shipment's current ETag.
+func (c *ShipmentController) UpdateShipment(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["id"])
 	if err != nil {
 		http.Error(w, "Invalid shipment ID", http.StatusBadRequest)
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
 	var shipment models.Shipment
 	err = json.NewDecoder(r.Body).Decode(&shipment)
@@ -75,26 +111,53 @@ func UpdateShipment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	shipment.ID = uint(id)
-	err = services.UpdateShipment(&shipment)
-	if err != nil {
+	shipment.Version = version
+	err = c.shipments.UpdateShipment(r.Context(), &shipment, currentUserID(r))
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Shipment not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrInvalidSerial):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrSerialUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
 		http.Error(w, "Error updating shipment", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, shipment.Version)
 	json.NewEncoder(w).Encode(shipment)
 }
 
-// DeleteShipment deletes a shipment by its ID
-func DeleteShipment(w http.ResponseWriter, r *http.Request) {
+// DeleteShipment deletes a shipment by its ID. The If-Match header must
+// carry the shipment's current ETag.
+func (c *ShipmentController) DeleteShipment(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id, err := strconv.Atoi(params["id"])
 	if err != nil {
 		http.Error(w, "Invalid shipment ID", http.StatusBadRequest)
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	err = services.DeleteShipment(uint(id))
-	if err != nil {
+	err = c.shipments.DeleteShipment(r.Context(), uint(id), version)
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Shipment not found", http.StatusNotFound)
+		return
+	case err != nil:
 		http.Error(w, "Error deleting shipment", http.StatusInternalServerError)
 		return
 	}
@@ -103,14 +166,14 @@ func DeleteShipment(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByStatus fetches all shipments by status
-func GetShipmentsByStatus(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByStatus(w http.ResponseWriter, r *http.Request) {
 	status := r.URL.Query().Get("status")
 	if status == "" {
 		http.Error(w, "Missing status parameter", http.StatusBadRequest)
 		return
 	}
 
-	shipments, err := services.GetShipmentsByStatus(status)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{Status: status})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by status", http.StatusInternalServerError)
 		return
@@ -120,7 +183,7 @@ func GetShipmentsByStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByProductID fetches all shipments by product ID
-func GetShipmentsByProductID(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByProductID(w http.ResponseWriter, r *http.Request) {
 	productIDStr := r.URL.Query().Get("product_id")
 	productID, err := strconv.Atoi(productIDStr)
 	if err != nil || productIDStr == "" {
@@ -128,7 +191,7 @@ func GetShipmentsByProductID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shipments, err := services.GetShipmentsByProductID(uint(productID))
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{ProductID: uint(productID)})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by product ID", http.StatusInternalServerError)
 		return
@@ -138,14 +201,14 @@ func GetShipmentsByProductID(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByDestination fetches all shipments by destination
-func GetShipmentsByDestination(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByDestination(w http.ResponseWriter, r *http.Request) {
 	destination := r.URL.Query().Get("destination")
 	if destination == "" {
 		http.Error(w, "Missing destination parameter", http.StatusBadRequest)
 		return
 	}
 
-	shipments, err := services.GetShipmentsByDestination(destination)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{Destination: destination})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by destination", http.StatusInternalServerError)
 		return
@@ -155,14 +218,14 @@ func GetShipmentsByDestination(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByOrigin fetches all shipments by origin
-func GetShipmentsByOrigin(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByOrigin(w http.ResponseWriter, r *http.Request) {
 	origin := r.URL.Query().Get("origin")
 	if origin == "" {
 		http.Error(w, "Missing origin parameter", http.StatusBadRequest)
 		return
 	}
 
-	shipments, err := services.GetShipmentsByOrigin(origin)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{Origin: origin})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by origin", http.StatusInternalServerError)
 		return
@@ -172,7 +235,7 @@ func GetShipmentsByOrigin(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByWarehouseID fetches all shipments by warehouse ID
-func GetShipmentsByWarehouseID(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByWarehouseID(w http.ResponseWriter, r *http.Request) {
 	warehouseIDStr := r.URL.Query().Get("warehouse_id")
 	warehouseID, err := strconv.Atoi(warehouseIDStr)
 	if err != nil || warehouseIDStr == "" {
@@ -180,7 +243,7 @@ func GetShipmentsByWarehouseID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shipments, err := services.GetShipmentsByWarehouseID(uint(warehouseID))
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{WarehouseID: uint(warehouseID)})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by warehouse ID", http.StatusInternalServerError)
 		return
@@ -190,14 +253,14 @@ func GetShipmentsByWarehouseID(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByCarrier fetches all shipments by carrier
-func GetShipmentsByCarrier(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByCarrier(w http.ResponseWriter, r *http.Request) {
 	carrier := r.URL.Query().Get("carrier")
 	if carrier == "" {
 		http.Error(w, "Missing carrier parameter", http.StatusBadRequest)
 		return
 	}
 
-	shipments, err := services.GetShipmentsByCarrier(carrier)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{Carrier: carrier})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by carrier", http.StatusInternalServerError)
 		return
@@ -207,24 +270,25 @@ func GetShipmentsByCarrier(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByTrackingNumber fetches a shipment by its tracking number
-func GetShipmentsByTrackingNumber(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByTrackingNumber(w http.ResponseWriter, r *http.Request) {
 	trackingNumber := r.URL.Query().Get("tracking_number")
 	if trackingNumber == "" {
 		http.Error(w, "Missing tracking number parameter", http.StatusBadRequest)
 		return
 	}
 
-	shipment, err := services.GetShipmentsByTrackingNumber(trackingNumber)
+	shipment, err := c.shipments.GetShipmentByTrackingNumber(r.Context(), trackingNumber)
 	if err != nil {
 		http.Error(w, "Error fetching shipment by tracking number", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, shipment.Version)
 	json.NewEncoder(w).Encode(shipment)
 }
 
 // GetShipmentsByWarehouseIDAndStatus fetches all shipments by warehouse ID and status
-func GetShipmentsByWarehouseIDAndStatus(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByWarehouseIDAndStatus(w http.ResponseWriter, r *http.Request) {
 	warehouseIDStr := r.URL.Query().Get("warehouse_id")
 	status := r.URL.Query().Get("status")
 
@@ -234,7 +298,7 @@ func GetShipmentsByWarehouseIDAndStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	shipments, err := services.GetShipmentsByWarehouseIDAndStatus(uint(warehouseID), status)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{WarehouseID: uint(warehouseID), Status: status})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by warehouse ID and status", http.StatusInternalServerError)
 		return
@@ -244,7 +308,7 @@ func GetShipmentsByWarehouseIDAndStatus(w http.ResponseWriter, r *http.Request)
 }
 
 // GetShipmentsByProductIDAndStatus fetches all shipments by product ID and status
-func GetShipmentsByProductIDAndStatus(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByProductIDAndStatus(w http.ResponseWriter, r *http.Request) {
 	productIDStr := r.URL.Query().Get("product_id")
 	status := r.URL.Query().Get("status")
 
@@ -254,7 +318,7 @@ func GetShipmentsByProductIDAndStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shipments, err := services.GetShipmentsByProductIDAndStatus(uint(productID), status)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{ProductID: uint(productID), Status: status})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by product ID and status", http.StatusInternalServerError)
 		return
@@ -264,7 +328,7 @@ func GetShipmentsByProductIDAndStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByCarrierAndStatus fetches all shipments by carrier and status
-func GetShipmentsByCarrierAndStatus(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByCarrierAndStatus(w http.ResponseWriter, r *http.Request) {
 	carrier := r.URL.Query().Get("carrier")
 	status := r.URL.Query().Get("status")
 
@@ -273,7 +337,7 @@ func GetShipmentsByCarrierAndStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shipments, err := services.GetShipmentsByCarrierAndStatus(carrier, status)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{Carrier: carrier, Status: status})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by carrier and status", http.StatusInternalServerError)
 		return
@@ -283,7 +347,7 @@ func GetShipmentsByCarrierAndStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByDestinationAndStatus fetches all shipments by destination and status
-func GetShipmentsByDestinationAndStatus(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByDestinationAndStatus(w http.ResponseWriter, r *http.Request) {
 	destination := r.URL.Query().Get("destination")
 	status := r.URL.Query().Get("status")
 
@@ -292,7 +356,7 @@ func GetShipmentsByDestinationAndStatus(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	shipments, err := services.GetShipmentsByDestinationAndStatus(destination, status)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{Destination: destination, Status: status})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by destination and status", http.StatusInternalServerError)
 		return
@@ -302,7 +366,7 @@ func GetShipmentsByDestinationAndStatus(w http.ResponseWriter, r *http.Request)
 }
 
 // GetShipmentsByOriginAndStatus fetches all shipments by origin and status
-func GetShipmentsByOriginAndStatus(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByOriginAndStatus(w http.ResponseWriter, r *http.Request) {
 	origin := r.URL.Query().Get("origin")
 	status := r.URL.Query().Get("status")
 
@@ -311,7 +375,7 @@ func GetShipmentsByOriginAndStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shipments, err := services.GetShipmentsByOriginAndStatus(origin, status)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{Origin: origin, Status: status})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by origin and status", http.StatusInternalServerError)
 		return
@@ -321,7 +385,7 @@ func GetShipmentsByOriginAndStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByWarehouseIDAndProductID fetches all shipments by warehouse ID and product ID
-func GetShipmentsByWarehouseIDAndProductID(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByWarehouseIDAndProductID(w http.ResponseWriter, r *http.Request) {
 	warehouseIDStr := r.URL.Query().Get("warehouse_id")
 	productIDStr := r.URL.Query().Get("product_id")
 
@@ -332,7 +396,7 @@ func GetShipmentsByWarehouseIDAndProductID(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	shipments, err := services.GetShipmentsByWarehouseIDAndProductID(uint(warehouseID), uint(productID))
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{WarehouseID: uint(warehouseID), ProductID: uint(productID)})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by warehouse ID and product ID", http.StatusInternalServerError)
 		return
@@ -342,7 +406,7 @@ func GetShipmentsByWarehouseIDAndProductID(w http.ResponseWriter, r *http.Reques
 }
 
 // GetShipmentsByWarehouseIDAndCarrier fetches all shipments by warehouse ID and carrier
-func GetShipmentsByWarehouseIDAndCarrier(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByWarehouseIDAndCarrier(w http.ResponseWriter, r *http.Request) {
 	warehouseIDStr := r.URL.Query().Get("warehouse_id")
 	carrier := r.URL.Query().Get("carrier")
 
@@ -352,7 +416,7 @@ func GetShipmentsByWarehouseIDAndCarrier(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	shipments, err := services.GetShipmentsByWarehouseIDAndCarrier(uint(warehouseID), carrier)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{WarehouseID: uint(warehouseID), Carrier: carrier})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by warehouse ID and carrier", http.StatusInternalServerError)
 		return
@@ -362,7 +426,7 @@ func GetShipmentsByWarehouseIDAndCarrier(w http.ResponseWriter, r *http.Request)
 }
 
 // GetShipmentsByWarehouseIDAndDestination fetches all shipments by warehouse ID and destination
-func GetShipmentsByWarehouseIDAndDestination(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByWarehouseIDAndDestination(w http.ResponseWriter, r *http.Request) {
 	warehouseIDStr := r.URL.Query().Get("warehouse_id")
 	destination := r.URL.Query().Get("destination")
 
@@ -372,7 +436,7 @@ func GetShipmentsByWarehouseIDAndDestination(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	shipments, err := services.GetShipmentsByWarehouseIDAndDestination(uint(warehouseID), destination)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{WarehouseID: uint(warehouseID), Destination: destination})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by warehouse ID and destination", http.StatusInternalServerError)
 		return
@@ -382,7 +446,7 @@ func GetShipmentsByWarehouseIDAndDestination(w http.ResponseWriter, r *http.Requ
 }
 
 // GetShipmentsByWarehouseIDAndOrigin fetches all shipments by warehouse ID and origin
-func GetShipmentsByWarehouseIDAndOrigin(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByWarehouseIDAndOrigin(w http.ResponseWriter, r *http.Request) {
 	warehouseIDStr := r.URL.Query().Get("warehouse_id")
 	origin := r.URL.Query().Get("origin")
 
@@ -392,7 +456,7 @@ func GetShipmentsByWarehouseIDAndOrigin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	shipments, err := services.GetShipmentsByWarehouseIDAndOrigin(uint(warehouseID), origin)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{WarehouseID: uint(warehouseID), Origin: origin})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by warehouse ID and origin", http.StatusInternalServerError)
 		return
@@ -402,7 +466,7 @@ func GetShipmentsByWarehouseIDAndOrigin(w http.ResponseWriter, r *http.Request)
 }
 
 // GetShipmentsByProductIDAndCarrier fetches all shipments by product ID and carrier
-func GetShipmentsByProductIDAndCarrier(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByProductIDAndCarrier(w http.ResponseWriter, r *http.Request) {
 	productIDStr := r.URL.Query().Get("product_id")
 	carrier := r.URL.Query().Get("carrier")
 
@@ -412,7 +476,7 @@ func GetShipmentsByProductIDAndCarrier(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shipments, err := services.GetShipmentsByProductIDAndCarrier(uint(productID), carrier)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{ProductID: uint(productID), Carrier: carrier})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by product ID and carrier", http.StatusInternalServerError)
 		return
@@ -422,7 +486,7 @@ func GetShipmentsByProductIDAndCarrier(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipmentsByProductIDAndDestination fetches all shipments by product ID and destination
-func GetShipmentsByProductIDAndDestination(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByProductIDAndDestination(w http.ResponseWriter, r *http.Request) {
 	productIDStr := r.URL.Query().Get("product_id")
 	destination := r.URL.Query().Get("destination")
 
@@ -432,7 +496,7 @@ func GetShipmentsByProductIDAndDestination(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	shipments, err := services.GetShipmentsByProductIDAndDestination(uint(productID), destination)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{ProductID: uint(productID), Destination: destination})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by product ID and destination", http.StatusInternalServerError)
 		return
@@ -442,7 +506,7 @@ func GetShipmentsByProductIDAndDestination(w http.ResponseWriter, r *http.Reques
 }
 
 // GetShipmentsByProductIDAndOrigin fetches all shipments by product ID and origin
-func GetShipmentsByProductIDAndOrigin(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipmentsByProductIDAndOrigin(w http.ResponseWriter, r *http.Request) {
 	productIDStr := r.URL.Query().Get("product_id")
 	origin := r.URL.Query().Get("origin")
 
@@ -452,7 +516,7 @@ func GetShipmentsByProductIDAndOrigin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shipments, err := services.GetShipmentsByProductIDAndOrigin(uint(productID), origin)
+	shipments, err := c.shipments.FindShipments(r.Context(), repository.ShipmentFilter{ProductID: uint(productID), Origin: origin})
 	if err != nil {
 		http.Error(w, "Error fetching shipments by product ID and origin", http.StatusInternalServerError)
 		return
@@ -462,7 +526,7 @@ func GetShipmentsByProductIDAndOrigin(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetShipment handles the HTTP GET request to fetch a shipment by its ID
-func GetShipment(w http.ResponseWriter, r *http.Request) {
+func (c *ShipmentController) GetShipment(w http.ResponseWriter, r *http.Request) {
 	// Get the ID from the URL parameters
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -475,13 +539,14 @@ func GetShipment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch the shipment from the service
-	shipment, err := services.GetShipmentByID(uint(id))
+	shipment, err := c.shipments.GetShipmentByID(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, "Shipment not found", http.StatusNotFound)
 		return
 	}
 
 	// Return the shipment as a JSON response
+	setETag(w, shipment.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(shipment)
 }
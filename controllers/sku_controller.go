@@ -0,0 +1,396 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+
+	"github.com/gorilla/mux"
+)
+
+// SKUController serves the SKU endpoints, which live under /items: the
+// sellable items of the catalog.
+type SKUController struct {
+	skus *services.SKUService
+}
+
+// NewSKUController returns a SKUController backed by skus.
+func NewSKUController(skus *services.SKUService) *SKUController {
+	return &SKUController{skus: skus}
+}
+
+// CreateSKU adds a new SKU to a product.
+func (c *SKUController) CreateSKU(w http.ResponseWriter, r *http.Request) {
+	var sku models.SKU
+	if err := json.NewDecoder(r.Body).Decode(&sku); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.skus.CreateSKU(r.Context(), &sku); err != nil {
+		writeSKUError(w, err, "Failed to create SKU")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sku)
+}
+
+// GetSKUs fetches a page of SKUs.
+func (c *SKUController) GetSKUs(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.GetSKUs(r.Context(), page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, skus)
+}
+
+// GetSKUByID fetches a single SKU with its product by its ID.
+func (c *SKUController) GetSKUByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	sku, err := c.skus.GetSKUByID(r.Context(), uint(id))
+	if err != nil {
+		http.Error(w, "SKU not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sku)
+}
+
+// UpdateSKU updates an existing SKU.
+func (c *SKUController) UpdateSKU(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	sku, err := c.skus.GetSKUByID(r.Context(), uint(id))
+	if err != nil {
+		http.Error(w, "SKU not found", http.StatusNotFound)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(sku); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	sku.ID = uint(id)
+
+	if err := c.skus.UpdateSKU(r.Context(), sku); err != nil {
+		writeSKUError(w, err, "Failed to update SKU")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sku)
+}
+
+// GetSKULots fetches the lots a SKU has been received in.
+func (c *SKUController) GetSKULots(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	lots, err := c.skus.GetSKULots(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "SKU not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch lots", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(lots)
+}
+
+// GetSKUSerials fetches the serial numbers of a SKU, filtered by the status query parameter when given.
+func (c *SKUController) GetSKUSerials(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	serials, err := c.skus.GetSKUSerials(r.Context(), id, r.URL.Query().Get("status"))
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "SKU not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch serial numbers", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(serials)
+}
+
+// DeleteSKU removes a SKU that no longer has a stock record by its ID.
+func (c *SKUController) DeleteSKU(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.skus.DeleteSKU(r.Context(), uint(id)); err != nil {
+		writeSKUError(w, err, "Failed to delete SKU")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeSKUError answers a failed SKU write, falling back to a 500 with message.
+func writeSKUError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSKU):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrDuplicate):
+		http.Error(w, "SKU code already exists", http.StatusConflict)
+	case errors.Is(err, services.ErrSKUStocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// GetSKUsByCategory fetches SKUs based on their category.
+func (c *SKUController) GetSKUsByCategory(w http.ResponseWriter, r *http.Request) {
+	category := r.URL.Query().Get("category")
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{Category: category})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsByWarehouseID fetches SKUs stored in a specific warehouse.
+func (c *SKUController) GetSKUsByWarehouseID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	warehouseID, err := strconv.ParseUint(vars["warehouseID"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{WarehouseID: uint(warehouseID)})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsBySupplierID fetches SKUs based on their supplier ID.
+func (c *SKUController) GetSKUsBySupplierID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	supplierID, err := strconv.ParseUint(vars["supplierID"], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{SupplierID: uint(supplierID)})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsByStockRange fetches SKUs within a specific stock level range.
+func (c *SKUController) GetSKUsByStockRange(w http.ResponseWriter, r *http.Request) {
+	minStock, err := strconv.Atoi(r.URL.Query().Get("minStock"))
+	if err != nil {
+		http.Error(w, "Invalid minimum stock", http.StatusBadRequest)
+		return
+	}
+
+	maxStock, err := strconv.Atoi(r.URL.Query().Get("maxStock"))
+	if err != nil {
+		http.Error(w, "Invalid maximum stock", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{MinStock: &minStock, MaxStock: &maxStock})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsByPriceRange fetches SKUs within a specific price range.
+func (c *SKUController) GetSKUsByPriceRange(w http.ResponseWriter, r *http.Request) {
+	minPrice, err := strconv.ParseFloat(r.URL.Query().Get("minPrice"), 64)
+	if err != nil {
+		http.Error(w, "Invalid minimum price", http.StatusBadRequest)
+		return
+	}
+
+	maxPrice, err := strconv.ParseFloat(r.URL.Query().Get("maxPrice"), 64)
+	if err != nil {
+		http.Error(w, "Invalid maximum price", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{MinPrice: &minPrice, MaxPrice: &maxPrice})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsByCategoryAndPriceRange fetches SKUs based on their category and price range.
+func (c *SKUController) GetSKUsByCategoryAndPriceRange(w http.ResponseWriter, r *http.Request) {
+	category := r.URL.Query().Get("category")
+	minPrice, err := strconv.ParseFloat(r.URL.Query().Get("minPrice"), 64)
+	if err != nil {
+		http.Error(w, "Invalid minimum price", http.StatusBadRequest)
+		return
+	}
+
+	maxPrice, err := strconv.ParseFloat(r.URL.Query().Get("maxPrice"), 64)
+	if err != nil {
+		http.Error(w, "Invalid maximum price", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{Category: category, MinPrice: &minPrice, MaxPrice: &maxPrice})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsByCategoryAndStockRange fetches SKUs based on their category and stock level range.
+func (c *SKUController) GetSKUsByCategoryAndStockRange(w http.ResponseWriter, r *http.Request) {
+	category := r.URL.Query().Get("category")
+	minStock, err := strconv.Atoi(r.URL.Query().Get("minStock"))
+	if err != nil {
+		http.Error(w, "Invalid minimum stock", http.StatusBadRequest)
+		return
+	}
+
+	maxStock, err := strconv.Atoi(r.URL.Query().Get("maxStock"))
+	if err != nil {
+		http.Error(w, "Invalid maximum stock", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{Category: category, MinStock: &minStock, MaxStock: &maxStock})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsByCategoryAndSupplierID fetches SKUs based on their category and supplier ID.
+func (c *SKUController) GetSKUsByCategoryAndSupplierID(w http.ResponseWriter, r *http.Request) {
+	category := r.URL.Query().Get("category")
+	supplierID, err := strconv.ParseUint(r.URL.Query().Get("supplierID"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{Category: category, SupplierID: uint(supplierID)})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsByCategoryAndWarehouseID fetches SKUs based on their category and warehouse ID.
+func (c *SKUController) GetSKUsByCategoryAndWarehouseID(w http.ResponseWriter, r *http.Request) {
+	category := r.URL.Query().Get("category")
+	warehouseID, err := strconv.ParseUint(r.URL.Query().Get("warehouseID"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{Category: category, WarehouseID: uint(warehouseID)})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
+
+// GetSKUsBySupplierIDAndWarehouseID fetches SKUs based on their supplier ID and warehouse ID.
+func (c *SKUController) GetSKUsBySupplierIDAndWarehouseID(w http.ResponseWriter, r *http.Request) {
+	supplierID, err := strconv.ParseUint(r.URL.Query().Get("supplierID"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	warehouseID, err := strconv.ParseUint(r.URL.Query().Get("warehouseID"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid warehouse ID", http.StatusBadRequest)
+		return
+	}
+
+	skus, err := c.skus.FindSKUs(r.Context(), repository.SKUFilter{SupplierID: uint(supplierID), WarehouseID: uint(warehouseID)})
+	if err != nil {
+		http.Error(w, "Failed to fetch SKUs", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(skus)
+}
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 	"inventory-supply-chain-system/services"
 	"net/http"
 	"strconv"
@@ -10,8 +12,18 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// SupplierController serves the supplier endpoints.
+type SupplierController struct {
+	suppliers *services.SupplierService
+}
+
+// NewSupplierController returns a SupplierController backed by suppliers.
+func NewSupplierController(suppliers *services.SupplierService) *SupplierController {
+	return &SupplierController{suppliers: suppliers}
+}
+
 // CreateSupplier handles the creation of a new supplier.
-func CreateSupplier(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) CreateSupplier(w http.ResponseWriter, r *http.Request) {
 	var supplier models.Supplier
 	err := json.NewDecoder(r.Body).Decode(&supplier)
 	if err != nil {
@@ -19,7 +31,7 @@ func CreateSupplier(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.CreateSupplier(supplier)
+	err = c.suppliers.CreateSupplier(r.Context(), &supplier)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -29,20 +41,29 @@ func CreateSupplier(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(supplier)
 }
 
-// GetSuppliers handles fetching all suppliers from the database.
-func GetSuppliers(w http.ResponseWriter, r *http.Request) {
-	suppliers, err := services.GetSuppliers()
+// GetSuppliers handles fetching a page of suppliers from the database.
+func (c *SupplierController) GetSuppliers(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	suppliers, err := c.suppliers.GetSuppliers(r.Context(), page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(suppliers)
+	writePage(w, r, suppliers)
 }
 
 // GetSupplierByID handles fetching a single supplier by its ID.
-func GetSupplierByID(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSupplierByID(w http.ResponseWriter, r *http.Request) {
 	idParam := mux.Vars(r)["id"]
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
@@ -50,7 +71,7 @@ func GetSupplierByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	supplier, err := services.GetSupplierByID(uint(id))
+	supplier, err := c.suppliers.GetSupplierByID(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -61,7 +82,7 @@ func GetSupplierByID(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateSupplier handles updating an existing supplier.
-func UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
 	idParam := mux.Vars(r)["id"]
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
@@ -77,7 +98,7 @@ func UpdateSupplier(w http.ResponseWriter, r *http.Request) {
 	}
 
 	supplier.ID = uint(id)
-	err = services.UpdateSupplier(&supplier)
+	err = c.suppliers.UpdateSupplier(r.Context(), &supplier)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -87,8 +108,40 @@ func UpdateSupplier(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(supplier)
 }
 
+// SetSupplierCategories handles replacing the categories a supplier is assigned to.
+func (c *SupplierController) SetSupplierCategories(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		CategoryIDs []uint `json:"category_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := c.suppliers.SetSupplierCategories(r.Context(), id, body.CategoryIDs)
+	switch {
+	case errors.Is(err, services.ErrInvalidCategory):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Supplier not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(supplier)
+}
+
 // DeleteSupplier handles the deletion of a supplier by its ID.
-func DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
 	idParam := mux.Vars(r)["id"]
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
@@ -96,7 +149,7 @@ func DeleteSupplier(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.DeleteSupplier(uint(id))
+	err = c.suppliers.DeleteSupplier(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -106,9 +159,9 @@ func DeleteSupplier(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetSuppliersByCategory handles fetching suppliers by their category.
-func GetSuppliersByCategory(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByCategory(w http.ResponseWriter, r *http.Request) {
 	category := r.URL.Query().Get("category")
-	suppliers, err := services.GetSuppliersByCategory(category)
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{Category: category})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -119,7 +172,7 @@ func GetSuppliersByCategory(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetSuppliersByProductID handles fetching suppliers based on the products they supply.
-func GetSuppliersByProductID(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByProductID(w http.ResponseWriter, r *http.Request) {
 	productIDParam := r.URL.Query().Get("product_id")
 	productID, err := strconv.ParseUint(productIDParam, 10, 32)
 	if err != nil {
@@ -127,7 +180,7 @@ func GetSuppliersByProductID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	suppliers, err := services.GetSuppliersByProductID(uint(productID))
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{ProductID: uint(productID)})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -138,9 +191,9 @@ func GetSuppliersByProductID(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetSuppliersByLocation handles fetching suppliers based on their location.
-func GetSuppliersByLocation(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByLocation(w http.ResponseWriter, r *http.Request) {
 	location := r.URL.Query().Get("location")
-	suppliers, err := services.GetSuppliersByLocation(location)
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{Location: location})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -151,7 +204,7 @@ func GetSuppliersByLocation(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetSuppliersByRating handles fetching suppliers based on their rating.
-func GetSuppliersByRating(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByRating(w http.ResponseWriter, r *http.Request) {
 	ratingParam := r.URL.Query().Get("rating")
 	rating, err := strconv.ParseFloat(ratingParam, 32)
 	if err != nil {
@@ -159,7 +212,8 @@ func GetSuppliersByRating(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	suppliers, err := services.GetSuppliersByRating(float32(rating))
+	ratingValue := float32(rating)
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{Rating: &ratingValue})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -170,7 +224,7 @@ func GetSuppliersByRating(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetSuppliersByProductIDAndLocation handles fetching suppliers based on the products they supply and their location.
-func GetSuppliersByProductIDAndLocation(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByProductIDAndLocation(w http.ResponseWriter, r *http.Request) {
 	productIDParam := r.URL.Query().Get("product_id")
 	productID, err := strconv.ParseUint(productIDParam, 10, 32)
 	if err != nil {
@@ -179,7 +233,7 @@ func GetSuppliersByProductIDAndLocation(w http.ResponseWriter, r *http.Request)
 	}
 
 	location := r.URL.Query().Get("location")
-	suppliers, err := services.GetSuppliersByProductIDAndLocation(uint(productID), location)
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{ProductID: uint(productID), Location: location})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -190,7 +244,7 @@ func GetSuppliersByProductIDAndLocation(w http.ResponseWriter, r *http.Request)
 }
 
 // GetSuppliersByProductIDAndRating handles fetching suppliers based on the products they supply and their rating.
-func GetSuppliersByProductIDAndRating(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByProductIDAndRating(w http.ResponseWriter, r *http.Request) {
 	productIDParam := r.URL.Query().Get("product_id")
 	productID, err := strconv.ParseUint(productIDParam, 10, 32)
 	if err != nil {
@@ -205,7 +259,8 @@ func GetSuppliersByProductIDAndRating(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	suppliers, err := services.GetSuppliersByProductIDAndRating(uint(productID), float32(rating))
+	ratingValue := float32(rating)
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{ProductID: uint(productID), Rating: &ratingValue})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -216,7 +271,7 @@ func GetSuppliersByProductIDAndRating(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetSuppliersByLocationAndRating handles fetching suppliers based on their location and rating.
-func GetSuppliersByLocationAndRating(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByLocationAndRating(w http.ResponseWriter, r *http.Request) {
 	location := r.URL.Query().Get("location")
 	ratingParam := r.URL.Query().Get("rating")
 	rating, err := strconv.ParseFloat(ratingParam, 32)
@@ -225,7 +280,8 @@ func GetSuppliersByLocationAndRating(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	suppliers, err := services.GetSuppliersByLocationAndRating(location, float32(rating))
+	ratingValue := float32(rating)
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{Location: location, Rating: &ratingValue})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -236,7 +292,7 @@ func GetSuppliersByLocationAndRating(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetSuppliersByProductIDAndLocationAndRating handles fetching suppliers based on the products they supply, their location, and rating.
-func GetSuppliersByProductIDAndLocationAndRating(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByProductIDAndLocationAndRating(w http.ResponseWriter, r *http.Request) {
 	productIDParam := r.URL.Query().Get("product_id")
 	productID, err := strconv.ParseUint(productIDParam, 10, 32)
 	if err != nil {
@@ -252,7 +308,8 @@ func GetSuppliersByProductIDAndLocationAndRating(w http.ResponseWriter, r *http.
 		return
 	}
 
-	suppliers, err := services.GetSuppliersByProductIDAndLocationAndRating(uint(productID), location, float32(rating))
+	ratingValue := float32(rating)
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{ProductID: uint(productID), Location: location, Rating: &ratingValue})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -263,7 +320,7 @@ func GetSuppliersByProductIDAndLocationAndRating(w http.ResponseWriter, r *http.
 }
 
 // GetSuppliersByCategoryAndLocationAndRating handles fetching suppliers based on their category, location, and rating.
-func GetSuppliersByCategoryAndLocationAndRating(w http.ResponseWriter, r *http.Request) {
+func (c *SupplierController) GetSuppliersByCategoryAndLocationAndRating(w http.ResponseWriter, r *http.Request) {
 	category := r.URL.Query().Get("category")
 	location := r.URL.Query().Get("location")
 	ratingParam := r.URL.Query().Get("rating")
@@ -273,7 +330,8 @@ func GetSuppliersByCategoryAndLocationAndRating(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	suppliers, err := services.GetSuppliersByCategoryAndLocationAndRating(category, location, float32(rating))
+	ratingValue := float32(rating)
+	suppliers, err := c.suppliers.FindSuppliers(r.Context(), repository.SupplierFilter{Category: category, Location: location, Rating: &ratingValue})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+)
+
+// TransferController serves the transfer order endpoints
+type TransferController struct {
+	transfers *services.TransferService
+}
+
+// NewTransferController returns a TransferController backed by transfers
+func NewTransferController(transfers *services.TransferService) *TransferController {
+	return &TransferController{transfers: transfers}
+}
+
+// CreateTransferOrder handles the creation of a draft transfer order
+func (c *TransferController) CreateTransferOrder(w http.ResponseWriter, r *http.Request) {
+	var transfer models.TransferOrder
+	if err := json.NewDecoder(r.Body).Decode(&transfer); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	transfer.UserID = currentUserID(r)
+
+	if err := c.transfers.CreateTransferOrder(r.Context(), &transfer); err != nil {
+		writeTransferError(w, err, "Failed to create transfer order")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// ListTransferOrders returns a page of transfer orders filtered by the
+// source_warehouse_id, destination_warehouse_id and status parameters
+func (c *TransferController) ListTransferOrders(w http.ResponseWriter, r *http.Request) {
+	var filter repository.TransferFilter
+	var err error
+	if filter.SourceWarehouseID, err = parseUintParam(r, "source_warehouse_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.DestinationWarehouseID, err = parseUintParam(r, "destination_warehouse_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Statuses = queryList(r, "status")
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transfers, err := c.transfers.FindTransferOrders(r.Context(), filter, page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve transfer orders", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, transfers)
+}
+
+// GetTransferOrder fetches a transfer order with its lines
+func (c *TransferController) GetTransferOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	transfer, err := c.transfers.GetTransferOrder(r.Context(), id)
+	if err != nil {
+		writeTransferError(w, err, "Failed to fetch transfer order")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// PickTransferOrder takes a draft transfer's stock out of the source warehouse
+func (c *TransferController) PickTransferOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	transfer, err := c.transfers.PickTransferOrder(r.Context(), id, currentUserID(r))
+	if err != nil {
+		writeTransferError(w, err, "Failed to pick transfer order")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// DispatchTransferOrder ships a picked transfer with the given carrier details
+func (c *TransferController) DispatchTransferOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var dispatch services.TransferDispatch
+	if err := json.NewDecoder(r.Body).Decode(&dispatch); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := c.transfers.DispatchTransferOrder(r.Context(), id, dispatch)
+	if err != nil {
+		writeTransferError(w, err, "Failed to dispatch transfer order")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// ReceiveTransferOrder books the quantities counted in at the destination
+func (c *TransferController) ReceiveTransferOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Lines []services.TransferReceipt `json:"lines"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := c.transfers.ReceiveTransferOrder(r.Context(), id, input.Lines, currentUserID(r))
+	if err != nil {
+		writeTransferError(w, err, "Failed to receive transfer order")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// CloseTransferOrder completes a short transfer, recording the missing units
+// as a discrepancy
+func (c *TransferController) CloseTransferOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := c.transfers.CloseTransferOrder(r.Context(), id, input.Reason)
+	if err != nil {
+		writeTransferError(w, err, "Failed to close transfer order")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// CancelTransferOrder cancels a transfer that has not been dispatched yet
+func (c *TransferController) CancelTransferOrder(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	transfer, err := c.transfers.CancelTransferOrder(r.Context(), id, currentUserID(r))
+	if err != nil {
+		writeTransferError(w, err, "Failed to cancel transfer order")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// writeTransferError maps transfer service errors to HTTP responses
+func writeTransferError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTransfer), errors.Is(err, services.ErrInvalidMovement),
+		errors.Is(err, services.ErrInvalidUnit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Transfer order, warehouse, bin or inventory item not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidTransferStatus),
+		errors.Is(err, services.ErrInsufficientStock),
+		errors.Is(err, services.ErrLocationUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
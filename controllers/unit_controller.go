@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+
+	"github.com/gorilla/mux"
+)
+
+// UnitController serves the unit of measure catalog endpoints
+type UnitController struct {
+	units *services.UnitService
+}
+
+// NewUnitController returns a UnitController backed by units
+func NewUnitController(units *services.UnitService) *UnitController {
+	return &UnitController{units: units}
+}
+
+// CreateUnit handles adding a unit to the catalog
+func (c *UnitController) CreateUnit(w http.ResponseWriter, r *http.Request) {
+	var unit models.Unit
+	if err := json.NewDecoder(r.Body).Decode(&unit); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.units.CreateUnit(r.Context(), &unit); err != nil {
+		writeUnitError(w, err, "Failed to create unit")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(unit)
+}
+
+// GetUnits fetches the whole unit catalog
+func (c *UnitController) GetUnits(w http.ResponseWriter, r *http.Request) {
+	units, err := c.units.GetUnits(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch units", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(units)
+}
+
+// GetUnit fetches a unit by its code
+func (c *UnitController) GetUnit(w http.ResponseWriter, r *http.Request) {
+	unit, err := c.units.GetUnit(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		writeUnitError(w, err, "Failed to fetch unit")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(unit)
+}
+
+// DeleteUnit removes a unit that no SKU uses
+func (c *UnitController) DeleteUnit(w http.ResponseWriter, r *http.Request) {
+	if err := c.units.DeleteUnit(r.Context(), mux.Vars(r)["code"]); err != nil {
+		writeUnitError(w, err, "Failed to delete unit")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Unit deleted successfully"})
+}
+
+// writeUnitError answers a failed unit request, falling back to a 500 with message
+func writeUnitError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidUnit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Unit not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrDuplicate):
+		http.Error(w, "Unit code already exists", http.StatusConflict)
+	case errors.Is(err, services.ErrUnitInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
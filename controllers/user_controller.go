@@ -2,7 +2,10 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"inventory-supply-chain-system/internal/authz"
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 	"inventory-supply-chain-system/services"
 	"net/http"
 	"strconv"
@@ -10,8 +13,19 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// UserController serves user administration
+type UserController struct {
+	users  *services.UserService
+	logins *services.LoginService
+}
+
+// NewUserController returns a UserController backed by the given services
+func NewUserController(users *services.UserService, logins *services.LoginService) *UserController {
+	return &UserController{users: users, logins: logins}
+}
+
 // CreateUserController handles the creation of a new user
-func CreateUserController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) CreateUserController(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	err := json.NewDecoder(r.Body).Decode(&user)
 	if err != nil {
@@ -19,7 +33,7 @@ func CreateUserController(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.CreateUser(user)
+	err = c.users.CreateUser(r.Context(), &user)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -30,14 +44,14 @@ func CreateUserController(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetUserController handles retrieving a user by ID
-func GetUserController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) GetUserController(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	user, err := services.GetUser(uint(id))
+	user, err := c.users.GetUser(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -47,14 +61,14 @@ func GetUserController(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetUserByEmailController handles retrieving a user by email
-func GetUserByEmailController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) GetUserByEmailController(w http.ResponseWriter, r *http.Request) {
 	email := r.URL.Query().Get("email")
 	if email == "" {
 		http.Error(w, "Email is required", http.StatusBadRequest)
 		return
 	}
 
-	user, err := services.GetUserByEmail(email)
+	user, err := c.users.GetUserByEmail(r.Context(), email)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -64,16 +78,34 @@ func GetUserByEmailController(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateUserController handles updating a user's details
-func UpdateUserController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) UpdateUserController(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
 	var user models.User
-	err := json.NewDecoder(r.Body).Decode(&user)
+	err = json.NewDecoder(r.Body).Decode(&user)
 	if err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	user.ID = uint(id)
 
-	err = services.UpdateUser(user)
-	if err != nil {
+	err = c.users.UpdateUser(r.Context(), user)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrEmailRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case errors.Is(err, repository.ErrDuplicate):
+		http.Error(w, "Email is already in use", http.StatusConflict)
+		return
+	default:
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -82,14 +114,14 @@ func UpdateUserController(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteUserController handles deleting a user by ID
-func DeleteUserController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) DeleteUserController(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	err = services.DeleteUser(uint(id))
+	err = c.users.DeleteUser(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -98,41 +130,29 @@ func DeleteUserController(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// ListUsersController handles retrieving all users
-func ListUsersController(w http.ResponseWriter, r *http.Request) {
-	users, err := services.ListUsers()
+// ListUsersController handles retrieving a page of users
+func (c *UserController) ListUsersController(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	json.NewEncoder(w).Encode(users)
-}
-
-// LoginUserController handles logging in a user
-func LoginUserController(w http.ResponseWriter, r *http.Request) {
-	var loginUser struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&loginUser)
-	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	users, err := c.users.ListUsers(r.Context(), page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	user, err := services.LoginUser(loginUser.Email, loginUser.Password)
 	if err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(user)
+	writePage(w, r, users)
 }
 
 // ChangePasswordController handles changing a user's password
-func ChangePasswordController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) ChangePasswordController(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Password string `json:"password"`
 	}
@@ -149,46 +169,11 @@ func ChangePasswordController(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.ChangePassword(uint(id), input.Password)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-// ResetPasswordController handles resetting a user's password by email
-func ResetPasswordController(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&input)
-	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
-		return
-	}
-
-	err = services.ResetPassword(input.Email, input.Password)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-// VerifyUserController handles verifying a user's email
-func VerifyUserController(w http.ResponseWriter, r *http.Request) {
-	email := r.URL.Query().Get("email")
-	if email == "" {
-		http.Error(w, "Email is required", http.StatusBadRequest)
+	err = c.users.ChangePassword(r.Context(), uint(id), input.Password)
+	if errors.Is(err, services.ErrWeakPassword) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	err := services.VerifyUser(email)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -198,14 +183,14 @@ func VerifyUserController(w http.ResponseWriter, r *http.Request) {
 }
 
 // UnverifyUserController handles unverifying a user's email
-func UnverifyUserController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) UnverifyUserController(w http.ResponseWriter, r *http.Request) {
 	email := r.URL.Query().Get("email")
 	if email == "" {
 		http.Error(w, "Email is required", http.StatusBadRequest)
 		return
 	}
 
-	err := services.UnverifyUser(email)
+	err := c.users.UnverifyUser(r.Context(), email)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -215,7 +200,7 @@ func UnverifyUserController(w http.ResponseWriter, r *http.Request) {
 }
 
 // AddRoleController handles adding a role to a user
-func AddRoleController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) AddRoleController(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
@@ -232,7 +217,11 @@ func AddRoleController(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.AddRole(uint(id), input.Role)
+	err = c.users.AddRole(r.Context(), uint(id), input.Role)
+	if errors.Is(err, services.ErrUnknownRole) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -242,14 +231,14 @@ func AddRoleController(w http.ResponseWriter, r *http.Request) {
 }
 
 // RemoveRoleController handles removing a role from a user
-func RemoveRoleController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) RemoveRoleController(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
-	err = services.RemoveRole(uint(id))
+	err = c.users.RemoveRole(r.Context(), uint(id))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -259,7 +248,7 @@ func RemoveRoleController(w http.ResponseWriter, r *http.Request) {
 }
 
 // AddPermissionController handles adding a permission to a user
-func AddPermissionController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) AddPermissionController(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
@@ -276,7 +265,11 @@ func AddPermissionController(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.AddPermission(uint(id), input.Permission)
+	err = c.users.AddPermission(r.Context(), uint(id), input.Permission)
+	if errors.Is(err, services.ErrUnknownPermission) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -286,7 +279,7 @@ func AddPermissionController(w http.ResponseWriter, r *http.Request) {
 }
 
 // RemovePermissionController handles removing a permission from a user
-func RemovePermissionController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) RemovePermissionController(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
@@ -303,7 +296,7 @@ func RemovePermissionController(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.RemovePermission(uint(id), input.Permission)
+	err = c.users.RemovePermission(r.Context(), uint(id), input.Permission)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -313,7 +306,7 @@ func RemovePermissionController(w http.ResponseWriter, r *http.Request) {
 }
 
 // AddAddressController handles adding an address to a user
-func AddAddressController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) AddAddressController(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
@@ -327,7 +320,7 @@ func AddAddressController(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.AddAddress(uint(id), address)
+	err = c.users.AddAddress(r.Context(), uint(id), address)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -337,7 +330,7 @@ func AddAddressController(w http.ResponseWriter, r *http.Request) {
 }
 
 // RemoveAddressController handles removing an address from a user
-func RemoveAddressController(w http.ResponseWriter, r *http.Request) {
+func (c *UserController) RemoveAddressController(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
@@ -351,7 +344,29 @@ func RemoveAddressController(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = services.RemoveAddress(uint(id), address)
+	err = c.users.RemoveAddress(r.Context(), uint(id), address)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlockUserController clears a user's failed logins so a locked-out user
+// can log in again
+func (c *UserController) UnlockUserController(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	err = c.logins.UnlockAccount(r.Context(), uint(id), currentUserID(r))
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -359,3 +374,39 @@ func RemoveAddressController(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ListSecurityEventsController returns a page of security events filtered
+// by the type, user_id and ip parameters
+func (c *UserController) ListSecurityEventsController(w http.ResponseWriter, r *http.Request) {
+	var filter repository.SecurityEventFilter
+	var err error
+	if filter.UserID, err = parseUintParam(r, "user_id"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Types = queryList(r, "type")
+	filter.IP = r.URL.Query().Get("ip")
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := c.logins.FindSecurityEvents(r.Context(), filter, page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retrieve security events", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, events)
+}
+
+// ListRolesController returns the defined roles and the permissions each grants
+func (c *UserController) ListRolesController(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(authz.Roles())
+}
@@ -2,28 +2,55 @@ package controllers
 
 import (
 	"encoding/json"
-	"inventory-supply-chain-system/db"
+	"errors"
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
 	"net/http"
 )
 
+// VendorController serves the vendor endpoints
+type VendorController struct {
+	vendors *services.VendorService
+}
+
+// NewVendorController returns a VendorController backed by vendors
+func NewVendorController(vendors *services.VendorService) *VendorController {
+	return &VendorController{vendors: vendors}
+}
+
 // GetVendors returns a list of all vendors
-func GetVendors(w http.ResponseWriter, r *http.Request) {
-	var vendors []models.Vendor
-	db.DB.Find(&vendors)
+func (c *VendorController) GetVendors(w http.ResponseWriter, r *http.Request) {
+	vendors, err := c.vendors.GetVendors(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch vendors", http.StatusInternalServerError)
+		return
+	}
 	json.NewEncoder(w).Encode(vendors)
 }
 
 // GetVendor returns a vendor by ID
-func GetVendor(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	var vendor models.Vendor
-	db.DB.First(&vendor, id)
+func (c *VendorController) GetVendor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	vendor, err := c.vendors.GetVendor(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "Vendor not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch vendor", http.StatusInternalServerError)
+		return
+	}
+	setETag(w, vendor.Version)
 	json.NewEncoder(w).Encode(vendor)
 }
 
 // CreateVendor creates a new vendor
-func CreateVendor(w http.ResponseWriter, r *http.Request) {
+func (c *VendorController) CreateVendor(w http.ResponseWriter, r *http.Request) {
 	var vendor models.Vendor
 	err := json.NewDecoder(r.Body).Decode(&vendor)
 	if err != nil {
@@ -31,38 +58,77 @@ func CreateVendor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result := db.DB.Create(&vendor)
-	if result.Error != nil {
+	if err := c.vendors.CreateVendor(r.Context(), &vendor); err != nil {
 		http.Error(w, "Failed to create vendor", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, vendor.Version)
 	json.NewEncoder(w).Encode(vendor)
 }
 
-// UpdateVendor updates an existing vendor
-func UpdateVendor(w http.ResponseWriter, r *http.Request) {
+// UpdateVendor updates an existing vendor. The If-Match header must carry
+// the vendor's current ETag.
+func (c *VendorController) UpdateVendor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	var vendor models.Vendor
 	err := json.NewDecoder(r.Body).Decode(&vendor)
 	if err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	vendor.ID = id
+	vendor.Version = version
 
-	result := db.DB.Save(&vendor)
-	if result.Error != nil {
+	err = c.vendors.UpdateVendor(r.Context(), &vendor)
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Vendor not found", http.StatusNotFound)
+		return
+	case err != nil:
 		http.Error(w, "Failed to update vendor", http.StatusInternalServerError)
 		return
 	}
 
+	setETag(w, vendor.Version)
 	json.NewEncoder(w).Encode(vendor)
 }
 
-// DeleteVendor deletes a vendor by ID
-func DeleteVendor(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	var vendor models.Vendor
-	db.DB.Delete(&vendor, id)
-}
+// DeleteVendor deletes a vendor by ID. The If-Match header must carry the
+// vendor's current ETag.
+func (c *VendorController) DeleteVendor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.vendors.DeleteVendor(r.Context(), id, version)
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		writeVersionConflict(w)
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Vendor not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "Failed to delete vendor", http.StatusInternalServerError)
+		return
+	}
 
-//
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+
+	"github.com/gorilla/mux"
+)
+
+// WarehouseController serves warehouses and their zones, aisles and bins
+type WarehouseController struct {
+	warehouses *services.WarehouseService
+}
+
+// NewWarehouseController returns a WarehouseController backed by warehouses
+func NewWarehouseController(warehouses *services.WarehouseService) *WarehouseController {
+	return &WarehouseController{warehouses: warehouses}
+}
+
+// CreateWarehouse handles the creation of a new warehouse. Warehouses are
+// active unless the request says otherwise.
+func (c *WarehouseController) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	warehouse := models.Warehouse{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&warehouse); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.warehouses.CreateWarehouse(r.Context(), &warehouse); err != nil {
+		writeWarehouseError(w, err, "Failed to create warehouse")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+// GetWarehouses fetches a page of warehouses
+func (c *WarehouseController) GetWarehouses(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	warehouses, err := c.warehouses.GetWarehouses(r.Context(), page)
+	if isListRequestError(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch warehouses", http.StatusInternalServerError)
+		return
+	}
+
+	writePage(w, r, warehouses)
+}
+
+// GetWarehouse fetches a warehouse with its zones, aisles and bins
+func (c *WarehouseController) GetWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	warehouse, err := c.warehouses.GetWarehouseByID(r.Context(), id)
+	if err != nil {
+		writeWarehouseError(w, err, "Failed to fetch warehouse")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+// UpdateWarehouse handles the update of an existing warehouse
+func (c *WarehouseController) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	warehouse, err := c.warehouses.GetWarehouseByID(r.Context(), id)
+	if err != nil {
+		writeWarehouseError(w, err, "Failed to fetch warehouse")
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(warehouse); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	warehouse.ID = id
+
+	if err := c.warehouses.UpdateWarehouse(r.Context(), warehouse); err != nil {
+		writeWarehouseError(w, err, "Failed to update warehouse")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+// DeleteWarehouse deletes an empty warehouse and its locations
+func (c *WarehouseController) DeleteWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := c.warehouses.DeleteWarehouse(r.Context(), id); err != nil {
+		writeWarehouseError(w, err, "Failed to delete warehouse")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWarehouseStock lists the stock balances held in a warehouse
+func (c *WarehouseController) GetWarehouseStock(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	balances, err := c.warehouses.GetWarehouseStock(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to fetch warehouse stock", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(balances)
+}
+
+// CreateZone adds a zone to a warehouse
+func (c *WarehouseController) CreateZone(w http.ResponseWriter, r *http.Request) {
+	warehouseID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var zone models.Zone
+	if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	zone.WarehouseID = warehouseID
+
+	if err := c.warehouses.CreateZone(r.Context(), &zone); err != nil {
+		writeWarehouseError(w, err, "Failed to create zone")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(zone)
+}
+
+// GetZones lists the zones of a warehouse
+func (c *WarehouseController) GetZones(w http.ResponseWriter, r *http.Request) {
+	warehouseID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	zones, err := c.warehouses.GetZones(r.Context(), warehouseID)
+	if err != nil {
+		http.Error(w, "Failed to fetch zones", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(zones)
+}
+
+// UpdateZone renames a zone
+func (c *WarehouseController) UpdateZone(w http.ResponseWriter, r *http.Request) {
+	zone, ok := c.lookupZone(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.Zone
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	zone.Code, zone.Name = input.Code, input.Name
+
+	if err := c.warehouses.UpdateZone(r.Context(), zone); err != nil {
+		writeWarehouseError(w, err, "Failed to update zone")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(zone)
+}
+
+// DeleteZone deletes an empty zone with its aisles and bins
+func (c *WarehouseController) DeleteZone(w http.ResponseWriter, r *http.Request) {
+	zone, ok := c.lookupZone(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.warehouses.DeleteZone(r.Context(), zone); err != nil {
+		writeWarehouseError(w, err, "Failed to delete zone")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateAisle adds an aisle to a zone
+func (c *WarehouseController) CreateAisle(w http.ResponseWriter, r *http.Request) {
+	zone, ok := c.lookupZone(w, r)
+	if !ok {
+		return
+	}
+
+	var aisle models.Aisle
+	if err := json.NewDecoder(r.Body).Decode(&aisle); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	aisle.ZoneID = zone.ID
+
+	if err := c.warehouses.CreateAisle(r.Context(), &aisle); err != nil {
+		writeWarehouseError(w, err, "Failed to create aisle")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(aisle)
+}
+
+// GetAisles lists the aisles of a zone
+func (c *WarehouseController) GetAisles(w http.ResponseWriter, r *http.Request) {
+	zone, ok := c.lookupZone(w, r)
+	if !ok {
+		return
+	}
+
+	aisles, err := c.warehouses.GetAisles(r.Context(), zone.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch aisles", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(aisles)
+}
+
+// UpdateAisle renames an aisle
+func (c *WarehouseController) UpdateAisle(w http.ResponseWriter, r *http.Request) {
+	aisle, ok := c.lookupAisle(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.Aisle
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	aisle.Code, aisle.Name = input.Code, input.Name
+
+	if err := c.warehouses.UpdateAisle(r.Context(), aisle); err != nil {
+		writeWarehouseError(w, err, "Failed to update aisle")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(aisle)
+}
+
+// DeleteAisle deletes an empty aisle with its bins
+func (c *WarehouseController) DeleteAisle(w http.ResponseWriter, r *http.Request) {
+	aisle, ok := c.lookupAisle(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.warehouses.DeleteAisle(r.Context(), aisle); err != nil {
+		writeWarehouseError(w, err, "Failed to delete aisle")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateBin adds a bin to an aisle
+func (c *WarehouseController) CreateBin(w http.ResponseWriter, r *http.Request) {
+	aisle, ok := c.lookupAisle(w, r)
+	if !ok {
+		return
+	}
+
+	var bin models.Bin
+	if err := json.NewDecoder(r.Body).Decode(&bin); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	bin.AisleID = aisle.ID
+
+	if err := c.warehouses.CreateBin(r.Context(), &bin); err != nil {
+		writeWarehouseError(w, err, "Failed to create bin")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(bin)
+}
+
+// GetBins lists the bins of an aisle
+func (c *WarehouseController) GetBins(w http.ResponseWriter, r *http.Request) {
+	aisle, ok := c.lookupAisle(w, r)
+	if !ok {
+		return
+	}
+
+	bins, err := c.warehouses.GetBins(r.Context(), aisle.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch bins", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bins)
+}
+
+// UpdateBin renames a bin
+func (c *WarehouseController) UpdateBin(w http.ResponseWriter, r *http.Request) {
+	bin, ok := c.lookupBin(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.Bin
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	bin.Code = input.Code
+
+	if err := c.warehouses.UpdateBin(r.Context(), bin); err != nil {
+		writeWarehouseError(w, err, "Failed to update bin")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bin)
+}
+
+// DeleteBin deletes an empty bin
+func (c *WarehouseController) DeleteBin(w http.ResponseWriter, r *http.Request) {
+	bin, ok := c.lookupBin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.warehouses.DeleteBin(r.Context(), bin); err != nil {
+		writeWarehouseError(w, err, "Failed to delete bin")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pathID parses a numeric route variable, answering 400 if it is malformed
+func pathID(w http.ResponseWriter, r *http.Request, name string) (uint, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)[name], 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// lookupZone loads the zone addressed by the id and zoneID route variables
+func (c *WarehouseController) lookupZone(w http.ResponseWriter, r *http.Request) (*models.Zone, bool) {
+	warehouseID, ok := pathID(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+	zoneID, ok := pathID(w, r, "zoneID")
+	if !ok {
+		return nil, false
+	}
+
+	zone, err := c.warehouses.GetZone(r.Context(), warehouseID, zoneID)
+	if err != nil {
+		writeWarehouseError(w, err, "Failed to fetch zone")
+		return nil, false
+	}
+	return zone, true
+}
+
+// lookupAisle loads the aisle addressed by the id, zoneID and aisleID route variables
+func (c *WarehouseController) lookupAisle(w http.ResponseWriter, r *http.Request) (*models.Aisle, bool) {
+	zone, ok := c.lookupZone(w, r)
+	if !ok {
+		return nil, false
+	}
+	aisleID, ok := pathID(w, r, "aisleID")
+	if !ok {
+		return nil, false
+	}
+
+	aisle, err := c.warehouses.GetAisle(r.Context(), zone.ID, aisleID)
+	if err != nil {
+		writeWarehouseError(w, err, "Failed to fetch aisle")
+		return nil, false
+	}
+	return aisle, true
+}
+
+// lookupBin loads the bin addressed by the id, zoneID, aisleID and binID route variables
+func (c *WarehouseController) lookupBin(w http.ResponseWriter, r *http.Request) (*models.Bin, bool) {
+	aisle, ok := c.lookupAisle(w, r)
+	if !ok {
+		return nil, false
+	}
+	binID, ok := pathID(w, r, "binID")
+	if !ok {
+		return nil, false
+	}
+
+	bin, err := c.warehouses.GetBin(r.Context(), aisle.ID, binID)
+	if err != nil {
+		writeWarehouseError(w, err, "Failed to fetch bin")
+		return nil, false
+	}
+	return bin, true
+}
+
+// writeWarehouseError maps warehouse service errors to HTTP responses
+func writeWarehouseError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWarehouse):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrLocationNotEmpty):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repository.ErrDuplicate):
+		http.Error(w, "Code already in use", http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
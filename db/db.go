@@ -1,47 +1,108 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
+	"strings"
 
-	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/internal/audit"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+// sqliteScheme starts the DSNs that open an embedded SQLite database
+const sqliteScheme = "sqlite:"
 
-func ConnectDB() {
-	// Build the DSN from environment variables
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+// DSNFromEnv returns DATABASE_URL if it is set, and otherwise a Postgres DSN
+// built from the DB_* environment variables
+func DSNFromEnv() string {
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		return dsn
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_USER"),
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_NAME"),
 		os.Getenv("DB_PORT"),
 	)
+}
 
-	// Connect to the database
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// Open connects to the database named by dsn and installs the audit
+// callbacks. A DSN starting with sqlite: opens a pure-Go SQLite database,
+// either a file such as sqlite:dev.db or a private in-memory one with
+// sqlite::memory:; anything else, URL or key=value, goes to Postgres.
+func Open(dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	if path, ok := strings.CutPrefix(dsn, sqliteScheme); ok {
+		dialector = sqlite.Open(sqliteDSN(path))
+	} else {
+		dialector = postgres.Open(dsn)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
 	if err != nil {
-		log.Fatalf("Failed to connect to the database: %v", err)
+		return nil, fmt.Errorf("connecting to the database: %w", err)
 	}
 
-	// Auto-migrate models
-	err = DB.AutoMigrate(
-		&models.User{},
-		&models.Address{},
-		&models.Inventory{},
-		&models.Order{},
-		&models.Shipment{},
-		&models.Vendor{},
-	)
+	if dialector.Name() == sqlite.DriverName {
+		// SQLite has no row locks, so every statement goes through one
+		// connection and transactions run one after another. This also keeps
+		// an in-memory database alive for as long as db is open.
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+	}
+
+	// Record every change to an entity in the audit log
+	if err := audit.Register(db); err != nil {
+		return nil, fmt.Errorf("registering audit callbacks: %w", err)
+	}
+
+	return db, nil
+}
+
+// ConnectDB opens the database configured by the environment and makes sure
+// its schema is current. With migrate set, pending migrations are applied
+// first; otherwise a database that is missing migrations is refused with
+// ErrSchemaOutdated.
+func ConnectDB(migrate bool) (*gorm.DB, error) {
+	db, err := Open(DSNFromEnv())
 	if err != nil {
-		log.Fatalf("Error with auto-migration: %v", err)
+		return nil, err
 	}
 
-	log.Println("Connected to the database and applied migrations successfully!")
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	if migrate {
+		if err := migrator.Up(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqliteDSN turns the part of a DSN after sqlite: into a SQLite file name
+// with foreign keys enforced, as they are on Postgres, and a busy timeout
+// for other processes holding the file
+func sqliteDSN(path string) string {
+	if path == ":memory:" || path == "" {
+		path = "file::memory:"
+	}
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
 }
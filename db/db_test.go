@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// openMemory opens a private in-memory SQLite database for one test
+func openMemory(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := Open("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// tables lists the tables of a SQLite database other than its own
+func tables(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var names []string
+	err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name").
+		Scan(&names).Error
+	if err != nil {
+		t.Fatalf("listing tables: %v", err)
+	}
+	return names
+}
+
+func TestOpenSelectsDialect(t *testing.T) {
+	db := openMemory(t)
+	if db.Dialector.Name() != sqlite.DriverName {
+		t.Fatalf("dialect = %s, want %s", db.Dialector.Name(), sqlite.DriverName)
+	}
+
+	var foreignKeys int
+	if err := db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error; err != nil {
+		t.Fatal(err)
+	}
+	if foreignKeys != 1 {
+		t.Error("foreign keys are not enforced")
+	}
+}
+
+func TestOpenMemoryDatabasesArePrivate(t *testing.T) {
+	first, second := openMemory(t), openMemory(t)
+	if err := first.Exec("CREATE TABLE only_here (id integer)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := tables(t, second); len(got) != 0 {
+		t.Fatalf("second database has tables %v", got)
+	}
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := map[string]string{
+		":memory:":       "file::memory:?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+		"":               "file::memory:?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+		"dev.db":         "dev.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+		"dev.db?mode=ro": "dev.db?mode=ro&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+	}
+	for path, want := range tests {
+		if got := sqliteDSN(path); got != want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := openMemory(t)
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.Check(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("Check on an empty database = %v, want ErrSchemaOutdated", err)
+	}
+
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("Check after Up: %v", err)
+	}
+	migrated := tables(t, db)
+
+	// Reverting everything leaves only the bookkeeping table behind
+	if err := migrator.To(ctx, 0); err != nil {
+		t.Fatalf("To(0): %v", err)
+	}
+	if got := tables(t, db); len(got) != 1 || got[0] != "schema_migrations" {
+		t.Fatalf("tables after To(0) = %v, want [schema_migrations]", got)
+	}
+
+	// and the schema comes back the same
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up after To(0): %v", err)
+	}
+	if got := tables(t, db); len(got) != len(migrated) {
+		t.Fatalf("tables after Up again = %v, want %v", got, migrated)
+	}
+}
+
+func TestMigratorDownRevertsOne(t *testing.T) {
+	ctx := context.Background()
+	migrator, err := NewMigrator(openMemory(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Down(ctx); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		latest := status.Version == migrator.Latest()
+		if applied := status.AppliedAt != nil; applied == latest {
+			t.Errorf("migration %04d applied = %v after Down", status.Version, applied)
+		}
+	}
+
+	if err := migrator.To(ctx, migrator.Latest()+1); !errors.Is(err, ErrUnknownMigration) {
+		t.Fatalf("To an unknown version = %v, want ErrUnknownMigration", err)
+	}
+}
+
+// Every migration has to exist for both dialects, so a schema version means
+// the same thing on Postgres and SQLite
+func TestMigrationsMatchAcrossDialects(t *testing.T) {
+	postgres, err := loadMigrations("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := loadMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("%d postgres migrations, %d sqlite", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		p, s := postgres[i], sqlite[i]
+		if p.Version != s.Version || p.Name != s.Name {
+			t.Errorf("migration %d is %04d_%s on postgres and %04d_%s on sqlite", i, p.Version, p.Name, s.Version, s.Name)
+		}
+		for _, m := range []Migration{p, s} {
+			if m.Up == "" || m.Down == "" {
+				t.Errorf("migration %04d_%s lacks an up or down script", m.Version, m.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS suppliers;
+DROP TABLE IF EXISTS transfer_order_lines;
+DROP TABLE IF EXISTS transfer_orders;
+DROP TABLE IF EXISTS stock_balances;
+DROP TABLE IF EXISTS bins;
+DROP TABLE IF EXISTS aisles;
+DROP TABLE IF EXISTS zones;
+DROP TABLE IF EXISTS warehouses;
+DROP TABLE IF EXISTS vendors;
+DROP TABLE IF EXISTS shipments;
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS inventories;
+DROP TABLE IF EXISTS addresses;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS o_id_c_logins;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS security_events;
+DROP TABLE IF EXISTS login_throttles;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
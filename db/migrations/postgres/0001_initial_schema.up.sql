@@ -0,0 +1,396 @@
+-- The schema AutoMigrate used to create. Every statement is IF NOT EXISTS so
+-- databases created that way can adopt the migration history as they are.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    email text,
+    password text,
+    role text,
+    verified boolean,
+    permissions text[],
+    phone text,
+    token_version bigint,
+    verification_sent_at timestamptz,
+    mfa_enabled boolean,
+    mfa_secret text,
+    mfa_last_counter bigint,
+    external_id varchar(512),
+    PRIMARY KEY (id),
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_external_id ON users (external_id);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id varchar(32),
+    created_at timestamptz,
+    user_id bigint,
+    user_agent text,
+    ip text,
+    last_used_at timestamptz,
+    expires_at timestamptz,
+    revoked_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id bigserial,
+    created_at timestamptz,
+    session_id varchar(32),
+    token_hash varchar(64),
+    expires_at timestamptz,
+    used_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id bigserial,
+    created_at timestamptz,
+    user_id bigint,
+    token_hash varchar(64),
+    expires_at timestamptz,
+    used_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id bigserial,
+    created_at timestamptz,
+    user_id bigint,
+    code_hash varchar(64),
+    used_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes (code_hash);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS login_throttles (
+    subject varchar(320),
+    failures bigint,
+    last_failure_at timestamptz,
+    locked_until timestamptz,
+    PRIMARY KEY (subject)
+);
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id bigserial,
+    created_at timestamptz,
+    type text,
+    user_id bigint,
+    email text,
+    ip text,
+    actor_id bigint,
+    detail text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_security_events_type ON security_events (type);
+CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events (created_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    name text,
+    prefix varchar(32),
+    secret_hash varchar(64),
+    user_id bigint,
+    created_by_id bigint,
+    permissions text[],
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+
+CREATE TABLE IF NOT EXISTS o_id_c_logins (
+    state_hash varchar(64),
+    created_at timestamptz,
+    code_verifier text,
+    nonce text,
+    expires_at timestamptz,
+    PRIMARY KEY (state_hash)
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id bigserial,
+    created_at timestamptz,
+    actor_id bigint,
+    api_key_id bigint,
+    entity_type varchar(64),
+    entity_id varchar(128),
+    action varchar(16),
+    changes text,
+    request_id varchar(64),
+    ip text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_request_id ON audit_logs (request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs (entity_type, entity_id);
+
+CREATE TABLE IF NOT EXISTS addresses (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    street text,
+    city text,
+    state text,
+    zip_code text,
+    user_id bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_users_addresses FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_addresses_deleted_at ON addresses (deleted_at);
+
+CREATE TABLE IF NOT EXISTS inventories (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    sku text,
+    quantity bigint,
+    reserved bigint,
+    price decimal,
+    vendor_id bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT uni_inventories_sku UNIQUE (sku)
+);
+CREATE INDEX IF NOT EXISTS idx_inventories_deleted_at ON inventories (deleted_at);
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id bigserial,
+    created_at timestamptz,
+    inventory_id bigint,
+    sku text,
+    warehouse_id bigint,
+    bin_id bigint,
+    type text,
+    quantity bigint,
+    balance_after bigint,
+    reason_code text,
+    reference text,
+    user_id bigint,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_warehouse_id ON stock_movements (warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_sku ON stock_movements (sku);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_inventory_id ON stock_movements (inventory_id);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    inventory_id bigint,
+    warehouse_id bigint,
+    quantity bigint,
+    total_price decimal,
+    status text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id bigint,
+    inventory_id bigint,
+    quantity bigint,
+    status text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_inventory_id ON stock_reservations (inventory_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_reservations_order_id ON stock_reservations (order_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_deleted_at ON stock_reservations (deleted_at);
+
+CREATE TABLE IF NOT EXISTS shipments (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    order_id bigint,
+    transfer_order_id bigint,
+    warehouse_id bigint,
+    tracking_number text,
+    carrier text,
+    shipping_status text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shipments_transfer_order_id ON shipments (transfer_order_id);
+CREATE INDEX IF NOT EXISTS idx_shipments_deleted_at ON shipments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shipments_warehouse_id ON shipments (warehouse_id);
+
+CREATE TABLE IF NOT EXISTS vendors (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    contact_info text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_vendors_deleted_at ON vendors (deleted_at);
+
+CREATE TABLE IF NOT EXISTS warehouses (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    code text,
+    name text,
+    street text,
+    city text,
+    state text,
+    postal_code text,
+    country text,
+    timezone text,
+    active boolean,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_code ON warehouses (code);
+CREATE INDEX IF NOT EXISTS idx_warehouses_deleted_at ON warehouses (deleted_at);
+
+CREATE TABLE IF NOT EXISTS zones (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    warehouse_id bigint,
+    code text,
+    name text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_warehouses_zones FOREIGN KEY (warehouse_id) REFERENCES warehouses(id)
+);
+CREATE INDEX IF NOT EXISTS idx_zones_deleted_at ON zones (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_zone_code ON zones (warehouse_id, code);
+
+CREATE TABLE IF NOT EXISTS aisles (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    zone_id bigint,
+    code text,
+    name text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_zones_aisles FOREIGN KEY (zone_id) REFERENCES zones(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_aisle_code ON aisles (zone_id, code);
+CREATE INDEX IF NOT EXISTS idx_aisles_deleted_at ON aisles (deleted_at);
+
+CREATE TABLE IF NOT EXISTS bins (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    aisle_id bigint,
+    warehouse_id bigint,
+    code text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_aisles_bins FOREIGN KEY (aisle_id) REFERENCES aisles(id)
+);
+CREATE INDEX IF NOT EXISTS idx_bins_warehouse_id ON bins (warehouse_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bin_code ON bins (aisle_id, code);
+CREATE INDEX IF NOT EXISTS idx_bins_deleted_at ON bins (deleted_at);
+
+CREATE TABLE IF NOT EXISTS stock_balances (
+    id bigserial,
+    inventory_id bigint,
+    warehouse_id bigint,
+    bin_id bigint,
+    quantity bigint,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_balances_warehouse_id ON stock_balances (warehouse_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_location ON stock_balances (inventory_id, warehouse_id, bin_id);
+
+CREATE TABLE IF NOT EXISTS transfer_orders (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    source_warehouse_id bigint,
+    destination_warehouse_id bigint,
+    status text,
+    shipment_id bigint,
+    notes text,
+    discrepancy_reason text,
+    user_id bigint,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_transfer_orders_status ON transfer_orders (status);
+CREATE INDEX IF NOT EXISTS idx_transfer_orders_destination_warehouse_id ON transfer_orders (destination_warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_transfer_orders_source_warehouse_id ON transfer_orders (source_warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_transfer_orders_deleted_at ON transfer_orders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS transfer_order_lines (
+    id bigserial,
+    transfer_order_id bigint,
+    inventory_id bigint,
+    source_bin_id bigint,
+    destination_bin_id bigint,
+    quantity bigint,
+    picked_quantity bigint,
+    received_quantity bigint,
+    discrepancy_quantity bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_transfer_orders_lines FOREIGN KEY (transfer_order_id) REFERENCES transfer_orders(id)
+);
+CREATE INDEX IF NOT EXISTS idx_transfer_order_lines_transfer_order_id ON transfer_order_lines (transfer_order_id);
+
+CREATE TABLE IF NOT EXISTS suppliers (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_suppliers_deleted_at ON suppliers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS items (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    quantity bigint,
+    price decimal,
+    supplier_id bigint,
+    warehouse_id bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_suppliers_items FOREIGN KEY (supplier_id) REFERENCES suppliers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_items_warehouse_id ON items (warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items (deleted_at);
+
+CREATE TABLE IF NOT EXISTS products (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    description text,
+    price decimal,
+    quantity bigint,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
@@ -0,0 +1,4 @@
+ALTER TABLE vendors DROP COLUMN version;
+ALTER TABLE shipments DROP COLUMN version;
+ALTER TABLE orders DROP COLUMN version;
+ALTER TABLE inventories DROP COLUMN version;
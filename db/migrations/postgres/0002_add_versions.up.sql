@@ -0,0 +1,7 @@
+-- Versions for optimistic concurrency control. Every write bumps them, and
+-- updates and deletes only apply to the version the client last read.
+
+ALTER TABLE inventories ADD COLUMN version bigint NOT NULL DEFAULT 1;
+ALTER TABLE orders ADD COLUMN version bigint NOT NULL DEFAULT 1;
+ALTER TABLE shipments ADD COLUMN version bigint NOT NULL DEFAULT 1;
+ALTER TABLE vendors ADD COLUMN version bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,54 @@
+-- Items are not restored: what was migrated from them stays as products and
+-- inventory items. Each product takes the lowest price of its SKUs and the
+-- stock of all of them, and each inventory item the name, code and price of
+-- its SKU.
+
+CREATE TABLE items (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    quantity bigint,
+    price decimal,
+    supplier_id bigint,
+    warehouse_id bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_suppliers_items FOREIGN KEY (supplier_id) REFERENCES suppliers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_items_warehouse_id ON items (warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items (deleted_at);
+
+ALTER TABLE products
+    ADD COLUMN price decimal,
+    ADD COLUMN quantity bigint;
+UPDATE products SET
+    price = (SELECT MIN(skus.price) FROM skus WHERE skus.product_id = products.id),
+    quantity = (
+        SELECT COALESCE(SUM(inventories.quantity), 0) FROM inventories
+        JOIN skus ON skus.id = inventories.sku_id
+        WHERE skus.product_id = products.id
+    );
+
+ALTER TABLE inventories
+    ADD COLUMN name text,
+    ADD COLUMN sku text,
+    ADD COLUMN price decimal;
+UPDATE inventories SET
+    name = (
+        SELECT COALESCE(NULLIF(skus.name, ''), products.name) FROM skus
+        JOIN products ON products.id = skus.product_id
+        WHERE skus.id = inventories.sku_id
+    ),
+    sku = (SELECT skus.code FROM skus WHERE skus.id = inventories.sku_id),
+    price = (SELECT skus.price FROM skus WHERE skus.id = inventories.sku_id);
+ALTER TABLE inventories
+    DROP COLUMN sku_id,
+    ADD CONSTRAINT uni_inventories_sku UNIQUE (sku);
+
+DROP TABLE skus;
+
+ALTER TABLE products
+    DROP COLUMN category,
+    DROP COLUMN brand,
+    DROP COLUMN attributes;
@@ -0,0 +1,88 @@
+-- One catalog: products are master records, SKUs their sellable variants and
+-- inventories the stock records of SKUs, one per SKU.
+--
+-- Existing rows are carried over. Every product gets a default SKU with its
+-- price, and every inventory item and every item becomes a product with one
+-- SKU. Item quantities become inventory items; like other stock that
+-- predates the ledger they have no movements, so reconcile them to book an
+-- opening balance. Product quantities and item warehouses are dropped.
+
+ALTER TABLE products
+    ADD COLUMN category text,
+    ADD COLUMN brand text,
+    ADD COLUMN attributes jsonb NOT NULL DEFAULT '{}',
+    ADD COLUMN legacy_source text,
+    ADD COLUMN legacy_id bigint;
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
+
+CREATE TABLE skus (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    product_id bigint NOT NULL,
+    code text NOT NULL,
+    name text,
+    size text,
+    colour text,
+    pack_size bigint NOT NULL DEFAULT 1,
+    price decimal,
+    supplier_id bigint,
+    attributes jsonb NOT NULL DEFAULT '{}',
+    PRIMARY KEY (id),
+    CONSTRAINT uni_skus_code UNIQUE (code),
+    CONSTRAINT fk_skus_product FOREIGN KEY (product_id) REFERENCES products(id),
+    CONSTRAINT fk_skus_supplier FOREIGN KEY (supplier_id) REFERENCES suppliers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_skus_product_id ON skus (product_id);
+CREATE INDEX IF NOT EXISTS idx_skus_supplier_id ON skus (supplier_id);
+CREATE INDEX IF NOT EXISTS idx_skus_deleted_at ON skus (deleted_at);
+
+INSERT INTO skus (created_at, updated_at, deleted_at, product_id, code, name, price)
+SELECT created_at, updated_at, deleted_at, id, 'PRODUCT-' || id, name, price
+FROM products;
+
+ALTER TABLE inventories ADD COLUMN sku_id bigint;
+
+INSERT INTO products (created_at, updated_at, deleted_at, name, legacy_source, legacy_id)
+SELECT created_at, updated_at, deleted_at, name, 'inventory', id
+FROM inventories;
+INSERT INTO skus (created_at, updated_at, deleted_at, product_id, code, name, price)
+SELECT inventories.created_at, inventories.updated_at, inventories.deleted_at, products.id,
+    COALESCE(NULLIF(inventories.sku, ''), 'INVENTORY-' || inventories.id), inventories.name, inventories.price
+FROM inventories
+JOIN products ON products.legacy_source = 'inventory' AND products.legacy_id = inventories.id;
+UPDATE inventories SET sku_id = (
+    SELECT skus.id FROM skus
+    JOIN products ON products.id = skus.product_id
+    WHERE products.legacy_source = 'inventory' AND products.legacy_id = inventories.id
+);
+
+INSERT INTO products (created_at, updated_at, deleted_at, name, legacy_source, legacy_id)
+SELECT created_at, updated_at, deleted_at, name, 'item', id
+FROM items;
+INSERT INTO skus (created_at, updated_at, deleted_at, product_id, code, name, price, supplier_id)
+SELECT items.created_at, items.updated_at, items.deleted_at, products.id,
+    'ITEM-' || items.id, items.name, items.price, NULLIF(items.supplier_id, 0)
+FROM items
+JOIN products ON products.legacy_source = 'item' AND products.legacy_id = items.id;
+INSERT INTO inventories (created_at, updated_at, deleted_at, sku_id, quantity, reserved)
+SELECT items.created_at, items.updated_at, items.deleted_at, skus.id, items.quantity, 0
+FROM items
+JOIN skus ON skus.code = 'ITEM-' || items.id;
+
+ALTER TABLE inventories
+    DROP COLUMN name,
+    DROP COLUMN sku,
+    DROP COLUMN price,
+    ALTER COLUMN sku_id SET NOT NULL,
+    ADD CONSTRAINT uni_inventories_sku_id UNIQUE (sku_id),
+    ADD CONSTRAINT fk_inventories_sku FOREIGN KEY (sku_id) REFERENCES skus(id);
+
+ALTER TABLE products
+    DROP COLUMN price,
+    DROP COLUMN quantity,
+    DROP COLUMN legacy_source,
+    DROP COLUMN legacy_id;
+
+DROP TABLE items;
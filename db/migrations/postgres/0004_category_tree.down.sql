@@ -0,0 +1,14 @@
+-- Products take back the name of their category as a flat category.
+-- Supplier assignments and the shape of the tree are lost.
+
+ALTER TABLE products ADD COLUMN category text;
+UPDATE products SET category = categories.name
+FROM categories
+WHERE categories.id = products.category_id;
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
+
+DROP INDEX IF EXISTS idx_products_category_id;
+ALTER TABLE products DROP COLUMN category_id;
+
+DROP TABLE supplier_categories;
+DROP TABLE categories;
@@ -0,0 +1,51 @@
+-- Product categories become a tree. Each category has a slug and a path of
+-- slugs from the root, e.g. electrical/cables, and suppliers are assigned to
+-- categories through supplier_categories.
+--
+-- Every distinct category a product had becomes a root category named after
+-- it; product categories that only differ in case or punctuation share one.
+
+CREATE TABLE categories (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text NOT NULL,
+    slug text NOT NULL,
+    parent_id bigint,
+    path text NOT NULL,
+    CONSTRAINT uni_categories_path UNIQUE (path),
+    CONSTRAINT fk_categories_children FOREIGN KEY (parent_id) REFERENCES categories(id)
+);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories (deleted_at);
+
+CREATE TABLE supplier_categories (
+    supplier_id bigint NOT NULL,
+    category_id bigint NOT NULL,
+    PRIMARY KEY (supplier_id, category_id),
+    CONSTRAINT fk_supplier_categories_supplier FOREIGN KEY (supplier_id) REFERENCES suppliers(id),
+    CONSTRAINT fk_supplier_categories_category FOREIGN KEY (category_id) REFERENCES categories(id)
+);
+
+INSERT INTO categories (created_at, updated_at, name, slug, path)
+SELECT NOW(), NOW(), MIN(name), slug, slug
+FROM (
+    SELECT TRIM(category) AS name,
+        TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(category), '[^a-z0-9]+', '-', 'g')) AS slug
+    FROM products
+    WHERE category IS NOT NULL
+) legacy
+WHERE slug <> ''
+GROUP BY slug;
+
+ALTER TABLE products
+    ADD COLUMN category_id bigint,
+    ADD CONSTRAINT fk_products_category FOREIGN KEY (category_id) REFERENCES categories(id);
+UPDATE products SET category_id = categories.id
+FROM categories
+WHERE categories.path = TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(products.category), '[^a-z0-9]+', '-', 'g'));
+
+DROP INDEX IF EXISTS idx_products_category;
+ALTER TABLE products DROP COLUMN category;
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
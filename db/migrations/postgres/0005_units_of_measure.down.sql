@@ -0,0 +1,14 @@
+-- Quantities entered in other units are dropped; the base quantities stay.
+
+ALTER TABLE transfer_order_lines DROP COLUMN unit_quantity;
+ALTER TABLE transfer_order_lines DROP COLUMN unit;
+
+ALTER TABLE stock_movements DROP COLUMN unit_quantity;
+ALTER TABLE stock_movements DROP COLUMN unit;
+
+ALTER TABLE orders DROP COLUMN unit_quantity;
+ALTER TABLE orders DROP COLUMN unit;
+
+DROP TABLE sku_units;
+ALTER TABLE skus DROP COLUMN base_unit;
+DROP TABLE units;
@@ -0,0 +1,62 @@
+-- Units of measure. Stock stays a whole number of each SKU's base unit,
+-- which is ea for every existing SKU; orders, movements and transfer lines
+-- also record the unit and quantity they were entered in.
+--
+-- The catalog is seeded with common units. Ratios convert units of one
+-- dimension into each other; packaging units have none and convert through
+-- the factors SKUs give them in sku_units.
+
+CREATE TABLE units (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    code text NOT NULL,
+    name text,
+    dimension text NOT NULL,
+    ratio double precision,
+    CONSTRAINT uni_units_code UNIQUE (code)
+);
+CREATE INDEX IF NOT EXISTS idx_units_deleted_at ON units (deleted_at);
+
+INSERT INTO units (created_at, updated_at, code, name, dimension, ratio) VALUES
+    (NOW(), NOW(), 'ea', 'Each', 'count', 1),
+    (NOW(), NOW(), 'pair', 'Pair', 'count', 2),
+    (NOW(), NOW(), 'dozen', 'Dozen', 'count', 12),
+    (NOW(), NOW(), 'pack', 'Pack', 'count', 0),
+    (NOW(), NOW(), 'case', 'Case', 'count', 0),
+    (NOW(), NOW(), 'pallet', 'Pallet', 'count', 0),
+    (NOW(), NOW(), 'g', 'Gram', 'mass', 1),
+    (NOW(), NOW(), 'kg', 'Kilogram', 'mass', 1000),
+    (NOW(), NOW(), 't', 'Tonne', 'mass', 1000000),
+    (NOW(), NOW(), 'oz', 'Ounce', 'mass', 28.349523125),
+    (NOW(), NOW(), 'lb', 'Pound', 'mass', 453.59237),
+    (NOW(), NOW(), 'ml', 'Millilitre', 'volume', 1),
+    (NOW(), NOW(), 'l', 'Litre', 'volume', 1000),
+    (NOW(), NOW(), 'mm', 'Millimetre', 'length', 1),
+    (NOW(), NOW(), 'cm', 'Centimetre', 'length', 10),
+    (NOW(), NOW(), 'm', 'Metre', 'length', 1000);
+
+ALTER TABLE skus ADD COLUMN base_unit text NOT NULL DEFAULT 'ea';
+
+CREATE TABLE sku_units (
+    id bigserial PRIMARY KEY,
+    sku_id bigint NOT NULL,
+    unit text NOT NULL,
+    factor double precision NOT NULL,
+    CONSTRAINT fk_skus_units FOREIGN KEY (sku_id) REFERENCES skus(id),
+    CONSTRAINT fk_sku_units_unit FOREIGN KEY (unit) REFERENCES units(code)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sku_units_sku_unit ON sku_units (sku_id, unit);
+
+ALTER TABLE orders ADD COLUMN unit text;
+ALTER TABLE orders ADD COLUMN unit_quantity double precision;
+UPDATE orders SET unit = 'ea', unit_quantity = quantity;
+
+ALTER TABLE stock_movements ADD COLUMN unit text;
+ALTER TABLE stock_movements ADD COLUMN unit_quantity double precision;
+UPDATE stock_movements SET unit = 'ea', unit_quantity = quantity;
+
+ALTER TABLE transfer_order_lines ADD COLUMN unit text;
+ALTER TABLE transfer_order_lines ADD COLUMN unit_quantity double precision;
+UPDATE transfer_order_lines SET unit = 'ea', unit_quantity = quantity;
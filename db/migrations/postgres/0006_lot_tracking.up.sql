@@ -0,0 +1,30 @@
+-- Lots. Stock balances are kept per lot as well as per location, with
+-- lot_id 0 for stock without a lot, which is all existing stock. Movements
+-- and transfer lines record the lot they moved.
+
+CREATE TABLE lots (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    sku_id bigint NOT NULL,
+    number text NOT NULL,
+    manufactured_on timestamptz,
+    expires_on timestamptz,
+    supplier_reference text,
+    CONSTRAINT fk_lots_sku FOREIGN KEY (sku_id) REFERENCES skus(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lots_sku_number ON lots (sku_id, number);
+CREATE INDEX IF NOT EXISTS idx_lots_expires_on ON lots (expires_on);
+CREATE INDEX IF NOT EXISTS idx_lots_deleted_at ON lots (deleted_at);
+
+ALTER TABLE skus ADD COLUMN lot_controlled boolean NOT NULL DEFAULT false;
+
+ALTER TABLE stock_balances ADD COLUMN lot_id bigint NOT NULL DEFAULT 0;
+DROP INDEX IF EXISTS idx_stock_location;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_location ON stock_balances (inventory_id, warehouse_id, bin_id, lot_id);
+
+ALTER TABLE stock_movements ADD COLUMN lot_id bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_stock_movements_lot_id ON stock_movements (lot_id);
+
+ALTER TABLE transfer_order_lines ADD COLUMN lot_id bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP TABLE serial_events;
+DROP TABLE serial_numbers;
+ALTER TABLE skus DROP COLUMN serial_controlled;
@@ -0,0 +1,41 @@
+-- Serial numbers of serial-controlled SKUs, one row per unit, and the
+-- append-only history of each.
+
+ALTER TABLE skus ADD COLUMN serial_controlled boolean NOT NULL DEFAULT false;
+
+CREATE TABLE serial_numbers (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    serial text NOT NULL,
+    sku_id bigint NOT NULL,
+    inventory_id bigint NOT NULL,
+    status text NOT NULL,
+    warehouse_id bigint,
+    bin_id bigint,
+    lot_id bigint,
+    order_id bigint,
+    CONSTRAINT fk_serial_numbers_sku FOREIGN KEY (sku_id) REFERENCES skus(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_serial_numbers_serial ON serial_numbers (serial);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_sku_id ON serial_numbers (sku_id);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_status ON serial_numbers (status);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_order_id ON serial_numbers (order_id);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_deleted_at ON serial_numbers (deleted_at);
+
+CREATE TABLE serial_events (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    serial_id bigint NOT NULL,
+    status text,
+    movement_id bigint,
+    order_id bigint,
+    warehouse_id bigint,
+    bin_id bigint,
+    lot_id bigint,
+    reference text,
+    user_id bigint,
+    CONSTRAINT fk_serial_events_serial FOREIGN KEY (serial_id) REFERENCES serial_numbers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_serial_events_serial_id ON serial_events (serial_id);
@@ -0,0 +1,4 @@
+DELETE FROM serial_events WHERE shipment_id IS NOT NULL AND shipment_id <> 0;
+DROP INDEX IF EXISTS idx_serial_numbers_shipment_id;
+ALTER TABLE serial_events DROP COLUMN shipment_id;
+ALTER TABLE serial_numbers DROP COLUMN shipment_id;
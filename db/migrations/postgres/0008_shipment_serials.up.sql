@@ -0,0 +1,23 @@
+-- The shipment that carries each serial number, on the serial and on the
+-- events that record it. Serials shipped before this are put on their
+-- order's shipment when the order has exactly one.
+
+ALTER TABLE serial_numbers ADD COLUMN shipment_id bigint;
+ALTER TABLE serial_events ADD COLUMN shipment_id bigint;
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_shipment_id ON serial_numbers (shipment_id);
+
+UPDATE serial_numbers
+SET shipment_id = (
+    SELECT shipments.id FROM shipments
+    WHERE shipments.order_id = serial_numbers.order_id AND shipments.deleted_at IS NULL
+)
+WHERE status = 'shipped' AND order_id <> 0
+    AND (
+        SELECT COUNT(*) FROM shipments
+        WHERE shipments.order_id = serial_numbers.order_id AND shipments.deleted_at IS NULL
+    ) = 1;
+
+INSERT INTO serial_events (created_at, serial_id, status, order_id, shipment_id, warehouse_id, bin_id, lot_id, reference, user_id)
+SELECT CURRENT_TIMESTAMP, id, status, order_id, shipment_id, warehouse_id, bin_id, lot_id, 'shipment:' || shipment_id, 0
+FROM serial_numbers
+WHERE shipment_id IS NOT NULL;
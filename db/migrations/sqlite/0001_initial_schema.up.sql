@@ -0,0 +1,372 @@
+-- The schema AutoMigrate used to create. Every statement is IF NOT EXISTS so
+-- databases created that way can adopt the migration history as they are.
+
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    email text,
+    password text,
+    role text,
+    verified numeric,
+    permissions text,
+    phone text,
+    token_version integer,
+    verification_sent_at datetime,
+    mfa_enabled numeric,
+    mfa_secret text,
+    mfa_last_counter integer,
+    external_id text,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_users_external_id ON users (external_id);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id text,
+    created_at datetime,
+    user_id integer,
+    user_agent text,
+    ip text,
+    last_used_at datetime,
+    expires_at datetime,
+    revoked_at datetime,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    session_id text,
+    token_hash text,
+    expires_at datetime,
+    used_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    user_id integer,
+    token_hash text,
+    expires_at datetime,
+    used_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    user_id integer,
+    code_hash text,
+    used_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes (code_hash);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS login_throttles (
+    subject text,
+    failures integer,
+    last_failure_at datetime,
+    locked_until datetime,
+    PRIMARY KEY (subject)
+);
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    type text,
+    user_id integer,
+    email text,
+    ip text,
+    actor_id integer,
+    detail text
+);
+CREATE INDEX IF NOT EXISTS idx_security_events_created_at ON security_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_security_events_user_id ON security_events (user_id);
+CREATE INDEX IF NOT EXISTS idx_security_events_type ON security_events (type);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    name text,
+    prefix text,
+    secret_hash text,
+    user_id integer,
+    created_by_id integer,
+    permissions text,
+    expires_at datetime,
+    last_used_at datetime,
+    revoked_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys (prefix);
+
+CREATE TABLE IF NOT EXISTS o_id_c_logins (
+    state_hash text,
+    created_at datetime,
+    code_verifier text,
+    nonce text,
+    expires_at datetime,
+    PRIMARY KEY (state_hash)
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    actor_id integer,
+    api_key_id integer,
+    entity_type text,
+    entity_id text,
+    action text,
+    changes text,
+    request_id text,
+    ip text
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_request_id ON audit_logs (request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs (entity_type, entity_id);
+
+CREATE TABLE IF NOT EXISTS addresses (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    street text,
+    city text,
+    state text,
+    zip_code text,
+    user_id integer,
+    CONSTRAINT fk_users_addresses FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_addresses_deleted_at ON addresses (deleted_at);
+
+CREATE TABLE IF NOT EXISTS inventories (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    sku text,
+    quantity integer,
+    reserved integer,
+    price real,
+    vendor_id integer,
+    CONSTRAINT uni_inventories_sku UNIQUE (sku)
+);
+CREATE INDEX IF NOT EXISTS idx_inventories_deleted_at ON inventories (deleted_at);
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    inventory_id integer,
+    sku text,
+    warehouse_id integer,
+    bin_id integer,
+    type text,
+    quantity integer,
+    balance_after integer,
+    reason_code text,
+    reference text,
+    user_id integer
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_warehouse_id ON stock_movements (warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_sku ON stock_movements (sku);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_inventory_id ON stock_movements (inventory_id);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    inventory_id integer,
+    warehouse_id integer,
+    quantity integer,
+    total_price real,
+    status text
+);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    order_id integer,
+    inventory_id integer,
+    quantity integer,
+    status text
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_deleted_at ON stock_reservations (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_inventory_id ON stock_reservations (inventory_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_reservations_order_id ON stock_reservations (order_id);
+
+CREATE TABLE IF NOT EXISTS shipments (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    order_id integer,
+    transfer_order_id integer,
+    warehouse_id integer,
+    tracking_number text,
+    carrier text,
+    shipping_status text
+);
+CREATE INDEX IF NOT EXISTS idx_shipments_transfer_order_id ON shipments (transfer_order_id);
+CREATE INDEX IF NOT EXISTS idx_shipments_deleted_at ON shipments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shipments_warehouse_id ON shipments (warehouse_id);
+
+CREATE TABLE IF NOT EXISTS vendors (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    contact_info text
+);
+CREATE INDEX IF NOT EXISTS idx_vendors_deleted_at ON vendors (deleted_at);
+
+CREATE TABLE IF NOT EXISTS warehouses (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    code text,
+    name text,
+    street text,
+    city text,
+    state text,
+    postal_code text,
+    country text,
+    timezone text,
+    active numeric
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_code ON warehouses (code);
+CREATE INDEX IF NOT EXISTS idx_warehouses_deleted_at ON warehouses (deleted_at);
+
+CREATE TABLE IF NOT EXISTS zones (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    warehouse_id integer,
+    code text,
+    name text,
+    CONSTRAINT fk_warehouses_zones FOREIGN KEY (warehouse_id) REFERENCES warehouses(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_zone_code ON zones (warehouse_id, code);
+CREATE INDEX IF NOT EXISTS idx_zones_deleted_at ON zones (deleted_at);
+
+CREATE TABLE IF NOT EXISTS aisles (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    zone_id integer,
+    code text,
+    name text,
+    CONSTRAINT fk_zones_aisles FOREIGN KEY (zone_id) REFERENCES zones(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_aisle_code ON aisles (zone_id, code);
+CREATE INDEX IF NOT EXISTS idx_aisles_deleted_at ON aisles (deleted_at);
+
+CREATE TABLE IF NOT EXISTS bins (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    aisle_id integer,
+    warehouse_id integer,
+    code text,
+    CONSTRAINT fk_aisles_bins FOREIGN KEY (aisle_id) REFERENCES aisles(id)
+);
+CREATE INDEX IF NOT EXISTS idx_bins_warehouse_id ON bins (warehouse_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bin_code ON bins (aisle_id, code);
+CREATE INDEX IF NOT EXISTS idx_bins_deleted_at ON bins (deleted_at);
+
+CREATE TABLE IF NOT EXISTS stock_balances (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    inventory_id integer,
+    warehouse_id integer,
+    bin_id integer,
+    quantity integer
+);
+CREATE INDEX IF NOT EXISTS idx_stock_balances_warehouse_id ON stock_balances (warehouse_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_location ON stock_balances (inventory_id, warehouse_id, bin_id);
+
+CREATE TABLE IF NOT EXISTS transfer_orders (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    source_warehouse_id integer,
+    destination_warehouse_id integer,
+    status text,
+    shipment_id integer,
+    notes text,
+    discrepancy_reason text,
+    user_id integer
+);
+CREATE INDEX IF NOT EXISTS idx_transfer_orders_status ON transfer_orders (status);
+CREATE INDEX IF NOT EXISTS idx_transfer_orders_destination_warehouse_id ON transfer_orders (destination_warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_transfer_orders_source_warehouse_id ON transfer_orders (source_warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_transfer_orders_deleted_at ON transfer_orders (deleted_at);
+
+CREATE TABLE IF NOT EXISTS transfer_order_lines (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    transfer_order_id integer,
+    inventory_id integer,
+    source_bin_id integer,
+    destination_bin_id integer,
+    quantity integer,
+    picked_quantity integer,
+    received_quantity integer,
+    discrepancy_quantity integer,
+    CONSTRAINT fk_transfer_orders_lines FOREIGN KEY (transfer_order_id) REFERENCES transfer_orders(id)
+);
+CREATE INDEX IF NOT EXISTS idx_transfer_order_lines_transfer_order_id ON transfer_order_lines (transfer_order_id);
+
+CREATE TABLE IF NOT EXISTS suppliers (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text
+);
+CREATE INDEX IF NOT EXISTS idx_suppliers_deleted_at ON suppliers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS items (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    quantity integer,
+    price real,
+    supplier_id integer,
+    warehouse_id integer,
+    CONSTRAINT fk_suppliers_items FOREIGN KEY (supplier_id) REFERENCES suppliers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_items_warehouse_id ON items (warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items (deleted_at);
+
+CREATE TABLE IF NOT EXISTS products (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    description text,
+    price real,
+    quantity integer
+);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
@@ -0,0 +1,61 @@
+-- Items are not restored: what was migrated from them stays as products and
+-- inventory items. Each product takes the lowest price of its SKUs and the
+-- stock of all of them, and each inventory item the name, code and price of
+-- its SKU.
+
+CREATE TABLE items (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    quantity integer,
+    price real,
+    supplier_id integer,
+    warehouse_id integer,
+    CONSTRAINT fk_suppliers_items FOREIGN KEY (supplier_id) REFERENCES suppliers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_items_warehouse_id ON items (warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items (deleted_at);
+
+ALTER TABLE products ADD COLUMN price real;
+ALTER TABLE products ADD COLUMN quantity integer;
+UPDATE products SET
+    price = (SELECT MIN(skus.price) FROM skus WHERE skus.product_id = products.id),
+    quantity = (
+        SELECT COALESCE(SUM(inventories.quantity), 0) FROM inventories
+        JOIN skus ON skus.id = inventories.sku_id
+        WHERE skus.product_id = products.id
+    );
+
+CREATE TABLE inventories_old (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    sku text,
+    quantity integer,
+    reserved integer,
+    price real,
+    vendor_id integer,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT uni_inventories_sku UNIQUE (sku)
+);
+INSERT INTO inventories_old (id, created_at, updated_at, deleted_at, name, sku, quantity, reserved, price, vendor_id, version)
+SELECT inventories.id, inventories.created_at, inventories.updated_at, inventories.deleted_at,
+    COALESCE(NULLIF(skus.name, ''), products.name), skus.code,
+    inventories.quantity, inventories.reserved, skus.price, inventories.vendor_id, inventories.version
+FROM inventories
+JOIN skus ON skus.id = inventories.sku_id
+JOIN products ON products.id = skus.product_id;
+DROP TABLE inventories;
+ALTER TABLE inventories_old RENAME TO inventories;
+CREATE INDEX IF NOT EXISTS idx_inventories_deleted_at ON inventories (deleted_at);
+
+DROP TABLE skus;
+
+DROP INDEX idx_products_category;
+ALTER TABLE products DROP COLUMN category;
+ALTER TABLE products DROP COLUMN brand;
+ALTER TABLE products DROP COLUMN attributes;
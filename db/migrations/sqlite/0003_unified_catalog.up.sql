@@ -0,0 +1,94 @@
+-- One catalog: products are master records, SKUs their sellable variants and
+-- inventories the stock records of SKUs, one per SKU.
+--
+-- Existing rows are carried over. Every product gets a default SKU with its
+-- price, and every inventory item and every item becomes a product with one
+-- SKU. Item quantities become inventory items; like other stock that
+-- predates the ledger they have no movements, so reconcile them to book an
+-- opening balance. Product quantities and item warehouses are dropped.
+
+ALTER TABLE products ADD COLUMN category text;
+ALTER TABLE products ADD COLUMN brand text;
+ALTER TABLE products ADD COLUMN attributes text NOT NULL DEFAULT '{}';
+ALTER TABLE products ADD COLUMN legacy_source text;
+ALTER TABLE products ADD COLUMN legacy_id integer;
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
+
+CREATE TABLE skus (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    product_id integer NOT NULL,
+    code text NOT NULL,
+    name text,
+    size text,
+    colour text,
+    pack_size integer NOT NULL DEFAULT 1,
+    price real,
+    supplier_id integer,
+    attributes text NOT NULL DEFAULT '{}',
+    CONSTRAINT uni_skus_code UNIQUE (code),
+    CONSTRAINT fk_skus_product FOREIGN KEY (product_id) REFERENCES products(id),
+    CONSTRAINT fk_skus_supplier FOREIGN KEY (supplier_id) REFERENCES suppliers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_skus_product_id ON skus (product_id);
+CREATE INDEX IF NOT EXISTS idx_skus_supplier_id ON skus (supplier_id);
+CREATE INDEX IF NOT EXISTS idx_skus_deleted_at ON skus (deleted_at);
+
+INSERT INTO skus (created_at, updated_at, deleted_at, product_id, code, name, price)
+SELECT created_at, updated_at, deleted_at, id, 'PRODUCT-' || id, name, price
+FROM products;
+
+INSERT INTO products (created_at, updated_at, deleted_at, name, legacy_source, legacy_id)
+SELECT created_at, updated_at, deleted_at, name, 'inventory', id
+FROM inventories;
+INSERT INTO skus (created_at, updated_at, deleted_at, product_id, code, name, price)
+SELECT inventories.created_at, inventories.updated_at, inventories.deleted_at, products.id,
+    COALESCE(NULLIF(inventories.sku, ''), 'INVENTORY-' || inventories.id), inventories.name, inventories.price
+FROM inventories
+JOIN products ON products.legacy_source = 'inventory' AND products.legacy_id = inventories.id;
+
+INSERT INTO products (created_at, updated_at, deleted_at, name, legacy_source, legacy_id)
+SELECT created_at, updated_at, deleted_at, name, 'item', id
+FROM items;
+INSERT INTO skus (created_at, updated_at, deleted_at, product_id, code, name, price, supplier_id)
+SELECT items.created_at, items.updated_at, items.deleted_at, products.id,
+    'ITEM-' || items.id, items.name, items.price, NULLIF(items.supplier_id, 0)
+FROM items
+JOIN products ON products.legacy_source = 'item' AND products.legacy_id = items.id;
+
+-- SQLite cannot drop the unique sku column, so inventories is rebuilt
+CREATE TABLE inventories_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    sku_id integer NOT NULL,
+    quantity integer,
+    reserved integer,
+    vendor_id integer,
+    version integer NOT NULL DEFAULT 1,
+    CONSTRAINT uni_inventories_sku_id UNIQUE (sku_id),
+    CONSTRAINT fk_inventories_sku FOREIGN KEY (sku_id) REFERENCES skus(id)
+);
+INSERT INTO inventories_new (id, created_at, updated_at, deleted_at, sku_id, quantity, reserved, vendor_id, version)
+SELECT inventories.id, inventories.created_at, inventories.updated_at, inventories.deleted_at, skus.id,
+    inventories.quantity, inventories.reserved, inventories.vendor_id, inventories.version
+FROM inventories
+JOIN products ON products.legacy_source = 'inventory' AND products.legacy_id = inventories.id
+JOIN skus ON skus.product_id = products.id;
+INSERT INTO inventories_new (created_at, updated_at, deleted_at, sku_id, quantity, reserved)
+SELECT items.created_at, items.updated_at, items.deleted_at, skus.id, items.quantity, 0
+FROM items
+JOIN skus ON skus.code = 'ITEM-' || items.id;
+DROP TABLE inventories;
+ALTER TABLE inventories_new RENAME TO inventories;
+CREATE INDEX IF NOT EXISTS idx_inventories_deleted_at ON inventories (deleted_at);
+
+ALTER TABLE products DROP COLUMN price;
+ALTER TABLE products DROP COLUMN quantity;
+ALTER TABLE products DROP COLUMN legacy_source;
+ALTER TABLE products DROP COLUMN legacy_id;
+
+DROP TABLE items;
@@ -0,0 +1,70 @@
+-- Product categories become a tree. Each category has a slug and a path of
+-- slugs from the root, e.g. electrical/cables, and suppliers are assigned to
+-- categories through supplier_categories.
+--
+-- Every distinct category a product had becomes a root category named after
+-- it; product categories that only differ in case or punctuation share one.
+-- SQLite has no regular expressions, so the slugs are built one character at
+-- a time with the same rule as models.Slugify: ASCII letters and digits are
+-- kept and every other run of characters becomes a single hyphen.
+
+CREATE TABLE categories (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text NOT NULL,
+    slug text NOT NULL,
+    parent_id integer,
+    path text NOT NULL,
+    CONSTRAINT uni_categories_path UNIQUE (path),
+    CONSTRAINT fk_categories_children FOREIGN KEY (parent_id) REFERENCES categories(id)
+);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories (deleted_at);
+
+CREATE TABLE supplier_categories (
+    supplier_id integer NOT NULL,
+    category_id integer NOT NULL,
+    PRIMARY KEY (supplier_id, category_id),
+    CONSTRAINT fk_supplier_categories_supplier FOREIGN KEY (supplier_id) REFERENCES suppliers(id),
+    CONSTRAINT fk_supplier_categories_category FOREIGN KEY (category_id) REFERENCES categories(id)
+);
+
+CREATE TABLE legacy_category_slugs (
+    category text PRIMARY KEY,
+    slug text NOT NULL
+);
+WITH RECURSIVE slugging(category, pos, slug, hyphen) AS (
+    SELECT DISTINCT category, 1, '', 0 FROM products WHERE category IS NOT NULL
+    UNION ALL
+    SELECT category, pos + 1,
+        CASE WHEN LOWER(SUBSTR(category, pos, 1)) BETWEEN 'a' AND 'z' OR SUBSTR(category, pos, 1) BETWEEN '0' AND '9'
+            THEN slug || CASE WHEN hyphen = 1 AND slug <> '' THEN '-' ELSE '' END || LOWER(SUBSTR(category, pos, 1))
+            ELSE slug END,
+        CASE WHEN LOWER(SUBSTR(category, pos, 1)) BETWEEN 'a' AND 'z' OR SUBSTR(category, pos, 1) BETWEEN '0' AND '9'
+            THEN 0 ELSE 1 END
+    FROM slugging
+    WHERE pos <= LENGTH(category)
+)
+INSERT INTO legacy_category_slugs (category, slug)
+SELECT category, slug FROM slugging WHERE pos > LENGTH(category);
+
+INSERT INTO categories (created_at, updated_at, name, slug, path)
+SELECT CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, MIN(TRIM(category)), slug, slug
+FROM legacy_category_slugs
+WHERE slug <> ''
+GROUP BY slug;
+
+-- Without a foreign key, so that the down migration can drop the column
+ALTER TABLE products ADD COLUMN category_id integer;
+UPDATE products SET category_id = (
+    SELECT categories.id FROM categories
+    JOIN legacy_category_slugs ON legacy_category_slugs.slug = categories.path
+    WHERE legacy_category_slugs.category = products.category
+);
+DROP TABLE legacy_category_slugs;
+
+DROP INDEX IF EXISTS idx_products_category;
+ALTER TABLE products DROP COLUMN category;
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
@@ -0,0 +1,62 @@
+-- Units of measure. Stock stays a whole number of each SKU's base unit,
+-- which is ea for every existing SKU; orders, movements and transfer lines
+-- also record the unit and quantity they were entered in.
+--
+-- The catalog is seeded with common units. Ratios convert units of one
+-- dimension into each other; packaging units have none and convert through
+-- the factors SKUs give them in sku_units.
+
+CREATE TABLE units (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    code text NOT NULL,
+    name text,
+    dimension text NOT NULL,
+    ratio real,
+    CONSTRAINT uni_units_code UNIQUE (code)
+);
+CREATE INDEX IF NOT EXISTS idx_units_deleted_at ON units (deleted_at);
+
+INSERT INTO units (created_at, updated_at, code, name, dimension, ratio) VALUES
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'ea', 'Each', 'count', 1),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'pair', 'Pair', 'count', 2),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'dozen', 'Dozen', 'count', 12),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'pack', 'Pack', 'count', 0),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'case', 'Case', 'count', 0),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'pallet', 'Pallet', 'count', 0),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'g', 'Gram', 'mass', 1),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'kg', 'Kilogram', 'mass', 1000),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 't', 'Tonne', 'mass', 1000000),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'oz', 'Ounce', 'mass', 28.349523125),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'lb', 'Pound', 'mass', 453.59237),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'ml', 'Millilitre', 'volume', 1),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'l', 'Litre', 'volume', 1000),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'mm', 'Millimetre', 'length', 1),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'cm', 'Centimetre', 'length', 10),
+    (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'm', 'Metre', 'length', 1000);
+
+ALTER TABLE skus ADD COLUMN base_unit text NOT NULL DEFAULT 'ea';
+
+CREATE TABLE sku_units (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    sku_id integer NOT NULL,
+    unit text NOT NULL,
+    factor real NOT NULL,
+    CONSTRAINT fk_skus_units FOREIGN KEY (sku_id) REFERENCES skus(id),
+    CONSTRAINT fk_sku_units_unit FOREIGN KEY (unit) REFERENCES units(code)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sku_units_sku_unit ON sku_units (sku_id, unit);
+
+ALTER TABLE orders ADD COLUMN unit text;
+ALTER TABLE orders ADD COLUMN unit_quantity real;
+UPDATE orders SET unit = 'ea', unit_quantity = quantity;
+
+ALTER TABLE stock_movements ADD COLUMN unit text;
+ALTER TABLE stock_movements ADD COLUMN unit_quantity real;
+UPDATE stock_movements SET unit = 'ea', unit_quantity = quantity;
+
+ALTER TABLE transfer_order_lines ADD COLUMN unit text;
+ALTER TABLE transfer_order_lines ADD COLUMN unit_quantity real;
+UPDATE transfer_order_lines SET unit = 'ea', unit_quantity = quantity;
@@ -0,0 +1,25 @@
+-- Lot balances in a warehouse are merged into one balance per location.
+-- Lot stock outside a warehouse becomes unlocated stock, which has no
+-- balance. Lots themselves are dropped.
+
+CREATE TABLE stock_balances_merged AS
+SELECT MIN(id) AS id, inventory_id, warehouse_id, bin_id, SUM(quantity) AS quantity
+FROM stock_balances
+WHERE warehouse_id <> 0
+GROUP BY inventory_id, warehouse_id, bin_id;
+DELETE FROM stock_balances;
+
+DROP INDEX IF EXISTS idx_stock_location;
+ALTER TABLE stock_balances DROP COLUMN lot_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_location ON stock_balances (inventory_id, warehouse_id, bin_id);
+INSERT INTO stock_balances (id, inventory_id, warehouse_id, bin_id, quantity)
+SELECT id, inventory_id, warehouse_id, bin_id, quantity FROM stock_balances_merged;
+DROP TABLE stock_balances_merged;
+
+ALTER TABLE transfer_order_lines DROP COLUMN lot_id;
+
+DROP INDEX IF EXISTS idx_stock_movements_lot_id;
+ALTER TABLE stock_movements DROP COLUMN lot_id;
+
+ALTER TABLE skus DROP COLUMN lot_controlled;
+DROP TABLE lots;
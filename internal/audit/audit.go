@@ -0,0 +1,334 @@
+// Package audit records every create, update and delete made through GORM
+// as an AuditLog row, written in the same transaction as the change.
+//
+// The actor, API key, request ID and client IP are read from the statement's
+// context, so changes are attributed only when the query runs with the
+// request context. The repository package passes it on every call.
+package audit
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// redacted replaces the values of columns that are never serialized to
+// clients, such as password hashes, so the log shows that they changed
+// without storing them
+const redacted = "[redacted]"
+
+// snapshotKey is where the rows an update or delete is about to touch are
+// kept between the before and after callbacks
+const snapshotKey = "audit:snapshot"
+
+// skippedTables holds credentials, throttling state and the audit log
+// itself, none of which are entity changes worth recording
+var skippedTables = map[string]bool{
+	"audit_logs":            true,
+	"sessions":              true,
+	"refresh_tokens":        true,
+	"password_reset_tokens": true,
+	"recovery_codes":        true,
+	"login_throttles":       true,
+	"security_events":       true,
+	"oidc_logins":           true,
+}
+
+// Register installs the audit callbacks on db
+func Register(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("audit:create", afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("audit:before_update", snapshot); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:update", afterUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:before_delete", snapshot); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:delete", afterDelete)
+}
+
+// audited reports whether the statement changes a table the log covers
+func audited(db *gorm.DB) bool {
+	s := db.Statement.Schema
+	return db.Error == nil && s != nil && len(s.PrimaryFields) > 0 && !skippedTables[s.Table]
+}
+
+// afterCreate logs every created row with its non-zero columns
+func afterCreate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	var logs []models.AuditLog
+	eachRow(db.Statement.ReflectValue, func(row reflect.Value) {
+		if log, ok := entry(db, row, models.AuditCreate, nil, values(db, row, false)); ok {
+			logs = append(logs, log)
+		}
+	})
+	write(db, logs)
+}
+
+// snapshot loads the rows an update or delete is about to touch
+func snapshot(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	rows, err := load(db, nil)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	db.InstanceSet(snapshotKey, rows)
+}
+
+// afterUpdate reloads the rows snapshot saw and logs the columns that changed
+func afterUpdate(db *gorm.DB) {
+	before, ok := snapshotRows(db)
+	if !ok || len(before) == 0 {
+		return
+	}
+
+	after, err := load(db, before)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	current := map[string]reflect.Value{}
+	for _, row := range after {
+		current[entityID(db.Statement, row)] = row
+	}
+
+	var logs []models.AuditLog
+	for _, old := range before {
+		row, ok := current[entityID(db.Statement, old)]
+		if !ok {
+			continue
+		}
+		if log, ok := entry(db, row, models.AuditUpdate, values(db, old, true), values(db, row, true)); ok {
+			logs = append(logs, log)
+		}
+	}
+	write(db, logs)
+}
+
+// afterDelete logs the rows snapshot saw with the non-zero values they had
+func afterDelete(db *gorm.DB) {
+	before, ok := snapshotRows(db)
+	if !ok {
+		return
+	}
+
+	var logs []models.AuditLog
+	for _, row := range before {
+		if log, ok := entry(db, row, models.AuditDelete, values(db, row, false), nil); ok {
+			logs = append(logs, log)
+		}
+	}
+	write(db, logs)
+}
+
+// snapshotRows returns what snapshot stored, if the statement succeeded
+func snapshotRows(db *gorm.DB) ([]reflect.Value, bool) {
+	if !audited(db) {
+		return nil, false
+	}
+	v, ok := db.InstanceGet(snapshotKey)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := v.([]reflect.Value)
+	return rows, ok
+}
+
+// load reads the rows matched by the statement's conditions and the primary
+// keys of its model value. When only is given, the rows with those primary
+// keys are read instead, which is how updated rows are found again after
+// their matching columns may have changed.
+func load(db *gorm.DB, only []reflect.Value) ([]reflect.Value, error) {
+	stmt := db.Statement
+	s := stmt.Schema
+
+	q := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Model(reflect.New(s.ModelType).Interface())
+	if stmt.Unscoped {
+		q = q.Unscoped()
+	}
+
+	if only != nil {
+		q = q.Clauses(primaryKeyIn(db, only))
+	} else {
+		if where, ok := stmt.Clauses["WHERE"]; ok && where.Expression != nil {
+			q = q.Clauses(where.Expression)
+		}
+		var keyed []reflect.Value
+		eachRow(stmt.ReflectValue, func(row reflect.Value) {
+			if _, zero := s.PrioritizedPrimaryField.ValueOf(stmt.Context, row); !zero {
+				keyed = append(keyed, row)
+			}
+		})
+		if len(keyed) > 0 {
+			q = q.Clauses(primaryKeyIn(db, keyed))
+		}
+	}
+
+	dest := reflect.New(reflect.SliceOf(s.ModelType))
+	if err := q.Find(dest.Interface()).Error; err != nil {
+		return nil, err
+	}
+
+	rows := make([]reflect.Value, dest.Elem().Len())
+	for i := range rows {
+		rows[i] = dest.Elem().Index(i)
+	}
+	return rows, nil
+}
+
+// primaryKeyIn builds a condition matching the primary keys of rows
+func primaryKeyIn(db *gorm.DB, rows []reflect.Value) clause.Expression {
+	s := db.Statement.Schema
+	columns := make([]clause.Column, len(s.PrimaryFields))
+	for i, field := range s.PrimaryFields {
+		columns[i] = clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	}
+
+	keys := make([]interface{}, len(rows))
+	for i, row := range rows {
+		if len(s.PrimaryFields) == 1 {
+			keys[i], _ = s.PrimaryFields[0].ValueOf(db.Statement.Context, row)
+			continue
+		}
+		key := make([]interface{}, len(s.PrimaryFields))
+		for j, field := range s.PrimaryFields {
+			key[j], _ = field.ValueOf(db.Statement.Context, row)
+		}
+		keys[i] = key
+	}
+
+	if len(columns) == 1 {
+		return clause.IN{Column: columns[0], Values: keys}
+	}
+	return clause.IN{Column: columns, Values: keys}
+}
+
+// entry builds the log of one row, or reports false when no recorded column changed
+func entry(db *gorm.DB, row reflect.Value, action string, before, after map[string]interface{}) (models.AuditLog, bool) {
+	changes := map[string]models.FieldChange{}
+	for _, field := range fields(db.Statement.Schema) {
+		old, hadOld := before[field.DBName]
+		cur, hasCur := after[field.DBName]
+		if !hadOld && !hasCur {
+			continue
+		}
+		if action == models.AuditUpdate && reflect.DeepEqual(old, cur) {
+			continue
+		}
+		if sensitive(field) {
+			old, cur = mask(hadOld, old), mask(hasCur, cur)
+		}
+		changes[field.DBName] = models.FieldChange{Before: old, After: cur}
+	}
+	if action == models.AuditUpdate && len(changes) == 0 {
+		return models.AuditLog{}, false
+	}
+
+	ctx := db.Statement.Context
+	log := models.AuditLog{
+		EntityType: db.Statement.Schema.Table,
+		EntityID:   entityID(db.Statement, row),
+		Action:     action,
+		Changes:    changes,
+	}
+	log.ActorID, _ = ctx.Value("userID").(uint)
+	log.APIKeyID, _ = ctx.Value("apiKeyID").(uint)
+	log.RequestID, _ = ctx.Value("requestID").(string)
+	log.IP, _ = ctx.Value("clientIP").(string)
+	return log, log.EntityID != ""
+}
+
+// write stores logs in the statement's transaction, failing it if they cannot be saved
+func write(db *gorm.DB, logs []models.AuditLog) {
+	if len(logs) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(&logs).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
+
+// values returns the recorded columns of row by column name, leaving out
+// zero values unless all is set
+func values(db *gorm.DB, row reflect.Value, all bool) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, field := range fields(db.Statement.Schema) {
+		if v, zero := field.ValueOf(db.Statement.Context, row); all || !zero {
+			out[field.DBName] = v
+		}
+	}
+	return out
+}
+
+// fields returns the columns the log records: every column except the
+// automatic timestamps and those tagged audit:"-"
+func fields(s *schema.Schema) []*schema.Field {
+	var out []*schema.Field
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.AutoUpdateTime > 0 || field.Tag.Get("audit") == "-" {
+			continue
+		}
+		out = append(out, field)
+	}
+	return out
+}
+
+// sensitive reports whether a column is hidden from API responses
+func sensitive(field *schema.Field) bool {
+	return strings.Split(field.Tag.Get("json"), ",")[0] == "-"
+}
+
+// mask hides a sensitive value, keeping whether it was set
+func mask(present bool, v interface{}) interface{} {
+	if !present || v == nil || reflect.ValueOf(v).IsZero() {
+		return nil
+	}
+	return redacted
+}
+
+// entityID formats the primary key of row; composite keys are joined with commas
+func entityID(stmt *gorm.Statement, row reflect.Value) string {
+	parts := make([]string, 0, len(stmt.Schema.PrimaryFields))
+	for _, field := range stmt.Schema.PrimaryFields {
+		v, zero := field.ValueOf(stmt.Context, row)
+		if zero {
+			return ""
+		}
+		parts = append(parts, fmt.Sprint(v))
+	}
+	return strings.Join(parts, ",")
+}
+
+// eachRow calls fn with every struct held by v, which may be a struct,
+// a pointer to one, or a slice or array of either
+func eachRow(v reflect.Value, fn func(reflect.Value)) {
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		fn(v)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if row := reflect.Indirect(v.Index(i)); row.Kind() == reflect.Struct {
+				fn(row)
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"inventory-supply-chain-system/internal/audit"
+	"inventory-supply-chain-system/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// widget is an audited table with a hidden column and one left out of the log
+type widget struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+	Name      string
+	Stock     int
+	Secret    string `json:"-"`
+	Scratch   string `audit:"-"`
+}
+
+// session is a table the log skips
+type session struct {
+	ID    uint `gorm:"primarykey"`
+	Token string
+}
+
+// openAudited opens a private in-memory database with the audit callbacks
+func openAudited(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&models.AuditLog{}, &widget{}, &session{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := audit.Register(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// request is the context a request by user 7 with API key 3 carries
+func request() context.Context {
+	ctx := context.WithValue(context.Background(), "userID", uint(7))
+	ctx = context.WithValue(ctx, "apiKeyID", uint(3))
+	ctx = context.WithValue(ctx, "requestID", "req-1")
+	return context.WithValue(ctx, "clientIP", "203.0.113.9")
+}
+
+// logs returns the audit log in the order it was written
+func logs(t *testing.T, db *gorm.DB) []models.AuditLog {
+	t.Helper()
+	var out []models.AuditLog
+	if err := db.Order("id").Find(&out).Error; err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// changes renders a log's changes as JSON so they compare the same before
+// and after the round trip through the database
+func changes(t *testing.T, changes map[string]models.FieldChange) string {
+	t.Helper()
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(encoded)
+}
+
+func TestCreateRecordsTheActorAndSetColumns(t *testing.T) {
+	db := openAudited(t)
+	w := widget{Name: "bolt", Stock: 4, Secret: "s3cret", Scratch: "tmp"}
+	if err := db.WithContext(request()).Create(&w).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	got := logs(t, db)
+	if len(got) != 1 {
+		t.Fatalf("got %d logs, want 1", len(got))
+	}
+	log := got[0]
+	if log.EntityType != "widgets" || log.EntityID != "1" || log.Action != models.AuditCreate {
+		t.Errorf("log = %s %s %s, want create of widgets 1", log.Action, log.EntityType, log.EntityID)
+	}
+	if log.ActorID != 7 || log.APIKeyID != 3 || log.RequestID != "req-1" || log.IP != "203.0.113.9" {
+		t.Errorf("log attributed to actor %d, key %d, request %q from %q; want 7, 3, req-1 from 203.0.113.9",
+			log.ActorID, log.APIKeyID, log.RequestID, log.IP)
+	}
+
+	for column, want := range map[string]models.FieldChange{
+		"name":   {After: "bolt"},
+		"stock":  {After: float64(4)},
+		"secret": {After: "[redacted]"},
+	} {
+		if got := changes(t, map[string]models.FieldChange{column: log.Changes[column]}); got != changes(t, map[string]models.FieldChange{column: want}) {
+			t.Errorf("change of %s = %s, want %+v", column, got, want)
+		}
+	}
+	for _, column := range []string{"scratch", "updated_at", "deleted_at"} {
+		if change, ok := log.Changes[column]; ok {
+			t.Errorf("create logged %s = %+v, want it left out", column, change)
+		}
+	}
+}
+
+func TestChangesWithoutARequestAreAnonymous(t *testing.T) {
+	db := openAudited(t)
+	if err := db.Create(&widget{Name: "nut"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if log := logs(t, db)[0]; log.ActorID != 0 || log.APIKeyID != 0 || log.RequestID != "" {
+		t.Errorf("log without a request context = %+v, want no actor", log)
+	}
+}
+
+func TestUpdateRecordsBeforeAndAfter(t *testing.T) {
+	db := openAudited(t)
+	ctx := request()
+	first, second := widget{Name: "bolt", Stock: 4}, widget{Name: "nut", Stock: 9}
+	if err := db.Create(&first).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&second).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.WithContext(ctx).Model(&first).Updates(map[string]any{"stock": 2, "secret": "new", "scratch": "x"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Unchanged values and columns left out of the log write no entry
+	if err := db.WithContext(ctx).Model(&second).Updates(map[string]any{"stock": 9, "scratch": "y"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// An update by condition logs each row it matched, even though the
+	// condition no longer matches them afterwards
+	if err := db.WithContext(ctx).Model(&widget{}).Where("stock < ?", 5).Update("stock", 5).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	got := logs(t, db)[2:]
+	want := []struct {
+		id      string
+		changes map[string]models.FieldChange
+	}{
+		{"1", map[string]models.FieldChange{
+			"stock":  {Before: float64(4), After: float64(2)},
+			"secret": {Before: nil, After: "[redacted]"},
+		}},
+		{"1", map[string]models.FieldChange{"stock": {Before: float64(2), After: float64(5)}}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d update logs, want %d: %+v", len(got), len(want), got)
+	}
+	for i, log := range got {
+		if log.Action != models.AuditUpdate || log.EntityID != want[i].id || log.ActorID != 7 {
+			t.Errorf("log %d = %s of %s by %d, want update of %s by 7", i, log.Action, log.EntityID, log.ActorID, want[i].id)
+		}
+		if got, want := changes(t, log.Changes), changes(t, want[i].changes); got != want {
+			t.Errorf("log %d changes = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestDeleteRecordsWhatTheRowHeld(t *testing.T) {
+	db := openAudited(t)
+	w := widget{Name: "bolt", Stock: 4, Secret: "s3cret"}
+	if err := db.Create(&w).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(request()).Delete(&w).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Deleting nothing logs nothing
+	if err := db.WithContext(request()).Delete(&widget{}, 99).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	got := logs(t, db)
+	if len(got) != 2 {
+		t.Fatalf("got %d logs, want a create and a delete", len(got))
+	}
+	log := got[1]
+	if log.Action != models.AuditDelete || log.EntityID != "1" || log.ActorID != 7 {
+		t.Errorf("log = %s of %s by %d, want delete of 1 by 7", log.Action, log.EntityID, log.ActorID)
+	}
+	for column, want := range map[string]models.FieldChange{
+		"name":   {Before: "bolt"},
+		"stock":  {Before: float64(4)},
+		"secret": {Before: "[redacted]"},
+	} {
+		if got := changes(t, map[string]models.FieldChange{column: log.Changes[column]}); got != changes(t, map[string]models.FieldChange{column: want}) {
+			t.Errorf("change of %s = %s, want %+v", column, got, want)
+		}
+	}
+}
+
+func TestSkippedTablesAreNotLogged(t *testing.T) {
+	db := openAudited(t)
+	s := session{Token: "t"}
+	if err := db.WithContext(request()).Create(&s).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(request()).Model(&s).Update("token", "u").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(request()).Delete(&s).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := logs(t, db); len(got) != 0 {
+		t.Errorf("changes to sessions wrote %d logs, want none", len(got))
+	}
+}
+
+func TestFailedChangesAreNotLogged(t *testing.T) {
+	db := openAudited(t)
+	w := widget{ID: 1, Name: "bolt"}
+	if err := db.Create(&w).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(request()).Create(&widget{ID: 1, Name: "copy"}).Error; err == nil {
+		t.Fatal("creating a duplicate primary key succeeded")
+	}
+	if got := logs(t, db); len(got) != 1 {
+		t.Errorf("got %d logs, want only the first create", len(got))
+	}
+}
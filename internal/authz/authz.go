@@ -0,0 +1,157 @@
+// Package authz maps roles to the permissions that guard the API routes.
+package authz
+
+import (
+	"sort"
+	"strings"
+)
+
+// Permissions checked by the API routes
+const (
+	InventoryRead   = "inventory:read"
+	InventoryWrite  = "inventory:write"
+	OrdersRead      = "orders:read"
+	OrdersWrite     = "orders:write"
+	ShipmentsRead   = "shipments:read"
+	ShipmentsWrite  = "shipments:write"
+	WarehousesRead  = "warehouses:read"
+	WarehousesWrite = "warehouses:write"
+	TransfersRead   = "transfers:read"
+	TransfersWrite  = "transfers:write"
+	CatalogRead     = "catalog:read"
+	CatalogWrite    = "catalog:write"
+	SuppliersRead   = "suppliers:read"
+	SuppliersWrite  = "suppliers:write"
+	UsersRead       = "users:read"
+	UsersWrite      = "users:write"
+	RolesManage     = "roles:manage"
+	APIKeysManage   = "api-keys:manage"
+	AuditRead       = "audit:read"
+
+	// All grants every permission; only the admin role holds it
+	All = "*"
+)
+
+// Roles
+const (
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleStaff   = "staff"
+	RoleViewer  = "viewer"
+
+	// DefaultRole is given to self-registered users
+	DefaultRole = RoleViewer
+)
+
+var readAll = []string{
+	InventoryRead, OrdersRead, ShipmentsRead, WarehousesRead,
+	TransfersRead, CatalogRead, SuppliersRead,
+}
+
+// rolePermissions lists the permissions each role grants
+var rolePermissions = map[string][]string{
+	RoleAdmin: {All},
+	RoleManager: append([]string{
+		InventoryWrite, OrdersWrite, ShipmentsWrite, WarehousesWrite,
+		TransfersWrite, CatalogWrite, SuppliersWrite, UsersRead,
+	}, readAll...),
+	RoleStaff: append([]string{
+		InventoryWrite, OrdersWrite, ShipmentsWrite, TransfersWrite,
+	}, readAll...),
+	RoleViewer: readAll,
+}
+
+// knownPermissions is every permission that can be granted to a user directly
+var knownPermissions = map[string]bool{
+	InventoryRead: true, InventoryWrite: true, OrdersRead: true, OrdersWrite: true,
+	ShipmentsRead: true, ShipmentsWrite: true, WarehousesRead: true, WarehousesWrite: true,
+	TransfersRead: true, TransfersWrite: true, CatalogRead: true, CatalogWrite: true,
+	SuppliersRead: true, SuppliersWrite: true, UsersRead: true, UsersWrite: true,
+	RolesManage: true, APIKeysManage: true, AuditRead: true,
+}
+
+// IsRole reports whether role is defined
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsPermission reports whether permission can be granted to a user
+func IsPermission(permission string) bool {
+	return knownPermissions[permission]
+}
+
+// Roles returns the defined roles with the permissions they grant
+func Roles() map[string][]string {
+	roles := make(map[string][]string, len(rolePermissions))
+	for role, permissions := range rolePermissions {
+		roles[role] = append([]string(nil), permissions...)
+	}
+	return roles
+}
+
+// Effective returns the sorted union of the permissions granted by role and
+// the permissions granted to the user directly. Unknown direct grants are
+// dropped.
+func Effective(role string, granted []string) []string {
+	set := make(map[string]bool)
+	for _, p := range rolePermissions[role] {
+		set[p] = true
+	}
+	for _, p := range granted {
+		if knownPermissions[p] {
+			set[p] = true
+		}
+	}
+
+	permissions := make([]string, 0, len(set))
+	for p := range set {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// ReadOnly keeps only the read permissions among granted. All is narrowed
+// to every read permission.
+func ReadOnly(granted []string) []string {
+	set := make(map[string]bool)
+	for _, p := range granted {
+		if p == All {
+			for _, read := range readAll {
+				set[read] = true
+			}
+		} else if strings.HasSuffix(p, ":read") {
+			set[p] = true
+		}
+	}
+
+	permissions := make([]string, 0, len(set))
+	for p := range set {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// Restrict returns the permissions in requested that granted allows, so a
+// delegated credential never holds more than its owner
+func Restrict(granted, requested []string) []string {
+	permissions := make([]string, 0, len(requested))
+	for _, p := range requested {
+		if p != All && Allows(granted, p) {
+			permissions = append(permissions, p)
+		}
+	}
+	return permissions
+}
+
+// Allows reports whether the granted permissions include required
+func Allows(granted []string, required string) bool {
+	for _, p := range granted {
+		if p == All || p == required {
+			return true
+		}
+	}
+	return false
+}
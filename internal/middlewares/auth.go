@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -9,35 +10,84 @@ import (
 	"inventory-supply-chain-system/pkg/utils"
 )
 
-// AuthMiddleware is a middleware that validates JWT tokens for protected routes
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract the token from the Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header is required", http.StatusUnauthorized)
-			return
-		}
-
-		// Check if the header contains the Bearer token
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			http.Error(w, "Authorization token must be a Bearer token", http.StatusUnauthorized)
-			return
-		}
-
-		// Validate the token
-		claims, err := utils.ValidateToken(tokenString)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
-			return
-		}
-
-		// Set the user information into the request context
-		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-		ctx = context.WithValue(ctx, "userRole", claims.Role)
-
-		// Continue with the next handler
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// ErrAPIKeyRejected is returned by an APIKeyAuthenticator for keys that
+// are unknown, expired or revoked
+var ErrAPIKeyRejected = errors.New("invalid, expired or revoked API key")
+
+// Principal is who a request authenticated with an API key acts as
+type Principal struct {
+	KeyID       uint
+	UserID      uint
+	Role        string
+	Permissions []string
+}
+
+// APIKeyAuthenticator checks the API keys presented to AuthMiddleware
+type APIKeyAuthenticator interface {
+	// IsAPIKey reports whether a credential is an API key rather than a JWT
+	IsAPIKey(credential string) bool
+	// AuthenticateAPIKey returns who an API key acts as, or an error
+	// wrapping ErrAPIKeyRejected if the key is not accepted
+	AuthenticateAPIKey(ctx context.Context, key string) (Principal, error)
+}
+
+// AuthMiddleware returns a middleware that authenticates protected routes
+// with either a Bearer JWT or an API key checked by apiKeys. API keys may be
+// sent as a Bearer token or in the X-API-Key header.
+func AuthMiddleware(apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Extract the credential from the Authorization or X-API-Key header
+			tokenString := r.Header.Get("X-API-Key")
+			if tokenString == "" {
+				authHeader := r.Header.Get("Authorization")
+				if authHeader == "" {
+					http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+					return
+				}
+
+				// Check if the header contains the Bearer token
+				tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+				if tokenString == authHeader {
+					http.Error(w, "Authorization token must be a Bearer token", http.StatusUnauthorized)
+					return
+				}
+			}
+
+			if apiKeys.IsAPIKey(tokenString) {
+				principal, err := apiKeys.AuthenticateAPIKey(r.Context(), tokenString)
+				if errors.Is(err, ErrAPIKeyRejected) {
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+					return
+				}
+				if err != nil {
+					http.Error(w, "Error checking API key", http.StatusInternalServerError)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), "userID", principal.UserID)
+				ctx = context.WithValue(ctx, "userRole", principal.Role)
+				ctx = context.WithValue(ctx, "permissions", principal.Permissions)
+				ctx = context.WithValue(ctx, "apiKeyID", principal.KeyID)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// Validate the token
+			claims, err := utils.ValidateToken(tokenString)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
+				return
+			}
+
+			// Set the user information into the request context
+			ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+			ctx = context.WithValue(ctx, "userRole", claims.Role)
+			ctx = context.WithValue(ctx, "permissions", claims.Permissions)
+			ctx = context.WithValue(ctx, "sessionID", claims.SessionID)
+
+			// Continue with the next handler
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
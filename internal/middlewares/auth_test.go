@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeKeys accepts the key "key_good" and fails on "key_broken"
+type fakeKeys struct{}
+
+func (fakeKeys) IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, "key_")
+}
+
+func (fakeKeys) AuthenticateAPIKey(ctx context.Context, key string) (Principal, error) {
+	switch key {
+	case "key_good":
+		return Principal{KeyID: 7, UserID: 3, Role: "staff", Permissions: []string{"orders:read"}}, nil
+	case "key_broken":
+		return Principal{}, errors.New("database is down")
+	}
+	return Principal{}, ErrAPIKeyRejected
+}
+
+func TestAuthMiddlewareChecksAPIKeys(t *testing.T) {
+	var got context.Context
+	handler := AuthMiddleware(fakeKeys{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Context()
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"no credentials", "", "", http.StatusUnauthorized},
+		{"not a Bearer token", "Authorization", "Basic abc", http.StatusUnauthorized},
+		{"rejected key", "X-API-Key", "key_revoked", http.StatusUnauthorized},
+		{"failing check", "X-API-Key", "key_broken", http.StatusInternalServerError},
+		{"key in X-API-Key", "X-API-Key", "key_good", http.StatusOK},
+		{"key as a Bearer token", "Authorization", "Bearer key_good", http.StatusOK},
+	}
+	for _, tt := range tests {
+		got = nil
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		if got.Value("userID") != uint(3) || got.Value("apiKeyID") != uint(7) || got.Value("userRole") != "staff" {
+			t.Errorf("%s: context has user %v, key %v and role %v; want 3, 7 and staff",
+				tt.name, got.Value("userID"), got.Value("apiKeyID"), got.Value("userRole"))
+		}
+	}
+}
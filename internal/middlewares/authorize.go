@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"inventory-supply-chain-system/internal/authz"
+)
+
+// RequirePermission returns a middleware that lets a request through only if
+// the permissions AuthMiddleware stored in its context include permission.
+// An empty permission only requires an authenticated user; those routes
+// manage the user's own account and are not open to API keys.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := r.Context().Value("userID").(uint); !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			if _, viaKey := r.Context().Value("apiKeyID").(uint); viaKey && permission == "" {
+				http.Error(w, "Forbidden: this route requires a user session", http.StatusForbidden)
+				return
+			}
+
+			granted, _ := r.Context().Value("permissions").([]string)
+			if permission != "" && !authz.Allows(granted, permission) {
+				http.Error(w, "Forbidden: missing permission "+permission, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"inventory-supply-chain-system/pkg/utils"
+)
+
+// maxRequestIDLength bounds the request IDs accepted from clients
+const maxRequestIDLength = 64
+
+// RequestID is a middleware that tags every request with an ID and the
+// client's address, which the audit log records with each change. A client
+// or proxy may supply the ID in the X-Request-ID header; it is echoed back
+// in the response either way.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !validRequestID(requestID) {
+			var err error
+			if requestID, err = utils.RandomToken(16); err != nil {
+				http.Error(w, "Error tagging request", http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		ctx := context.WithValue(r.Context(), "requestID", requestID)
+		ctx = context.WithValue(ctx, "clientIP", ip)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether a client-supplied request ID is short and
+// made only of printable ASCII, so it is safe to store and log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey lets an integration call the API without a user session. It acts
+// as its owner, limited to its own permissions. The key is shown once when
+// it is issued; only the SHA-256 hash of its secret is stored, and Prefix
+// identifies it in listings and logs.
+type APIKey struct {
+	ID          uint        `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Name        string      `json:"name"`
+	Prefix      string      `json:"prefix" gorm:"uniqueIndex;size:32"`
+	SecretHash  string      `json:"-" gorm:"size:64"`
+	UserID      uint        `json:"user_id" gorm:"index"` // owner the key acts as
+	CreatedByID uint        `json:"created_by_id"`
+	Permissions StringArray `json:"permissions"`
+	ExpiresAt   *time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time  `json:"last_used_at" audit:"-"`
+	RevokedAt   *time.Time  `json:"revoked_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Attributes are free-form name/value pairs describing a catalog entry, such
+// as material or voltage, kept as a JSON object in a single column
+type Attributes map[string]string
+
+// Value encodes the attributes as a JSON object
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan decodes a JSON object
+func (a *Attributes) Scan(src interface{}) error {
+	var raw []byte
+	switch src := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		raw = src
+	case string:
+		raw = []byte(src)
+	default:
+		return fmt.Errorf("cannot scan %T into Attributes", src)
+	}
+	return json.Unmarshal(raw, a)
+}
+
+// GormDataType lets GORM treat the attributes as a single column
+func (Attributes) GormDataType() string {
+	return "text"
+}
+
+// GormDBDataType picks the column type for the connected database
+func (Attributes) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "text"
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// FieldChange is the value of one column before and after a change. Before
+// is null for creates and After is null for deletes.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLog records one change to a row of an audited table: who made it,
+// from which request, and the columns that changed
+type AuditLog struct {
+	ID         uint                   `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time              `json:"created_at" gorm:"index"`
+	ActorID    uint                   `json:"actor_id" gorm:"index"` // zero for anonymous requests and startup tasks
+	APIKeyID   uint                   `json:"api_key_id,omitempty"`  // set when the actor authenticated with an API key
+	EntityType string                 `json:"entity_type" gorm:"index:idx_audit_entity;size:64"`
+	EntityID   string                 `json:"entity_id" gorm:"index:idx_audit_entity;size:128"`
+	Action     string                 `json:"action" gorm:"size:16"`
+	Changes    map[string]FieldChange `json:"changes" gorm:"serializer:json"`
+	RequestID  string                 `json:"request_id" gorm:"index;size:64"`
+	IP         string                 `json:"ip"`
+}
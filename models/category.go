@@ -0,0 +1,51 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Category is a node of the product category tree. Path joins the slugs
+// from the root down, e.g. electrical/cables, so a subtree is every
+// category whose path is the root's path or starts with it and a slash.
+type Category struct {
+	gorm.Model
+	Name     string     `json:"name" gorm:"not null"`
+	Slug     string     `json:"slug" gorm:"not null"`
+	ParentID *uint      `json:"parent_id" gorm:"index"`
+	Path     string     `json:"path" gorm:"not null;unique"`
+	Children []Category `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+// Slugify turns a name into a slug: lower-case ASCII letters and digits
+// with every other run of characters replaced by a single hyphen, so Café
+// becomes caf. The 0004_category_tree migration applies the same rule.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// CategoryPath turns a path written with names, such as Electrical/Cables,
+// into the slug path of the category it names
+func CategoryPath(names string) string {
+	var slugs []string
+	for _, name := range strings.Split(names, "/") {
+		if slug := Slugify(name); slug != "" {
+			slugs = append(slugs, slug)
+		}
+	}
+	return strings.Join(slugs, "/")
+}
@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Electrical":         "electrical",
+		"Power Tools & More": "power-tools-more",
+		"power-tools/more":   "power-tools-more",
+		"  X9 2000  ":        "x9-2000",
+		"Café Items":         "caf-items",
+		"Électrique":         "lectrique",
+		"日本":                 "",
+	}
+	for name, want := range tests {
+		if got := Slugify(name); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCategoryPath(t *testing.T) {
+	tests := map[string]string{
+		"Electrical/Cables":     "electrical/cables",
+		"electrical/cables":     "electrical/cables",
+		"/Electrical//Cables/ ": "electrical/cables",
+	}
+	for names, want := range tests {
+		if got := CategoryPath(names); got != want {
+			t.Errorf("CategoryPath(%q) = %q, want %q", names, got, want)
+		}
+	}
+}
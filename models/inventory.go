@@ -2,11 +2,13 @@ package models
 
 import "gorm.io/gorm"
 
+// Inventory is the stock record of one SKU; its name and price come from the SKU
 type Inventory struct {
 	gorm.Model
-	Name     string  `json:"name"`
-	SKU      string  `json:"sku" gorm:"unique"`
-	Quantity int     `json:"quantity"`
-	Price    float64 `json:"price"`
-	VendorID uint    `json:"vendor_id"`
+	SKUID    uint `json:"sku_id" gorm:"column:sku_id;not null;unique"`
+	SKU      *SKU `json:"sku,omitempty" gorm:"foreignKey:SKUID"`
+	Quantity int  `json:"quantity"`
+	Reserved int  `json:"reserved"` // units held by open orders; available = Quantity - Reserved
+	VendorID uint `json:"vendor_id"`
+	Version  int  `json:"version" gorm:"not null;default:1" audit:"-"` // bumped by every write, sent as the ETag
 }
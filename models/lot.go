@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Lot is a batch of a SKU received together, identified by its number
+// within the SKU. Stock of a lot is held in StockBalance rows carrying its
+// ID, so every unit can be traced back to the lot it came from.
+type Lot struct {
+	gorm.Model
+	SKUID             uint       `json:"sku_id" gorm:"column:sku_id;not null;uniqueIndex:idx_lots_sku_number"`
+	Number            string     `json:"number" gorm:"not null;uniqueIndex:idx_lots_sku_number"`
+	ManufacturedOn    *time.Time `json:"manufactured_on"`
+	ExpiresOn         *time.Time `json:"expires_on" gorm:"index"`
+	SupplierReference string     `json:"supplier_reference"`
+}
+
+// Expired reports whether the lot is past its expiry date at now
+func (l *Lot) Expired(now time.Time) bool {
+	return l.ExpiresOn != nil && l.ExpiresOn.Before(now)
+}
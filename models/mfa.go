@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RecoveryCode is a one-time code that stands in for a TOTP code when the
+// user has lost their authenticator. Only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	CodeHash  string     `json:"-" gorm:"uniqueIndex;size:64"`
+	UsedAt    *time.Time `json:"used_at"`
+}
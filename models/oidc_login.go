@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// OIDCLogin is a single sign-on login waiting for the provider to redirect
+// back. It keeps the PKCE verifier and nonce on the server; the browser only
+// carries the state, whose hash is the key. It is deleted when used.
+type OIDCLogin struct {
+	StateHash    string `gorm:"primarykey;size:64"`
+	CreatedAt    time.Time
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
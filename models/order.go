@@ -2,11 +2,25 @@ package models
 
 import "gorm.io/gorm"
 
+// Order lifecycle statuses
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+)
+
 type Order struct {
 	gorm.Model
-	UserID      uint    `json:"user_id"`
-	InventoryID uint    `json:"inventory_id"`
-	Quantity    int     `json:"quantity"`
-	TotalPrice  float64 `json:"total_price"`
-	Status      string  `json:"status"`
+	UserID       uint     `json:"user_id"`
+	InventoryID  uint     `json:"inventory_id"`
+	WarehouseID  uint     `json:"warehouse_id"` // optional warehouse to fulfil from
+	Quantity     int      `json:"quantity"`     // in base units of the SKU
+	Unit         string   `json:"unit"`
+	UnitQuantity float64  `json:"unit_quantity"` // the quantity as ordered, in Unit
+	TotalPrice   float64  `json:"total_price"`
+	Status       string   `json:"status"`
+	Serials      []string `json:"serials,omitempty" gorm:"-"`                  // serial numbers picked, for serial-controlled SKUs
+	Version      int      `json:"version" gorm:"not null;default:1" audit:"-"` // bumped by every write, sent as the ETag
 }
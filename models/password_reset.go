@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PasswordResetToken lets a user who forgot their password choose a new
+// one. Only the SHA-256 hash of the token is stored; it expires quickly and
+// works once.
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;size:64"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
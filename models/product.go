@@ -2,11 +2,16 @@ package models
 
 import "gorm.io/gorm"
 
-// Product represents a product in the system
+// Product is the master record of something the catalog sells, independent
+// of how it is packaged. Its sellable variants are SKUs, which carry the
+// price and hold the stock.
 type Product struct {
 	gorm.Model
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CategoryID  *uint      `json:"category_id" gorm:"index"`
+	Category    *Category  `json:"category,omitempty"`
+	Brand       string     `json:"brand"`
+	Attributes  Attributes `json:"attributes"`
+	SKUs        []SKU      `json:"skus,omitempty" gorm:"foreignKey:ProductID"`
 }
@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm"
+
+// Stock reservation statuses
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusReleased  = "released"
+	ReservationStatusFulfilled = "fulfilled"
+)
+
+// StockReservation holds inventory for an order between placement and shipment
+type StockReservation struct {
+	gorm.Model
+	OrderID     uint   `json:"order_id" gorm:"uniqueIndex"`
+	InventoryID uint   `json:"inventory_id" gorm:"index"`
+	Quantity    int    `json:"quantity"`
+	Status      string `json:"status"`
+}
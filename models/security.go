@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// LoginThrottle counts recent failed logins for one account or client IP.
+// Subject is "account:<email>" or "ip:<address>".
+type LoginThrottle struct {
+	Subject       string     `json:"subject" gorm:"primarykey;size:320"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+// Security event types
+const (
+	SecurityEventAccountLocked   = "account_locked"
+	SecurityEventIPLocked        = "ip_locked"
+	SecurityEventAccountUnlocked = "account_unlocked"
+)
+
+// SecurityEvent records something that happened to an account's security,
+// such as a lockout, for later review
+type SecurityEvent struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	Type      string    `json:"type" gorm:"index"`
+	UserID    uint      `json:"user_id" gorm:"index"` // zero when the event is not tied to a known user
+	Email     string    `json:"email"`
+	IP        string    `json:"ip"`
+	ActorID   uint      `json:"actor_id"` // user who caused the event, for administrator actions
+	Detail    string    `json:"detail"`
+}
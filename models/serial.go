@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Serial number lifecycle statuses
+const (
+	SerialStatusInStock  = "in_stock"
+	SerialStatusReserved = "reserved"
+	SerialStatusShipped  = "shipped"
+	SerialStatusReturned = "returned"
+	SerialStatusScrapped = "scrapped"
+)
+
+// SerialNumber is one unit of a serial-controlled SKU. Serials are unique
+// across the catalog. WarehouseID, BinID and LotID locate the unit while it
+// is on hand, that is in stock, reserved or returned; OrderID is the order
+// it was last picked for and ShipmentID the shipment of that order that
+// carries it.
+type SerialNumber struct {
+	gorm.Model
+	Serial      string `json:"serial" gorm:"not null;uniqueIndex"`
+	SKUID       uint   `json:"sku_id" gorm:"column:sku_id;not null;index"`
+	InventoryID uint   `json:"inventory_id" gorm:"not null"`
+	Status      string `json:"status" gorm:"not null;index"`
+	WarehouseID uint   `json:"warehouse_id"`
+	BinID       uint   `json:"bin_id"`
+	LotID       uint   `json:"lot_id"`
+	OrderID     uint   `json:"order_id" gorm:"index"`
+	ShipmentID  uint   `json:"shipment_id" gorm:"index"`
+}
+
+// OnHand reports whether the unit is in stock and not yet picked for an order
+func (s *SerialNumber) OnHand() bool {
+	return s.Status == SerialStatusInStock || s.Status == SerialStatusReturned
+}
+
+// SerialEvent records a change in the status or location of a serial
+// number, together with the stock movement, order or shipment that caused it.
+// Events are append-only.
+type SerialEvent struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"created_at"`
+	SerialID    uint      `json:"serial_id" gorm:"not null;index"`
+	Status      string    `json:"status"`
+	MovementID  uint      `json:"movement_id"`
+	OrderID     uint      `json:"order_id"`
+	ShipmentID  uint      `json:"shipment_id"`
+	WarehouseID uint      `json:"warehouse_id"`
+	BinID       uint      `json:"bin_id"`
+	LotID       uint      `json:"lot_id"`
+	Reference   string    `json:"reference"`
+	UserID      uint      `json:"user_id"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Session is one signed-in device or client. Access tokens carry the
+// session ID, so revoking the session rejects them before they expire.
+type Session struct {
+	ID         string     `json:"id" gorm:"primarykey;size:32"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// RefreshToken is a single-use token that renews a session. Only its
+// SHA-256 hash is stored. Refreshing marks it used and issues a successor;
+// presenting a used token again revokes the whole session.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at"`
+	SessionID string     `json:"session_id" gorm:"index;size:32"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;size:64"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
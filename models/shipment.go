@@ -4,8 +4,15 @@ import "gorm.io/gorm"
 
 type Shipment struct {
 	gorm.Model
-	OrderID        uint   `json:"order_id"`
-	TrackingNumber string `json:"tracking_number"`
-	Carrier        string `json:"carrier"`
-	ShippingStatus string `json:"shipping_status"`
+	OrderID         uint   `json:"order_id"`
+	TransferOrderID uint   `json:"transfer_order_id" gorm:"index"` // set for the in-transit leg of a transfer order
+	WarehouseID     uint   `json:"warehouse_id" gorm:"index"`      // warehouse the shipment leaves from
+	TrackingNumber  string `json:"tracking_number"`
+	Carrier         string `json:"carrier"`
+	ShippingStatus  string `json:"shipping_status"`
+	Version         int    `json:"version" gorm:"not null;default:1" audit:"-"` // bumped by every write, sent as the ETag
+
+	// Serials are the serial numbers of the order that a create or update
+	// records on the shipment. They are kept on the serial numbers, not here.
+	Serials []string `json:"serials,omitempty" gorm:"-" audit:"-"`
 }
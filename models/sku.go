@@ -0,0 +1,28 @@
+package models
+
+import "gorm.io/gorm"
+
+// SKU is a sellable variant of a product, identified by its stock keeping
+// unit code. Variants differ by size, colour or pack; PackSize is the number
+// of units sold as one. Stock is held against SKUs through Inventory, in
+// whole numbers of BaseUnit; Units lists the other units it can be counted
+// in and how many base units each holds. Stock added to a lot-controlled
+// SKU must name the lot it belongs to; every unit of a serial-controlled SKU
+// is tracked by its serial number.
+type SKU struct {
+	gorm.Model
+	ProductID        uint       `json:"product_id" gorm:"not null;index"`
+	Code             string     `json:"code" gorm:"not null;unique"`
+	Name             string     `json:"name"`
+	Size             string     `json:"size"`
+	Colour           string     `json:"colour"`
+	PackSize         int        `json:"pack_size" gorm:"not null;default:1"`
+	BaseUnit         string     `json:"base_unit" gorm:"not null;default:ea"`
+	Units            []SKUUnit  `json:"units,omitempty" gorm:"foreignKey:SKUID"`
+	LotControlled    bool       `json:"lot_controlled" gorm:"not null;default:false"`
+	SerialControlled bool       `json:"serial_controlled" gorm:"not null;default:false"`
+	Price            float64    `json:"price"` // per base unit
+	SupplierID       *uint      `json:"supplier_id" gorm:"index"`
+	Attributes       Attributes `json:"attributes"`
+	Product          *Product   `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
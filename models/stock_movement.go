@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stock movement types
+const (
+	MovementTypeReceipt    = "receipt"
+	MovementTypeIssue      = "issue"
+	MovementTypeAdjustment = "adjustment"
+	MovementTypeTransfer   = "transfer"
+	MovementTypeReturn     = "return"
+)
+
+// ErrStockMovementImmutable is returned when something tries to change or
+// remove a recorded stock movement
+var ErrStockMovementImmutable = errors.New("stock movements are immutable")
+
+// StockMovement is an append-only ledger entry recording one change to the
+// on-hand quantity of an inventory item. Quantity is the signed change and
+// BalanceAfter the item's total on-hand quantity once it was applied.
+// WarehouseID and BinID locate the change; both are 0 for stock that is not
+// assigned to a warehouse. Quantities are in base units of the SKU; Unit
+// and UnitQuantity record the change as it was entered. LotID is 0 for stock
+// without a lot; Lot is the lot as given with the movement, which a receipt
+// records if it is new. Serials lists the units of a serial-controlled SKU
+// the movement moved. ToWarehouseID and ToBinID name where a transfer a
+// client asks for moves its stock to; it is recorded as two movements.
+type StockMovement struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"created_at"`
+	InventoryID   uint      `json:"inventory_id" gorm:"index"`
+	SKU           string    `json:"sku" gorm:"index"`
+	WarehouseID   uint      `json:"warehouse_id" gorm:"index"`
+	BinID         uint      `json:"bin_id"`
+	LotID         uint      `json:"lot_id" gorm:"index"`
+	Lot           *Lot      `json:"lot,omitempty" gorm:"-"`
+	Serials       []string  `json:"serials,omitempty" gorm:"-"`
+	ToWarehouseID uint      `json:"to_warehouse_id,omitempty" gorm:"-"`
+	ToBinID       uint      `json:"to_bin_id,omitempty" gorm:"-"`
+	Type          string    `json:"type"`
+	Quantity      int       `json:"quantity"`
+	Unit          string    `json:"unit"`
+	UnitQuantity  float64   `json:"unit_quantity"`
+	BalanceAfter  int       `json:"balance_after"`
+	ReasonCode    string    `json:"reason_code"`
+	Reference     string    `json:"reference"`
+	UserID        uint      `json:"user_id"`
+}
+
+// BeforeUpdate rejects any update so the ledger stays append-only
+func (StockMovement) BeforeUpdate(*gorm.DB) error {
+	return ErrStockMovementImmutable
+}
+
+// BeforeDelete rejects any delete so the ledger stays append-only
+func (StockMovement) BeforeDelete(*gorm.DB) error {
+	return ErrStockMovementImmutable
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"database/sql/driver"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// StringArray is a list of strings kept in a single column. Postgres stores
+// it as a native text[]; other databases keep the same array literal, e.g.
+// {a,"b c"}, in a text column, so values read back identically everywhere.
+type StringArray []string
+
+// Value encodes the list as a Postgres array literal
+func (a StringArray) Value() (driver.Value, error) {
+	return pq.StringArray(a).Value()
+}
+
+// Scan decodes a Postgres array literal
+func (a *StringArray) Scan(src interface{}) error {
+	return (*pq.StringArray)(a).Scan(src)
+}
+
+// GormDataType lets GORM treat the list as a single column
+func (StringArray) GormDataType() string {
+	return "text"
+}
+
+// GormDBDataType picks the column type for the connected database
+func (StringArray) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "text[]"
+	}
+	return "text"
+}
@@ -4,6 +4,7 @@ import "gorm.io/gorm"
 
 type Supplier struct {
 	gorm.Model
-	Name  string `json:"name"`
-	Items []Item `gorm:"foreignKey:SupplierID"`
+	Name       string     `json:"name"`
+	SKUs       []SKU      `json:"skus,omitempty" gorm:"foreignKey:SupplierID"`
+	Categories []Category `json:"categories,omitempty" gorm:"many2many:supplier_categories"`
 }
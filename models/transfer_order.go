@@ -0,0 +1,47 @@
+package models
+
+import "gorm.io/gorm"
+
+// Transfer order statuses
+const (
+	TransferStatusDraft     = "draft"
+	TransferStatusPicked    = "picked"
+	TransferStatusInTransit = "in_transit"
+	TransferStatusReceived  = "received"
+	TransferStatusCancelled = "cancelled"
+)
+
+// TransferOrder moves stock from one warehouse to another. Stock leaves the
+// source when the order is picked, travels on the linked shipment and is
+// credited to the destination as it is received.
+type TransferOrder struct {
+	gorm.Model
+	SourceWarehouseID      uint                `json:"source_warehouse_id" gorm:"index"`
+	DestinationWarehouseID uint                `json:"destination_warehouse_id" gorm:"index"`
+	Status                 string              `json:"status" gorm:"index"`
+	ShipmentID             uint                `json:"shipment_id"`
+	Notes                  string              `json:"notes"`
+	DiscrepancyReason      string              `json:"discrepancy_reason"`
+	UserID                 uint                `json:"user_id"`
+	Lines                  []TransferOrderLine `json:"lines"`
+}
+
+// TransferOrderLine is one inventory item on a transfer order. The
+// discrepancy is the picked quantity that never arrived, recorded when the
+// order is closed short. Quantities are in base units of the SKU; Unit and
+// UnitQuantity record the quantity as it was requested. A line with a LotID
+// moves stock of that lot only.
+type TransferOrderLine struct {
+	ID                  uint    `json:"id" gorm:"primarykey"`
+	TransferOrderID     uint    `json:"transfer_order_id" gorm:"index"`
+	InventoryID         uint    `json:"inventory_id"`
+	SourceBinID         uint    `json:"source_bin_id"`
+	DestinationBinID    uint    `json:"destination_bin_id"`
+	LotID               uint    `json:"lot_id"`
+	Quantity            int     `json:"quantity"`
+	Unit                string  `json:"unit"`
+	UnitQuantity        float64 `json:"unit_quantity"`
+	PickedQuantity      int     `json:"picked_quantity"`
+	ReceivedQuantity    int     `json:"received_quantity"`
+	DiscrepancyQuantity int     `json:"discrepancy_quantity"`
+}
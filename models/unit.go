@@ -0,0 +1,34 @@
+package models
+
+import "gorm.io/gorm"
+
+// Unit dimensions; quantities only convert between units of one dimension
+// unless a SKU defines its own factor
+const (
+	UnitDimensionCount  = "count"
+	UnitDimensionMass   = "mass"
+	UnitDimensionVolume = "volume"
+	UnitDimensionLength = "length"
+)
+
+// Unit is an entry of the unit of measure catalog, such as ea, case or kg.
+// Ratio is its size in the smallest unit of its dimension (kg is 1000 for
+// g), which converts it to any other unit of the dimension. Packaging units
+// whose size differs from SKU to SKU, such as case, have no ratio and only
+// convert through the factors SKUs give them.
+type Unit struct {
+	gorm.Model
+	Code      string  `json:"code" gorm:"not null;unique"`
+	Name      string  `json:"name"`
+	Dimension string  `json:"dimension" gorm:"not null"`
+	Ratio     float64 `json:"ratio"`
+}
+
+// SKUUnit is a unit a SKU is bought, stored or sold in, with Factor the
+// number of the SKU's base units in one of it: a case of 24 has factor 24.
+type SKUUnit struct {
+	ID     uint    `json:"id" gorm:"primarykey"`
+	SKUID  uint    `json:"sku_id" gorm:"column:sku_id;not null;uniqueIndex:idx_sku_units_sku_unit"`
+	Unit   string  `json:"unit" gorm:"not null;uniqueIndex:idx_sku_units_sku_unit"`
+	Factor float64 `json:"factor" gorm:"not null"`
+}
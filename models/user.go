@@ -1,7 +1,8 @@
 package models
 
 import (
-	"github.com/lib/pq"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -19,12 +20,24 @@ type Address struct {
 
 type User struct {
 	gorm.Model
-	Name        string         `json:"name"`
-	Email       string         `json:"email" gorm:"unique"`
-	Password    string         `json:"-"`
-	Role        string         `json:"role"`
-	Verified    bool           `json:"verified"`
-	Permissions pq.StringArray `json:"permissions" gorm:"type:text[]"`
-	Phone       string         `json:"phone"`
-	Addresses   []Address      `json:"addresses"`
+	Name               string      `json:"name"`
+	Email              string      `json:"email" gorm:"unique"`
+	Password           string      `json:"-"`
+	Role               string      `json:"role"`
+	Verified           bool        `json:"verified"`
+	Permissions        StringArray `json:"permissions"`
+	Phone              string      `json:"phone"`
+	TokenVersion       int         `json:"-" audit:"-"` // bumped to invalidate every token issued to the user
+	VerificationSentAt *time.Time  `json:"-" audit:"-"` // when the last verification email went out, for throttling resends
+	MFAEnabled         bool        `json:"mfa_enabled"`
+	MFASecret          string      `json:"-"`                       // TOTP secret, confirmed or awaiting confirmation
+	MFALastCounter     int64       `json:"-" audit:"-"`             // last accepted TOTP time step, so a code cannot be replayed
+	ExternalID         string      `json:"-" gorm:"index;size:512"` // "<issuer>#<subject>" of a single sign-on identity
+	Addresses          []Address   `json:"addresses"`
+}
+
+// MFAEnrolled reports whether the user has confirmed an authenticator app.
+// MFAEnabled without a secret is not enough, as no code could ever match.
+func (u *User) MFAEnrolled() bool {
+	return u.MFAEnabled && u.MFASecret != ""
 }
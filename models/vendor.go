@@ -6,4 +6,5 @@ type Vendor struct {
 	gorm.Model
 	Name        string `json:"name"`
 	ContactInfo string `json:"contact_info"`
+	Version     int    `json:"version" gorm:"not null;default:1" audit:"-"` // bumped by every write, sent as the ETag
 }
@@ -0,0 +1,59 @@
+package models
+
+import "gorm.io/gorm"
+
+// Warehouse is a physical site that holds stock
+type Warehouse struct {
+	gorm.Model
+	Code       string `json:"code" gorm:"uniqueIndex"`
+	Name       string `json:"name"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Timezone   string `json:"timezone"`
+	Active     bool   `json:"active"`
+	Zones      []Zone `json:"zones,omitempty"`
+}
+
+// Zone is an area of a warehouse, such as cold storage or bulk racking
+type Zone struct {
+	gorm.Model
+	WarehouseID uint    `json:"warehouse_id" gorm:"uniqueIndex:idx_zone_code"`
+	Code        string  `json:"code" gorm:"uniqueIndex:idx_zone_code"`
+	Name        string  `json:"name"`
+	Aisles      []Aisle `json:"aisles,omitempty"`
+}
+
+// Aisle is a row of bins within a zone
+type Aisle struct {
+	gorm.Model
+	ZoneID uint   `json:"zone_id" gorm:"uniqueIndex:idx_aisle_code"`
+	Code   string `json:"code" gorm:"uniqueIndex:idx_aisle_code"`
+	Name   string `json:"name"`
+	Bins   []Bin  `json:"bins,omitempty"`
+}
+
+// Bin is the smallest addressable storage location. WarehouseID is copied
+// from the owning zone so stock can be located without walking the tree.
+type Bin struct {
+	gorm.Model
+	AisleID     uint   `json:"aisle_id" gorm:"uniqueIndex:idx_bin_code"`
+	WarehouseID uint   `json:"warehouse_id" gorm:"index"`
+	Code        string `json:"code" gorm:"uniqueIndex:idx_bin_code"`
+}
+
+// StockBalance is the on-hand quantity of an inventory item at one location,
+// from one lot. BinID is 0 for stock held in a warehouse but not assigned to
+// a bin, and LotID 0 for stock without a lot. Lot stock that is not in a
+// warehouse is held with WarehouseID 0.
+type StockBalance struct {
+	ID          uint `json:"id" gorm:"primarykey"`
+	InventoryID uint `json:"inventory_id" gorm:"uniqueIndex:idx_stock_location"`
+	WarehouseID uint `json:"warehouse_id" gorm:"uniqueIndex:idx_stock_location;index"`
+	BinID       uint `json:"bin_id" gorm:"uniqueIndex:idx_stock_location"`
+	LotID       uint `json:"lot_id" gorm:"uniqueIndex:idx_stock_location"`
+	Lot         *Lot `json:"lot,omitempty" gorm:"foreignKey:LotID"`
+	Quantity    int  `json:"quantity"`
+}
@@ -0,0 +1,154 @@
+// Package notifier delivers messages such as password reset and email
+// verification links to users. The Notifier interface lets the transport be
+// swapped without touching the services that send them.
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a single notification to one recipient
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier sends messages to users
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// Log writes messages to the application log. It is meant for development,
+// where no real delivery is configured.
+type Log struct{}
+
+// Send logs the message
+func (Log) Send(msg Message) error {
+	log.Printf("notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// File appends messages to a file as JSON lines, so they can be picked up
+// by a script or inspected during testing
+type File struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Send appends the message to the file
+func (f *File) Send(msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return json.NewEncoder(out).Encode(struct {
+		SentAt time.Time `json:"sent_at"`
+		Message
+	}{time.Now(), msg})
+}
+
+// SMTP sends messages as plain-text email through an SMTP server
+type SMTP struct {
+	Addr string // host:port of the server
+	From string
+	Auth smtp.Auth // nil for servers that accept mail without authentication
+}
+
+// Send emails the message
+func (s *SMTP) Send(msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid header in message to %q", msg.To)
+	}
+
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.From, msg.To, msg.Subject, strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{msg.To}, []byte(body))
+}
+
+// Memory keeps sent messages in memory. It stands in for a real notifier in
+// tests and local tooling.
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send records the message
+func (m *Memory) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Last returns the most recent message sent to the given address
+func (m *Memory) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// FromEnv returns the notifier selected by NOTIFIER:
+//
+//   - log (the default) writes messages to the application log
+//   - file appends them to NOTIFIER_FILE
+//   - smtp emails them through SMTP_HOST and SMTP_PORT (default 587) from
+//     SMTP_FROM, logging in with SMTP_USERNAME and SMTP_PASSWORD when set
+//   - memory keeps them in memory
+func FromEnv() (Notifier, error) {
+	switch kind := os.Getenv("NOTIFIER"); kind {
+	case "", "log":
+		return Log{}, nil
+	case "file":
+		path := os.Getenv("NOTIFIER_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("NOTIFIER_FILE is required for the file notifier")
+		}
+		return &File{Path: path}, nil
+	case "smtp":
+		host, from := os.Getenv("SMTP_HOST"), os.Getenv("SMTP_FROM")
+		if host == "" || from == "" {
+			return nil, fmt.Errorf("SMTP_HOST and SMTP_FROM are required for the smtp notifier")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		sender := &SMTP{Addr: net.JoinHostPort(host, port), From: from}
+		if user := os.Getenv("SMTP_USERNAME"); user != "" {
+			sender.Auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		return sender, nil
+	case "memory":
+		return &Memory{}, nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER %q", kind)
+	}
+}
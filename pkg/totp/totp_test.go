@@ -0,0 +1,71 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors, base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeMatchesRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAllowsOneStepOfDrift(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	code, _ := Code(rfcSecret, Counter(now)-1)
+
+	counter, ok := Validate(rfcSecret, code, now)
+	if !ok || counter != Counter(now)-1 {
+		t.Fatalf("Validate previous step = %d, %v; want %d, true", counter, ok, Counter(now)-1)
+	}
+
+	code, _ = Code(rfcSecret, Counter(now)-2)
+	if _, ok := Validate(rfcSecret, code, now); ok {
+		t.Fatal("Validate accepted a code two steps old")
+	}
+}
+
+func TestValidateRejectsEmptySecret(t *testing.T) {
+	code, err := Code("", Counter(time.Now()))
+	if err == nil {
+		t.Fatalf("Code with an empty secret = %s, want an error", code)
+	}
+
+	// An empty key still has an HMAC, so without the check some code would match
+	for _, secret := range []string{"", "   "} {
+		for _, code := range []string{"000000", "123456", "328482"} {
+			if _, ok := Validate(secret, code, time.Now()); ok {
+				t.Errorf("Validate(%q, %s) accepted a code for an empty secret", secret, code)
+			}
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Fatalf("generated secret %q is not usable: %v", secret, err)
+	}
+}
@@ -1,55 +1,174 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"os"
+	"fmt"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v4"
 )
 
-// JWTClaims holds the data we store in the JWT token
+// AccessTokenTTL is how long an access token is accepted; clients renew it
+// with their refresh token
+const AccessTokenTTL = 15 * time.Minute
+
+// JWTClaims holds the data we store in the JWT token. SessionID and
+// TokenVersion tie the token to a server-side session and to the user's
+// current token version so it can be revoked before it expires.
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
-	jwt.StandardClaims
+	UserID       uint     `json:"user_id"`
+	Role         string   `json:"role"`
+	Permissions  []string `json:"permissions"`
+	SessionID    string   `json:"sid"`
+	TokenVersion int      `json:"ver"`
+	jwt.RegisteredClaims
 }
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// RevocationCheck reports an error if the session or token version of
+// otherwise valid claims has been revoked
+type RevocationCheck func(claims *JWTClaims) error
+
+var revocationCheck RevocationCheck
+
+// SetRevocationCheck installs the check ValidateToken runs on every token
+// whose signature and expiry are valid
+func SetRevocationCheck(check RevocationCheck) {
+	revocationCheck = check
+}
 
-// GenerateToken generates a JWT token for a given user ID and role
-func GenerateToken(userID uint, role string) (string, error) {
-	claims := &JWTClaims{
-		UserID: userID,
-		Role:   role,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(), // Token expiry set to 24 hours
-		},
+// PurposeClaims are the claims of single-purpose tokens such as email
+// verification links. The audience names the purpose, which keeps them from
+// being accepted as access tokens or for any other purpose.
+type PurposeClaims struct {
+	Email string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateToken signs a short-lived access token for the given claims,
+// filling in its ID, issue time and expiry. The token header names the
+// signing key in kid so verifiers can pick the matching public key.
+func GenerateToken(claims JWTClaims) (string, error) {
+	registered, err := newRegisteredClaims(AccessTokenTTL)
+	if err != nil {
+		return "", err
 	}
+	claims.RegisteredClaims = registered
 
-	// Create the token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return sign(&claims)
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a JWT token and returns the claims. The key is
+// chosen by the token's kid, and the token must use that key's algorithm.
 func ValidateToken(tokenString string) (*JWTClaims, error) {
 	claims := &JWTClaims{}
+	if err := parse(tokenString, claims); err != nil {
+		return nil, err
+	}
+
+	// Purpose tokens are signed with the same keys but are not access tokens
+	if len(claims.Audience) > 0 {
+		return nil, errors.New("not an access token")
+	}
+
+	if revocationCheck != nil {
+		if err := revocationCheck(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// GeneratePurposeToken signs a token that is only accepted for purpose. The
+// subject identifies what the token is about, usually a user ID.
+func GeneratePurposeToken(purpose, subject, email string, ttl time.Duration) (string, error) {
+	registered, err := newRegisteredClaims(ttl)
+	if err != nil {
+		return "", err
+	}
+	registered.Subject = subject
+	registered.Audience = jwt.ClaimStrings{purpose}
+
+	return sign(&PurposeClaims{Email: email, RegisteredClaims: registered})
+}
+
+// ValidatePurposeToken validates a token from GeneratePurposeToken and
+// checks that it was issued for purpose
+func ValidatePurposeToken(tokenString, purpose string) (*PurposeClaims, error) {
+	claims := &PurposeClaims{}
+	if err := parse(tokenString, claims); err != nil {
+		return nil, err
+	}
+	if !claims.VerifyAudience(purpose, true) {
+		return nil, errors.New("token was issued for another purpose")
+	}
+	return claims, nil
+}
+
+// newRegisteredClaims returns claims with a fresh ID that expire after ttl
+func newRegisteredClaims(ttl time.Duration) (jwt.RegisteredClaims, error) {
+	id, err := RandomToken(16)
+	if err != nil {
+		return jwt.RegisteredClaims{}, err
+	}
+
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		ID:        id,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}, nil
+}
+
+// sign signs claims with the current signing key and names it in the kid header
+func sign(claims jwt.Claims) (string, error) {
+	set, err := currentKeys()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(set.signing.method, claims)
+	if set.signing.id != "" {
+		token.Header["kid"] = set.signing.id
+	}
+	return token.SignedString(set.signing.key)
+}
+
+// parse verifies a token's signature and expiry and decodes it into claims
+func parse(tokenString string, claims jwt.Claims) error {
+	set, err := currentKeys()
+	if err != nil {
+		return err
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// Ensure the token method used for signing is HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := set.verify[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return jwtSecret, nil
+		return key.key, nil
 	})
-
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if !token.Valid {
-		return nil, errors.New("invalid token")
+		return errors.New("invalid token")
 	}
+	return nil
+}
 
-	return claims, nil
+// RandomToken returns n random bytes encoded as hex
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingKey is the key new tokens are signed with
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// verificationKey is a key tokens are accepted from. method pins the
+// algorithm so a token cannot pick a weaker one for the same key.
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// keySet is the signing key plus every key that is currently accepted,
+// indexed by kid
+type keySet struct {
+	signing signingKey
+	verify  map[string]verificationKey
+}
+
+var (
+	keysMu sync.RWMutex
+	keys   *keySet
+)
+
+// LoadKeys reads the signing configuration from the environment:
+//
+//   - JWT_SIGNING_ALG: HS256 (default), RS256 or EdDSA
+//   - JWT_SECRET: the HMAC secret used with HS256
+//   - JWT_PRIVATE_KEY_FILE: PEM private key used with RS256 or EdDSA
+//   - JWT_KEY_ID: kid of the signing key; defaults to a thumbprint of its public key
+//   - JWT_VERIFY_KEYS: extra public keys still accepted while rotating, as
+//     comma-separated kid=path pairs of PEM files
+//
+// It is called once the environment is loaded; tokens are signed and checked
+// with whatever it loaded last.
+func LoadKeys() error {
+	set, err := loadKeySet()
+	if err != nil {
+		return err
+	}
+
+	keysMu.Lock()
+	keys = set
+	keysMu.Unlock()
+	return nil
+}
+
+// currentKeys returns the loaded key set, loading it on first use
+func currentKeys() (*keySet, error) {
+	keysMu.RLock()
+	set := keys
+	keysMu.RUnlock()
+	if set != nil {
+		return set, nil
+	}
+
+	if err := LoadKeys(); err != nil {
+		return nil, err
+	}
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return keys, nil
+}
+
+func loadKeySet() (*keySet, error) {
+	set := &keySet{verify: make(map[string]verificationKey)}
+
+	alg := os.Getenv("JWT_SIGNING_ALG")
+	switch alg {
+	case "", "HS256":
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("JWT_SECRET is required for HS256")
+		}
+		set.signing = signingKey{id: os.Getenv("JWT_KEY_ID"), method: jwt.SigningMethodHS256, key: []byte(secret)}
+		set.verify[set.signing.id] = verificationKey{method: jwt.SigningMethodHS256, key: []byte(secret)}
+
+	case "RS256", "EdDSA":
+		private, err := readPrivateKey(os.Getenv("JWT_PRIVATE_KEY_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		method, err := methodFor(private.Public())
+		if err != nil {
+			return nil, err
+		}
+		if method.Alg() != alg {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE holds a %s key, not %s", method.Alg(), alg)
+		}
+
+		id := os.Getenv("JWT_KEY_ID")
+		if id == "" {
+			if id, err = thumbprint(private.Public()); err != nil {
+				return nil, err
+			}
+		}
+		set.signing = signingKey{id: id, method: method, key: private}
+		set.verify[id] = verificationKey{method: method, key: private.Public()}
+
+	default:
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q", alg)
+	}
+
+	for _, entry := range strings.Split(os.Getenv("JWT_VERIFY_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, path, ok := strings.Cut(entry, "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("JWT_VERIFY_KEYS entry %q must be kid=path", entry)
+		}
+		public, err := readPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		method, err := methodFor(public)
+		if err != nil {
+			return nil, err
+		}
+		set.verify[id] = verificationKey{method: method, key: public}
+	}
+
+	return set, nil
+}
+
+// readPrivateKey parses a PKCS#8 or PKCS#1 PEM private key
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key in %s", path)
+	}
+	return signer, nil
+}
+
+// readPublicKey parses a PKIX PEM public key
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	if path == "" {
+		return nil, errors.New("key file path is empty")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
+
+// methodFor returns the signing method used with a public key's type
+func methodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+}
+
+// thumbprint derives a stable kid from a public key
+func thumbprint(public crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// JWK is the public half of a verification key in JSON Web Key form
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS returns every asymmetric key tokens are accepted from. HMAC
+// secrets are never published, so the set is empty when signing with HS256.
+func PublicJWKS() (JWKSet, error) {
+	set, err := currentKeys()
+	if err != nil {
+		return JWKSet{}, err
+	}
+
+	jwks := JWKSet{Keys: []JWK{}}
+	for id, key := range set.verify {
+		switch public := key.key.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA", Kid: id, Use: "sig", Alg: key.method.Alg(),
+				N: base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP", Kid: id, Use: "sig", Alg: key.method.Alg(),
+				Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository stores API keys
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	Get(ctx context.Context, id uint) (*models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	Find(ctx context.Context, filter APIKeyFilter, page PageRequest) (Page[models.APIKey], error)
+	Revoke(ctx context.Context, id uint, at time.Time) error
+	// Touch records a use of a key unless one was recorded after notBefore
+	Touch(ctx context.Context, id uint, now, notBefore time.Time) error
+}
+
+// APIKeyFilter narrows the API key listing; zero values are ignored
+type APIKeyFilter struct {
+	UserID uint
+	Active bool // only keys that are neither revoked nor expired
+}
+
+// apiKeySortFields maps the sort keys accepted by the API key listing to columns
+var apiKeySortFields = map[string]string{
+	"id":           "id",
+	"created_at":   "created_at",
+	"name":         "name",
+	"expires_at":   "expires_at",
+	"last_used_at": "last_used_at",
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func (r apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r apiKeyRepository) Get(ctx context.Context, id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r apiKeyRepository) Find(ctx context.Context, filter APIKeyFilter, page PageRequest) (Page[models.APIKey], error) {
+	q := NewQueryBuilder(apiKeySortFields).Equal("user_id", filter.UserID)
+	if filter.Active {
+		q.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", time.Now())
+	}
+	return paginate[models.APIKey](r.db.WithContext(ctx).Model(&models.APIKey{}), q, page)
+}
+
+func (r apiKeyRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).Update("revoked_at", at).Error
+}
+
+func (r apiKeyRepository) Touch(ctx context.Context, id uint, now, notBefore time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, notBefore).
+		Update("last_used_at", now).Error
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"unicode/utf8"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CategoryRepository stores the product category tree. Categories are
+// deleted outright rather than soft-deleted, so their paths can be reused.
+type CategoryRepository interface {
+	Create(ctx context.Context, category *models.Category) error
+	// Get loads a category with its direct children
+	Get(ctx context.Context, id uint) (*models.Category, error)
+	// GetByPath loads the category with a slug path
+	GetByPath(ctx context.Context, path string) (*models.Category, error)
+	// All lists every category in path order, parents before children
+	All(ctx context.Context) ([]models.Category, error)
+	// Children lists the direct children of a category
+	Children(ctx context.Context, id uint) ([]models.Category, error)
+	// Save writes a category's own columns except its path
+	Save(ctx context.Context, category *models.Category) error
+	// RenamePath replaces the path prefix oldPath by newPath on a category
+	// and all its descendants
+	RenamePath(ctx context.Context, oldPath, newPath string) error
+	// Reassign moves the products and supplier links of one category to another
+	Reassign(ctx context.Context, fromID, toID uint) error
+	// CountProducts counts the products assigned to a category
+	CountProducts(ctx context.Context, id uint) (int64, error)
+	// Delete deletes a category and its supplier links, and unassigns the
+	// deleted products that still refer to it
+	Delete(ctx context.Context, id uint) error
+}
+
+// categoryTree selects the IDs of the category named by ref and all its
+// descendants. ref is a category ID or a path, written with slugs or names:
+// "electrical/cables" and "Electrical/Cables" name the same category.
+func categoryTree(tx *gorm.DB, ref string) *gorm.DB {
+	root := tx.Model(&models.Category{}).Select("path")
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		root = root.Where("id = ?", id)
+	} else {
+		root = root.Where("path = ?", models.CategoryPath(ref))
+	}
+	return tx.Model(&models.Category{}).Select("id").Where("path IN (?) OR path LIKE (?) || '/%'", root, root)
+}
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+func (r categoryRepository) Create(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(category).Error
+}
+
+func (r categoryRepository) Get(ctx context.Context, id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).Preload("Children", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("path")
+	}).First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r categoryRepository) GetByPath(ctx context.Context, path string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).Where("path = ?", path).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r categoryRepository) All(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	if err := r.db.WithContext(ctx).Order("path").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r categoryRepository) Children(ctx context.Context, id uint) ([]models.Category, error) {
+	var children []models.Category
+	if err := r.db.WithContext(ctx).Where("parent_id = ?", id).Order("path").Find(&children).Error; err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+func (r categoryRepository) Save(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations, "path").Save(category).Error
+}
+
+// RenamePath keeps each path after the old prefix. SUBSTR counts characters,
+// not bytes, on both Postgres and SQLite.
+func (r categoryRepository) RenamePath(ctx context.Context, oldPath, newPath string) error {
+	return r.db.WithContext(ctx).Model(&models.Category{}).
+		Where("path = ? OR path LIKE ?", oldPath, oldPath+"/%").
+		Update("path", gorm.Expr("? || SUBSTR(path, ?)", newPath, utf8.RuneCountInString(oldPath)+1)).Error
+}
+
+func (r categoryRepository) Reassign(ctx context.Context, fromID, toID uint) error {
+	tx := r.db.WithContext(ctx)
+	err := tx.Unscoped().Model(&models.Product{}).Where("category_id = ?", fromID).Update("category_id", toID).Error
+	if err != nil {
+		return err
+	}
+
+	// Suppliers already linked to the target keep their one link
+	err = tx.Exec(`INSERT INTO supplier_categories (supplier_id, category_id)
+		SELECT supplier_id, ? FROM supplier_categories
+		WHERE category_id = ? AND supplier_id NOT IN (
+			SELECT supplier_id FROM supplier_categories WHERE category_id = ?
+		)`, toID, fromID, toID).Error
+	if err != nil {
+		return err
+	}
+	return tx.Exec("DELETE FROM supplier_categories WHERE category_id = ?", fromID).Error
+}
+
+func (r categoryRepository) CountProducts(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Product{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r categoryRepository) Delete(ctx context.Context, id uint) error {
+	tx := r.db.WithContext(ctx)
+	if err := tx.Exec("DELETE FROM supplier_categories WHERE category_id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Product{}).Where("category_id = ?", id).Update("category_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&models.Category{}, id).Error
+}
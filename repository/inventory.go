@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InventoryRepository stores inventory items, the stock records of SKUs.
+// Every item it loads comes with its SKU.
+type InventoryRepository interface {
+	Create(ctx context.Context, inventory *models.Inventory) error
+	Get(ctx context.Context, id uint) (*models.Inventory, error)
+	// GetBySKU loads the inventory item holding the stock of a SKU
+	GetBySKU(ctx context.Context, skuID uint) (*models.Inventory, error)
+	// Lock loads an inventory item and locks its row until the transaction ends
+	Lock(ctx context.Context, id uint) (*models.Inventory, error)
+	List(ctx context.Context, page PageRequest) (Page[models.Inventory], error)
+	// Update writes every column of inventory except those named in omit if
+	// the stored item still has inventory.Version, which it then increments
+	Update(ctx context.Context, inventory *models.Inventory, omit ...string) error
+	// Delete deletes an inventory item if it still has version
+	Delete(ctx context.Context, id uint, version int) error
+	// FindByProduct lists the items of every SKU of a product
+	FindByProduct(ctx context.Context, productID uint) ([]models.Inventory, error)
+	// FindInWarehouse lists the items with stock in a warehouse
+	FindInWarehouse(ctx context.Context, warehouseID uint) ([]models.Inventory, error)
+	SetQuantity(ctx context.Context, id uint, quantity int) error
+	// AdjustReserved adds delta to an item's reserved quantity
+	AdjustReserved(ctx context.Context, id uint, delta int) error
+}
+
+// StockRepository stores the stock ledger and the per-location balances
+// it maintains
+type StockRepository interface {
+	CreateMovement(ctx context.Context, movement *models.StockMovement) error
+	MovementsBySKU(ctx context.Context, sku string, page PageRequest) (Page[models.StockMovement], error)
+	// LedgerTotals sums the movements of an inventory item and counts them
+	LedgerTotals(ctx context.Context, inventoryID uint) (balance, count int, err error)
+
+	// Balance returns the balance of an item at a location from a lot, or a
+	// new unsaved one if there is none
+	Balance(ctx context.Context, inventoryID, warehouseID, binID, lotID uint) (*models.StockBalance, error)
+	SaveBalance(ctx context.Context, balance *models.StockBalance) error
+	// LocatedQuantity is the part of an item's stock held in balances, that
+	// is in warehouses or in lots
+	LocatedQuantity(ctx context.Context, inventoryID uint) (int, error)
+	// WarehouseBalances lists the non-zero balances held in a warehouse
+	WarehouseBalances(ctx context.Context, warehouseID uint) ([]models.StockBalance, error)
+	// InventoryBalances lists the non-zero balances of an item
+	InventoryBalances(ctx context.Context, inventoryID uint) ([]models.StockBalance, error)
+	// IssuableBalances lists the positive balances of an item that can be
+	// issued at now, in active warehouses or in lots outside a warehouse,
+	// optionally only in one warehouse. Balances are in FEFO order: the
+	// earliest expiry first and stock without an expiry date last, each
+	// largest first. Lots that expired before now are left out.
+	IssuableBalances(ctx context.Context, inventoryID, warehouseID uint, now time.Time) ([]models.StockBalance, error)
+	// ExpiredQuantity sums the positive balances of an item in lots that
+	// expired before now, optionally only in one warehouse
+	ExpiredQuantity(ctx context.Context, inventoryID, warehouseID uint, now time.Time) (int, error)
+	// ExpiringBalances lists the positive lot balances expiring before a
+	// time, soonest first, optionally only in one warehouse
+	ExpiringBalances(ctx context.Context, before time.Time, warehouseID uint) ([]models.StockBalance, error)
+	// HoldsStock reports whether any balance at or below a location is non-zero
+	HoldsStock(ctx context.Context, location Location) (bool, error)
+}
+
+// Location names a warehouse, zone, aisle or bin. The most specific
+// non-zero field is the one that counts.
+type Location struct {
+	WarehouseID uint
+	ZoneID      uint
+	AisleID     uint
+	BinID       uint
+}
+
+// inventorySortFields maps the sort keys accepted by the inventory listing to columns
+var inventorySortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"sku_id":     "sku_id",
+	"quantity":   "quantity",
+	"reserved":   "reserved",
+}
+
+// movementSortFields maps the sort keys accepted by the movement listing to columns
+var movementSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+type inventoryRepository struct {
+	db *gorm.DB
+}
+
+func (r inventoryRepository) Create(ctx context.Context, inventory *models.Inventory) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(inventory).Error
+}
+
+func (r inventoryRepository) Get(ctx context.Context, id uint) (*models.Inventory, error) {
+	var inventory models.Inventory
+	if err := r.db.WithContext(ctx).Preload("SKU").First(&inventory, id).Error; err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+func (r inventoryRepository) GetBySKU(ctx context.Context, skuID uint) (*models.Inventory, error) {
+	var inventory models.Inventory
+	if err := r.db.WithContext(ctx).Preload("SKU").Where("sku_id = ?", skuID).First(&inventory).Error; err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
+
+func (r inventoryRepository) Lock(ctx context.Context, id uint) (*models.Inventory, error) {
+	var inventory models.Inventory
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&inventory, id).Error; err != nil {
+		return nil, err
+	}
+
+	// Loaded on its own so the row lock does not extend to the SKU
+	var sku models.SKU
+	if err := r.db.WithContext(ctx).First(&sku, inventory.SKUID).Error; err != nil {
+		return nil, err
+	}
+	inventory.SKU = &sku
+	return &inventory, nil
+}
+
+func (r inventoryRepository) List(ctx context.Context, page PageRequest) (Page[models.Inventory], error) {
+	return paginate[models.Inventory](r.db.WithContext(ctx).Model(&models.Inventory{}).Preload("SKU"), NewQueryBuilder(inventorySortFields), page)
+}
+
+func (r inventoryRepository) Update(ctx context.Context, inventory *models.Inventory, omit ...string) error {
+	return updateVersioned(r.db.WithContext(ctx), inventory, inventory.ID, &inventory.Version, omit...)
+}
+
+func (r inventoryRepository) Delete(ctx context.Context, id uint, version int) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Inventory{}, id, version)
+}
+
+func (r inventoryRepository) FindByProduct(ctx context.Context, productID uint) ([]models.Inventory, error) {
+	tx := r.db.WithContext(ctx)
+	skus := tx.Model(&models.SKU{}).Select("id").Where("product_id = ?", productID)
+
+	var inventory []models.Inventory
+	if err := tx.Preload("SKU").Where("sku_id IN (?)", skus).Find(&inventory).Error; err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+func (r inventoryRepository) FindInWarehouse(ctx context.Context, warehouseID uint) ([]models.Inventory, error) {
+	tx := r.db.WithContext(ctx)
+	held := tx.Model(&models.StockBalance{}).Select("inventory_id").Where("warehouse_id = ? AND quantity <> 0", warehouseID)
+
+	var inventory []models.Inventory
+	if err := tx.Preload("SKU").Where("id IN (?)", held).Find(&inventory).Error; err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+func (r inventoryRepository) SetQuantity(ctx context.Context, id uint, quantity int) error {
+	return r.db.WithContext(ctx).Model(&models.Inventory{}).Where("id = ?", id).
+		Updates(bumpVersion(map[string]interface{}{"quantity": quantity})).Error
+}
+
+func (r inventoryRepository) AdjustReserved(ctx context.Context, id uint, delta int) error {
+	return r.db.WithContext(ctx).Model(&models.Inventory{}).Where("id = ?", id).
+		Updates(bumpVersion(map[string]interface{}{"reserved": gorm.Expr("reserved + ?", delta)})).Error
+}
+
+type stockRepository struct {
+	db *gorm.DB
+}
+
+func (r stockRepository) CreateMovement(ctx context.Context, movement *models.StockMovement) error {
+	return r.db.WithContext(ctx).Create(movement).Error
+}
+
+func (r stockRepository) MovementsBySKU(ctx context.Context, sku string, page PageRequest) (Page[models.StockMovement], error) {
+	q := NewQueryBuilder(movementSortFields).Equal("sku", sku)
+	return paginate[models.StockMovement](r.db.WithContext(ctx).Model(&models.StockMovement{}), q, page)
+}
+
+func (r stockRepository) LedgerTotals(ctx context.Context, inventoryID uint) (int, int, error) {
+	var totals struct {
+		Balance int
+		Count   int
+	}
+	err := r.db.WithContext(ctx).Model(&models.StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0) AS balance, COUNT(*) AS count").
+		Where("inventory_id = ?", inventoryID).
+		Scan(&totals).Error
+	return totals.Balance, totals.Count, err
+}
+
+func (r stockRepository) Balance(ctx context.Context, inventoryID, warehouseID, binID, lotID uint) (*models.StockBalance, error) {
+	balance := models.StockBalance{
+		InventoryID: inventoryID,
+		WarehouseID: warehouseID,
+		BinID:       binID,
+		LotID:       lotID,
+	}
+	if err := r.db.WithContext(ctx).Where(&balance, "InventoryID", "WarehouseID", "BinID", "LotID").FirstOrInit(&balance).Error; err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+func (r stockRepository) SaveBalance(ctx context.Context, balance *models.StockBalance) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(balance).Error
+}
+
+func (r stockRepository) LocatedQuantity(ctx context.Context, inventoryID uint) (int, error) {
+	var located int
+	err := r.db.WithContext(ctx).Model(&models.StockBalance{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("inventory_id = ?", inventoryID).
+		Scan(&located).Error
+	return located, err
+}
+
+func (r stockRepository) WarehouseBalances(ctx context.Context, warehouseID uint) ([]models.StockBalance, error) {
+	var balances []models.StockBalance
+	err := r.db.WithContext(ctx).Preload("Lot").Where("warehouse_id = ? AND quantity <> 0", warehouseID).Order("inventory_id, bin_id, lot_id").Find(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (r stockRepository) InventoryBalances(ctx context.Context, inventoryID uint) ([]models.StockBalance, error) {
+	var balances []models.StockBalance
+	err := r.db.WithContext(ctx).Preload("Lot").Where("inventory_id = ? AND quantity <> 0", inventoryID).Order("warehouse_id, bin_id, lot_id").Find(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (r stockRepository) IssuableBalances(ctx context.Context, inventoryID, warehouseID uint, now time.Time) ([]models.StockBalance, error) {
+	query := r.db.WithContext(ctx).Model(&models.StockBalance{}).Preload("Lot").
+		Joins("LEFT JOIN warehouses ON warehouses.id = stock_balances.warehouse_id").
+		Joins("LEFT JOIN lots ON lots.id = stock_balances.lot_id").
+		Where("stock_balances.inventory_id = ? AND stock_balances.quantity > 0", inventoryID).
+		Where("warehouses.active = ? OR (stock_balances.warehouse_id = 0 AND stock_balances.lot_id <> 0)", true).
+		Where("lots.expires_on IS NULL OR lots.expires_on >= ?", now)
+	if warehouseID != 0 {
+		query = query.Where("stock_balances.warehouse_id = ?", warehouseID)
+	}
+
+	var balances []models.StockBalance
+	err := query.Order("CASE WHEN lots.expires_on IS NULL THEN 1 ELSE 0 END, lots.expires_on, stock_balances.quantity DESC, stock_balances.id").
+		Find(&balances).Error
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (r stockRepository) ExpiredQuantity(ctx context.Context, inventoryID, warehouseID uint, now time.Time) (int, error) {
+	query := r.db.WithContext(ctx).Model(&models.StockBalance{}).
+		Select("COALESCE(SUM(stock_balances.quantity), 0)").
+		Joins("JOIN lots ON lots.id = stock_balances.lot_id").
+		Where("stock_balances.inventory_id = ? AND stock_balances.quantity > 0 AND lots.expires_on < ?", inventoryID, now)
+	if warehouseID != 0 {
+		query = query.Where("stock_balances.warehouse_id = ?", warehouseID)
+	}
+
+	var expired int
+	err := query.Scan(&expired).Error
+	return expired, err
+}
+
+func (r stockRepository) ExpiringBalances(ctx context.Context, before time.Time, warehouseID uint) ([]models.StockBalance, error) {
+	query := r.db.WithContext(ctx).Model(&models.StockBalance{}).Preload("Lot").
+		Joins("JOIN lots ON lots.id = stock_balances.lot_id").
+		Where("stock_balances.quantity > 0 AND lots.expires_on < ?", before)
+	if warehouseID != 0 {
+		query = query.Where("stock_balances.warehouse_id = ?", warehouseID)
+	}
+
+	var balances []models.StockBalance
+	if err := query.Order("lots.expires_on, stock_balances.inventory_id, stock_balances.id").Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (r stockRepository) HoldsStock(ctx context.Context, location Location) (bool, error) {
+	tx := r.db.WithContext(ctx)
+	query := tx.Model(&models.StockBalance{}).Where("quantity <> 0")
+	switch {
+	case location.BinID != 0:
+		query = query.Where("bin_id = ?", location.BinID)
+	case location.AisleID != 0:
+		bins := tx.Model(&models.Bin{}).Select("id").Where("aisle_id = ?", location.AisleID)
+		query = query.Where("bin_id IN (?)", bins)
+	case location.ZoneID != 0:
+		aisles := tx.Model(&models.Aisle{}).Select("id").Where("zone_id = ?", location.ZoneID)
+		bins := tx.Model(&models.Bin{}).Select("id").Where("aisle_id IN (?)", aisles)
+		query = query.Where("bin_id IN (?)", bins)
+	default:
+		query = query.Where("warehouse_id = ?", location.WarehouseID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
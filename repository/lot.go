@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+)
+
+// LotRepository stores the lots SKUs are received in
+type LotRepository interface {
+	Create(ctx context.Context, lot *models.Lot) error
+	Get(ctx context.Context, id uint) (*models.Lot, error)
+	// GetByNumber loads the lot of a SKU with a lot number
+	GetByNumber(ctx context.Context, skuID uint, number string) (*models.Lot, error)
+	// ListBySKU lists the lots of a SKU, earliest expiry first
+	ListBySKU(ctx context.Context, skuID uint) ([]models.Lot, error)
+}
+
+type lotRepository struct {
+	db *gorm.DB
+}
+
+func (r lotRepository) Create(ctx context.Context, lot *models.Lot) error {
+	return r.db.WithContext(ctx).Create(lot).Error
+}
+
+func (r lotRepository) Get(ctx context.Context, id uint) (*models.Lot, error) {
+	var lot models.Lot
+	if err := r.db.WithContext(ctx).First(&lot, id).Error; err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r lotRepository) GetByNumber(ctx context.Context, skuID uint, number string) (*models.Lot, error) {
+	var lot models.Lot
+	if err := r.db.WithContext(ctx).Where("sku_id = ? AND number = ?", skuID, number).First(&lot).Error; err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r lotRepository) ListBySKU(ctx context.Context, skuID uint) ([]models.Lot, error) {
+	var lots []models.Lot
+	err := r.db.WithContext(ctx).Where("sku_id = ?", skuID).
+		Order("CASE WHEN expires_on IS NULL THEN 1 ELSE 0 END, expires_on, id").Find(&lots).Error
+	if err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
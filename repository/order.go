@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderRepository stores orders and the stock reservations held for them
+type OrderRepository interface {
+	Create(ctx context.Context, order *models.Order) error
+	Get(ctx context.Context, id uint) (*models.Order, error)
+	// Lock loads an order and locks its row until the transaction ends
+	Lock(ctx context.Context, id uint) (*models.Order, error)
+	Find(ctx context.Context, filter OrderFilter, page PageRequest) (Page[models.Order], error)
+	// UpdateStatus changes the status of an order if it still has version,
+	// which it then increments
+	UpdateStatus(ctx context.Context, id uint, status string, version int) error
+	// Delete deletes an order if it still has version
+	Delete(ctx context.Context, id uint, version int) error
+
+	CreateReservation(ctx context.Context, reservation *models.StockReservation) error
+	// ActiveReservation returns the reservation an order still holds, or
+	// ErrNotFound if it holds none
+	ActiveReservation(ctx context.Context, orderID uint) (*models.StockReservation, error)
+	UpdateReservationStatus(ctx context.Context, id uint, status string) error
+	// ReservedIn sums the active reservations of an item held by orders
+	// that ship from one warehouse
+	ReservedIn(ctx context.Context, inventoryID, warehouseID uint) (int, error)
+}
+
+// orderSortFields maps the sort keys accepted by the order listing to columns.
+// Keys are the column names so pagination can read them back off the model.
+var orderSortFields = map[string]string{
+	"id":          "orders.id",
+	"created_at":  "orders.created_at",
+	"updated_at":  "orders.updated_at",
+	"status":      "orders.status",
+	"quantity":    "orders.quantity",
+	"total_price": "orders.total_price",
+}
+
+// OrderFilter holds every criterion the order listing can be narrowed by.
+// Zero values mean "no filter" for that field.
+type OrderFilter struct {
+	CustomerID uint
+	VendorID   uint
+	ProductID  uint
+	ShipmentID uint
+	Statuses   []string
+	From       *time.Time
+	To         *time.Time
+	Before     *time.Time // exclusive end, e.g. the day after a range of whole days
+	MinTotal   *float64
+	MaxTotal   *float64
+}
+
+// query translates the filter into a QueryBuilder over the orders table.
+// Customers are the ordering users. Product, vendor and shipment are
+// resolved through the SKUs and inventory records an order draws from and
+// the shipments table.
+func (f OrderFilter) query(tx *gorm.DB) *QueryBuilder {
+	q := NewQueryBuilder(orderSortFields).
+		Equal("orders.user_id", f.CustomerID).
+		In("orders.status", f.Statuses).
+		Between("orders.created_at", f.From, f.To).
+		Before("orders.created_at", f.Before).
+		Between("orders.total_price", f.MinTotal, f.MaxTotal)
+
+	if f.ProductID != 0 {
+		skus := tx.Model(&models.SKU{}).Select("id").Where("product_id = ?", f.ProductID)
+		q.Where("orders.inventory_id IN (?)",
+			tx.Model(&models.Inventory{}).Select("id").Where("sku_id IN (?)", skus))
+	}
+	if f.VendorID != 0 {
+		q.Where("orders.inventory_id IN (?)",
+			tx.Model(&models.Inventory{}).Select("id").Where("vendor_id = ?", f.VendorID))
+	}
+	if f.ShipmentID != 0 {
+		q.Where("orders.id IN (?)",
+			tx.Model(&models.Shipment{}).Select("order_id").Where("id = ?", f.ShipmentID))
+	}
+
+	return q
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func (r orderRepository) Create(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+func (r orderRepository) Get(ctx context.Context, id uint) (*models.Order, error) {
+	var order models.Order
+	if err := r.db.WithContext(ctx).First(&order, id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r orderRepository) Lock(ctx context.Context, id uint) (*models.Order, error) {
+	var order models.Order
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r orderRepository) Find(ctx context.Context, filter OrderFilter, page PageRequest) (Page[models.Order], error) {
+	tx := r.db.WithContext(ctx)
+	return paginate[models.Order](tx.Model(&models.Order{}), filter.query(tx), page)
+}
+
+func (r orderRepository) UpdateStatus(ctx context.Context, id uint, status string, version int) error {
+	tx := r.db.WithContext(ctx)
+	result := tx.Model(&models.Order{}).Where("id = ? AND version = ?", id, version).
+		Updates(bumpVersion(map[string]interface{}{"status": status}))
+	return checkVersioned(tx, result, &models.Order{}, id)
+}
+
+func (r orderRepository) Delete(ctx context.Context, id uint, version int) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Order{}, id, version)
+}
+
+func (r orderRepository) CreateReservation(ctx context.Context, reservation *models.StockReservation) error {
+	return r.db.WithContext(ctx).Create(reservation).Error
+}
+
+func (r orderRepository) ActiveReservation(ctx context.Context, orderID uint) (*models.StockReservation, error) {
+	var reservation models.StockReservation
+	err := r.db.WithContext(ctx).Where("order_id = ? AND status = ?", orderID, models.ReservationStatusActive).First(&reservation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (r orderRepository) UpdateReservationStatus(ctx context.Context, id uint, status string) error {
+	return r.db.WithContext(ctx).Model(&models.StockReservation{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r orderRepository) ReservedIn(ctx context.Context, inventoryID, warehouseID uint) (int, error) {
+	var reserved int
+	err := r.db.WithContext(ctx).Model(&models.StockReservation{}).
+		Select("COALESCE(SUM(stock_reservations.quantity), 0)").
+		Joins("JOIN orders ON orders.id = stock_reservations.order_id").
+		Where("stock_reservations.inventory_id = ? AND stock_reservations.status = ? AND orders.warehouse_id = ?",
+			inventoryID, models.ReservationStatusActive, warehouseID).
+		Scan(&reserved).Error
+	return reserved, err
+}
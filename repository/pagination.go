@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// DefaultPageLimit is the page size used when the caller does not ask for one
+	DefaultPageLimit = 50
+	// MaxPageLimit caps the page size a caller can ask for
+	MaxPageLimit = 200
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or was issued
+// for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest describes which slice of a listing to return
+type PageRequest struct {
+	Limit  int
+	Cursor string
+	Sort   []SortKey
+}
+
+// Page is one slice of a listing together with the cursor for the next one.
+// NextCursor is empty on the last page.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Limit      int    `json:"limit"`
+}
+
+// pageCursor is the decoded form of the opaque cursor handed to clients
+type pageCursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+var schemaCache sync.Map
+
+// paginate runs the query built by q against tx one page at a time. Rows are
+// ordered by the requested sort keys with the primary key as a tiebreaker, and
+// the cursor records the sort values of the last row so the next page starts
+// strictly after it (keyset pagination) instead of using OFFSET.
+func paginate[T any](tx *gorm.DB, q *QueryBuilder, req PageRequest) (Page[T], error) {
+	page := Page[T]{Data: []T{}, Limit: normalizeLimit(req.Limit)}
+
+	keys := sortKeysWithTiebreaker(req.Sort)
+	signature := sortSignature(keys)
+
+	sch, err := schema.Parse(new(T), &schemaCache, tx.NamingStrategy)
+	if err != nil {
+		return page, err
+	}
+
+	columns := make([]string, len(keys))
+	fields := make([]*schema.Field, len(keys))
+	for i, key := range keys {
+		column, ok := q.sortable[key.Field]
+		if !ok {
+			return page, fmt.Errorf("%w: %s", ErrInvalidSortField, key.Field)
+		}
+		field := sch.LookUpField(key.Field)
+		if field == nil {
+			return page, fmt.Errorf("%w: %s", ErrInvalidSortField, key.Field)
+		}
+		columns[i], fields[i] = column, field
+	}
+
+	q.sorts = nil
+	tx, err = q.SortBy(keys...).Build(tx)
+	if err != nil {
+		return page, err
+	}
+
+	if req.Cursor != "" {
+		values, err := decodeCursor(req.Cursor, signature, fields)
+		if err != nil {
+			return page, err
+		}
+		condition, args := keysetCondition(keys, columns, values)
+		tx = tx.Where(condition, args...)
+	}
+
+	var rows []T
+	if err := tx.Limit(page.Limit + 1).Find(&rows).Error; err != nil {
+		return page, err
+	}
+
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := reflect.ValueOf(&rows[len(rows)-1]).Elem()
+		page.NextCursor, err = encodeCursor(signature, fields, last)
+		if err != nil {
+			return page, err
+		}
+	}
+	page.Data = rows
+
+	return page, nil
+}
+
+// normalizeLimit applies the default and maximum page sizes
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
+
+// sortKeysWithTiebreaker appends the primary key so the ordering is total
+func sortKeysWithTiebreaker(keys []SortKey) []SortKey {
+	for _, key := range keys {
+		if key.Field == "id" {
+			return keys
+		}
+	}
+	return append(append([]SortKey{}, keys...), SortKey{Field: "id"})
+}
+
+// sortSignature identifies an ordering so cursors cannot be replayed against another
+func sortSignature(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.Field
+		if key.Desc {
+			parts[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// keysetCondition builds the "row comes after the cursor" predicate:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... with < for descending keys
+func keysetCondition(keys []SortKey, columns []string, values []interface{}) (string, []interface{}) {
+	var ors []string
+	var args []interface{}
+	for i, key := range keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, columns[j]+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if key.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, columns[i]+op)
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// encodeCursor captures the sort values of row as an opaque string
+func encodeCursor(signature string, fields []*schema.Field, row reflect.Value) (string, error) {
+	cursor := pageCursor{Sort: signature}
+	for _, field := range fields {
+		value, _ := field.ValueOf(context.Background(), row)
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		cursor.Values = append(cursor.Values, raw)
+	}
+
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+// decodeCursor restores the sort values of a cursor as typed Go values
+func decodeCursor(encoded, signature string, fields []*schema.Field) ([]interface{}, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != signature || len(cursor.Values) != len(fields) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(cursor.Values[i], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+
+	return values, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// pageRow is a minimal table with a sort key shared by several rows
+type pageRow struct {
+	ID    uint `gorm:"primaryKey"`
+	Group int
+	Name  string
+}
+
+var pageRowSortFields = map[string]string{"id": "id", "group": `"group"`, "name": "name"}
+
+// openPageRows opens a private in-memory database holding rows with the
+// given groups, inserted in order so their IDs run from 1
+func openPageRows(t *testing.T, groups ...int) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("opening the database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&pageRow{}); err != nil {
+		t.Fatal(err)
+	}
+	for i, group := range groups {
+		if err := db.Create(&pageRow{Group: group, Name: string(rune('a' + i))}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// listPage fetches one page of rows
+func listPage(t *testing.T, db *gorm.DB, req PageRequest) (Page[pageRow], error) {
+	t.Helper()
+	return paginate[pageRow](db.Model(&pageRow{}), NewQueryBuilder(pageRowSortFields), req)
+}
+
+// collect follows next cursors from the first page to the last and returns
+// the IDs seen along with the size of every page
+func collect(t *testing.T, db *gorm.DB, limit int, sort string) (ids []uint, sizes []int) {
+	t.Helper()
+	req := PageRequest{Limit: limit, Sort: ParseSortKeys(sort)}
+	for {
+		page, err := listPage(t, db, req)
+		if err != nil {
+			t.Fatalf("sort %q after cursor %q: %v", sort, req.Cursor, err)
+		}
+		for _, row := range page.Data {
+			ids = append(ids, row.ID)
+		}
+		sizes = append(sizes, len(page.Data))
+		if page.NextCursor == "" {
+			return ids, sizes
+		}
+		if len(sizes) > 10 {
+			t.Fatalf("sort %q: still paging after %v", sort, sizes)
+		}
+		req.Cursor = page.NextCursor
+	}
+}
+
+func TestPaginateOrdersAcrossPages(t *testing.T) {
+	// Groups repeat so most rows tie on the sort key and only the ID orders them
+	db := openPageRows(t, 2, 1, 2, 1, 2, 1, 2)
+
+	for _, test := range []struct {
+		sort  string
+		limit int
+		want  []uint
+	}{
+		{"", 3, []uint{1, 2, 3, 4, 5, 6, 7}},
+		{"-id", 3, []uint{7, 6, 5, 4, 3, 2, 1}},
+		{"group", 2, []uint{2, 4, 6, 1, 3, 5, 7}},
+		{"group", 3, []uint{2, 4, 6, 1, 3, 5, 7}},
+		{"-group", 2, []uint{1, 3, 5, 7, 2, 4, 6}},
+		{"group,-id", 2, []uint{6, 4, 2, 7, 5, 3, 1}},
+		{"-group,-id", 3, []uint{7, 5, 3, 1, 6, 4, 2}},
+		{"-name", 4, []uint{7, 6, 5, 4, 3, 2, 1}},
+	} {
+		got, _ := collect(t, db, test.limit, test.sort)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("sort %q by %d = %v, want %v", test.sort, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestPaginateEndsOnTheLastPage(t *testing.T) {
+	for _, test := range []struct {
+		rows, limit int
+		want        []int
+	}{
+		{0, 3, []int{0}},
+		{2, 3, []int{2}},
+		// A full last page has no cursor, rather than one to an empty page
+		{6, 3, []int{3, 3}},
+		{7, 3, []int{3, 3, 1}},
+	} {
+		groups := make([]int, test.rows)
+		_, sizes := collect(t, openPageRows(t, groups...), test.limit, "group")
+		if !slices.Equal(sizes, test.want) {
+			t.Errorf("%d rows by %d = pages of %v, want %v", test.rows, test.limit, sizes, test.want)
+		}
+	}
+}
+
+func TestPaginateRefusesBadCursors(t *testing.T) {
+	db := openPageRows(t, 1, 1, 1)
+	first, err := listPage(t, db, PageRequest{Limit: 1, Sort: ParseSortKeys("-group")})
+	if err != nil || first.NextCursor == "" {
+		t.Fatalf("first page = %+v, %v; want a next cursor", first, err)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(first.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload))
+	}
+
+	for _, test := range []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"not base64", "!!!", "-group"},
+		{"not JSON", encode("cursor"), "-group"},
+		{"truncated", encode(string(raw[:len(raw)-2])), "-group"},
+		{"another sort", first.NextCursor, "group"},
+		{"the default sort", first.NextCursor, ""},
+		{"forged sort", encode(`{"s":"group,id","v":[1,1]}`), "-group"},
+		{"too few values", encode(`{"s":"-group,id","v":[1]}`), "-group"},
+		{"too many values", encode(`{"s":"-group,id","v":[1,1,1]}`), "-group"},
+		{"mistyped value", encode(`{"s":"-group,id","v":["1",1]}`), "-group"},
+		{"negative ID", encode(`{"s":"-group,id","v":[1,-1]}`), "-group"},
+	} {
+		_, err := listPage(t, db, PageRequest{Limit: 1, Cursor: test.cursor, Sort: ParseSortKeys(test.sort)})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: error = %v, want ErrInvalidCursor", test.name, err)
+		}
+	}
+
+	// The untouched cursor still works for the sort it was issued for
+	next, err := listPage(t, db, PageRequest{Limit: 1, Cursor: first.NextCursor, Sort: ParseSortKeys("-group")})
+	if err != nil || len(next.Data) != 1 || next.Data[0].ID != 2 {
+		t.Errorf("second page = %+v, %v; want row 2", next, err)
+	}
+}
+
+func TestPaginateLimits(t *testing.T) {
+	for limit, want := range map[int]int{-1: DefaultPageLimit, 0: DefaultPageLimit, 1: 1, MaxPageLimit: MaxPageLimit, MaxPageLimit + 1: MaxPageLimit} {
+		if got := normalizeLimit(limit); got != want {
+			t.Errorf("normalizeLimit(%d) = %d, want %d", limit, got, want)
+		}
+	}
+
+	db := openPageRows(t, 1)
+	if _, err := listPage(t, db, PageRequest{Sort: ParseSortKeys("secret")}); !errors.Is(err, ErrInvalidSortField) {
+		t.Errorf("sorting by an unexposed field: error = %v, want ErrInvalidSortField", err)
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductRepository stores the product master records of the catalog
+type ProductRepository interface {
+	// Create stores a product together with any SKUs it carries
+	Create(ctx context.Context, product *models.Product) error
+	// Get loads a product with its category and SKUs
+	Get(ctx context.Context, id uint) (*models.Product, error)
+	List(ctx context.Context, page PageRequest) (Page[models.Product], error)
+	Find(ctx context.Context, filter ProductFilter) ([]models.Product, error)
+	// Save writes the product's own columns; its SKUs are left as they are
+	Save(ctx context.Context, product *models.Product) error
+	Delete(ctx context.Context, id uint) error
+	// CountSKUs counts the SKUs of a product
+	CountSKUs(ctx context.Context, id uint) (int64, error)
+}
+
+// ProductFilter narrows the product finders; zero values and nil bounds are
+// ignored. Category is a category ID or path and matches its whole subtree.
+// A product matches a price range if any of its SKUs does, and its stock is
+// the on-hand quantity of all its SKUs together.
+type ProductFilter struct {
+	Category string
+	MinPrice *float64
+	MaxPrice *float64
+	Stock    *int
+	MinStock *int
+	MaxStock *int
+}
+
+// productStock is the on-hand quantity of a product across its SKUs
+const productStock = `(SELECT COALESCE(SUM(inventories.quantity), 0) FROM inventories
+	JOIN skus ON skus.id = inventories.sku_id AND skus.deleted_at IS NULL
+	WHERE skus.product_id = products.id AND inventories.deleted_at IS NULL)`
+
+// productSortFields maps the sort keys accepted by the product listing to columns
+var productSortFields = map[string]string{
+	"id":          "id",
+	"created_at":  "created_at",
+	"name":        "name",
+	"category_id": "category_id",
+}
+
+type productRepository struct {
+	db *gorm.DB
+}
+
+func (r productRepository) Create(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Create(product).Error
+}
+
+func (r productRepository) Get(ctx context.Context, id uint) (*models.Product, error) {
+	var product models.Product
+	if err := r.db.WithContext(ctx).Preload("Category").Preload("SKUs").Preload("SKUs.Units").First(&product, id).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r productRepository) List(ctx context.Context, page PageRequest) (Page[models.Product], error) {
+	return paginate[models.Product](r.db.WithContext(ctx).Model(&models.Product{}), NewQueryBuilder(productSortFields), page)
+}
+
+func (r productRepository) Find(ctx context.Context, filter ProductFilter) ([]models.Product, error) {
+	tx := r.db.WithContext(ctx)
+	q := NewQueryBuilder(productSortFields).
+		Equal(productStock, filter.Stock).
+		Between(productStock, filter.MinStock, filter.MaxStock)
+	if filter.Category != "" {
+		q.Where("category_id IN (?)", categoryTree(tx, filter.Category))
+	}
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		skus := tx.Model(&models.SKU{}).Select("product_id")
+		if filter.MinPrice != nil {
+			skus = skus.Where("price >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			skus = skus.Where("price <= ?", *filter.MaxPrice)
+		}
+		q.Where("id IN (?)", skus)
+	}
+	return findAll[models.Product](tx, q)
+}
+
+func (r productRepository) Save(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(product).Error
+}
+
+func (r productRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Product{}, id).Error
+}
+
+func (r productRepository) CountSKUs(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.SKU{}).Where("product_id = ?", id).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSortField is returned when a caller asks to sort by a field the
+// listing does not expose
+var ErrInvalidSortField = errors.New("invalid sort field")
+
+// SortKey is a single field to order a listing by
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// QueryBuilder composes optional filters and sort keys into one query.
+// Filters given a zero value are skipped, so callers can pass through
+// whatever the request carried without branching on every field.
+type QueryBuilder struct {
+	sortable map[string]string
+	clauses  []queryClause
+	sorts    []SortKey
+}
+
+type queryClause struct {
+	query string
+	args  []interface{}
+}
+
+// NewQueryBuilder creates a builder whose sort keys are restricted to the
+// given map of public field names to column expressions
+func NewQueryBuilder(sortable map[string]string) *QueryBuilder {
+	return &QueryBuilder{sortable: sortable}
+}
+
+// Equal adds a "column = value" filter unless value is zero
+func (q *QueryBuilder) Equal(column string, value interface{}) *QueryBuilder {
+	if isZero(value) {
+		return q
+	}
+	return q.Where(column+" = ?", value)
+}
+
+// In adds a "column IN (values)" filter unless values is empty
+func (q *QueryBuilder) In(column string, values []string) *QueryBuilder {
+	if len(values) == 0 {
+		return q
+	}
+	return q.Where(column+" IN ?", values)
+}
+
+// Between adds inclusive lower and upper bounds on column; either side may
+// be nil or zero to leave that end of the range open
+func (q *QueryBuilder) Between(column string, min, max interface{}) *QueryBuilder {
+	if !isZero(min) {
+		q.Where(column+" >= ?", min)
+	}
+	if !isZero(max) {
+		q.Where(column+" <= ?", max)
+	}
+	return q
+}
+
+// Before adds an exclusive upper bound on column unless limit is nil or zero
+func (q *QueryBuilder) Before(column string, limit interface{}) *QueryBuilder {
+	if isZero(limit) {
+		return q
+	}
+	return q.Where(column+" < ?", limit)
+}
+
+// Where adds a raw condition, for filters that need a join or subquery
+func (q *QueryBuilder) Where(query string, args ...interface{}) *QueryBuilder {
+	q.clauses = append(q.clauses, queryClause{query: query, args: args})
+	return q
+}
+
+// SortBy appends sort keys; unknown fields are rejected when the query is built
+func (q *QueryBuilder) SortBy(keys ...SortKey) *QueryBuilder {
+	q.sorts = append(q.sorts, keys...)
+	return q
+}
+
+// Build applies the accumulated filters and sort keys to tx
+func (q *QueryBuilder) Build(tx *gorm.DB) (*gorm.DB, error) {
+	for _, c := range q.clauses {
+		tx = tx.Where(c.query, c.args...)
+	}
+
+	for _, key := range q.sorts {
+		column, ok := q.sortable[key.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSortField, key.Field)
+		}
+		if key.Desc {
+			column += " DESC"
+		}
+		tx = tx.Order(column)
+	}
+
+	return tx, nil
+}
+
+// ParseSortKeys parses a comma-separated sort expression such as
+// "-created_at,total_price" where a leading "-" means descending
+func ParseSortKeys(expr string) []SortKey {
+	var keys []SortKey
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key := SortKey{Field: part}
+		if strings.HasPrefix(part, "-") {
+			key = SortKey{Field: part[1:], Desc: true}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// isZero reports whether v is unset: nil, a nil pointer or, for non-pointer
+// values, its type's zero value. A non-nil pointer to zero counts as set.
+func isZero(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
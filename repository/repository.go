@@ -0,0 +1,188 @@
+// Package repository is the data access layer. Each aggregate has a
+// repository interface; Store hands them out and runs units of work that
+// span several of them in one transaction.
+//
+// Every method takes the request context, so changes are attributed in the
+// audit log and queries are cancelled with the request.
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = gorm.ErrRecordNotFound
+	// ErrDuplicate is returned when a record would break a unique constraint
+	ErrDuplicate = gorm.ErrDuplicatedKey
+	// ErrVersionConflict is returned when a versioned record was changed
+	// after the version a write was based on was read
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// AnyVersion is the version of a write that applies to a versioned record
+// whatever its current version, as for If-Match: *. Stored versions start
+// at 1.
+const AnyVersion = 0
+
+// VersionMatches reports whether a record stored at version satisfies a
+// write based on expected
+func VersionMatches(version, expected int) bool {
+	return expected == AnyVersion || version == expected
+}
+
+// Store gives access to every repository. The repositories of a Store
+// returned by Transaction share its transaction.
+type Store interface {
+	Users() UserRepository
+	Sessions() SessionRepository
+	PasswordResets() PasswordResetRepository
+	RecoveryCodes() RecoveryCodeRepository
+	LoginThrottles() LoginThrottleRepository
+	SecurityEvents() SecurityEventRepository
+	OIDCLogins() OIDCLoginRepository
+	APIKeys() APIKeyRepository
+	AuditLogs() AuditLogRepository
+	Inventory() InventoryRepository
+	Stock() StockRepository
+	Lots() LotRepository
+	Serials() SerialRepository
+	Orders() OrderRepository
+	Warehouses() WarehouseRepository
+	Transfers() TransferRepository
+	Shipments() ShipmentRepository
+	Categories() CategoryRepository
+	Units() UnitRepository
+	Products() ProductRepository
+	SKUs() SKURepository
+	Suppliers() SupplierRepository
+	Vendors() VendorRepository
+
+	// Transaction runs fn with a Store bound to a new transaction, which is
+	// committed if fn returns nil and rolled back otherwise. Calling it on a
+	// Store that is already in a transaction nests a savepoint.
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+}
+
+// gormStore is the Store backed by a GORM database
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewStore returns a Store over db
+func NewStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Users() UserRepository                   { return userRepository{s.db} }
+func (s *gormStore) Sessions() SessionRepository             { return sessionRepository{s.db} }
+func (s *gormStore) PasswordResets() PasswordResetRepository { return passwordResetRepository{s.db} }
+func (s *gormStore) RecoveryCodes() RecoveryCodeRepository   { return recoveryCodeRepository{s.db} }
+func (s *gormStore) LoginThrottles() LoginThrottleRepository { return loginThrottleRepository{s.db} }
+func (s *gormStore) SecurityEvents() SecurityEventRepository { return securityEventRepository{s.db} }
+func (s *gormStore) OIDCLogins() OIDCLoginRepository         { return oidcLoginRepository{s.db} }
+func (s *gormStore) APIKeys() APIKeyRepository               { return apiKeyRepository{s.db} }
+func (s *gormStore) AuditLogs() AuditLogRepository           { return auditLogRepository{s.db} }
+func (s *gormStore) Inventory() InventoryRepository          { return inventoryRepository{s.db} }
+func (s *gormStore) Stock() StockRepository                  { return stockRepository{s.db} }
+func (s *gormStore) Lots() LotRepository                     { return lotRepository{s.db} }
+func (s *gormStore) Serials() SerialRepository               { return serialRepository{s.db} }
+func (s *gormStore) Orders() OrderRepository                 { return orderRepository{s.db} }
+func (s *gormStore) Warehouses() WarehouseRepository         { return warehouseRepository{s.db} }
+func (s *gormStore) Transfers() TransferRepository           { return transferRepository{s.db} }
+func (s *gormStore) Shipments() ShipmentRepository           { return shipmentRepository{s.db} }
+func (s *gormStore) Categories() CategoryRepository          { return categoryRepository{s.db} }
+func (s *gormStore) Units() UnitRepository                   { return unitRepository{s.db} }
+func (s *gormStore) Products() ProductRepository             { return productRepository{s.db} }
+func (s *gormStore) SKUs() SKURepository                     { return skuRepository{s.db} }
+func (s *gormStore) Suppliers() SupplierRepository           { return supplierRepository{s.db} }
+func (s *gormStore) Vendors() VendorRepository               { return vendorRepository{s.db} }
+
+func (s *gormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+// findAll runs the query built by q against tx and returns every matching row
+func findAll[T any](tx *gorm.DB, q *QueryBuilder) ([]T, error) {
+	tx, err := q.Build(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []T
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// updateVersioned writes every column of model except omit and its
+// associations, provided the stored row still has version, and then
+// increments version. It fails with ErrVersionConflict if the row was
+// changed in the meantime. With AnyVersion the row is written whatever its
+// version, and version is set to the one it ends up with.
+func updateVersioned(tx *gorm.DB, model interface{}, id uint, version *int, omit ...string) error {
+	omit = append([]string{"id", "created_at", "deleted_at", clause.Associations}, omit...)
+	expected := *version
+	if expected == AnyVersion {
+		return tx.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(model).Select("*").Omit(append(omit, "version")...).Updates(model)
+			if err := checkVersioned(tx, result, model, id); err != nil {
+				return err
+			}
+			if err := tx.Model(model).UpdateColumn("version", gorm.Expr("version + 1")).Error; err != nil {
+				return err
+			}
+			return tx.Model(model).Select("version").Where("id = ?", id).Row().Scan(version)
+		})
+	}
+
+	*version = expected + 1
+	result := tx.Model(model).Where("version = ?", expected).Select("*").Omit(omit...).Updates(model)
+	err := checkVersioned(tx, result, model, id)
+	if err != nil {
+		*version = expected
+	}
+	return err
+}
+
+// deleteVersioned deletes the row of model with id, provided it still has
+// version or version is AnyVersion. It fails with ErrVersionConflict if the
+// row was changed in the meantime.
+func deleteVersioned(tx *gorm.DB, model interface{}, id uint, version int) error {
+	query := tx
+	if version != AnyVersion {
+		query = tx.Where("version = ?", version)
+	}
+	return checkVersioned(tx, query.Delete(model, id), model, id)
+}
+
+// checkVersioned turns a versioned write that touched no row into
+// ErrNotFound if the row is gone and ErrVersionConflict otherwise
+func checkVersioned(tx *gorm.DB, result *gorm.DB, model interface{}, id uint) error {
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	var count int64
+	if err := tx.Session(&gorm.Session{NewDB: true}).Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+// bumpVersion adds the version increment to the columns of an update that
+// does not go through updateVersioned, so every change shows in the ETag
+func bumpVersion(columns map[string]interface{}) map[string]interface{} {
+	columns["version"] = gorm.Expr("version + 1")
+	return columns
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+)
+
+// SecurityEventRepository stores security events
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event *models.SecurityEvent) error
+	Find(ctx context.Context, filter SecurityEventFilter, page PageRequest) (Page[models.SecurityEvent], error)
+}
+
+// AuditLogRepository reads the audit log, which internal/audit writes
+type AuditLogRepository interface {
+	Find(ctx context.Context, filter AuditFilter, page PageRequest) (Page[models.AuditLog], error)
+}
+
+// securityEventSortFields maps the sort keys accepted by the security event listing to columns
+var securityEventSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"type":       "type",
+}
+
+// SecurityEventFilter narrows the security event listing; zero values are ignored
+type SecurityEventFilter struct {
+	Types  []string
+	UserID uint
+	IP     string
+}
+
+// auditSortFields maps the sort keys accepted by the audit log listing to columns
+var auditSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+// AuditFilter narrows the audit log listing; zero values are ignored
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    uint
+	Actions    []string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Before     *time.Time // exclusive end, e.g. the day after a range of whole days
+}
+
+type securityEventRepository struct {
+	db *gorm.DB
+}
+
+func (r securityEventRepository) Create(ctx context.Context, event *models.SecurityEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r securityEventRepository) Find(ctx context.Context, filter SecurityEventFilter, page PageRequest) (Page[models.SecurityEvent], error) {
+	q := NewQueryBuilder(securityEventSortFields).
+		In("type", filter.Types).
+		Equal("user_id", filter.UserID).
+		Equal("ip", filter.IP)
+	return paginate[models.SecurityEvent](r.db.WithContext(ctx).Model(&models.SecurityEvent{}), q, page)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func (r auditLogRepository) Find(ctx context.Context, filter AuditFilter, page PageRequest) (Page[models.AuditLog], error) {
+	q := NewQueryBuilder(auditSortFields).
+		Equal("entity_type", filter.EntityType).
+		Equal("entity_id", filter.EntityID).
+		Equal("actor_id", filter.ActorID).
+		In("action", filter.Actions).
+		Equal("request_id", filter.RequestID).
+		Between("created_at", filter.From, filter.To).
+		Before("created_at", filter.Before)
+	return paginate[models.AuditLog](r.db.WithContext(ctx).Model(&models.AuditLog{}), q, page)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+)
+
+// SerialRepository stores the serial numbers of serial-controlled SKUs and
+// the history of each
+type SerialRepository interface {
+	Create(ctx context.Context, serial *models.SerialNumber) error
+	Save(ctx context.Context, serial *models.SerialNumber) error
+	// GetBySerial loads a serial number by its serial
+	GetBySerial(ctx context.Context, serial string) (*models.SerialNumber, error)
+	// ReservedFor lists the serial numbers picked for an order and not yet shipped
+	ReservedFor(ctx context.Context, orderID uint) ([]models.SerialNumber, error)
+	// PickedFor lists the serial numbers picked for an order, whether
+	// reserved or shipped
+	PickedFor(ctx context.Context, orderID uint) ([]models.SerialNumber, error)
+	// ListBySKU lists the serial numbers of a SKU, optionally only those with status
+	ListBySKU(ctx context.Context, skuID uint, status string) ([]models.SerialNumber, error)
+
+	CreateEvent(ctx context.Context, event *models.SerialEvent) error
+	// Events lists the history of a serial number, oldest first
+	Events(ctx context.Context, serialID uint) ([]models.SerialEvent, error)
+}
+
+// serialSortFields maps the sort keys of the serial number listing to columns
+var serialSortFields = map[string]string{
+	"serial": "serial",
+}
+
+type serialRepository struct {
+	db *gorm.DB
+}
+
+func (r serialRepository) Create(ctx context.Context, serial *models.SerialNumber) error {
+	return r.db.WithContext(ctx).Create(serial).Error
+}
+
+func (r serialRepository) Save(ctx context.Context, serial *models.SerialNumber) error {
+	return r.db.WithContext(ctx).Save(serial).Error
+}
+
+func (r serialRepository) GetBySerial(ctx context.Context, serial string) (*models.SerialNumber, error) {
+	var found models.SerialNumber
+	if err := r.db.WithContext(ctx).Where("serial = ?", serial).First(&found).Error; err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+func (r serialRepository) ReservedFor(ctx context.Context, orderID uint) ([]models.SerialNumber, error) {
+	var serials []models.SerialNumber
+	err := r.db.WithContext(ctx).Where("order_id = ? AND status = ?", orderID, models.SerialStatusReserved).
+		Order("serial").Find(&serials).Error
+	if err != nil {
+		return nil, err
+	}
+	return serials, nil
+}
+
+func (r serialRepository) PickedFor(ctx context.Context, orderID uint) ([]models.SerialNumber, error) {
+	var serials []models.SerialNumber
+	err := r.db.WithContext(ctx).Where("order_id = ? AND status IN ?", orderID, []string{models.SerialStatusReserved, models.SerialStatusShipped}).
+		Order("serial").Find(&serials).Error
+	if err != nil {
+		return nil, err
+	}
+	return serials, nil
+}
+
+func (r serialRepository) ListBySKU(ctx context.Context, skuID uint, status string) ([]models.SerialNumber, error) {
+	q := NewQueryBuilder(serialSortFields).
+		Equal("sku_id", skuID).
+		Equal("status", status).
+		SortBy(SortKey{Field: "serial"})
+	return findAll[models.SerialNumber](r.db.WithContext(ctx), q)
+}
+
+func (r serialRepository) CreateEvent(ctx context.Context, event *models.SerialEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r serialRepository) Events(ctx context.Context, serialID uint) ([]models.SerialEvent, error) {
+	var events []models.SerialEvent
+	if err := r.db.WithContext(ctx).Where("serial_id = ?", serialID).Order("id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
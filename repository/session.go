@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenState is what an access token is checked against on every request
+type TokenState struct {
+	TokenVersion int
+	RevokedAt    *time.Time
+}
+
+// SessionRepository stores sessions and the refresh tokens that renew them
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	Get(ctx context.Context, id string) (*models.Session, error)
+	// Touch writes a session's last use and expiry
+	Touch(ctx context.Context, session *models.Session) error
+	// Revoke ends a user's session if it is still active
+	Revoke(ctx context.Context, userID uint, sessionID string) error
+	// RevokeAll ends every active session of a user
+	RevokeAll(ctx context.Context, userID uint) error
+	// TokenState returns the token version of a session's user and when the
+	// session was revoked, or nil if the session or its user does not exist
+	TokenState(ctx context.Context, userID uint, sessionID string) (*TokenState, error)
+
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, id uint, at time.Time) error
+}
+
+// PasswordResetRepository stores password reset tokens
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	// VoidForUser uses up every outstanding reset token of a user
+	VoidForUser(ctx context.Context, userID uint, at time.Time) error
+	// LockByHash loads a reset token and locks it until the transaction ends
+	LockByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uint, at time.Time) error
+}
+
+// RecoveryCodeRepository stores MFA recovery codes
+type RecoveryCodeRepository interface {
+	Create(ctx context.Context, codes []models.RecoveryCode) error
+	// Use marks a user's unused code with the given hash used, and reports
+	// whether there was one
+	Use(ctx context.Context, userID uint, codeHash string, at time.Time) (bool, error)
+	DeleteForUser(ctx context.Context, userID uint) error
+}
+
+// LoginThrottleRepository stores failed login counts
+type LoginThrottleRepository interface {
+	// Get returns the throttle of a subject, or a zero one if it has none
+	Get(ctx context.Context, subject string) (*models.LoginThrottle, error)
+	// Lock loads the throttle of a subject, creating it if needed, and locks
+	// it until the transaction ends
+	Lock(ctx context.Context, subject string) (*models.LoginThrottle, error)
+	Save(ctx context.Context, throttle *models.LoginThrottle) error
+	Delete(ctx context.Context, subject string) error
+}
+
+// OIDCLoginRepository stores single sign-on logins in progress
+type OIDCLoginRepository interface {
+	Create(ctx context.Context, login *models.OIDCLogin) error
+	// Consume loads and deletes a login, so that its state works once
+	Consume(ctx context.Context, stateHash string) (*models.OIDCLogin, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func (r sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r sessionRepository) Get(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).First(&session, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r sessionRepository) Touch(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Model(session).Select("last_used_at", "expires_at").Updates(session).Error
+}
+
+func (r sessionRepository) Revoke(ctx context.Context, userID uint, sessionID string) error {
+	return r.revoke(r.db.WithContext(ctx).Where("id = ? AND user_id = ?", sessionID, userID))
+}
+
+func (r sessionRepository) RevokeAll(ctx context.Context, userID uint) error {
+	return r.revoke(r.db.WithContext(ctx).Where("user_id = ?", userID))
+}
+
+// revoke marks the still-active sessions matched by query as revoked
+func (sessionRepository) revoke(query *gorm.DB) error {
+	return query.Model(&models.Session{}).Where("revoked_at IS NULL").Update("revoked_at", time.Now()).Error
+}
+
+func (r sessionRepository) TokenState(ctx context.Context, userID uint, sessionID string) (*TokenState, error) {
+	var state TokenState
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Select("users.token_version, sessions.revoked_at").
+		Joins("JOIN users ON users.id = sessions.user_id AND users.deleted_at IS NULL").
+		Where("sessions.id = ? AND sessions.user_id = ?", sessionID, userID).
+		Scan(&state)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (r sessionRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r sessionRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r sessionRepository) UseRefreshToken(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).Where("id = ?", id).Update("used_at", at).Error
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func (r passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r passwordResetRepository) VoidForUser(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+func (r passwordResetRepository) LockByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r passwordResetRepository) MarkUsed(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).Where("id = ?", id).Update("used_at", at).Error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func (r recoveryCodeRepository) Create(ctx context.Context, codes []models.RecoveryCode) error {
+	return r.db.WithContext(ctx).Create(&codes).Error
+}
+
+func (r recoveryCodeRepository) Use(ctx context.Context, userID uint, codeHash string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r recoveryCodeRepository) DeleteForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func (r loginThrottleRepository) Get(ctx context.Context, subject string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	if err := r.db.WithContext(ctx).Where("subject = ?", subject).Limit(1).Find(&throttle).Error; err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r loginThrottleRepository) Lock(ctx context.Context, subject string) (*models.LoginThrottle, error) {
+	tx := r.db.WithContext(ctx)
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{Subject: subject}).Error; err != nil {
+		return nil, err
+	}
+
+	var throttle models.LoginThrottle
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("subject = ?", subject).First(&throttle).Error; err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r loginThrottleRepository) Save(ctx context.Context, throttle *models.LoginThrottle) error {
+	return r.db.WithContext(ctx).Save(throttle).Error
+}
+
+func (r loginThrottleRepository) Delete(ctx context.Context, subject string) error {
+	return r.db.WithContext(ctx).Delete(&models.LoginThrottle{}, "subject = ?", subject).Error
+}
+
+type oidcLoginRepository struct {
+	db *gorm.DB
+}
+
+func (r oidcLoginRepository) Create(ctx context.Context, login *models.OIDCLogin) error {
+	return r.db.WithContext(ctx).Create(login).Error
+}
+
+func (r oidcLoginRepository) Consume(ctx context.Context, stateHash string) (*models.OIDCLogin, error) {
+	var login models.OIDCLogin
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ?", stateHash).First(&login).Error; err != nil {
+			return err
+		}
+		result := tx.Where("state_hash = ?", login.StateHash).Delete(&models.OIDCLogin{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+func (r oidcLoginRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.OIDCLogin{}).Error
+}
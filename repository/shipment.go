@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+)
+
+// ShipmentRepository stores shipments
+type ShipmentRepository interface {
+	Create(ctx context.Context, shipment *models.Shipment) error
+	Get(ctx context.Context, id uint) (*models.Shipment, error)
+	GetByTrackingNumber(ctx context.Context, trackingNumber string) (*models.Shipment, error)
+	List(ctx context.Context, page PageRequest) (Page[models.Shipment], error)
+	Find(ctx context.Context, filter ShipmentFilter) ([]models.Shipment, error)
+	// Update writes every column of shipment if the stored shipment still
+	// has shipment.Version, which it then increments
+	Update(ctx context.Context, shipment *models.Shipment) error
+	// SetStatus changes the shipping status of a shipment
+	SetStatus(ctx context.Context, id uint, status string) error
+	// Delete deletes a shipment if it still has version
+	Delete(ctx context.Context, id uint, version int) error
+}
+
+// ShipmentFilter narrows the shipment finders; zero values are ignored
+type ShipmentFilter struct {
+	OrderID     uint
+	Status      string
+	ProductID   uint
+	Destination string
+	Origin      string
+	WarehouseID uint
+	Carrier     string
+}
+
+// shipmentSortFields maps the sort keys accepted by the shipment listing to columns
+var shipmentSortFields = map[string]string{
+	"id":              "id",
+	"created_at":      "created_at",
+	"order_id":        "order_id",
+	"carrier":         "carrier",
+	"shipping_status": "shipping_status",
+}
+
+type shipmentRepository struct {
+	db *gorm.DB
+}
+
+func (r shipmentRepository) Create(ctx context.Context, shipment *models.Shipment) error {
+	return r.db.WithContext(ctx).Create(shipment).Error
+}
+
+func (r shipmentRepository) Get(ctx context.Context, id uint) (*models.Shipment, error) {
+	var shipment models.Shipment
+	if err := r.db.WithContext(ctx).First(&shipment, id).Error; err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (r shipmentRepository) GetByTrackingNumber(ctx context.Context, trackingNumber string) (*models.Shipment, error) {
+	var shipment models.Shipment
+	if err := r.db.WithContext(ctx).Where("tracking_number = ?", trackingNumber).First(&shipment).Error; err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (r shipmentRepository) List(ctx context.Context, page PageRequest) (Page[models.Shipment], error) {
+	return paginate[models.Shipment](r.db.WithContext(ctx).Model(&models.Shipment{}), NewQueryBuilder(shipmentSortFields), page)
+}
+
+func (r shipmentRepository) Find(ctx context.Context, filter ShipmentFilter) ([]models.Shipment, error) {
+	q := NewQueryBuilder(shipmentSortFields).
+		Equal("order_id", filter.OrderID).
+		Equal("status", filter.Status).
+		Equal("product_id", filter.ProductID).
+		Equal("destination", filter.Destination).
+		Equal("origin", filter.Origin).
+		Equal("warehouse_id", filter.WarehouseID).
+		Equal("carrier", filter.Carrier)
+	return findAll[models.Shipment](r.db.WithContext(ctx), q)
+}
+
+func (r shipmentRepository) Update(ctx context.Context, shipment *models.Shipment) error {
+	return updateVersioned(r.db.WithContext(ctx), shipment, shipment.ID, &shipment.Version)
+}
+
+func (r shipmentRepository) SetStatus(ctx context.Context, id uint, status string) error {
+	return r.db.WithContext(ctx).Model(&models.Shipment{}).Where("id = ?", id).
+		Updates(bumpVersion(map[string]interface{}{"shipping_status": status})).Error
+}
+
+func (r shipmentRepository) Delete(ctx context.Context, id uint, version int) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Shipment{}, id, version)
+}
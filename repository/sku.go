@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SKURepository stores the sellable variants of the catalog's products
+type SKURepository interface {
+	Create(ctx context.Context, sku *models.SKU) error
+	// Get loads a SKU with its product and units
+	Get(ctx context.Context, id uint) (*models.SKU, error)
+	List(ctx context.Context, page PageRequest) (Page[models.SKU], error)
+	Find(ctx context.Context, filter SKUFilter) ([]models.SKU, error)
+	// Save writes the SKU's own columns; its product and units are left as they are
+	Save(ctx context.Context, sku *models.SKU) error
+	// SetUnits replaces the unit conversions of a SKU
+	SetUnits(ctx context.Context, id uint, units []models.SKUUnit) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// SKUFilter narrows the SKU finders; zero values and nil bounds are ignored.
+// Category is a category ID or path, matching SKUs of products anywhere in
+// its subtree. WarehouseID matches SKUs with stock in the warehouse and the
+// stock bounds apply to the on-hand quantity.
+type SKUFilter struct {
+	ProductID   uint
+	Category    string
+	WarehouseID uint
+	SupplierID  uint
+	MinStock    *int
+	MaxStock    *int
+	MinPrice    *float64
+	MaxPrice    *float64
+}
+
+// skuStock is the on-hand quantity of a SKU
+const skuStock = `(SELECT COALESCE(SUM(inventories.quantity), 0) FROM inventories
+	WHERE inventories.sku_id = skus.id AND inventories.deleted_at IS NULL)`
+
+// skuSortFields maps the sort keys accepted by the SKU listing to columns
+var skuSortFields = map[string]string{
+	"id":          "id",
+	"created_at":  "created_at",
+	"code":        "code",
+	"name":        "name",
+	"price":       "price",
+	"product_id":  "product_id",
+	"supplier_id": "supplier_id",
+}
+
+type skuRepository struct {
+	db *gorm.DB
+}
+
+func (r skuRepository) Create(ctx context.Context, sku *models.SKU) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(sku).Error
+}
+
+func (r skuRepository) Get(ctx context.Context, id uint) (*models.SKU, error) {
+	var sku models.SKU
+	if err := r.db.WithContext(ctx).Preload("Product").Preload("Units").First(&sku, id).Error; err != nil {
+		return nil, err
+	}
+	return &sku, nil
+}
+
+func (r skuRepository) List(ctx context.Context, page PageRequest) (Page[models.SKU], error) {
+	return paginate[models.SKU](r.db.WithContext(ctx).Model(&models.SKU{}), NewQueryBuilder(skuSortFields), page)
+}
+
+func (r skuRepository) Find(ctx context.Context, filter SKUFilter) ([]models.SKU, error) {
+	tx := r.db.WithContext(ctx)
+	q := NewQueryBuilder(skuSortFields).
+		Equal("product_id", filter.ProductID).
+		Equal("supplier_id", filter.SupplierID).
+		Between(skuStock, filter.MinStock, filter.MaxStock).
+		Between("price", filter.MinPrice, filter.MaxPrice)
+	if filter.Category != "" {
+		q.Where("product_id IN (?)", tx.Model(&models.Product{}).Select("id").Where("category_id IN (?)", categoryTree(tx, filter.Category)))
+	}
+	if filter.WarehouseID != 0 {
+		held := tx.Model(&models.StockBalance{}).Select("inventory_id").Where("warehouse_id = ? AND quantity <> 0", filter.WarehouseID)
+		q.Where("id IN (?)", tx.Model(&models.Inventory{}).Select("sku_id").Where("id IN (?)", held))
+	}
+	return findAll[models.SKU](tx, q)
+}
+
+func (r skuRepository) Save(ctx context.Context, sku *models.SKU) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(sku).Error
+}
+
+func (r skuRepository) SetUnits(ctx context.Context, id uint, units []models.SKUUnit) error {
+	tx := r.db.WithContext(ctx)
+	if err := tx.Where("sku_id = ?", id).Delete(&models.SKUUnit{}).Error; err != nil {
+		return err
+	}
+	for i := range units {
+		units[i].ID = 0
+		units[i].SKUID = id
+	}
+	if len(units) == 0 {
+		return nil
+	}
+	return tx.Create(&units).Error
+}
+
+func (r skuRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.SKU{}, id).Error
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SupplierRepository stores suppliers
+type SupplierRepository interface {
+	Create(ctx context.Context, supplier *models.Supplier) error
+	Get(ctx context.Context, id uint) (*models.Supplier, error)
+	List(ctx context.Context, page PageRequest) (Page[models.Supplier], error)
+	Find(ctx context.Context, filter SupplierFilter) ([]models.Supplier, error)
+	Save(ctx context.Context, supplier *models.Supplier) error
+	// SetCategories replaces the categories a supplier is assigned to
+	SetCategories(ctx context.Context, id uint, categoryIDs []uint) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// SupplierFilter narrows the supplier finders; zero values and a nil rating
+// are ignored. Category is a category ID or path and matches suppliers
+// assigned to anything in its subtree. ProductID matches the suppliers of
+// any of the product's SKUs.
+type SupplierFilter struct {
+	Category  string
+	ProductID uint
+	Location  string
+	Rating    *float32
+}
+
+// supplierSortFields maps the sort keys accepted by the supplier listing to columns.
+var supplierSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"name":       "name",
+}
+
+type supplierRepository struct {
+	db *gorm.DB
+}
+
+func (r supplierRepository) Create(ctx context.Context, supplier *models.Supplier) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(supplier).Error
+}
+
+func (r supplierRepository) Get(ctx context.Context, id uint) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := r.db.WithContext(ctx).Preload("Categories", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("path")
+	}).First(&supplier, id).Error; err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+func (r supplierRepository) List(ctx context.Context, page PageRequest) (Page[models.Supplier], error) {
+	return paginate[models.Supplier](r.db.WithContext(ctx).Model(&models.Supplier{}), NewQueryBuilder(supplierSortFields), page)
+}
+
+func (r supplierRepository) Find(ctx context.Context, filter SupplierFilter) ([]models.Supplier, error) {
+	tx := r.db.WithContext(ctx)
+	q := NewQueryBuilder(supplierSortFields).
+		Equal("location", filter.Location).
+		Equal("rating", filter.Rating)
+	if filter.Category != "" {
+		q.Where("id IN (?)", tx.Table("supplier_categories").Select("supplier_id").
+			Where("category_id IN (?)", categoryTree(tx, filter.Category)))
+	}
+	if filter.ProductID != 0 {
+		q.Where("id IN (?)", tx.Model(&models.SKU{}).Select("supplier_id").Where("product_id = ?", filter.ProductID))
+	}
+	return findAll[models.Supplier](tx, q)
+}
+
+func (r supplierRepository) Save(ctx context.Context, supplier *models.Supplier) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(supplier).Error
+}
+
+func (r supplierRepository) SetCategories(ctx context.Context, id uint, categoryIDs []uint) error {
+	tx := r.db.WithContext(ctx)
+	if err := tx.Exec("DELETE FROM supplier_categories WHERE supplier_id = ?", id).Error; err != nil {
+		return err
+	}
+	for _, categoryID := range categoryIDs {
+		err := tx.Exec("INSERT INTO supplier_categories (supplier_id, category_id) VALUES (?, ?)", id, categoryID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r supplierRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Supplier{}, id).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TransferRepository stores transfer orders and their lines
+type TransferRepository interface {
+	// Create stores a transfer order together with its lines
+	Create(ctx context.Context, transfer *models.TransferOrder) error
+	Get(ctx context.Context, id uint) (*models.TransferOrder, error)
+	// Lock loads a transfer order with its lines and locks the order row
+	// until the transaction ends
+	Lock(ctx context.Context, id uint) (*models.TransferOrder, error)
+	Find(ctx context.Context, filter TransferFilter, page PageRequest) (Page[models.TransferOrder], error)
+	// Update writes the named columns of a transfer order
+	Update(ctx context.Context, transfer *models.TransferOrder, columns ...string) error
+	// UpdateLine writes the named columns of a transfer order line
+	UpdateLine(ctx context.Context, line *models.TransferOrderLine, columns ...string) error
+}
+
+// transferSortFields maps the sort keys accepted by the transfer listing to columns
+var transferSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"status":     "status",
+}
+
+// TransferFilter narrows the transfer order listing; zero values are ignored
+type TransferFilter struct {
+	SourceWarehouseID      uint
+	DestinationWarehouseID uint
+	Statuses               []string
+}
+
+type transferRepository struct {
+	db *gorm.DB
+}
+
+func (r transferRepository) Create(ctx context.Context, transfer *models.TransferOrder) error {
+	return r.db.WithContext(ctx).Create(transfer).Error
+}
+
+func (r transferRepository) Get(ctx context.Context, id uint) (*models.TransferOrder, error) {
+	var transfer models.TransferOrder
+	if err := r.db.WithContext(ctx).Preload("Lines").First(&transfer, id).Error; err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r transferRepository) Lock(ctx context.Context, id uint) (*models.TransferOrder, error) {
+	tx := r.db.WithContext(ctx)
+
+	var transfer models.TransferOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, id).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("transfer_order_id = ?", transfer.ID).Order("id").Find(&transfer.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r transferRepository) Find(ctx context.Context, filter TransferFilter, page PageRequest) (Page[models.TransferOrder], error) {
+	q := NewQueryBuilder(transferSortFields).
+		Equal("source_warehouse_id", filter.SourceWarehouseID).
+		Equal("destination_warehouse_id", filter.DestinationWarehouseID).
+		In("status", filter.Statuses)
+	return paginate[models.TransferOrder](r.db.WithContext(ctx).Model(&models.TransferOrder{}).Preload("Lines"), q, page)
+}
+
+func (r transferRepository) Update(ctx context.Context, transfer *models.TransferOrder, columns ...string) error {
+	return r.db.WithContext(ctx).Model(transfer).Select(columns).Updates(transfer).Error
+}
+
+func (r transferRepository) UpdateLine(ctx context.Context, line *models.TransferOrderLine, columns ...string) error {
+	return r.db.WithContext(ctx).Model(line).Select(columns).Updates(line).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+)
+
+// UnitRepository stores the unit of measure catalog. Units are deleted
+// outright rather than soft-deleted, so their codes can be reused.
+type UnitRepository interface {
+	Create(ctx context.Context, unit *models.Unit) error
+	// All lists the catalog by dimension, then by size
+	All(ctx context.Context) ([]models.Unit, error)
+	GetByCode(ctx context.Context, code string) (*models.Unit, error)
+	// Conversion loads the factor a SKU gives a unit
+	Conversion(ctx context.Context, skuID uint, code string) (*models.SKUUnit, error)
+	// CountUses counts the SKUs that use a unit as their base unit or give it a factor
+	CountUses(ctx context.Context, code string) (int64, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type unitRepository struct {
+	db *gorm.DB
+}
+
+func (r unitRepository) Create(ctx context.Context, unit *models.Unit) error {
+	return r.db.WithContext(ctx).Create(unit).Error
+}
+
+func (r unitRepository) All(ctx context.Context) ([]models.Unit, error) {
+	var units []models.Unit
+	if err := r.db.WithContext(ctx).Order("dimension, ratio, code").Find(&units).Error; err != nil {
+		return nil, err
+	}
+	return units, nil
+}
+
+func (r unitRepository) GetByCode(ctx context.Context, code string) (*models.Unit, error) {
+	var unit models.Unit
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&unit).Error; err != nil {
+		return nil, err
+	}
+	return &unit, nil
+}
+
+func (r unitRepository) Conversion(ctx context.Context, skuID uint, code string) (*models.SKUUnit, error) {
+	var conversion models.SKUUnit
+	if err := r.db.WithContext(ctx).Where("sku_id = ? AND unit = ?", skuID, code).First(&conversion).Error; err != nil {
+		return nil, err
+	}
+	return &conversion, nil
+}
+
+func (r unitRepository) CountUses(ctx context.Context, code string) (int64, error) {
+	tx := r.db.WithContext(ctx)
+	var count int64
+	err := tx.Model(&models.SKU{}).
+		Where("base_unit = ? OR id IN (?)", code, tx.Model(&models.SKUUnit{}).Select("sku_id").Where("unit = ?", code)).
+		Count(&count).Error
+	return count, err
+}
+
+func (r unitRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&models.Unit{}, id).Error
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserRepository stores user accounts
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	Get(ctx context.Context, id uint) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// GetByExternalID finds the user linked to a single sign-on identity
+	GetByExternalID(ctx context.Context, externalID string) (*models.User, error)
+	// Lock loads a user and locks their row until the transaction ends
+	Lock(ctx context.Context, id uint) (*models.User, error)
+	List(ctx context.Context, page PageRequest) (Page[models.User], error)
+	// Save writes every column of user except those named in omit
+	Save(ctx context.Context, user *models.User, omit ...string) error
+	// Update sets the given columns of a user
+	Update(ctx context.Context, id uint, values map[string]interface{}) error
+	// BumpTokenVersion invalidates every access token issued to a user so far
+	BumpTokenVersion(ctx context.Context, id uint) error
+	// ClaimVerificationSend records a verification email sent at now unless
+	// one went out after notBefore, and reports whether it did
+	ClaimVerificationSend(ctx context.Context, id uint, now, notBefore time.Time) (bool, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// userSortFields maps the sort keys accepted by the user listing to columns
+var userSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"name":       "name",
+	"email":      "email",
+	"role":       "role",
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func (r userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r userRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r userRepository) GetByExternalID(ctx context.Context, externalID string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("external_id = ?", externalID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r userRepository) Lock(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r userRepository) List(ctx context.Context, page PageRequest) (Page[models.User], error) {
+	return paginate[models.User](r.db.WithContext(ctx).Model(&models.User{}), NewQueryBuilder(userSortFields), page)
+}
+
+func (r userRepository) Save(ctx context.Context, user *models.User, omit ...string) error {
+	tx := r.db.WithContext(ctx)
+	if len(omit) > 0 {
+		tx = tx.Omit(omit...)
+	}
+	return tx.Save(user).Error
+}
+
+func (r userRepository) Update(ctx context.Context, id uint, values map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(values).Error
+}
+
+func (r userRepository) BumpTokenVersion(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+func (r userRepository) ClaimVerificationSend(ctx context.Context, id uint, now, notBefore time.Time) (bool, error) {
+	// One statement, so concurrent requests cannot both pass
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND (verification_sent_at IS NULL OR verification_sent_at <= ?)", id, notBefore).
+		Update("verification_sent_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
+}
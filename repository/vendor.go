@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+)
+
+// VendorRepository stores vendors
+type VendorRepository interface {
+	Create(ctx context.Context, vendor *models.Vendor) error
+	Get(ctx context.Context, id uint) (*models.Vendor, error)
+	All(ctx context.Context) ([]models.Vendor, error)
+	// Update writes every column of vendor if the stored vendor still has
+	// vendor.Version, which it then increments
+	Update(ctx context.Context, vendor *models.Vendor) error
+	// Delete deletes a vendor if it still has version
+	Delete(ctx context.Context, id uint, version int) error
+}
+
+type vendorRepository struct {
+	db *gorm.DB
+}
+
+func (r vendorRepository) Create(ctx context.Context, vendor *models.Vendor) error {
+	return r.db.WithContext(ctx).Create(vendor).Error
+}
+
+func (r vendorRepository) Get(ctx context.Context, id uint) (*models.Vendor, error) {
+	var vendor models.Vendor
+	if err := r.db.WithContext(ctx).First(&vendor, id).Error; err != nil {
+		return nil, err
+	}
+	return &vendor, nil
+}
+
+func (r vendorRepository) All(ctx context.Context) ([]models.Vendor, error) {
+	var vendors []models.Vendor
+	if err := r.db.WithContext(ctx).Find(&vendors).Error; err != nil {
+		return nil, err
+	}
+	return vendors, nil
+}
+
+func (r vendorRepository) Update(ctx context.Context, vendor *models.Vendor) error {
+	return updateVersioned(r.db.WithContext(ctx), vendor, vendor.ID, &vendor.Version)
+}
+
+func (r vendorRepository) Delete(ctx context.Context, id uint, version int) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Vendor{}, id, version)
+}
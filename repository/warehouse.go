@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+
+	"gorm.io/gorm"
+)
+
+// WarehouseRepository stores warehouses and their zones, aisles and bins.
+// Deleting a location deletes everything inside it; callers check first
+// that none of it holds stock.
+type WarehouseRepository interface {
+	Create(ctx context.Context, warehouse *models.Warehouse) error
+	Get(ctx context.Context, id uint) (*models.Warehouse, error)
+	// GetWithLocations loads a warehouse with its zones, aisles and bins
+	GetWithLocations(ctx context.Context, id uint) (*models.Warehouse, error)
+	List(ctx context.Context, page PageRequest) (Page[models.Warehouse], error)
+	// Save writes a warehouse's own fields, leaving its locations alone
+	Save(ctx context.Context, warehouse *models.Warehouse) error
+	Delete(ctx context.Context, id uint) error
+
+	CreateZone(ctx context.Context, zone *models.Zone) error
+	Zones(ctx context.Context, warehouseID uint) ([]models.Zone, error)
+	GetZone(ctx context.Context, warehouseID, zoneID uint) (*models.Zone, error)
+	// ZoneOfAisle returns the zone an aisle belongs to
+	ZoneOfAisle(ctx context.Context, aisleID uint) (*models.Zone, error)
+	UpdateZone(ctx context.Context, zone *models.Zone) error
+	DeleteZone(ctx context.Context, zone *models.Zone) error
+
+	CreateAisle(ctx context.Context, aisle *models.Aisle) error
+	Aisles(ctx context.Context, zoneID uint) ([]models.Aisle, error)
+	GetAisle(ctx context.Context, zoneID, aisleID uint) (*models.Aisle, error)
+	UpdateAisle(ctx context.Context, aisle *models.Aisle) error
+	DeleteAisle(ctx context.Context, aisle *models.Aisle) error
+
+	CreateBin(ctx context.Context, bin *models.Bin) error
+	Bins(ctx context.Context, aisleID uint) ([]models.Bin, error)
+	GetBin(ctx context.Context, aisleID, binID uint) (*models.Bin, error)
+	// BinByID loads a bin without checking which aisle it is in
+	BinByID(ctx context.Context, id uint) (*models.Bin, error)
+	UpdateBin(ctx context.Context, bin *models.Bin) error
+	DeleteBin(ctx context.Context, bin *models.Bin) error
+}
+
+// warehouseSortFields maps the sort keys accepted by the warehouse listing to columns
+var warehouseSortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+	"code":       "code",
+	"name":       "name",
+}
+
+type warehouseRepository struct {
+	db *gorm.DB
+}
+
+func (r warehouseRepository) Create(ctx context.Context, warehouse *models.Warehouse) error {
+	return r.db.WithContext(ctx).Create(warehouse).Error
+}
+
+func (r warehouseRepository) Get(ctx context.Context, id uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := r.db.WithContext(ctx).First(&warehouse, id).Error; err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func (r warehouseRepository) GetWithLocations(ctx context.Context, id uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := r.db.WithContext(ctx).Preload("Zones.Aisles.Bins").First(&warehouse, id).Error; err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func (r warehouseRepository) List(ctx context.Context, page PageRequest) (Page[models.Warehouse], error) {
+	return paginate[models.Warehouse](r.db.WithContext(ctx).Model(&models.Warehouse{}), NewQueryBuilder(warehouseSortFields), page)
+}
+
+func (r warehouseRepository) Save(ctx context.Context, warehouse *models.Warehouse) error {
+	return r.db.WithContext(ctx).Omit("Zones").Save(warehouse).Error
+}
+
+func (r warehouseRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		zones := tx.Model(&models.Zone{}).Select("id").Where("warehouse_id = ?", id)
+		aisles := tx.Model(&models.Aisle{}).Select("id").Where("zone_id IN (?)", zones)
+		if err := tx.Where("warehouse_id = ?", id).Delete(&models.Bin{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN (?)", aisles).Delete(&models.Aisle{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("warehouse_id = ?", id).Delete(&models.Zone{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Warehouse{}, id).Error
+	})
+}
+
+func (r warehouseRepository) CreateZone(ctx context.Context, zone *models.Zone) error {
+	return r.db.WithContext(ctx).Create(zone).Error
+}
+
+func (r warehouseRepository) Zones(ctx context.Context, warehouseID uint) ([]models.Zone, error) {
+	var zones []models.Zone
+	err := r.db.WithContext(ctx).Where("warehouse_id = ?", warehouseID).Order("code").Find(&zones).Error
+	return zones, err
+}
+
+func (r warehouseRepository) GetZone(ctx context.Context, warehouseID, zoneID uint) (*models.Zone, error) {
+	var zone models.Zone
+	if err := r.db.WithContext(ctx).Where("warehouse_id = ?", warehouseID).First(&zone, zoneID).Error; err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (r warehouseRepository) ZoneOfAisle(ctx context.Context, aisleID uint) (*models.Zone, error) {
+	var zone models.Zone
+	err := r.db.WithContext(ctx).Joins("JOIN aisles ON aisles.zone_id = zones.id").Where("aisles.id = ?", aisleID).First(&zone).Error
+	if err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (r warehouseRepository) UpdateZone(ctx context.Context, zone *models.Zone) error {
+	return r.db.WithContext(ctx).Model(zone).Select("code", "name").Updates(zone).Error
+}
+
+func (r warehouseRepository) DeleteZone(ctx context.Context, zone *models.Zone) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		aisles := tx.Model(&models.Aisle{}).Select("id").Where("zone_id = ?", zone.ID)
+		if err := tx.Where("aisle_id IN (?)", aisles).Delete(&models.Bin{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("zone_id = ?", zone.ID).Delete(&models.Aisle{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(zone).Error
+	})
+}
+
+func (r warehouseRepository) CreateAisle(ctx context.Context, aisle *models.Aisle) error {
+	return r.db.WithContext(ctx).Create(aisle).Error
+}
+
+func (r warehouseRepository) Aisles(ctx context.Context, zoneID uint) ([]models.Aisle, error) {
+	var aisles []models.Aisle
+	err := r.db.WithContext(ctx).Where("zone_id = ?", zoneID).Order("code").Find(&aisles).Error
+	return aisles, err
+}
+
+func (r warehouseRepository) GetAisle(ctx context.Context, zoneID, aisleID uint) (*models.Aisle, error) {
+	var aisle models.Aisle
+	if err := r.db.WithContext(ctx).Where("zone_id = ?", zoneID).First(&aisle, aisleID).Error; err != nil {
+		return nil, err
+	}
+	return &aisle, nil
+}
+
+func (r warehouseRepository) UpdateAisle(ctx context.Context, aisle *models.Aisle) error {
+	return r.db.WithContext(ctx).Model(aisle).Select("code", "name").Updates(aisle).Error
+}
+
+func (r warehouseRepository) DeleteAisle(ctx context.Context, aisle *models.Aisle) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("aisle_id = ?", aisle.ID).Delete(&models.Bin{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(aisle).Error
+	})
+}
+
+func (r warehouseRepository) CreateBin(ctx context.Context, bin *models.Bin) error {
+	return r.db.WithContext(ctx).Create(bin).Error
+}
+
+func (r warehouseRepository) Bins(ctx context.Context, aisleID uint) ([]models.Bin, error) {
+	var bins []models.Bin
+	err := r.db.WithContext(ctx).Where("aisle_id = ?", aisleID).Order("code").Find(&bins).Error
+	return bins, err
+}
+
+func (r warehouseRepository) GetBin(ctx context.Context, aisleID, binID uint) (*models.Bin, error) {
+	var bin models.Bin
+	if err := r.db.WithContext(ctx).Where("aisle_id = ?", aisleID).First(&bin, binID).Error; err != nil {
+		return nil, err
+	}
+	return &bin, nil
+}
+
+func (r warehouseRepository) BinByID(ctx context.Context, id uint) (*models.Bin, error) {
+	var bin models.Bin
+	if err := r.db.WithContext(ctx).First(&bin, id).Error; err != nil {
+		return nil, err
+	}
+	return &bin, nil
+}
+
+func (r warehouseRepository) UpdateBin(ctx context.Context, bin *models.Bin) error {
+	return r.db.WithContext(ctx).Model(bin).Select("code").Updates(bin).Error
+}
+
+func (r warehouseRepository) DeleteBin(ctx context.Context, bin *models.Bin) error {
+	return r.db.WithContext(ctx).Delete(bin).Error
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterAPIKeyRoutes defines the routes administrators use to issue and
+// revoke API keys for integrations
+func RegisterAPIKeyRoutes(r *mux.Router, c *controllers.APIKeyController) {
+	api := r.PathPrefix("/api-keys").Subrouter()
+
+	api.Handle("", require(authz.APIKeysManage, c.CreateAPIKey)).Methods("POST")
+	api.Handle("", require(authz.APIKeysManage, c.ListAPIKeys)).Methods("GET")
+	api.Handle("/{id:[0-9]+}", require(authz.APIKeysManage, c.GetAPIKey)).Methods("GET")
+	api.Handle("/{id:[0-9]+}", require(authz.APIKeysManage, c.RevokeAPIKey)).Methods("DELETE")
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterAuditRoutes defines the routes for reviewing the audit log
+func RegisterAuditRoutes(r *mux.Router, c *controllers.AuditController) {
+	r.Handle("/audit", require(authz.AuditRead, c.ListAuditLogs)).Methods("GET")
+}
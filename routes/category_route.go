@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterCategoryRoutes registers the product category tree routes with the router
+func RegisterCategoryRoutes(router *mux.Router, c *controllers.CategoryController) {
+	router.Handle("/categories", require(authz.CatalogWrite, c.CreateCategory)).Methods("POST")
+	router.Handle("/categories", require(authz.CatalogRead, c.GetCategoryTree)).Methods("GET")
+	router.Handle("/categories/{id:[0-9]+}", require(authz.CatalogRead, c.GetCategoryByID)).Methods("GET")
+	router.Handle("/categories/{id:[0-9]+}", require(authz.CatalogWrite, c.UpdateCategory)).Methods("PUT")
+	router.Handle("/categories/{id:[0-9]+}", require(authz.CatalogWrite, c.DeleteCategory)).Methods("DELETE")
+	router.Handle("/categories/{id:[0-9]+}/move", require(authz.CatalogWrite, c.MoveCategory)).Methods("POST")
+	router.Handle("/categories/{id:[0-9]+}/merge", require(authz.CatalogWrite, c.MergeCategory)).Methods("POST")
+}
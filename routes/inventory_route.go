@@ -2,21 +2,28 @@ package routes
 
 import (
 	"inventory-supply-chain-system/controllers"
-	"inventory-supply-chain-system/internal/middlewares"
+	"inventory-supply-chain-system/internal/authz"
 
 	"github.com/gorilla/mux"
 )
 
 // InventoryRoutes defines the inventory-related routes
-func RegisterInventoryRoutes(r *mux.Router) {
-	api := r.PathPrefix("/api/inventory").Subrouter()
-	api.Use(middlewares.AuthMiddleware)
+func RegisterInventoryRoutes(r *mux.Router, c *controllers.InventoryController) {
+	api := r.PathPrefix("/inventory").Subrouter()
 
-	api.HandleFunc("", controllers.CreateInventory).Methods("POST")
-	api.HandleFunc("", controllers.GetInventoryItems).Methods("GET")
-	api.HandleFunc("/{id}", controllers.GetInventory).Methods("GET")
-	api.HandleFunc("/{id}", controllers.UpdateInventory).Methods("PUT")
-	api.HandleFunc("/{id}", controllers.DeleteInventory).Methods("DELETE")
-	api.HandleFunc("/product/{productID}", controllers.GetInventoryByProductID).Methods("GET")
-	api.HandleFunc("/warehouse/{warehouseID}", controllers.GetInventoryByWarehouseID).Methods("GET")
+	api.Handle("", require(authz.InventoryWrite, c.CreateInventory)).Methods("POST")
+	api.Handle("", require(authz.InventoryRead, c.GetInventoryItems)).Methods("GET")
+	api.Handle("/expiring", require(authz.InventoryRead, c.GetExpiringStock)).Methods("GET")
+	api.Handle("/{id}", require(authz.InventoryRead, c.GetInventory)).Methods("GET")
+	api.Handle("/{id}", require(authz.InventoryWrite, c.UpdateInventory)).Methods("PUT")
+	api.Handle("/{id}", require(authz.InventoryWrite, c.DeleteInventory)).Methods("DELETE")
+	api.Handle("/product/{productID}", require(authz.InventoryRead, c.GetInventoryByProductID)).Methods("GET")
+	api.Handle("/warehouse/{warehouseID}", require(authz.InventoryRead, c.GetInventoryByWarehouseID)).Methods("GET")
+
+	// Stock ledger
+	api.Handle("/{id}/movements", require(authz.InventoryWrite, c.RecordStockMovement)).Methods("POST")
+	api.Handle("/sku/{sku}/movements", require(authz.InventoryRead, c.GetStockMovementsBySKU)).Methods("GET")
+	api.Handle("/{id}/reconciliation", require(authz.InventoryRead, c.GetInventoryReconciliation)).Methods("GET")
+	api.Handle("/{id}/reconcile", require(authz.InventoryWrite, c.ReconcileInventory)).Methods("POST")
+	api.Handle("/{id}/locations", require(authz.InventoryRead, c.GetInventoryLocations)).Methods("GET")
 }
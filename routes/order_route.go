@@ -2,42 +2,41 @@ package routes
 
 import (
 	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
 
 	"github.com/gorilla/mux"
 )
 
 // RegisterOrderRoutes registers order-related routes with the router
-func RegisterOrderRoutes(router *mux.Router) {
+func RegisterOrderRoutes(router *mux.Router, c *controllers.OrderController) {
 	// Order CRUD operations
-	router.HandleFunc("/orders", controllers.CreateOrder).Methods("POST")
-	router.HandleFunc("/orders", controllers.GetAllOrdersHandler).Methods("GET")
-	router.HandleFunc("/orders/{id:[0-9]+}", controllers.GetOrder).Methods("GET")
-	router.HandleFunc("/orders/{id:[0-9]+}", controllers.UpdateOrder).Methods("PUT")
-	router.HandleFunc("/orders/{id:[0-9]+}", controllers.DeleteOrder).Methods("DELETE")
-
-	// Order filters based on different attributes
-	router.HandleFunc("/orders/customer/{customerID}", controllers.GetOrdersByCustomerIDHandler).Methods("GET")
-	router.HandleFunc("/orders/vendor/{vendorID}", controllers.GetOrdersByVendorIDHandler).Methods("GET")
-	router.HandleFunc("/orders/product/{productID}", controllers.GetOrdersByProductIDHandler).Methods("GET")
-	router.HandleFunc("/orders/shipment/{shipmentID}", controllers.GetOrdersByShipmentIDHandler).Methods("GET")
-	router.HandleFunc("/orders/status/{status}", controllers.GetOrdersByStatusHandler).Methods("GET")
-	router.HandleFunc("/orders/date-range", controllers.GetOrdersByDateRangeHandler).Methods("GET")
-
-	// Combined filters
-	router.HandleFunc("/orders/customer/{customerID}/status/{status}", controllers.GetOrdersByCustomerIDAndStatusHandler).Methods("GET")
-	router.HandleFunc("/orders/vendor/{vendorID}/status/{status}", controllers.GetOrdersByVendorIDAndStatusHandler).Methods("GET")
-	router.HandleFunc("/orders/product/{productID}/status/{status}", controllers.GetOrdersByProductIDAndStatusHandler).Methods("GET")
-
-	router.HandleFunc("/orders/vendor/{vendorID}/product/{productID}", controllers.GetOrdersByVendorIDAndProductIDHandler).Methods("GET")
-	router.HandleFunc("/orders/vendor/{vendorID}/shipment/{shipmentID}", controllers.GetOrdersByVendorIDAndShipmentIDHandler).Methods("GET")
-	router.HandleFunc("/orders/product/{productID}/shipment/{shipmentID}", controllers.GetOrdersByProductIDAndShipmentIDHandler).Methods("GET")
-
-	// Complex combined filters
-	router.HandleFunc("/orders/customer/{customerID}/product/{productID}/shipment/{shipmentID}", controllers.GetOrdersByCustomerIDAndProductIDAndShipmentIDHandler).Methods("GET")
-	router.HandleFunc("/orders/vendor/{vendorID}/product/{productID}/shipment/{shipmentID}", controllers.GetOrdersByVendorIDAndProductIDAndShipmentIDHandler).Methods("GET")
-	router.HandleFunc("/orders/customer/{customerID}/vendor/{vendorID}/product/{productID}/shipment/{shipmentID}", controllers.GetOrdersByCustomerIDAndVendorIDAndProductIDAndShipmentIDHandler).Methods("GET")
-
-	// Filters based on status and date range
-	router.HandleFunc("/orders/status/{status}/date-range", controllers.GetOrdersByStatusAndDateRangeHandler).Methods("GET")
-	router.HandleFunc("/orders/customer/{customerID}/status/{status}/date-range", controllers.GetOrdersByCustomerIDAndStatusAndDateRangeHandler).Methods("GET")
+	router.Handle("/orders", require(authz.OrdersWrite, c.CreateOrder)).Methods("POST")
+	router.Handle("/orders", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/{id:[0-9]+}", require(authz.OrdersRead, c.GetOrder)).Methods("GET")
+	router.Handle("/orders/{id:[0-9]+}", require(authz.OrdersWrite, c.UpdateOrder)).Methods("PUT")
+	router.Handle("/orders/{id:[0-9]+}", require(authz.OrdersWrite, c.DeleteOrder)).Methods("DELETE")
+
+	// Filter aliases kept for existing clients. Path variables use the same
+	// names as the query parameters of GET /orders and can be combined with them.
+	router.Handle("/orders/customer/{customer_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/vendor/{vendor_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/product/{product_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/shipment/{shipment_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/status/{status}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/date-range", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+
+	router.Handle("/orders/customer/{customer_id}/status/{status}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/vendor/{vendor_id}/status/{status}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/product/{product_id}/status/{status}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+
+	router.Handle("/orders/vendor/{vendor_id}/product/{product_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/vendor/{vendor_id}/shipment/{shipment_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/product/{product_id}/shipment/{shipment_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+
+	router.Handle("/orders/customer/{customer_id}/product/{product_id}/shipment/{shipment_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/vendor/{vendor_id}/product/{product_id}/shipment/{shipment_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/customer/{customer_id}/vendor/{vendor_id}/product/{product_id}/shipment/{shipment_id}", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+
+	router.Handle("/orders/status/{status}/date-range", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
+	router.Handle("/orders/customer/{customer_id}/status/{status}/date-range", require(authz.OrdersRead, c.ListOrdersHandler)).Methods("GET")
 }
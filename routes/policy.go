@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"inventory-supply-chain-system/internal/middlewares"
+
+	"github.com/gorilla/mux"
+)
+
+// policyHandler is a handler guarded by the permission it requires. Keeping
+// the permission on the handler lets VerifyPolicies and RoutePolicies read
+// each route's policy back from the router.
+type policyHandler struct {
+	permission string
+	http.Handler
+}
+
+// require guards a handler with a permission from the authz package
+func require(permission string, handler http.HandlerFunc) http.Handler {
+	return &policyHandler{
+		permission: permission,
+		Handler:    middlewares.RequirePermission(permission)(handler),
+	}
+}
+
+// authenticated marks a handler that any signed-in user may call
+func authenticated(handler http.HandlerFunc) http.Handler {
+	return require("", handler)
+}
+
+// RoutePolicies returns the permission required by every route of router,
+// keyed by "METHOD path". Routes that only need a signed-in user map to "".
+func RoutePolicies(router *mux.Router) (map[string]string, error) {
+	policies := make(map[string]string)
+	var unguarded []string
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		handler := route.GetHandler()
+		if handler == nil {
+			return nil // subrouter prefix
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"ANY"}
+		}
+
+		policy, ok := handler.(*policyHandler)
+		for _, method := range methods {
+			key := method + " " + path
+			if !ok {
+				unguarded = append(unguarded, key)
+				continue
+			}
+			policies[key] = policy.permission
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(unguarded) > 0 {
+		return policies, fmt.Errorf("routes without an authorization policy: %s", strings.Join(unguarded, ", "))
+	}
+	return policies, nil
+}
+
+// VerifyPolicies fails if any route of router was registered without a policy
+func VerifyPolicies(router *mux.Router) error {
+	_, err := RoutePolicies(router)
+	return err
+}
@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestRoutePoliciesReadsEachRoutesPermission(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/orders", require(authz.OrdersRead, ok)).Methods("GET")
+	router.Handle("/orders", require(authz.OrdersWrite, ok)).Methods("POST", "PUT")
+	router.Handle("/profile", authenticated(ok)).Methods("GET")
+
+	policies, err := RoutePolicies(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"GET /orders":  authz.OrdersRead,
+		"POST /orders": authz.OrdersWrite,
+		"PUT /orders":  authz.OrdersWrite,
+		"GET /profile": "",
+	}
+	if len(policies) != len(want) {
+		t.Fatalf("policies = %v, want %v", policies, want)
+	}
+	for key, permission := range want {
+		if got, ok := policies[key]; !ok || got != permission {
+			t.Errorf("policies[%q] = %q, %v; want %q", key, got, ok, permission)
+		}
+	}
+	if err := VerifyPolicies(router); err != nil {
+		t.Fatalf("VerifyPolicies = %v", err)
+	}
+}
+
+func TestVerifyPoliciesRejectsUnguardedRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/orders", require(authz.OrdersRead, ok)).Methods("GET")
+	router.HandleFunc("/orders", ok).Methods("DELETE")
+	router.HandleFunc("/anything", ok)
+
+	err := VerifyPolicies(router)
+	if err == nil {
+		t.Fatal("VerifyPolicies accepted unguarded routes")
+	}
+	for _, key := range []string{"DELETE /orders", "ANY /anything"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q does not name %s", err, key)
+		}
+	}
+}
+
+func TestRequireChecksTheRequestsPermissions(t *testing.T) {
+	handler := require(authz.OrdersWrite, ok)
+	tests := []struct {
+		name   string
+		values map[string]any
+		want   int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"without the permission", map[string]any{"userID": uint(1), "permissions": []string{authz.OrdersRead}}, http.StatusForbidden},
+		{"with the permission", map[string]any{"userID": uint(1), "permissions": []string{authz.OrdersWrite}}, http.StatusNoContent},
+		{"admin", map[string]any{"userID": uint(1), "permissions": []string{authz.All}}, http.StatusNoContent},
+		{"API key with the permission", map[string]any{"userID": uint(1), "apiKeyID": uint(2), "permissions": []string{authz.OrdersWrite}}, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if got := serve(handler, tt.values); got != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticatedRoutesAreClosedToAPIKeys(t *testing.T) {
+	handler := authenticated(ok)
+	if got := serve(handler, map[string]any{"userID": uint(1)}); got != http.StatusNoContent {
+		t.Errorf("user session: status %d, want 204", got)
+	}
+	if got := serve(handler, map[string]any{"userID": uint(1), "apiKeyID": uint(2), "permissions": []string{authz.All}}); got != http.StatusForbidden {
+		t.Errorf("API key: status %d, want 403", got)
+	}
+}
+
+// serve calls handler with values in the request context, as AuthMiddleware
+// would store them, and returns the response status
+func serve(handler http.Handler, values map[string]any) int {
+	req := httptest.NewRequest("GET", "/", nil)
+	ctx := req.Context()
+	for key, value := range values {
+		ctx = context.WithValue(ctx, key, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req.WithContext(ctx))
+	return rec.Code
+}
@@ -2,26 +2,27 @@ package routes
 
 import (
 	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
 
 	"github.com/gorilla/mux"
 )
 
 // RegisterProductRoutes registers product-related routes with the router
-func RegisterProductRoutes(router *mux.Router) {
-	router.HandleFunc("/products", controllers.CreateProductHandler).Methods("POST")
-	router.HandleFunc("/products", controllers.GetProductsHandler).Methods("GET")
-	router.HandleFunc("/products/{id:[0-9]+}", controllers.GetProductByIDHandler).Methods("GET")
-	router.HandleFunc("/products/{id:[0-9]+}", controllers.UpdateProductHandler).Methods("PUT")
-	router.HandleFunc("/products/{id:[0-9]+}", controllers.DeleteProductHandler).Methods("DELETE")
-	router.HandleFunc("/products/category/{category}", controllers.GetProductsByCategoryHandler).Methods("GET")
-	router.HandleFunc("/products/price-range", controllers.GetProductsByPriceRangeHandler).Methods("GET")
-	router.HandleFunc("/products/stock/{stock:[0-9]+}", controllers.GetProductsByStockHandler).Methods("GET")
-	router.HandleFunc("/products/stock-range", controllers.GetProductsByStockRangeHandler).Methods("GET")
-	router.HandleFunc("/products/category/{category}/price-range", controllers.GetProductsByCategoryAndPriceRangeHandler).Methods("GET")
-	router.HandleFunc("/products/category/{category}/stock/{stock:[0-9]+}", controllers.GetProductsByCategoryAndStockHandler).Methods("GET")
-	router.HandleFunc("/products/category/{category}/stock-range", controllers.GetProductsByCategoryAndStockRangeHandler).Methods("GET")
-	router.HandleFunc("/products/price-stock", controllers.GetProductsByPriceRangeAndStockHandler).Methods("GET")
-	router.HandleFunc("/products/price-stock-range", controllers.GetProductsByPriceRangeAndStockRangeHandler).Methods("GET")
-	router.HandleFunc("/products/category-price-stock", controllers.GetProductsByCategoryPriceRangeAndStockHandler).Methods("GET")
-	router.HandleFunc("/products/category-price-stock-range", controllers.GetProductsByCategoryPriceRangeAndStockRangeHandler).Methods("GET")
+func RegisterProductRoutes(router *mux.Router, c *controllers.ProductController) {
+	router.Handle("/products", require(authz.CatalogWrite, c.CreateProductHandler)).Methods("POST")
+	router.Handle("/products", require(authz.CatalogRead, c.GetProductsHandler)).Methods("GET")
+	router.Handle("/products/{id:[0-9]+}", require(authz.CatalogRead, c.GetProductByIDHandler)).Methods("GET")
+	router.Handle("/products/{id:[0-9]+}", require(authz.CatalogWrite, c.UpdateProductHandler)).Methods("PUT")
+	router.Handle("/products/{id:[0-9]+}", require(authz.CatalogWrite, c.DeleteProductHandler)).Methods("DELETE")
+	router.Handle("/products/category/{category}", require(authz.CatalogRead, c.GetProductsByCategoryHandler)).Methods("GET")
+	router.Handle("/products/price-range", require(authz.CatalogRead, c.GetProductsByPriceRangeHandler)).Methods("GET")
+	router.Handle("/products/stock/{stock:[0-9]+}", require(authz.CatalogRead, c.GetProductsByStockHandler)).Methods("GET")
+	router.Handle("/products/stock-range", require(authz.CatalogRead, c.GetProductsByStockRangeHandler)).Methods("GET")
+	router.Handle("/products/category/{category}/price-range", require(authz.CatalogRead, c.GetProductsByCategoryAndPriceRangeHandler)).Methods("GET")
+	router.Handle("/products/category/{category}/stock/{stock:[0-9]+}", require(authz.CatalogRead, c.GetProductsByCategoryAndStockHandler)).Methods("GET")
+	router.Handle("/products/category/{category}/stock-range", require(authz.CatalogRead, c.GetProductsByCategoryAndStockRangeHandler)).Methods("GET")
+	router.Handle("/products/price-stock", require(authz.CatalogRead, c.GetProductsByPriceRangeAndStockHandler)).Methods("GET")
+	router.Handle("/products/price-stock-range", require(authz.CatalogRead, c.GetProductsByPriceRangeAndStockRangeHandler)).Methods("GET")
+	router.Handle("/products/category-price-stock", require(authz.CatalogRead, c.GetProductsByCategoryPriceRangeAndStockHandler)).Methods("GET")
+	router.Handle("/products/category-price-stock-range", require(authz.CatalogRead, c.GetProductsByCategoryPriceRangeAndStockRangeHandler)).Methods("GET")
 }
@@ -7,8 +7,8 @@ import (
 )
 
 // RegisterProfileRoutes registers routes for profile-related operations
-func RegisterProfileRoutes(router *mux.Router) {
-	router.HandleFunc("/profile", controllers.GetProfileHandler).Methods("GET")
-	router.HandleFunc("/profile", controllers.UpdateProfileHandler).Methods("PUT")
-	router.HandleFunc("/profile/password", controllers.UpdatePasswordHandler).Methods("PUT")
+func RegisterProfileRoutes(router *mux.Router, c *controllers.ProfileController) {
+	router.Handle("/profile", authenticated(c.GetProfileHandler)).Methods("GET")
+	router.Handle("/profile", authenticated(c.UpdateProfileHandler)).Methods("PUT")
+	router.Handle("/profile/password", authenticated(c.UpdatePasswordHandler)).Methods("PUT")
 }
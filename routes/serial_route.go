@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterSerialRoutes registers the serial number trace route with the router
+func RegisterSerialRoutes(router *mux.Router, c *controllers.SerialController) {
+	router.Handle("/serials/{serial}", require(authz.InventoryRead, c.GetSerialTrace)).Methods("GET")
+}
@@ -2,42 +2,43 @@ package routes
 
 import (
 	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
 
 	"github.com/gorilla/mux"
 )
 
 // RegisterShipmentRoutes registers shipment-related routes with the router
-func RegisterShipmentRoutes(router *mux.Router) {
+func RegisterShipmentRoutes(router *mux.Router, c *controllers.ShipmentController) {
 	// Shipment CRUD operations
-	router.HandleFunc("/shipments", controllers.CreateShipment).Methods("POST")
-	router.HandleFunc("/shipments", controllers.GetShipments).Methods("GET")
-	router.HandleFunc("/shipments/{id:[0-9]+}", controllers.GetShipmentByID).Methods("GET")
-	router.HandleFunc("/shipments/{id:[0-9]+}", controllers.UpdateShipment).Methods("PUT")
-	router.HandleFunc("/shipments/{id:[0-9]+}", controllers.DeleteShipment).Methods("DELETE")
+	router.Handle("/shipments", require(authz.ShipmentsWrite, c.CreateShipment)).Methods("POST")
+	router.Handle("/shipments", require(authz.ShipmentsRead, c.GetShipments)).Methods("GET")
+	router.Handle("/shipments/{id:[0-9]+}", require(authz.ShipmentsRead, c.GetShipmentByID)).Methods("GET")
+	router.Handle("/shipments/{id:[0-9]+}", require(authz.ShipmentsWrite, c.UpdateShipment)).Methods("PUT")
+	router.Handle("/shipments/{id:[0-9]+}", require(authz.ShipmentsWrite, c.DeleteShipment)).Methods("DELETE")
 
 	// Shipment filters based on attributes
-	router.HandleFunc("/shipments/status", controllers.GetShipmentsByStatus).Methods("GET")
-	router.HandleFunc("/shipments/product", controllers.GetShipmentsByProductID).Methods("GET")
-	router.HandleFunc("/shipments/destination", controllers.GetShipmentsByDestination).Methods("GET")
-	router.HandleFunc("/shipments/origin", controllers.GetShipmentsByOrigin).Methods("GET")
-	router.HandleFunc("/shipments/warehouse", controllers.GetShipmentsByWarehouseID).Methods("GET")
-	router.HandleFunc("/shipments/carrier", controllers.GetShipmentsByCarrier).Methods("GET")
-	router.HandleFunc("/shipments/tracking", controllers.GetShipmentsByTrackingNumber).Methods("GET")
+	router.Handle("/shipments/status", require(authz.ShipmentsRead, c.GetShipmentsByStatus)).Methods("GET")
+	router.Handle("/shipments/product", require(authz.ShipmentsRead, c.GetShipmentsByProductID)).Methods("GET")
+	router.Handle("/shipments/destination", require(authz.ShipmentsRead, c.GetShipmentsByDestination)).Methods("GET")
+	router.Handle("/shipments/origin", require(authz.ShipmentsRead, c.GetShipmentsByOrigin)).Methods("GET")
+	router.Handle("/shipments/warehouse", require(authz.ShipmentsRead, c.GetShipmentsByWarehouseID)).Methods("GET")
+	router.Handle("/shipments/carrier", require(authz.ShipmentsRead, c.GetShipmentsByCarrier)).Methods("GET")
+	router.Handle("/shipments/tracking", require(authz.ShipmentsRead, c.GetShipmentsByTrackingNumber)).Methods("GET")
 
 	// Combined filters
-	router.HandleFunc("/shipments/warehouse/status", controllers.GetShipmentsByWarehouseIDAndStatus).Methods("GET")
-	router.HandleFunc("/shipments/product/status", controllers.GetShipmentsByProductIDAndStatus).Methods("GET")
-	router.HandleFunc("/shipments/carrier/status", controllers.GetShipmentsByCarrierAndStatus).Methods("GET")
-	router.HandleFunc("/shipments/destination/status", controllers.GetShipmentsByDestinationAndStatus).Methods("GET")
-	router.HandleFunc("/shipments/origin/status", controllers.GetShipmentsByOriginAndStatus).Methods("GET")
+	router.Handle("/shipments/warehouse/status", require(authz.ShipmentsRead, c.GetShipmentsByWarehouseIDAndStatus)).Methods("GET")
+	router.Handle("/shipments/product/status", require(authz.ShipmentsRead, c.GetShipmentsByProductIDAndStatus)).Methods("GET")
+	router.Handle("/shipments/carrier/status", require(authz.ShipmentsRead, c.GetShipmentsByCarrierAndStatus)).Methods("GET")
+	router.Handle("/shipments/destination/status", require(authz.ShipmentsRead, c.GetShipmentsByDestinationAndStatus)).Methods("GET")
+	router.Handle("/shipments/origin/status", require(authz.ShipmentsRead, c.GetShipmentsByOriginAndStatus)).Methods("GET")
 
 	// Shipment combinations with product ID
-	router.HandleFunc("/shipments/warehouse/product", controllers.GetShipmentsByWarehouseIDAndProductID).Methods("GET")
-	router.HandleFunc("/shipments/warehouse/carrier", controllers.GetShipmentsByWarehouseIDAndCarrier).Methods("GET")
-	router.HandleFunc("/shipments/warehouse/destination", controllers.GetShipmentsByWarehouseIDAndDestination).Methods("GET")
-	router.HandleFunc("/shipments/warehouse/origin", controllers.GetShipmentsByWarehouseIDAndOrigin).Methods("GET")
+	router.Handle("/shipments/warehouse/product", require(authz.ShipmentsRead, c.GetShipmentsByWarehouseIDAndProductID)).Methods("GET")
+	router.Handle("/shipments/warehouse/carrier", require(authz.ShipmentsRead, c.GetShipmentsByWarehouseIDAndCarrier)).Methods("GET")
+	router.Handle("/shipments/warehouse/destination", require(authz.ShipmentsRead, c.GetShipmentsByWarehouseIDAndDestination)).Methods("GET")
+	router.Handle("/shipments/warehouse/origin", require(authz.ShipmentsRead, c.GetShipmentsByWarehouseIDAndOrigin)).Methods("GET")
 
-	router.HandleFunc("/shipments/product/carrier", controllers.GetShipmentsByProductIDAndCarrier).Methods("GET")
-	router.HandleFunc("/shipments/product/destination", controllers.GetShipmentsByProductIDAndDestination).Methods("GET")
-	router.HandleFunc("/shipments/product/origin", controllers.GetShipmentsByProductIDAndOrigin).Methods("GET")
+	router.Handle("/shipments/product/carrier", require(authz.ShipmentsRead, c.GetShipmentsByProductIDAndCarrier)).Methods("GET")
+	router.Handle("/shipments/product/destination", require(authz.ShipmentsRead, c.GetShipmentsByProductIDAndDestination)).Methods("GET")
+	router.Handle("/shipments/product/origin", require(authz.ShipmentsRead, c.GetShipmentsByProductIDAndOrigin)).Methods("GET")
 }
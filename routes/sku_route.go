@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterSKURoutes registers the SKU routes with the router. SKUs are the
+// catalog's sellable items, so they are served under /items.
+func RegisterSKURoutes(router *mux.Router, c *controllers.SKUController) {
+	router.Handle("/items", require(authz.CatalogWrite, c.CreateSKU)).Methods("POST")
+	router.Handle("/items", require(authz.CatalogRead, c.GetSKUs)).Methods("GET")
+	router.Handle("/items/{id:[0-9]+}", require(authz.CatalogRead, c.GetSKUByID)).Methods("GET")
+	router.Handle("/items/{id:[0-9]+}", require(authz.CatalogWrite, c.UpdateSKU)).Methods("PUT")
+	router.Handle("/items/{id:[0-9]+}", require(authz.CatalogWrite, c.DeleteSKU)).Methods("DELETE")
+	router.Handle("/items/{id:[0-9]+}/lots", require(authz.CatalogRead, c.GetSKULots)).Methods("GET")
+	router.Handle("/items/{id:[0-9]+}/serials", require(authz.CatalogRead, c.GetSKUSerials)).Methods("GET")
+
+	router.Handle("/items/category", require(authz.CatalogRead, c.GetSKUsByCategory)).Methods("GET")
+	router.Handle("/items/warehouse/{warehouseID:[0-9]+}", require(authz.CatalogRead, c.GetSKUsByWarehouseID)).Methods("GET")
+	router.Handle("/items/supplier/{supplierID:[0-9]+}", require(authz.CatalogRead, c.GetSKUsBySupplierID)).Methods("GET")
+	router.Handle("/items/stock-range", require(authz.CatalogRead, c.GetSKUsByStockRange)).Methods("GET")
+	router.Handle("/items/price-range", require(authz.CatalogRead, c.GetSKUsByPriceRange)).Methods("GET")
+	router.Handle("/items/category-price", require(authz.CatalogRead, c.GetSKUsByCategoryAndPriceRange)).Methods("GET")
+	router.Handle("/items/category-stock", require(authz.CatalogRead, c.GetSKUsByCategoryAndStockRange)).Methods("GET")
+	router.Handle("/items/category-supplier", require(authz.CatalogRead, c.GetSKUsByCategoryAndSupplierID)).Methods("GET")
+	router.Handle("/items/category-warehouse", require(authz.CatalogRead, c.GetSKUsByCategoryAndWarehouseID)).Methods("GET")
+	router.Handle("/items/supplier-warehouse", require(authz.CatalogRead, c.GetSKUsBySupplierIDAndWarehouseID)).Methods("GET")
+}
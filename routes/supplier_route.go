@@ -2,27 +2,29 @@ package routes
 
 import (
 	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
 
 	"github.com/gorilla/mux"
 )
 
 // RegisterSupplierRoutes registers supplier-related routes with the router
-func RegisterSupplierRoutes(router *mux.Router) {
-	router.HandleFunc("/suppliers", controllers.CreateSupplier).Methods("POST")
-	router.HandleFunc("/suppliers", controllers.GetSuppliers).Methods("GET")
-	router.HandleFunc("/suppliers/{id:[0-9]+}", controllers.GetSupplierByID).Methods("GET")
-	router.HandleFunc("/suppliers/{id:[0-9]+}", controllers.UpdateSupplier).Methods("PUT")
-	router.HandleFunc("/suppliers/{id:[0-9]+}", controllers.DeleteSupplier).Methods("DELETE")
+func RegisterSupplierRoutes(router *mux.Router, c *controllers.SupplierController) {
+	router.Handle("/suppliers", require(authz.SuppliersWrite, c.CreateSupplier)).Methods("POST")
+	router.Handle("/suppliers", require(authz.SuppliersRead, c.GetSuppliers)).Methods("GET")
+	router.Handle("/suppliers/{id:[0-9]+}", require(authz.SuppliersRead, c.GetSupplierByID)).Methods("GET")
+	router.Handle("/suppliers/{id:[0-9]+}", require(authz.SuppliersWrite, c.UpdateSupplier)).Methods("PUT")
+	router.Handle("/suppliers/{id:[0-9]+}", require(authz.SuppliersWrite, c.DeleteSupplier)).Methods("DELETE")
+	router.Handle("/suppliers/{id:[0-9]+}/categories", require(authz.SuppliersWrite, c.SetSupplierCategories)).Methods("PUT")
 
-	router.HandleFunc("/suppliers/category", controllers.GetSuppliersByCategory).Methods("GET")
-	router.HandleFunc("/suppliers/product", controllers.GetSuppliersByProductID).Methods("GET")
-	router.HandleFunc("/suppliers/location", controllers.GetSuppliersByLocation).Methods("GET")
-	router.HandleFunc("/suppliers/rating", controllers.GetSuppliersByRating).Methods("GET")
+	router.Handle("/suppliers/category", require(authz.SuppliersRead, c.GetSuppliersByCategory)).Methods("GET")
+	router.Handle("/suppliers/product", require(authz.SuppliersRead, c.GetSuppliersByProductID)).Methods("GET")
+	router.Handle("/suppliers/location", require(authz.SuppliersRead, c.GetSuppliersByLocation)).Methods("GET")
+	router.Handle("/suppliers/rating", require(authz.SuppliersRead, c.GetSuppliersByRating)).Methods("GET")
 
-	router.HandleFunc("/suppliers/product-location", controllers.GetSuppliersByProductIDAndLocation).Methods("GET")
-	router.HandleFunc("/suppliers/product-rating", controllers.GetSuppliersByProductIDAndRating).Methods("GET")
-	router.HandleFunc("/suppliers/location-rating", controllers.GetSuppliersByLocationAndRating).Methods("GET")
+	router.Handle("/suppliers/product-location", require(authz.SuppliersRead, c.GetSuppliersByProductIDAndLocation)).Methods("GET")
+	router.Handle("/suppliers/product-rating", require(authz.SuppliersRead, c.GetSuppliersByProductIDAndRating)).Methods("GET")
+	router.Handle("/suppliers/location-rating", require(authz.SuppliersRead, c.GetSuppliersByLocationAndRating)).Methods("GET")
 
-	router.HandleFunc("/suppliers/product-location-rating", controllers.GetSuppliersByProductIDAndLocationAndRating).Methods("GET")
-	router.HandleFunc("/suppliers/category-location-rating", controllers.GetSuppliersByCategoryAndLocationAndRating).Methods("GET")
+	router.Handle("/suppliers/product-location-rating", require(authz.SuppliersRead, c.GetSuppliersByProductIDAndLocationAndRating)).Methods("GET")
+	router.Handle("/suppliers/category-location-rating", require(authz.SuppliersRead, c.GetSuppliersByCategoryAndLocationAndRating)).Methods("GET")
 }
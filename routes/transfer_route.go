@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterTransferRoutes defines the inter-warehouse transfer order routes
+func RegisterTransferRoutes(r *mux.Router, c *controllers.TransferController) {
+	api := r.PathPrefix("/transfers").Subrouter()
+
+	api.Handle("", require(authz.TransfersWrite, c.CreateTransferOrder)).Methods("POST")
+	api.Handle("", require(authz.TransfersRead, c.ListTransferOrders)).Methods("GET")
+	api.Handle("/{id:[0-9]+}", require(authz.TransfersRead, c.GetTransferOrder)).Methods("GET")
+
+	// Workflow: draft -> picked -> in_transit -> received
+	api.Handle("/{id:[0-9]+}/pick", require(authz.TransfersWrite, c.PickTransferOrder)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/dispatch", require(authz.TransfersWrite, c.DispatchTransferOrder)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/receive", require(authz.TransfersWrite, c.ReceiveTransferOrder)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/close", require(authz.TransfersWrite, c.CloseTransferOrder)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/cancel", require(authz.TransfersWrite, c.CancelTransferOrder)).Methods("POST")
+}
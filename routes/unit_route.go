@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterUnitRoutes registers the unit of measure catalog routes with the router
+func RegisterUnitRoutes(router *mux.Router, c *controllers.UnitController) {
+	router.Handle("/units", require(authz.CatalogWrite, c.CreateUnit)).Methods("POST")
+	router.Handle("/units", require(authz.CatalogRead, c.GetUnits)).Methods("GET")
+	router.Handle("/units/{code}", require(authz.CatalogRead, c.GetUnit)).Methods("GET")
+	router.Handle("/units/{code}", require(authz.CatalogWrite, c.DeleteUnit)).Methods("DELETE")
+}
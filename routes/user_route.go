@@ -2,36 +2,51 @@ package routes
 
 import (
 	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
 
 	"github.com/gorilla/mux"
 )
 
-// RegisterUserRoutes defines the user routes
-func RegisterUserRoutes(r *mux.Router) {
-	api := r.PathPrefix("/api/users").Subrouter()
+// RegisterUserRoutes defines the user routes. Registration, login and its
+// MFA step, token refresh, password reset and email verification are public
+// and registered by main outside the authenticated router.
+func RegisterUserRoutes(r *mux.Router, users *controllers.UserController, auth *controllers.AuthController) {
+	api := r.PathPrefix("/users").Subrouter()
 
-	// User registration and login
-	api.HandleFunc("/register", controllers.RegisterUser).Methods("POST")
-	api.HandleFunc("/login", controllers.LoginUser).Methods("POST")
+	// Get user by email using a query parameter
+	api.Handle("/by-email", require(authz.UsersRead, users.GetUserByEmailController)).Methods("GET").Queries("email", "{email}")
+
+	// Sessions of the signed-in user
+	api.Handle("/logout", authenticated(auth.LogoutUser)).Methods("POST")
+	api.Handle("/logout-all", authenticated(auth.LogoutAllSessions)).Methods("POST")
+
+	// Multi-factor authentication of the signed-in user
+	api.Handle("/mfa/enroll", authenticated(auth.EnrollMFA)).Methods("POST")
+	api.Handle("/mfa/confirm", authenticated(auth.ConfirmMFA)).Methods("POST")
+	api.Handle("/mfa/disable", authenticated(auth.DisableMFA)).Methods("POST")
+	api.Handle("/mfa/recovery-codes", authenticated(auth.RegenerateRecoveryCodes)).Methods("POST")
+
+	// Roles and the permissions they grant
+	api.Handle("/roles", require(authz.UsersRead, users.ListRolesController)).Methods("GET")
+
+	// Failed logins and lockouts
+	api.Handle("/security-events", require(authz.UsersRead, users.ListSecurityEventsController)).Methods("GET")
 
 	// CRUD operations
-	api.HandleFunc("/{id}", controllers.GetUserController).Methods("GET")
-	api.HandleFunc("/{id}", controllers.UpdateUserController).Methods("PUT")
-	api.HandleFunc("/{id}", controllers.DeleteUserController).Methods("DELETE")
-	api.HandleFunc("", controllers.ListUsersController).Methods("GET")
+	api.Handle("/{id:[0-9]+}", require(authz.UsersRead, users.GetUserController)).Methods("GET")
+	api.Handle("/{id:[0-9]+}", require(authz.UsersWrite, users.UpdateUserController)).Methods("PUT")
+	api.Handle("/{id:[0-9]+}", require(authz.UsersWrite, users.DeleteUserController)).Methods("DELETE")
+	api.Handle("", require(authz.UsersRead, users.ListUsersController)).Methods("GET")
 
 	// Additional operations
-	api.HandleFunc("/{id}/password", controllers.ChangePasswordController).Methods("PUT")
-	api.HandleFunc("/reset-password", controllers.ResetPasswordController).Methods("POST")
-	api.HandleFunc("/verify", controllers.VerifyUserController).Methods("POST")
-	api.HandleFunc("/unverify", controllers.UnverifyUserController).Methods("POST")
-	api.HandleFunc("/{id}/add-role", controllers.AddRoleController).Methods("POST")
-	api.HandleFunc("/{id}/remove-role", controllers.RemoveRoleController).Methods("DELETE")
-	api.HandleFunc("/{id}/add-permission", controllers.AddPermissionController).Methods("POST")
-	api.HandleFunc("/{id}/remove-permission", controllers.RemovePermissionController).Methods("DELETE")
-	api.HandleFunc("/{id}/add-address", controllers.AddAddressController).Methods("POST")
-	api.HandleFunc("/{id}/remove-address", controllers.RemoveAddressController).Methods("DELETE")
-
-	// Get user by email using a query parameter
-	api.HandleFunc("/by-email", controllers.GetUserByEmailController).Methods("GET").Queries("email", "{email}")
+	api.Handle("/{id:[0-9]+}/password", require(authz.UsersWrite, users.ChangePasswordController)).Methods("PUT")
+	api.Handle("/unverify", require(authz.UsersWrite, users.UnverifyUserController)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/add-role", require(authz.RolesManage, users.AddRoleController)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/remove-role", require(authz.RolesManage, users.RemoveRoleController)).Methods("DELETE")
+	api.Handle("/{id:[0-9]+}/add-permission", require(authz.RolesManage, users.AddPermissionController)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/remove-permission", require(authz.RolesManage, users.RemovePermissionController)).Methods("DELETE")
+	api.Handle("/{id:[0-9]+}/unlock", require(authz.UsersWrite, users.UnlockUserController)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/mfa", require(authz.RolesManage, auth.ResetUserMFA)).Methods("DELETE")
+	api.Handle("/{id:[0-9]+}/add-address", require(authz.UsersWrite, users.AddAddressController)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/remove-address", require(authz.UsersWrite, users.RemoveAddressController)).Methods("DELETE")
 }
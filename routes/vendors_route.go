@@ -2,18 +2,17 @@ package routes
 
 import (
 	"inventory-supply-chain-system/controllers"
-	"inventory-supply-chain-system/internal/middlewares"
+	"inventory-supply-chain-system/internal/authz"
 
 	"github.com/gorilla/mux"
 )
 
 // VendorRoutes defines the vendor routes
-func RegisterVendorRoutes(r *mux.Router) {
-	api := r.PathPrefix("/api/vendors").Subrouter()
-	api.Use(middlewares.AuthMiddleware)
+func RegisterVendorRoutes(r *mux.Router, c *controllers.VendorController) {
+	api := r.PathPrefix("/vendors").Subrouter()
 
-	api.HandleFunc("", controllers.CreateVendor).Methods("POST")
-	api.HandleFunc("/{id}", controllers.GetVendor).Methods("GET")
-	api.HandleFunc("/{id}", controllers.UpdateVendor).Methods("PUT")
-	api.HandleFunc("/{id}", controllers.DeleteVendor).Methods("DELETE")
+	api.Handle("", require(authz.SuppliersWrite, c.CreateVendor)).Methods("POST")
+	api.Handle("/{id}", require(authz.SuppliersRead, c.GetVendor)).Methods("GET")
+	api.Handle("/{id}", require(authz.SuppliersWrite, c.UpdateVendor)).Methods("PUT")
+	api.Handle("/{id}", require(authz.SuppliersWrite, c.DeleteVendor)).Methods("DELETE")
 }
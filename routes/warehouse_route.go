@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"inventory-supply-chain-system/controllers"
+	"inventory-supply-chain-system/internal/authz"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterWarehouseRoutes defines the warehouse and storage location routes
+func RegisterWarehouseRoutes(r *mux.Router, c *controllers.WarehouseController) {
+	api := r.PathPrefix("/warehouses").Subrouter()
+
+	api.Handle("", require(authz.WarehousesWrite, c.CreateWarehouse)).Methods("POST")
+	api.Handle("", require(authz.WarehousesRead, c.GetWarehouses)).Methods("GET")
+	api.Handle("/{id:[0-9]+}", require(authz.WarehousesRead, c.GetWarehouse)).Methods("GET")
+	api.Handle("/{id:[0-9]+}", require(authz.WarehousesWrite, c.UpdateWarehouse)).Methods("PUT")
+	api.Handle("/{id:[0-9]+}", require(authz.WarehousesWrite, c.DeleteWarehouse)).Methods("DELETE")
+	api.Handle("/{id:[0-9]+}/stock", require(authz.WarehousesRead, c.GetWarehouseStock)).Methods("GET")
+
+	// Zones, aisles and bins
+	api.Handle("/{id:[0-9]+}/zones", require(authz.WarehousesWrite, c.CreateZone)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/zones", require(authz.WarehousesRead, c.GetZones)).Methods("GET")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}", require(authz.WarehousesWrite, c.UpdateZone)).Methods("PUT")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}", require(authz.WarehousesWrite, c.DeleteZone)).Methods("DELETE")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}/aisles", require(authz.WarehousesWrite, c.CreateAisle)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}/aisles", require(authz.WarehousesRead, c.GetAisles)).Methods("GET")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}/aisles/{aisleID:[0-9]+}", require(authz.WarehousesWrite, c.UpdateAisle)).Methods("PUT")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}/aisles/{aisleID:[0-9]+}", require(authz.WarehousesWrite, c.DeleteAisle)).Methods("DELETE")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}/aisles/{aisleID:[0-9]+}/bins", require(authz.WarehousesWrite, c.CreateBin)).Methods("POST")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}/aisles/{aisleID:[0-9]+}/bins", require(authz.WarehousesRead, c.GetBins)).Methods("GET")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}/aisles/{aisleID:[0-9]+}/bins/{binID:[0-9]+}", require(authz.WarehousesWrite, c.UpdateBin)).Methods("PUT")
+	api.Handle("/{id:[0-9]+}/zones/{zoneID:[0-9]+}/aisles/{aisleID:[0-9]+}/bins/{binID:[0-9]+}", require(authz.WarehousesWrite, c.DeleteBin)).Methods("DELETE")
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+)
+
+// AccountPolicy holds the deployment's rules for what a signed-in user may
+// do: how unverified users are treated and which roles must use MFA
+type AccountPolicy struct {
+	verification string
+	mfaRoles     map[string]bool
+}
+
+// NewAccountPolicy returns the policy for a verification setting (block,
+// restrict or off; empty means block) and the roles whose users must enroll
+// in MFA. Until they do, their tokens carry no permissions.
+func NewAccountPolicy(verification string, mfaRoles []string) (*AccountPolicy, error) {
+	switch verification {
+	case "":
+		verification = VerificationBlock
+	case VerificationBlock, VerificationRestrict, VerificationOff:
+	default:
+		return nil, fmt.Errorf("unknown verification policy %q", verification)
+	}
+
+	required := make(map[string]bool, len(mfaRoles))
+	for _, role := range mfaRoles {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if !authz.IsRole(role) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRole, role)
+		}
+		required[role] = true
+	}
+
+	return &AccountPolicy{verification: verification, mfaRoles: required}, nil
+}
+
+// MFARequired reports whether a user's role requires MFA
+func (p *AccountPolicy) MFARequired(user *models.User) bool {
+	return p.mfaRoles[user.Role]
+}
+
+// checkVerified applies the verification policy to a user starting or
+// renewing a session
+func (p *AccountPolicy) checkVerified(user *models.User) error {
+	if !user.Verified && p.verification == VerificationBlock {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// sessionPermissions is the permission set put in a user's access tokens.
+// Users whose role requires MFA get none until they enroll.
+func (p *AccountPolicy) sessionPermissions(user *models.User) []string {
+	if p.MFARequired(user) && !user.MFAEnrolled() {
+		return []string{}
+	}
+
+	permissions := authz.Effective(user.Role, user.Permissions)
+	if !user.Verified && p.verification == VerificationRestrict {
+		return authz.ReadOnly(permissions)
+	}
+	return permissions
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+)
+
+// APIKeyPrefix starts every API key, so keys can be told apart from JWTs
+// and spotted by secret scanners
+const APIKeyPrefix = "iscs_"
+
+// apiKeyTouchInterval is how often last_used_at is written for a busy key
+const apiKeyTouchInterval = time.Minute
+
+var (
+	// ErrInvalidAPIKey is returned when an API key request is incomplete or
+	// asks for more than its owner holds
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrAPIKeyRejected is returned when a presented key is unknown, expired
+	// or revoked, or its owner is kept out by the verification policy
+	ErrAPIKeyRejected = errors.New("invalid, expired or revoked API key")
+)
+
+// APIKeyRequest describes a key to issue. UserID defaults to the issuer.
+type APIKeyRequest struct {
+	Name        string     `json:"name"`
+	UserID      uint       `json:"user_id"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// IssuedAPIKey is a newly issued key together with its secret, which is
+// not shown again
+type IssuedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyPrincipal is who a request authenticated with an API key acts as
+type APIKeyPrincipal struct {
+	KeyID       uint
+	UserID      uint
+	Role        string
+	Permissions []string
+}
+
+// APIKeyService issues API keys and authenticates requests made with them
+type APIKeyService struct {
+	store  repository.Store
+	policy *AccountPolicy
+}
+
+// NewAPIKeyService returns an APIKeyService over store. Keys never carry
+// more than policy lets their owner's sessions hold.
+func NewAPIKeyService(store repository.Store, policy *AccountPolicy) *APIKeyService {
+	return &APIKeyService{store: store, policy: policy}
+}
+
+// IssueAPIKey creates an API key. Its permissions must be ones its owner
+// holds; they are narrowed again on every request if the owner later loses some.
+func (s *APIKeyService) IssueAPIKey(ctx context.Context, req APIKeyRequest, issuerID uint) (*IssuedAPIKey, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if len(req.Permissions) == 0 {
+		return nil, fmt.Errorf("%w: at least one permission is required", ErrInvalidAPIKey)
+	}
+	for _, p := range req.Permissions {
+		if !authz.IsPermission(p) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownPermission, p)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKey)
+	}
+	if req.UserID == 0 {
+		req.UserID = issuerID
+	}
+
+	owner, err := s.store.Users().Get(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	granted := authz.Effective(owner.Role, owner.Permissions)
+	for _, p := range req.Permissions {
+		if !authz.Allows(granted, p) {
+			return nil, fmt.Errorf("%w: the owner does not hold %q", ErrInvalidAPIKey, p)
+		}
+	}
+
+	id, err := utils.RandomToken(4)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.RandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	key := IssuedAPIKey{
+		APIKey: models.APIKey{
+			Name:        strings.TrimSpace(req.Name),
+			Prefix:      APIKeyPrefix + id,
+			SecretHash:  hashToken(secret),
+			UserID:      owner.ID,
+			CreatedByID: issuerID,
+			Permissions: models.StringArray(req.Permissions),
+			ExpiresAt:   req.ExpiresAt,
+		},
+	}
+	if err := s.store.APIKeys().Create(ctx, &key.APIKey); err != nil {
+		return nil, err
+	}
+	key.Key = key.Prefix + "_" + secret
+	return &key, nil
+}
+
+// FindAPIKeys returns one page of the API keys matching filter
+func (s *APIKeyService) FindAPIKeys(ctx context.Context, filter repository.APIKeyFilter, page repository.PageRequest) (repository.Page[models.APIKey], error) {
+	return s.store.APIKeys().Find(ctx, filter, page)
+}
+
+// GetAPIKey fetches an API key by ID
+func (s *APIKeyService) GetAPIKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	return s.store.APIKeys().Get(ctx, id)
+}
+
+// RevokeAPIKey stops an API key from being accepted. Revoking a revoked key
+// does nothing.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	key, err := s.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := s.store.APIKeys().Revoke(ctx, key.ID, now); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// IsAPIKey reports whether a credential looks like an API key rather than a JWT
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// AuthenticateAPIKey checks a presented API key and returns who it acts as.
+// Its permissions are the key's, narrowed to what the owner holds now.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, credential string) (*APIKeyPrincipal, error) {
+	if !IsAPIKey(credential) {
+		return nil, ErrAPIKeyRejected
+	}
+	prefix, secret, ok := strings.Cut(credential[len(APIKeyPrefix):], "_")
+	if !ok {
+		return nil, ErrAPIKeyRejected
+	}
+
+	key, err := s.store.APIKeys().GetByPrefix(ctx, APIKeyPrefix+prefix)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAPIKeyRejected
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(key.SecretHash)) != 1 ||
+		key.RevokedAt != nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return nil, ErrAPIKeyRejected
+	}
+
+	owner, err := s.store.Users().Get(ctx, key.UserID)
+	if err != nil {
+		return nil, ErrAPIKeyRejected
+	}
+	// A key acts as its owner, so it is kept out whenever a session would be
+	if err := s.policy.checkVerified(owner); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAPIKeyRejected, err)
+	}
+
+	// Busy keys would otherwise write on every request
+	if err := s.store.APIKeys().Touch(ctx, key.ID, now, now.Add(-apiKeyTouchInterval)); err != nil {
+		return nil, err
+	}
+
+	return &APIKeyPrincipal{
+		KeyID:       key.ID,
+		UserID:      owner.ID,
+		Role:        owner.Role,
+		Permissions: authz.Restrict(s.policy.sessionPermissions(owner), key.Permissions),
+	}, nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+// AuditService reads the audit log
+type AuditService struct {
+	logs repository.AuditLogRepository
+}
+
+// NewAuditService returns an AuditService over logs
+func NewAuditService(logs repository.AuditLogRepository) *AuditService {
+	return &AuditService{logs: logs}
+}
+
+// FindAuditLogs returns one page of the audit log entries matching filter
+func (s *AuditService) FindAuditLogs(ctx context.Context, filter repository.AuditFilter, page repository.PageRequest) (repository.Page[models.AuditLog], error) {
+	return s.logs.Find(ctx, filter, page)
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+var (
+	// ErrInvalidCategory is returned when category data is malformed, names an
+	// unknown parent or would make a category its own ancestor
+	ErrInvalidCategory = errors.New("invalid category")
+	// ErrCategoryInUse is returned when deleting a category that still has
+	// subcategories or products
+	ErrCategoryInUse = errors.New("category still has subcategories or products")
+)
+
+// CategoryService manages the product category tree. Every change keeps
+// the slug paths of a category's descendants in step with it.
+type CategoryService struct {
+	store repository.Store
+}
+
+// NewCategoryService returns a CategoryService over store
+func NewCategoryService(store repository.Store) *CategoryService {
+	return &CategoryService{store: store}
+}
+
+// CreateCategory adds a category under its parent, or as a root when it
+// has none. The slug defaults to one made from the name.
+func (s *CategoryService) CreateCategory(ctx context.Context, category *models.Category) error {
+	if err := validateCategory(category); err != nil {
+		return err
+	}
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		category.Path = category.Slug
+		if category.ParentID != nil {
+			parent, err := tx.Categories().Get(ctx, *category.ParentID)
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: unknown parent %d", ErrInvalidCategory, *category.ParentID)
+			}
+			if err != nil {
+				return err
+			}
+			category.Path = parent.Path + "/" + category.Slug
+		}
+		category.Children = nil
+		return tx.Categories().Create(ctx, category)
+	})
+}
+
+// GetCategoryTree fetches every category, nested under its parent
+func (s *CategoryService) GetCategoryTree(ctx context.Context) ([]models.Category, error) {
+	categories, err := s.store.Categories().All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byParent := make(map[uint][]models.Category)
+	for _, category := range categories {
+		var parentID uint
+		if category.ParentID != nil {
+			parentID = *category.ParentID
+		}
+		byParent[parentID] = append(byParent[parentID], category)
+	}
+	var nest func(parentID uint) []models.Category
+	nest = func(parentID uint) []models.Category {
+		children := byParent[parentID]
+		for i := range children {
+			children[i].Children = nest(children[i].ID)
+		}
+		return children
+	}
+	roots := nest(0)
+	if roots == nil {
+		roots = []models.Category{}
+	}
+	return roots, nil
+}
+
+// GetCategoryByID fetches a category with its direct children by its ID
+func (s *CategoryService) GetCategoryByID(ctx context.Context, id uint) (*models.Category, error) {
+	return s.store.Categories().Get(ctx, id)
+}
+
+// UpdateCategory renames a category. Its parent is changed with
+// MoveCategory, so the one given here is ignored.
+func (s *CategoryService) UpdateCategory(ctx context.Context, category *models.Category) error {
+	if err := validateCategory(category); err != nil {
+		return err
+	}
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		current, err := tx.Categories().Get(ctx, category.ID)
+		if err != nil {
+			return err
+		}
+		category.ParentID = current.ParentID
+		category.CreatedAt = current.CreatedAt
+		category.Path = strings.TrimSuffix(current.Path, current.Slug) + category.Slug
+		category.Children = nil
+		if err := tx.Categories().Save(ctx, category); err != nil {
+			return err
+		}
+		return tx.Categories().RenamePath(ctx, current.Path, category.Path)
+	})
+}
+
+// MoveCategory moves a category, with its whole subtree, under another
+// parent, or to the root when parentID is nil
+func (s *CategoryService) MoveCategory(ctx context.Context, id uint, parentID *uint) (*models.Category, error) {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		category, err := tx.Categories().Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		path := category.Slug
+		if parentID != nil {
+			parent, err := tx.Categories().Get(ctx, *parentID)
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: unknown parent %d", ErrInvalidCategory, *parentID)
+			}
+			if err != nil {
+				return err
+			}
+			if inSubtree(parent, category) {
+				return fmt.Errorf("%w: cannot move a category under itself", ErrInvalidCategory)
+			}
+			path = parent.Path + "/" + category.Slug
+		}
+		return moveCategory(ctx, tx, category, parentID, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Categories().Get(ctx, id)
+}
+
+// MergeCategory folds a category into another: its products and supplier
+// assignments move to the target and it is deleted. Subcategories are
+// merged into the target's subcategory with the same slug when there is
+// one, and moved under the target otherwise.
+func (s *CategoryService) MergeCategory(ctx context.Context, id, intoID uint) (*models.Category, error) {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		source, err := tx.Categories().Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		target, err := tx.Categories().Get(ctx, intoID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: unknown category %d", ErrInvalidCategory, intoID)
+		}
+		if err != nil {
+			return err
+		}
+		if inSubtree(target, source) {
+			return fmt.Errorf("%w: cannot merge a category into itself or its subcategories", ErrInvalidCategory)
+		}
+		return mergeCategory(ctx, tx, source, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Categories().Get(ctx, intoID)
+}
+
+// DeleteCategory deletes a category that has no subcategories or products,
+// dropping any supplier assignments to it
+func (s *CategoryService) DeleteCategory(ctx context.Context, id uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		category, err := tx.Categories().Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(category.Children) > 0 {
+			return ErrCategoryInUse
+		}
+		count, err := tx.Categories().CountProducts(ctx, id)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryInUse
+		}
+		return tx.Categories().Delete(ctx, id)
+	})
+}
+
+// mergeCategory merges source into target, recursing into subcategories
+// whose slugs exist on both sides
+func mergeCategory(ctx context.Context, tx repository.Store, source, target *models.Category) error {
+	targetChildren := make(map[string]*models.Category, len(target.Children))
+	for i := range target.Children {
+		targetChildren[target.Children[i].Slug] = &target.Children[i]
+	}
+	for i := range source.Children {
+		child := &source.Children[i]
+		match, ok := targetChildren[child.Slug]
+		if !ok {
+			if err := moveCategory(ctx, tx, child, &target.ID, target.Path+"/"+child.Slug); err != nil {
+				return err
+			}
+			continue
+		}
+		childSource, err := tx.Categories().Get(ctx, child.ID)
+		if err != nil {
+			return err
+		}
+		childTarget, err := tx.Categories().Get(ctx, match.ID)
+		if err != nil {
+			return err
+		}
+		if err := mergeCategory(ctx, tx, childSource, childTarget); err != nil {
+			return err
+		}
+	}
+	if err := tx.Categories().Reassign(ctx, source.ID, target.ID); err != nil {
+		return err
+	}
+	return tx.Categories().Delete(ctx, source.ID)
+}
+
+// moveCategory gives a category a new parent and rewrites the paths of its subtree
+func moveCategory(ctx context.Context, tx repository.Store, category *models.Category, parentID *uint, path string) error {
+	oldPath := category.Path
+	category.ParentID = parentID
+	category.Children = nil
+	if err := tx.Categories().Save(ctx, category); err != nil {
+		return err
+	}
+	return tx.Categories().RenamePath(ctx, oldPath, path)
+}
+
+// inSubtree reports whether category is root or one of its descendants
+func inSubtree(category, root *models.Category) bool {
+	return category.ID == root.ID || strings.HasPrefix(category.Path, root.Path+"/")
+}
+
+// validateCategory checks the name of a category and defaults its slug
+func validateCategory(category *models.Category) error {
+	if strings.TrimSpace(category.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+	if category.Slug == "" {
+		category.Slug = category.Name
+	}
+	category.Slug = models.Slugify(category.Slug)
+	if category.Slug == "" {
+		return fmt.Errorf("%w: slug must contain a letter a-z or a digit", ErrInvalidCategory)
+	}
+	return nil
+}
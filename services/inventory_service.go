@@ -1,79 +1,153 @@
 package services
 
 import (
-	"inventory-supply-chain-system/db"
+	"context"
+	"errors"
+	"fmt"
+
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 )
 
-func CreateInventoryItem(inventory models.Inventory) error {
-	result := db.DB.Create(&inventory)
-	if result.Error != nil {
-		return result.Error
-	}
+// ErrInventoryInUse is returned when deleting an inventory item that still
+// has stock on hand, reserved or held in a location
+var ErrInventoryInUse = errors.New("inventory item still holds stock")
+
+// InventoryService manages inventory items and the stock ledger behind them
+type InventoryService struct {
+	store repository.Store
+}
 
-	return nil
+// NewInventoryService returns an InventoryService over store
+func NewInventoryService(store repository.Store) *InventoryService {
+	return &InventoryService{store: store}
 }
 
-// GetInventoryItems fetches all inventory items from the database
-func GetInventoryItems() ([]models.Inventory, error) {
-	var inventoryItems []models.Inventory
-	result := db.DB.Find(&inventoryItems)
-	if result.Error != nil {
-		return nil, result.Error
-	}
+// CreateInventoryItem creates the stock record of a SKU, which may have only
+// one. Any initial quantity is booked through the ledger as an
+// opening-balance receipt by userID. On success inventory holds the stored
+// item.
+func (s *InventoryService) CreateInventoryItem(ctx context.Context, inventory *models.Inventory, userID uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if _, err := tx.SKUs().Get(ctx, inventory.SKUID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: unknown SKU %d", ErrInvalidSKU, inventory.SKUID)
+			}
+			return err
+		}
+
+		opening := inventory.Quantity
+		inventory.Quantity, inventory.Reserved = 0, 0
+		if err := tx.Inventory().Create(ctx, inventory); err != nil {
+			return err
+		}
+
+		if opening != 0 {
+			if err := recordMovement(ctx, tx, &models.StockMovement{
+				InventoryID: inventory.ID,
+				Type:        models.MovementTypeReceipt,
+				Quantity:    opening,
+				ReasonCode:  ReasonOpeningBalance,
+				UserID:      userID,
+			}); err != nil {
+				return err
+			}
+		}
 
-	return inventoryItems, nil
+		created, err := tx.Inventory().Get(ctx, inventory.ID)
+		if err != nil {
+			return err
+		}
+		*inventory = *created
+		return nil
+	})
+}
+
+// GetInventoryItems fetches one page of inventory items
+func (s *InventoryService) GetInventoryItems(ctx context.Context, page repository.PageRequest) (repository.Page[models.Inventory], error) {
+	return s.store.Inventory().List(ctx, page)
 }
 
 // GetInventoryItemByID fetches an inventory item by its ID
-func GetInventoryItemByID(id uint) (*models.Inventory, error) {
-	var inventoryItem models.Inventory
-	result := db.DB.First(&inventoryItem, id)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return &inventoryItem, nil
+func (s *InventoryService) GetInventoryItemByID(ctx context.Context, id uint) (*models.Inventory, error) {
+	return s.store.Inventory().Get(ctx, id)
 }
 
-// UpdateInventoryItem updates an inventory item in the database
-func UpdateInventoryItem(inventory *models.Inventory) error {
-	result := db.DB.Save(inventory)
-	if result.Error != nil {
-		return result.Error
-	}
+// UpdateInventoryItem updates an inventory item, provided it still has
+// inventory.Version. The SKU it stocks is fixed, and the reserved quantity
+// is owned by order placement; neither can be changed here. A change to the on-hand quantity is not written
+// directly but booked as a ledger adjustment by userID, which also refuses to
+// drop below reserved stock. On success inventory holds the stored item.
+func (s *InventoryService) UpdateInventoryItem(ctx context.Context, inventory *models.Inventory, userID uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		current, err := tx.Inventory().Lock(ctx, inventory.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Inventory().Update(ctx, inventory, "sku_id", "quantity", "reserved"); err != nil {
+			return err
+		}
 
-	return nil
+		if delta := inventory.Quantity - current.Quantity; delta != 0 {
+			if err := recordMovement(ctx, tx, &models.StockMovement{
+				InventoryID: inventory.ID,
+				Type:        models.MovementTypeAdjustment,
+				Quantity:    delta,
+				ReasonCode:  ReasonInventoryUpdate,
+				UserID:      userID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		updated, err := tx.Inventory().Get(ctx, inventory.ID)
+		if err != nil {
+			return err
+		}
+		*inventory = *updated
+		return nil
+	})
 }
 
-// DeleteInventoryItem deletes an inventory item from the database
-func DeleteInventoryItem(id uint) error {
-	result := db.DB.Delete(&models.Inventory{}, id)
-	if result.Error != nil {
-		return result.Error
-	}
+// DeleteInventoryItem deletes an inventory item, provided it still has
+// version and holds no stock. Stock has to be issued or adjusted out first,
+// so the ledger accounts for it.
+func (s *InventoryService) DeleteInventoryItem(ctx context.Context, id uint, version int) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		inventory, err := tx.Inventory().Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !repository.VersionMatches(inventory.Version, version) {
+			return repository.ErrVersionConflict
+		}
+		if inventory.Quantity != 0 || inventory.Reserved != 0 {
+			return fmt.Errorf("%w: %d on hand, %d reserved", ErrInventoryInUse, inventory.Quantity, inventory.Reserved)
+		}
+		balances, err := tx.Stock().InventoryBalances(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(balances) > 0 {
+			return fmt.Errorf("%w: %d locations hold it", ErrInventoryInUse, len(balances))
+		}
 
-	return nil
+		return tx.Inventory().Delete(ctx, id, inventory.Version)
+	})
 }
 
 // GetInventoryItemsByProductID fetches all inventory items for a given product ID
-func GetInventoryItemsByProductID(productID uint) ([]models.Inventory, error) {
-	var inventoryItems []models.Inventory
-	result := db.DB.Where("product_id = ?", productID).Find(&inventoryItems)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return inventoryItems, nil
+func (s *InventoryService) GetInventoryItemsByProductID(ctx context.Context, productID uint) ([]models.Inventory, error) {
+	return s.store.Inventory().FindByProduct(ctx, productID)
 }
 
-// GetInventoryItemsByWarehouseID fetches all inventory items for a given warehouse ID
-func GetInventoryItemsByWarehouseID(warehouseID uint) ([]models.Inventory, error) {
-	var inventoryItems []models.Inventory
-	result := db.DB.Where("warehouse_id = ?", warehouseID).Find(&inventoryItems)
-	if result.Error != nil {
-		return nil, result.Error
-	}
+// GetInventoryItemsByWarehouseID fetches all inventory items with stock in a given warehouse
+func (s *InventoryService) GetInventoryItemsByWarehouseID(ctx context.Context, warehouseID uint) ([]models.Inventory, error) {
+	return s.store.Inventory().FindInWarehouse(ctx, warehouseID)
+}
 
-	return inventoryItems, nil
+// GetInventoryLocations lists where an inventory item is held
+func (s *InventoryService) GetInventoryLocations(ctx context.Context, inventoryID uint) ([]models.StockBalance, error) {
+	return s.store.Stock().InventoryBalances(ctx, inventoryID)
 }
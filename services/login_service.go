@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCredentials is returned for an unknown email or a wrong
+	// password alike, so a failed login does not reveal which accounts exist
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrLoginLocked is returned while an account or client is locked out
+	// after too many failed logins
+	ErrLoginLocked = errors.New("too many failed login attempts")
+)
+
+// throttlePolicy decides how failed logins for one key are slowed down.
+// After free failures each further attempt has to wait twice as long as the
+// last, up to maxDelay; after lockAfter failures the key is locked out.
+// Failures older than window are forgotten.
+type throttlePolicy struct {
+	prefix    string
+	event     string
+	free      int
+	lockAfter int
+	maxDelay  time.Duration
+	window    time.Duration
+	lockout   time.Duration
+}
+
+var (
+	accountThrottle = throttlePolicy{
+		prefix: "account:", event: models.SecurityEventAccountLocked,
+		free: 3, lockAfter: 10, maxDelay: time.Minute, window: 15 * time.Minute, lockout: 15 * time.Minute,
+	}
+	ipThrottle = throttlePolicy{
+		prefix: "ip:", event: models.SecurityEventIPLocked,
+		free: 10, lockAfter: 50, maxDelay: time.Minute, window: 15 * time.Minute, lockout: 30 * time.Minute,
+	}
+)
+
+// LoginService checks passwords and throttles failed logins
+type LoginService struct {
+	store repository.Store
+}
+
+// NewLoginService returns a LoginService over store
+func NewLoginService(store repository.Store) *LoginService {
+	return &LoginService{store: store}
+}
+
+// dummyHash is compared against for unknown emails so that they take as
+// long to reject as a wrong password
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+// LoginUser checks a user's credentials. Failures are counted per account
+// and per client IP; once either has failed too often, attempts are refused
+// with a *RetryError until the delay or lockout has passed.
+func (s *LoginService) LoginUser(ctx context.Context, email, password, ip string) (models.User, error) {
+	var user models.User
+	if err := s.checkLoginThrottle(ctx, email, ip); err != nil {
+		return user, err
+	}
+
+	stored, err := s.store.Users().GetByEmail(ctx, email)
+	found := err == nil
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return user, err
+	}
+	if found {
+		user = *stored
+	}
+
+	hash := dummyHash
+	if found {
+		hash = []byte(user.Password)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || !found {
+		if err := s.recordLoginFailure(ctx, email, ip, user.ID); err != nil {
+			return models.User{}, err
+		}
+		return models.User{}, ErrInvalidCredentials
+	}
+
+	// With MFA the login is not complete until the second factor is checked
+	if !user.MFAEnrolled() {
+		if err := s.clearLoginFailures(ctx, email); err != nil {
+			return user, err
+		}
+	}
+	return user, nil
+}
+
+// UnlockAccount clears the failed logins of a user so they can log in
+// again straight away
+func (s *LoginService) UnlockAccount(ctx context.Context, userID, actorID uint) error {
+	user, err := s.store.Users().Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.LoginThrottles().Delete(ctx, accountThrottle.key(user.Email)); err != nil {
+			return err
+		}
+		return tx.SecurityEvents().Create(ctx, &models.SecurityEvent{
+			Type:    models.SecurityEventAccountUnlocked,
+			UserID:  user.ID,
+			Email:   user.Email,
+			ActorID: actorID,
+		})
+	})
+}
+
+// FindSecurityEvents returns one page of the security events matching filter
+func (s *LoginService) FindSecurityEvents(ctx context.Context, filter repository.SecurityEventFilter, page repository.PageRequest) (repository.Page[models.SecurityEvent], error) {
+	return s.store.SecurityEvents().Find(ctx, filter, page)
+}
+
+// checkLoginThrottle refuses a login while the account or IP has to wait
+func (s *LoginService) checkLoginThrottle(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	var wait time.Duration
+	var cause error
+	for _, check := range []struct {
+		policy throttlePolicy
+		value  string
+	}{{accountThrottle, email}, {ipThrottle, ip}} {
+		if check.value == "" {
+			continue
+		}
+
+		throttle, err := s.store.LoginThrottles().Get(ctx, check.policy.key(check.value))
+		if err != nil {
+			return err
+		}
+		if d, locked := check.policy.wait(throttle, now); d > wait {
+			wait = d
+			cause = ErrTooManyRequests
+			if locked {
+				cause = ErrLoginLocked
+			}
+		}
+	}
+
+	if wait > 0 {
+		return &RetryError{Err: cause, RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against the account and the IP,
+// locking either out once it reaches its limit
+func (s *LoginService) recordLoginFailure(ctx context.Context, email, ip string, userID uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := accountThrottle.fail(ctx, tx, email, models.SecurityEvent{UserID: userID, Email: email, IP: ip}); err != nil {
+			return err
+		}
+		if ip == "" {
+			return nil
+		}
+		return ipThrottle.fail(ctx, tx, ip, models.SecurityEvent{Email: email, IP: ip})
+	})
+}
+
+// clearLoginFailures forgets an account's failed logins after a successful
+// one. The IP's failures are left to expire so that logging into one account
+// does not reset guessing against others.
+func (s *LoginService) clearLoginFailures(ctx context.Context, email string) error {
+	return s.store.LoginThrottles().Delete(ctx, accountThrottle.key(email))
+}
+
+func (p throttlePolicy) key(value string) string {
+	return p.prefix + strings.ToLower(strings.TrimSpace(value))
+}
+
+// wait returns how long the next attempt has to wait, and whether that is
+// because of a lockout
+func (p throttlePolicy) wait(throttle *models.LoginThrottle, now time.Time) (time.Duration, bool) {
+	if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+		return throttle.LockedUntil.Sub(now), true
+	}
+	if now.Sub(throttle.LastFailureAt) > p.window || throttle.Failures <= p.free {
+		return 0, false
+	}
+
+	delay := p.maxDelay
+	if shift := throttle.Failures - p.free - 1; shift < 16 {
+		if d := time.Second << shift; d < delay {
+			delay = d
+		}
+	}
+	if next := throttle.LastFailureAt.Add(delay); now.Before(next) {
+		return next.Sub(now), false
+	}
+	return 0, false
+}
+
+// fail counts one failure against value and records a security event when
+// it locks value out
+func (p throttlePolicy) fail(ctx context.Context, tx repository.Store, value string, event models.SecurityEvent) error {
+	throttle, err := tx.LoginThrottles().Lock(ctx, p.key(value))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if now.Sub(throttle.LastFailureAt) > p.window {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+
+	locking := throttle.Failures >= p.lockAfter && (throttle.LockedUntil == nil || !now.Before(*throttle.LockedUntil))
+	if locking {
+		until := now.Add(p.lockout)
+		throttle.LockedUntil = &until
+	}
+	if err := tx.LoginThrottles().Save(ctx, throttle); err != nil {
+		return err
+	}
+
+	if !locking {
+		return nil
+	}
+	event.Type = p.event
+	event.Detail = fmt.Sprintf("locked for %s after %d failed logins", p.lockout, throttle.Failures)
+	return tx.SecurityEvents().Create(ctx, &event)
+}
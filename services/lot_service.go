@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+// GetExpiringStock lists the lot stock that expires within days, soonest
+// first, optionally only in one warehouse. Stock that has already expired
+// is included.
+func (s *InventoryService) GetExpiringStock(ctx context.Context, days int, warehouseID uint) ([]models.StockBalance, error) {
+	before := time.Now().UTC().AddDate(0, 0, days)
+	return s.store.Stock().ExpiringBalances(ctx, before, warehouseID)
+}
+
+// resolveLot settles the lot a movement books stock against. A lot named by
+// number is looked up among its SKU's lots; receipts and returns record it,
+// with the dates and supplier reference given, when it is new. Stock added
+// to a lot-controlled SKU must name its lot, except on a transferLeg, which
+// only moves stock that was picked without one.
+func resolveLot(ctx context.Context, tx repository.Store, sku *models.SKU, movement *models.StockMovement, transferLeg bool) error {
+	if movement.LotID != 0 {
+		lot, err := tx.Lots().Get(ctx, movement.LotID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && lot.SKUID != sku.ID) {
+			return fmt.Errorf("%w: lot %d is not a lot of SKU %s", ErrInvalidMovement, movement.LotID, sku.Code)
+		}
+		if err != nil {
+			return err
+		}
+		movement.Lot = lot
+		return nil
+	}
+
+	if movement.Lot == nil {
+		if sku.LotControlled && movement.Quantity > 0 && !transferLeg {
+			return fmt.Errorf("%w: SKU %s is lot-controlled, so the lot is required", ErrInvalidMovement, sku.Code)
+		}
+		return nil
+	}
+
+	number := strings.TrimSpace(movement.Lot.Number)
+	if number == "" {
+		return fmt.Errorf("%w: lot number is required", ErrInvalidMovement)
+	}
+	lot, err := tx.Lots().GetByNumber(ctx, sku.ID, number)
+	if errors.Is(err, repository.ErrNotFound) {
+		if movement.Type != models.MovementTypeReceipt && movement.Type != models.MovementTypeReturn {
+			return fmt.Errorf("%w: SKU %s has no lot %s", ErrInvalidMovement, sku.Code, number)
+		}
+		lot, err = createLot(ctx, tx, sku, number, movement.Lot)
+	}
+	if err != nil {
+		return err
+	}
+	movement.LotID = lot.ID
+	movement.Lot = lot
+	return nil
+}
+
+// createLot records a new lot of a SKU from the details given with its first receipt
+func createLot(ctx context.Context, tx repository.Store, sku *models.SKU, number string, details *models.Lot) (*models.Lot, error) {
+	lot := &models.Lot{
+		SKUID:             sku.ID,
+		Number:            number,
+		ManufacturedOn:    utcDate(details.ManufacturedOn),
+		ExpiresOn:         utcDate(details.ExpiresOn),
+		SupplierReference: details.SupplierReference,
+	}
+	if lot.ManufacturedOn != nil && lot.ExpiresOn != nil && lot.ExpiresOn.Before(*lot.ManufacturedOn) {
+		return nil, fmt.Errorf("%w: lot %s expires before it was manufactured", ErrInvalidMovement, number)
+	}
+	if err := tx.Lots().Create(ctx, lot); err != nil {
+		return nil, err
+	}
+	return lot, nil
+}
+
+// utcDate returns t in UTC, so stored dates compare in one time zone
+func utcDate(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
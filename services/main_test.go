@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"inventory-supply-chain-system/db"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+
+	"gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Purpose tokens such as email verification links are signed with HS256
+	os.Setenv("JWT_SIGNING_ALG", "HS256")
+	os.Setenv("JWT_SECRET", "services-test-secret")
+	if err := utils.LoadKeys(); err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
+// newStore returns a store over a fresh migrated sqlite::memory: database
+func newStore(t *testing.T) repository.Store {
+	t.Helper()
+	database, err := db.Open("sqlite::memory:")
+	if err != nil {
+		t.Fatalf("opening the database: %v", err)
+	}
+	database.Logger = logger.Discard
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrating the database: %v", err)
+	}
+	return repository.NewStore(database)
+}
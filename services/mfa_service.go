@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/pkg/totp"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+)
+
+const (
+	// MFAChallengeTTL is how long the second login step can take
+	MFAChallengeTTL = 5 * time.Minute
+	// RecoveryCodeCount is how many recovery codes a user is given at a time
+	RecoveryCodeCount = 10
+
+	mfaLoginPurpose = "mfa_login"
+)
+
+var (
+	// ErrMFAAlreadyEnabled is returned when enrolling a user who already uses MFA
+	ErrMFAAlreadyEnabled = errors.New("multi-factor authentication is already enabled")
+	// ErrMFANotEnabled is returned for MFA operations on a user who has not enrolled
+	ErrMFANotEnabled = errors.New("multi-factor authentication is not enabled")
+	// ErrMFARequired is returned when disabling MFA for a role that requires it
+	ErrMFARequired = errors.New("multi-factor authentication is required for this role")
+	// ErrInvalidMFACode is returned for wrong, reused or expired codes
+	ErrInvalidMFACode = errors.New("invalid authentication code")
+	// ErrInvalidMFAChallenge is returned for malformed or expired challenge tokens
+	ErrInvalidMFAChallenge = errors.New("invalid or expired MFA challenge")
+)
+
+// MFAEnrollment is what a user needs to add their account to an
+// authenticator app. URI is the payload to render as a QR code.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// MFAChallenge is returned by a login that needs a second factor. The token
+// is exchanged for a session together with a TOTP or recovery code.
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// MFAService enrolls users in TOTP multi-factor authentication and checks
+// their second factor at login
+type MFAService struct {
+	store  repository.Store
+	policy *AccountPolicy
+	logins *LoginService
+}
+
+// NewMFAService returns an MFAService over store. Failed second factors are
+// counted against the account through logins.
+func NewMFAService(store repository.Store, policy *AccountPolicy, logins *LoginService) *MFAService {
+	return &MFAService{store: store, policy: policy, logins: logins}
+}
+
+// BeginMFAEnrollment generates a new TOTP secret for a user. MFA is only
+// turned on once ConfirmMFAEnrollment sees a code from it.
+func (s *MFAService) BeginMFAEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error) {
+	user, err := s.store.Users().Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnrolled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Users().Update(ctx, user.ID, map[string]interface{}{"mfa_secret": secret, "mfa_enabled": false}); err != nil {
+		return nil, err
+	}
+
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = "ISCS"
+	}
+	return &MFAEnrollment{Secret: secret, URI: totp.URI(issuer, user.Email, secret)}, nil
+}
+
+// ConfirmMFAEnrollment turns MFA on once the user proves their app produces
+// valid codes, and returns their first set of recovery codes
+func (s *MFAService) ConfirmMFAEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		user, err := tx.Users().Lock(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user.MFAEnrolled() {
+			return ErrMFAAlreadyEnabled
+		}
+		if user.MFASecret == "" {
+			return ErrMFANotEnabled
+		}
+		if err := useTOTP(ctx, tx, user, code); err != nil {
+			return err
+		}
+
+		if err := tx.Users().Update(ctx, user.ID, map[string]interface{}{"mfa_enabled": true}); err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(ctx, tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// DisableMFA turns MFA off after checking a current TOTP or recovery code
+func (s *MFAService) DisableMFA(ctx context.Context, userID uint, code string) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		user, err := tx.Users().Lock(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.MFAEnrolled() {
+			return ErrMFANotEnabled
+		}
+		if s.policy.MFARequired(user) {
+			return ErrMFARequired
+		}
+		if err := verifySecondFactor(ctx, tx, user, code); err != nil {
+			return err
+		}
+		return clearMFA(ctx, tx, user.ID)
+	})
+}
+
+// ResetMFA turns MFA off for a user who lost both their authenticator and
+// their recovery codes. It is an administrator action; the user's sessions
+// are ended.
+func (s *MFAService) ResetMFA(ctx context.Context, userID uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if _, err := tx.Users().Lock(ctx, userID); err != nil {
+			return err
+		}
+		if err := clearMFA(ctx, tx, userID); err != nil {
+			return err
+		}
+		return revokeAllSessions(ctx, tx, userID)
+	})
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes after checking a
+// current TOTP code. Earlier codes stop working.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	var codes []string
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		user, err := tx.Users().Lock(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.MFAEnrolled() {
+			return ErrMFANotEnabled
+		}
+		if err := useTOTP(ctx, tx, user, code); err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(ctx, tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// StartMFAChallenge issues the token for the second step of a login by a
+// user whose password has been checked
+func (s *MFAService) StartMFAChallenge(user models.User) (*MFAChallenge, error) {
+	token, err := utils.GeneratePurposeToken(mfaLoginPurpose, strconv.FormatUint(uint64(user.ID), 10), user.Email, MFAChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAChallenge{MFARequired: true, MFAToken: token, ExpiresIn: int(MFAChallengeTTL.Seconds())}, nil
+}
+
+// CompleteMFAChallenge checks the second factor for a login challenge and
+// returns the user to start a session for. Wrong codes count as failed
+// logins, so the account is locked out the same way as for wrong passwords.
+func (s *MFAService) CompleteMFAChallenge(ctx context.Context, challenge, code, ip string) (models.User, error) {
+	claims, err := utils.ValidatePurposeToken(challenge, mfaLoginPurpose)
+	if err != nil {
+		return models.User{}, ErrInvalidMFAChallenge
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return models.User{}, ErrInvalidMFAChallenge
+	}
+	if err := s.logins.checkLoginThrottle(ctx, claims.Email, ip); err != nil {
+		return models.User{}, err
+	}
+
+	var user *models.User
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		user, err = tx.Users().Lock(ctx, uint(userID))
+		if err != nil {
+			return ErrInvalidMFAChallenge
+		}
+		if !user.MFAEnrolled() || user.Email != claims.Email {
+			return ErrInvalidMFAChallenge
+		}
+		return verifySecondFactor(ctx, tx, user, code)
+	})
+	if errors.Is(err, ErrInvalidMFACode) {
+		if err := s.logins.recordLoginFailure(ctx, claims.Email, ip, uint(userID)); err != nil {
+			return models.User{}, err
+		}
+		return models.User{}, ErrInvalidMFACode
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if err := s.logins.clearLoginFailures(ctx, user.Email); err != nil {
+		return models.User{}, err
+	}
+	return *user, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code
+func verifySecondFactor(ctx context.Context, tx repository.Store, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return useTOTP(ctx, tx, user, code)
+	}
+	return useRecoveryCode(ctx, tx, user.ID, code)
+}
+
+// useTOTP checks a TOTP code and records its time step so it cannot be used
+// again. A user without a secret has no code that can match.
+func useTOTP(ctx context.Context, tx repository.Store, user *models.User, code string) error {
+	if user.MFASecret == "" {
+		return ErrInvalidMFACode
+	}
+	counter, ok := totp.Validate(user.MFASecret, code, time.Now())
+	if !ok || counter <= user.MFALastCounter {
+		return ErrInvalidMFACode
+	}
+	user.MFALastCounter = counter
+	return tx.Users().Update(ctx, user.ID, map[string]interface{}{"mfa_last_counter": counter})
+}
+
+// useRecoveryCode marks a recovery code used if it belongs to the user
+func useRecoveryCode(ctx context.Context, tx repository.Store, userID uint, code string) error {
+	used, err := tx.RecoveryCodes().Use(ctx, userID, hashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and issues new ones
+func replaceRecoveryCodes(ctx context.Context, tx repository.Store, userID uint) ([]string, error) {
+	if err := tx.RecoveryCodes().DeleteForUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	records := make([]models.RecoveryCode, RecoveryCodeCount)
+	for i := range codes {
+		raw, err := utils.RandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hashToken(raw)}
+	}
+	if err := tx.RecoveryCodes().Create(ctx, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// clearMFA turns MFA off and removes the secret and recovery codes
+func clearMFA(ctx context.Context, tx repository.Store, userID uint) error {
+	if err := tx.RecoveryCodes().DeleteForUser(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Users().Update(ctx, userID, map[string]interface{}{
+		"mfa_enabled":      false,
+		"mfa_secret":       "",
+		"mfa_last_counter": 0,
+	})
+}
+
+// normalizeRecoveryCode drops the separator and case users may type a code with
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/pkg/oidc"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+)
+
+// OIDCLoginTTL is how long a user has to finish signing in at the provider
+const OIDCLoginTTL = 10 * time.Minute
+
+var (
+	// ErrOIDCNotConfigured is returned when single sign-on is used without a provider
+	ErrOIDCNotConfigured = errors.New("single sign-on is not configured")
+	// ErrInvalidOIDCLogin is returned for unknown, expired or already used login states
+	ErrInvalidOIDCLogin = errors.New("invalid or expired single sign-on login")
+	// ErrOIDCIdentity is returned when the provider's identity cannot be mapped to a user
+	ErrOIDCIdentity = errors.New("single sign-on identity rejected")
+)
+
+// rolePriority orders roles from most to least privileged, to pick one
+// when a user is in groups mapped to several
+var rolePriority = []string{authz.RoleAdmin, authz.RoleManager, authz.RoleStaff, authz.RoleViewer}
+
+// OIDCMapping maps the provider's groups onto roles and permissions. When
+// both are empty, roles are managed here and new users get DefaultRole.
+type OIDCMapping struct {
+	Roles       map[string]string
+	Permissions map[string][]string
+}
+
+// managed reports whether the provider's groups decide roles and permissions
+func (m OIDCMapping) managed() bool {
+	return len(m.Roles) > 0 || len(m.Permissions) > 0
+}
+
+// OIDCService signs users in through an OpenID Connect provider
+type OIDCService struct {
+	store    repository.Store
+	provider *oidc.Provider
+	mapping  OIDCMapping
+}
+
+// NewOIDCService returns an OIDCService over store. A nil provider leaves
+// single sign-on disabled.
+func NewOIDCService(store repository.Store, provider *oidc.Provider, mapping OIDCMapping) *OIDCService {
+	return &OIDCService{store: store, provider: provider, mapping: mapping}
+}
+
+// ParseOIDCMapping reads group mappings written as "group=role,group=role"
+// and "group=perm|perm,group=perm"
+func ParseOIDCMapping(roles, permissions string) (OIDCMapping, error) {
+	mapping := OIDCMapping{Roles: map[string]string{}, Permissions: map[string][]string{}}
+
+	for _, entry := range splitList(roles) {
+		group, role, ok := strings.Cut(entry, "=")
+		if !ok || !authz.IsRole(role) {
+			return mapping, fmt.Errorf("invalid role mapping %q", entry)
+		}
+		mapping.Roles[group] = role
+	}
+	for _, entry := range splitList(permissions) {
+		group, perms, ok := strings.Cut(entry, "=")
+		if !ok {
+			return mapping, fmt.Errorf("invalid permission mapping %q", entry)
+		}
+		for _, p := range strings.Split(perms, "|") {
+			if !authz.IsPermission(p) {
+				return mapping, fmt.Errorf("%w: %q", ErrUnknownPermission, p)
+			}
+			mapping.Permissions[group] = append(mapping.Permissions[group], p)
+		}
+	}
+	return mapping, nil
+}
+
+// BeginOIDCLogin starts a single sign-on login and returns the provider
+// URL to send the user to
+func (s *OIDCService) BeginOIDCLogin(ctx context.Context) (string, error) {
+	if s.provider == nil {
+		return "", ErrOIDCNotConfigured
+	}
+
+	state, err := utils.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := utils.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := s.store.OIDCLogins().Create(ctx, &models.OIDCLogin{
+		StateHash:    hashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    now.Add(OIDCLoginTTL),
+	}); err != nil {
+		return "", err
+	}
+
+	// Expired logins are only ever read once, so clear them out as we go
+	s.store.OIDCLogins().DeleteExpired(ctx, now)
+
+	return s.provider.AuthCodeURL(state, nonce, challenge), nil
+}
+
+// CompleteOIDCLogin handles the provider's redirect: it exchanges the code,
+// verifies the ID token and returns the matching user, creating or updating
+// them from the token's claims
+func (s *OIDCService) CompleteOIDCLogin(ctx context.Context, state, code string) (models.User, error) {
+	if s.provider == nil {
+		return models.User{}, ErrOIDCNotConfigured
+	}
+
+	login, err := s.store.OIDCLogins().Consume(ctx, hashToken(state))
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.User{}, ErrInvalidOIDCLogin
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if time.Now().After(login.ExpiresAt) {
+		return models.User{}, ErrInvalidOIDCLogin
+	}
+
+	tokens, err := s.provider.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%w: %v", ErrOIDCIdentity, err)
+	}
+	identity, err := s.provider.VerifyIDToken(ctx, tokens.IDToken, login.Nonce)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%w: %v", ErrOIDCIdentity, err)
+	}
+	return s.provisionUser(ctx, identity)
+}
+
+// provisionUser finds the user for a provider identity, linking an existing
+// account with the same verified email or creating one. When group mappings
+// are configured, the user's role and permissions follow their groups on
+// every login.
+func (s *OIDCService) provisionUser(ctx context.Context, identity *oidc.Identity) (models.User, error) {
+	externalID := identity.Issuer + "#" + identity.Subject
+
+	var user *models.User
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		user, err = tx.Users().GetByExternalID(ctx, externalID)
+		if errors.Is(err, repository.ErrNotFound) {
+			if identity.Email == "" || !identity.EmailVerified {
+				return fmt.Errorf("%w: the provider did not supply a verified email", ErrOIDCIdentity)
+			}
+			user, err = tx.Users().GetByEmail(ctx, identity.Email)
+			if errors.Is(err, repository.ErrNotFound) {
+				user = &models.User{
+					Name:        identity.Name,
+					Email:       identity.Email,
+					Role:        authz.DefaultRole,
+					Permissions: models.StringArray{},
+					Verified:    true,
+					ExternalID:  externalID,
+				}
+				if s.mapping.managed() {
+					user.Role, user.Permissions = s.mapGroups(identity.Groups)
+				}
+				return tx.Users().Create(ctx, user)
+			}
+			if err != nil {
+				return err
+			}
+			if user.ExternalID != "" {
+				return fmt.Errorf("%w: the email belongs to another single sign-on identity", ErrOIDCIdentity)
+			}
+			user.ExternalID = externalID
+			user.Verified = true
+			if err := tx.Users().Update(ctx, user.ID, map[string]interface{}{"external_id": externalID, "verified": true}); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if !s.mapping.managed() {
+			return nil
+		}
+		role, permissions := s.mapGroups(identity.Groups)
+		if role == user.Role && strings.Join(permissions, ",") == strings.Join(user.Permissions, ",") {
+			return nil
+		}
+		user.Role, user.Permissions = role, permissions
+		user.TokenVersion++
+		return tx.Users().Update(ctx, user.ID, map[string]interface{}{
+			"role":          user.Role,
+			"permissions":   user.Permissions,
+			"token_version": user.TokenVersion,
+		})
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return *user, nil
+}
+
+// mapGroups returns the most privileged role and the union of the
+// permissions the groups map to
+func (s *OIDCService) mapGroups(groups []string) (string, models.StringArray) {
+	roles := make(map[string]bool)
+	set := make(map[string]bool)
+	for _, g := range groups {
+		if role, ok := s.mapping.Roles[g]; ok {
+			roles[role] = true
+		}
+		for _, p := range s.mapping.Permissions[g] {
+			set[p] = true
+		}
+	}
+
+	role := authz.DefaultRole
+	for _, r := range rolePriority {
+		if roles[r] {
+			role = r
+			break
+		}
+	}
+
+	permissions := make(models.StringArray, 0, len(set))
+	for p := range set {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return role, permissions
+}
+
+// splitList splits a comma-separated setting, dropping blanks
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
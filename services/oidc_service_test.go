@@ -0,0 +1,275 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"inventory-supply-chain-system/internal/authz"
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/pkg/oidc"
+	"inventory-supply-chain-system/pkg/oidc/oidctest"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+)
+
+// oidcFixture is an OIDCService signing users in through a mock provider
+type oidcFixture struct {
+	t        *testing.T
+	store    repository.Store
+	provider *oidctest.Provider
+	service  *services.OIDCService
+}
+
+func newOIDCFixture(t *testing.T, mapping services.OIDCMapping) *oidcFixture {
+	t.Helper()
+	mock, err := oidctest.NewProvider("iscs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mock.Close)
+
+	provider, err := oidc.Discover(context.Background(), oidc.Config{
+		Issuer:      mock.Issuer(),
+		ClientID:    mock.ClientID,
+		RedirectURL: "http://iscs.test/api/users/oidc/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newStore(t)
+	return &oidcFixture{t: t, store: store, provider: mock, service: services.NewOIDCService(store, provider, mapping)}
+}
+
+// begin starts a login and returns the provider URL it sends the user to
+func (f *oidcFixture) begin() *url.URL {
+	f.t.Helper()
+	authURL, err := f.service.BeginOIDCLogin(context.Background())
+	if err != nil {
+		f.t.Fatalf("BeginOIDCLogin: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return parsed
+}
+
+// approve sends the user to the provider and returns the state and code it
+// redirects back with
+func (f *oidcFixture) approve(authURL *url.URL) (state, code string) {
+	f.t.Helper()
+	back, err := f.provider.Login(authURL.String())
+	if err != nil {
+		f.t.Fatalf("logging in at the provider: %v", err)
+	}
+	return back.Query().Get("state"), back.Query().Get("code")
+}
+
+// login runs a whole login as the given claims
+func (f *oidcFixture) login(claims map[string]interface{}) (models.User, error) {
+	f.t.Helper()
+	f.provider.SetUser(claims)
+	state, code := f.approve(f.begin())
+	return f.service.CompleteOIDCLogin(context.Background(), state, code)
+}
+
+func TestOIDCLoginUsesStateNonceAndPKCE(t *testing.T) {
+	f := newOIDCFixture(t, services.OIDCMapping{})
+	authURL := f.begin()
+
+	q := authURL.Query()
+	for _, param := range []string{"state", "nonce", "code_challenge"} {
+		if q.Get(param) == "" {
+			t.Errorf("authorization URL has no %s", param)
+		}
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("response_type") != "code" {
+		t.Errorf("authorization URL = %s, want the code flow with S256 PKCE", authURL)
+	}
+
+	// Every login gets its own state and nonce
+	other := f.begin().Query()
+	if other.Get("state") == q.Get("state") || other.Get("nonce") == q.Get("nonce") || other.Get("code_challenge") == q.Get("code_challenge") {
+		t.Error("two logins share a state, nonce or PKCE challenge")
+	}
+}
+
+func TestOIDCLoginRejectsUnknownState(t *testing.T) {
+	f := newOIDCFixture(t, services.OIDCMapping{})
+	_, code := f.approve(f.begin())
+
+	_, err := f.service.CompleteOIDCLogin(context.Background(), "forged-state", code)
+	if !errors.Is(err, services.ErrInvalidOIDCLogin) {
+		t.Fatalf("CompleteOIDCLogin with a forged state = %v, want ErrInvalidOIDCLogin", err)
+	}
+}
+
+func TestOIDCLoginStateWorksOnce(t *testing.T) {
+	f := newOIDCFixture(t, services.OIDCMapping{})
+	state, code := f.approve(f.begin())
+	if _, err := f.service.CompleteOIDCLogin(context.Background(), state, code); err != nil {
+		t.Fatalf("first CompleteOIDCLogin: %v", err)
+	}
+
+	_, err := f.service.CompleteOIDCLogin(context.Background(), state, code)
+	if !errors.Is(err, services.ErrInvalidOIDCLogin) {
+		t.Fatalf("replayed CompleteOIDCLogin = %v, want ErrInvalidOIDCLogin", err)
+	}
+}
+
+func TestOIDCLoginRejectsNonceMismatch(t *testing.T) {
+	f := newOIDCFixture(t, services.OIDCMapping{})
+
+	// The provider puts a nonce from another login in the ID token
+	_, err := f.login(map[string]interface{}{
+		"sub": "user-1", "email": "user@example.com", "email_verified": true, "nonce": "another-login",
+	})
+	if !errors.Is(err, services.ErrOIDCIdentity) {
+		t.Fatalf("CompleteOIDCLogin with a wrong nonce = %v, want ErrOIDCIdentity", err)
+	}
+	if _, err := f.store.Users().GetByEmail(context.Background(), "user@example.com"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("a user was created from a rejected token: %v", err)
+	}
+}
+
+func TestOIDCLoginRejectsWrongPKCEChallenge(t *testing.T) {
+	f := newOIDCFixture(t, services.OIDCMapping{})
+
+	// An attacker swaps in their own challenge; the stored verifier no
+	// longer matches it, so the provider refuses the code exchange
+	authURL := f.begin()
+	q := authURL.Query()
+	q.Set("code_challenge", oidc.Challenge("attacker-verifier"))
+	authURL.RawQuery = q.Encode()
+	state, code := f.approve(authURL)
+
+	_, err := f.service.CompleteOIDCLogin(context.Background(), state, code)
+	if !errors.Is(err, services.ErrOIDCIdentity) {
+		t.Fatalf("CompleteOIDCLogin with a mismatched PKCE challenge = %v, want ErrOIDCIdentity", err)
+	}
+}
+
+func TestOIDCLoginProvisionsUsers(t *testing.T) {
+	f := newOIDCFixture(t, services.OIDCMapping{})
+	claims := map[string]interface{}{"sub": "user-1", "email": "new@example.com", "email_verified": true, "name": "New User"}
+
+	user, err := f.login(claims)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if user.ID == 0 || user.Email != "new@example.com" || user.Name != "New User" || !user.Verified || user.Role != authz.DefaultRole {
+		t.Fatalf("provisioned user = %+v", user)
+	}
+	if user.ExternalID != f.provider.Issuer()+"#user-1" {
+		t.Fatalf("external ID = %q", user.ExternalID)
+	}
+
+	// Later logins find the same user by their identity, even with a new email
+	claims["email"] = "renamed@example.com"
+	again, err := f.login(claims)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.ID != user.ID {
+		t.Fatalf("second login returned user %d, want %d", again.ID, user.ID)
+	}
+}
+
+func TestOIDCLoginLinksAccountsByVerifiedEmail(t *testing.T) {
+	f := newOIDCFixture(t, services.OIDCMapping{})
+	existing := models.User{Name: "Existing", Email: "existing@example.com", Password: "correct-horse-42", Role: authz.RoleStaff}
+	if err := services.NewUserService(f.store).CreateUser(context.Background(), &existing); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unverified email could belong to anyone, so it does not link
+	_, err := f.login(map[string]interface{}{"sub": "user-2", "email": "existing@example.com", "email_verified": false})
+	if !errors.Is(err, services.ErrOIDCIdentity) {
+		t.Fatalf("login with an unverified email = %v, want ErrOIDCIdentity", err)
+	}
+
+	user, err := f.login(map[string]interface{}{"sub": "user-2", "email": "existing@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != existing.ID || user.Role != authz.RoleStaff || !user.Verified {
+		t.Fatalf("linked user = %+v, want the existing staff account, verified", user)
+	}
+
+	// Another identity cannot take over the linked account
+	_, err = f.login(map[string]interface{}{"sub": "user-3", "email": "existing@example.com", "email_verified": true})
+	if !errors.Is(err, services.ErrOIDCIdentity) {
+		t.Fatalf("login by a second identity = %v, want ErrOIDCIdentity", err)
+	}
+}
+
+func TestOIDCLoginMapsGroups(t *testing.T) {
+	mapping, err := services.ParseOIDCMapping("inventory-admins=admin,warehouse=staff,viewers=viewer",
+		"erp-team=api-keys:manage|users:read,auditors=audit:read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newOIDCFixture(t, mapping)
+	claims := map[string]interface{}{
+		"sub": "user-1", "email": "mapped@example.com", "email_verified": true,
+		"groups": []string{"viewers", "warehouse", "erp-team", "unmapped"},
+	}
+
+	// The most privileged role wins and the permissions are combined
+	user, err := f.login(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != authz.RoleStaff {
+		t.Errorf("role = %s, want staff", user.Role)
+	}
+	if want := (models.StringArray{authz.APIKeysManage, authz.UsersRead}); !reflect.DeepEqual(user.Permissions, want) {
+		t.Errorf("permissions = %v, want %v", user.Permissions, want)
+	}
+
+	// Group changes apply at the next login and end the tokens issued before
+	claims["groups"] = []string{"inventory-admins"}
+	promoted, err := f.login(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if promoted.Role != authz.RoleAdmin || len(promoted.Permissions) != 0 {
+		t.Errorf("after the group change: role %s, permissions %v; want admin and none", promoted.Role, promoted.Permissions)
+	}
+	if promoted.TokenVersion <= user.TokenVersion {
+		t.Errorf("token version %d after the group change, was %d", promoted.TokenVersion, user.TokenVersion)
+	}
+
+	// Leaving every mapped group falls back to the default role
+	claims["groups"] = []string{}
+	demoted, err := f.login(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if demoted.Role != authz.DefaultRole {
+		t.Errorf("without groups: role %s, want %s", demoted.Role, authz.DefaultRole)
+	}
+}
+
+func TestParseOIDCMappingRejectsUnknownRolesAndPermissions(t *testing.T) {
+	if _, err := services.ParseOIDCMapping("admins=superuser", ""); err == nil {
+		t.Error("an unknown role was accepted")
+	}
+	if _, err := services.ParseOIDCMapping("", "erp=orders:delete"); !errors.Is(err, services.ErrUnknownPermission) {
+		t.Errorf("an unknown permission = %v, want ErrUnknownPermission", err)
+	}
+}
+
+func TestOIDCLoginNeedsAProvider(t *testing.T) {
+	service := services.NewOIDCService(newStore(t), nil, services.OIDCMapping{})
+	if _, err := service.BeginOIDCLogin(context.Background()); !errors.Is(err, services.ErrOIDCNotConfigured) {
+		t.Errorf("BeginOIDCLogin = %v, want ErrOIDCNotConfigured", err)
+	}
+	if _, err := service.CompleteOIDCLogin(context.Background(), "state", "code"); !errors.Is(err, services.ErrOIDCNotConfigured) {
+		t.Errorf("CompleteOIDCLogin = %v, want ErrOIDCNotConfigured", err)
+	}
+}
@@ -1,293 +1,362 @@
 package services
 
 import (
-	"inventory-supply-chain-system/db"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 )
 
-func CreateOrder(order models.Order) error {
-	result := db.DB.Create(&order)
-	if result.Error != nil {
-		return result.Error
-	}
+var (
+	// ErrInsufficientStock is returned when an order asks for more units than are available
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrStockExpired is returned when an order cannot ship because the stock
+	// left for it is in lots that have expired
+	ErrStockExpired = errors.New("stock expired")
+	// ErrInvalidQuantity is returned when an order quantity is not positive
+	ErrInvalidQuantity = errors.New("quantity must be greater than zero")
+	// ErrInvalidStatusTransition is returned when an order cannot move to the requested status
+	ErrInvalidStatusTransition = errors.New("invalid order status transition")
+)
 
-	return nil
+// orderTransitions lists the statuses each order status may move to
+var orderTransitions = map[string][]string{
+	models.OrderStatusPending:    {models.OrderStatusProcessing, models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusProcessing: {models.OrderStatusShipped, models.OrderStatusCancelled},
+	models.OrderStatusShipped:    {models.OrderStatusDelivered},
 }
 
-// GetAllOrders returns a list of all orders
-func GetAllOrders() ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Find(&orders).Error
-	return orders, err
+// OrderService places orders and moves them through their lifecycle
+type OrderService struct {
+	store repository.Store
 }
 
-// GetOrder returns an order by ID
-func GetOrder(id string) (models.Order, error) {
-	var order models.Order
-	err := db.DB.First(&order, id).Error
-	return order, err
+// NewOrderService returns an OrderService over store
+func NewOrderService(store repository.Store) *OrderService {
+	return &OrderService{store: store}
 }
 
-// UpdateOrder updates an existing order
-func UpdateOrder(order models.Order) error {
-	result := db.DB.Save(&order)
-	if result.Error != nil {
-		return result.Error
+// CreateOrder places an order in a single transaction: it locks the
+// inventory row, converts a quantity given in another unit to base units of
+// the SKU, checks that enough unreserved stock is left outside expired lots,
+// in the order's warehouse if it names one, prices the order from the price
+// of its SKU and reserves the stock for it. Concurrent
+// orders for the same inventory queue on the row lock, so stock is never
+// reserved twice.
+func (s *OrderService) CreateOrder(ctx context.Context, order *models.Order) error {
+	if order.Quantity < 0 || order.UnitQuantity < 0 {
+		return ErrInvalidQuantity
 	}
 
-	return nil
-}
-
-// DeleteOrder deletes an order by ID
-func DeleteOrder(id string) error {
-
-	result := db.DB.Where("id = ?", id).Delete(&models.Order{})
-	if result.Error != nil {
-		return result.Error
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		inventory, err := tx.Inventory().Lock(ctx, order.InventoryID)
+		if err != nil {
+			return err
+		}
+
+		if err := applyUnit(ctx, tx, inventory.SKU, &order.Quantity, &order.Unit, &order.UnitQuantity); err != nil {
+			return err
+		}
+		if order.Quantity <= 0 {
+			return ErrInvalidQuantity
+		}
+
+		// Expired lots stay on hand until they are adjusted out, but can
+		// never ship, so they are not available to order
+		expired, err := tx.Stock().ExpiredQuantity(ctx, inventory.ID, 0, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		available := inventory.Quantity - inventory.Reserved - expired
+		if available < order.Quantity {
+			if expired > 0 {
+				return fmt.Errorf("%w: %d requested, %d available and %d more in expired lots", ErrInsufficientStock, order.Quantity, max(available, 0), expired)
+			}
+			return fmt.Errorf("%w: %d requested, %d available", ErrInsufficientStock, order.Quantity, available)
+		}
+		if order.WarehouseID != 0 {
+			if err := checkWarehouseStock(ctx, tx, order); err != nil {
+				return err
+			}
+		}
+
+		order.TotalPrice = inventory.SKU.Price * float64(order.Quantity)
+		order.Status = models.OrderStatusPending
+		if err := tx.Orders().Create(ctx, order); err != nil {
+			return err
+		}
+
+		if err := tx.Inventory().AdjustReserved(ctx, inventory.ID, order.Quantity); err != nil {
+			return err
+		}
+
+		return tx.Orders().CreateReservation(ctx, &models.StockReservation{
+			OrderID:     order.ID,
+			InventoryID: order.InventoryID,
+			Quantity:    order.Quantity,
+			Status:      models.ReservationStatusActive,
+		})
+	})
+}
+
+// checkWarehouseStock checks that the warehouse an order ships from holds
+// enough stock for it that is not reserved by other orders from there. It
+// counts the balances issueForOrder ships from, so expired lots and
+// inactive warehouses hold none.
+func checkWarehouseStock(ctx context.Context, tx repository.Store, order *models.Order) error {
+	balances, err := tx.Stock().IssuableBalances(ctx, order.InventoryID, order.WarehouseID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	issuable := 0
+	for _, balance := range balances {
+		issuable += balance.Quantity
 	}
 
+	reserved, err := tx.Orders().ReservedIn(ctx, order.InventoryID, order.WarehouseID)
+	if err != nil {
+		return err
+	}
+	if available := issuable - reserved; available < order.Quantity {
+		return fmt.Errorf("%w: %d requested, %d available in warehouse %d", ErrInsufficientStock, order.Quantity, max(available, 0), order.WarehouseID)
+	}
 	return nil
 }
 
-// GetOrdersByCustomerID returns a list of orders by customer ID
-
-func GetOrdersByCustomerID(customerID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ?", customerID).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByVendorID returns a list of orders by vendor ID
-func GetOrdersByVendorID(vendorID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ?", vendorID).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByProductID returns a list of orders by product ID
-func GetOrdersByProductID(productID string) ([]models.Order, error) {
-
-	var orders []models.Order
-	err := db.DB.Where("product_id = ?", productID).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByShipmentID returns a list of orders by shipment ID
-func GetOrdersByShipmentID(shipmentID string) ([]models.Order, error) {
-
-	var orders []models.Order
-	err := db.DB.Where("shipment_id = ?", shipmentID).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByStatus returns a list of orders by status
-func GetOrdersByStatus(status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("status = ?", status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByDateRange returns a list of orders by date range
-func GetOrdersByDateRange(startDate string, endDate string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("created_at BETWEEN ? AND ?", startDate, endDate).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByCustomerIDAndStatus returns a list of orders by customer ID and status
-func GetOrdersByCustomerIDAndStatus(customerID string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND status = ?", customerID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByVendorIDAndStatus returns a list of orders by vendor ID and status
-func GetOrdersByVendorIDAndStatus(vendorID string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ? AND status = ?", vendorID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByProductIDAndStatus returns a list of orders by product ID and status
-func GetOrdersByProductIDAndStatus(productID string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("product_id = ? AND status = ?", productID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByShipmentIDAndStatus returns a list of orders by shipment ID and status
-func GetOrdersByShipmentIDAndStatus(shipmentID string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("shipment_id = ? AND status = ?", shipmentID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByDateRangeAndStatus returns a list of orders by date range and status
-func GetOrdersByDateRangeAndStatus(startDate string, endDate string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("created_at BETWEEN ? AND ? AND status = ?", startDate, endDate, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByCustomerIDAndDateRange returns a list of orders by customer ID and date range
-func GetOrdersByCustomerIDAndDateRange(customerID string, startDate string, endDate string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND created_at BETWEEN ? AND ?", customerID, startDate, endDate).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByVendorIDAndDateRange returns a list of orders by vendor ID and date range
-func GetOrdersByVendorIDAndDateRange(vendorID string, startDate string, endDate string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ? AND created_at BETWEEN ? AND ?", vendorID, startDate, endDate).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByProductIDAndDateRange returns a list of orders by product ID and date range
-func GetOrdersByProductIDAndDateRange(productID string, startDate string, endDate string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("product_id = ? AND created_at BETWEEN ? AND ?", productID, startDate, endDate).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByShipmentIDAndDateRange returns a list of orders by shipment ID and date range
-func GetOrdersByShipmentIDAndDateRange(shipmentID string, startDate string, endDate string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("shipment_id = ? AND created_at BETWEEN ? AND ?", shipmentID, startDate, endDate).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByCustomerIDVendorIDAndStatus returns a list of orders by customer ID, vendor ID, and status
-func GetOrdersByCustomerIDVendorIDAndStatus(customerID string, vendorID string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND vendor_id = ? AND status = ?", customerID, vendorID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByCustomerIDProductIDAndStatus returns a list of orders by customer ID, product ID, and status
-func GetOrdersByCustomerIDProductIDAndStatus(customerID string, productID string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND product_id = ? AND status = ?", customerID, productID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByCustomerIDShipmentIDAndStatus returns a list of orders by customer ID, shipment ID, and status
-func GetOrdersByCustomerIDShipmentIDAndStatus(customerID string, shipmentID string, status string) ([]models.Order, error) {
-
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND shipment_id = ? AND status = ?", customerID, shipmentID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByCustomerIDDateRangeAndStatus returns a list of orders by customer ID, date range, and status
-func GetOrdersByCustomerIDDateRangeAndStatus(customerID string, startDate string, endDate string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND created_at BETWEEN ? AND ? AND status = ?", customerID, startDate, endDate, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByVendorIDProductIDAndStatus returns a list of orders by vendor ID, product ID, and status
-func GetOrdersByVendorIDProductIDAndStatus(vendorID string, productID string, status string) ([]models.Order, error) {
-
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ? AND product_id = ? AND status = ?", vendorID, productID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByVendorIDShipmentIDAndStatus returns a list of orders by vendor ID, shipment ID, and status
-func GetOrdersByVendorIDShipmentIDAndStatus(vendorID string, shipmentID string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ? AND shipment_id = ? AND status = ?", vendorID, shipmentID, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByVendorIDDateRangeAndStatus returns a list of orders by vendor ID, date range, and status
-func GetOrdersByVendorIDDateRangeAndStatus(vendorID string, startDate string, endDate string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ? AND created_at BETWEEN ? AND ? AND status = ?", vendorID, startDate, endDate, status).Find(&orders).Error
-	return orders, err
-}
-
-// GetOrdersByProductIDShipmentIDAndStatus returns a list of orders by product ID, shipment ID, and status
-func GetOrdersByProductIDShipmentIDAndStatus(productID string, shipmentID string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("product_id = ? AND shipment_id = ? AND status = ?", productID, shipmentID, status).Find(&orders).Error
-	return orders, err
+// GetOrder returns an order by ID
+func (s *OrderService) GetOrder(ctx context.Context, id uint) (*models.Order, error) {
+	return s.store.Orders().Get(ctx, id)
+}
+
+// FindOrders returns one page of the orders matching filter
+func (s *OrderService) FindOrders(ctx context.Context, filter repository.OrderFilter, page repository.PageRequest) (repository.Page[models.Order], error) {
+	return s.store.Orders().Find(ctx, filter, page)
+}
+
+// UpdateOrder moves an order to the status carried by order on behalf of
+// userID, provided the order still has order.Version. The item, quantity and
+// price are fixed once the order is placed, so only the status is written;
+// cancelling releases the reserved stock and shipping issues it from the
+// ledger. Orders for serial-controlled SKUs are picked by giving their
+// serial numbers with the move to processing, or while processing, and at
+// the latest when they ship.
+// On success order is refreshed with the stored values.
+func (s *OrderService) UpdateOrder(ctx context.Context, order *models.Order, userID uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		current, err := tx.Orders().Lock(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if !repository.VersionMatches(current.Version, order.Version) {
+			return repository.ErrVersionConflict
+		}
+
+		serials := order.Serials
+		if len(serials) > 0 {
+			status := order.Status
+			if status == "" {
+				status = current.Status
+			}
+			picking := current.Status == models.OrderStatusPending || current.Status == models.OrderStatusProcessing
+			if !picking || (status != models.OrderStatusProcessing && status != models.OrderStatusShipped) {
+				return fmt.Errorf("%w: serial numbers are picked when an order moves to processing or shipped", ErrInvalidSerial)
+			}
+			if err := pickSerials(ctx, tx, current, serials, userID); err != nil {
+				return err
+			}
+		}
+
+		if order.Status != "" && order.Status != current.Status {
+			if !canTransition(current.Status, order.Status) {
+				return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, current.Status, order.Status)
+			}
+
+			switch order.Status {
+			case models.OrderStatusCancelled:
+				if err := settleReservation(ctx, tx, current.ID, models.ReservationStatusReleased, userID); err != nil {
+					return err
+				}
+			case models.OrderStatusShipped:
+				if err := settleReservation(ctx, tx, current.ID, models.ReservationStatusFulfilled, userID); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Orders().UpdateStatus(ctx, current.ID, order.Status, current.Version); err != nil {
+				return err
+			}
+			current.Status = order.Status
+			current.Version++
+		}
+
+		*order = *current
+		order.Serials = serials
+		return nil
+	})
+}
+
+// DeleteOrder deletes an order by ID, provided it still has version,
+// releasing any stock still reserved for it
+func (s *OrderService) DeleteOrder(ctx context.Context, id uint, version int) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		order, err := tx.Orders().Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !repository.VersionMatches(order.Version, version) {
+			return repository.ErrVersionConflict
+		}
+
+		if err := settleReservation(ctx, tx, order.ID, models.ReservationStatusReleased, 0); err != nil {
+			return err
+		}
+
+		return tx.Orders().Delete(ctx, order.ID, order.Version)
+	})
+}
+
+// canTransition reports whether an order may move from one status to another
+func canTransition(from, to string) bool {
+	for _, allowed := range orderTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
 }
 
-// GetOrdersByProductIDDateRangeAndStatus returns a list of orders by product ID, date range, and status
-func GetOrdersByProductIDDateRangeAndStatus(productID string, startDate string, endDate string, status string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("product_id = ? AND created_at BETWEEN ? AND ? AND status = ?", productID, startDate, endDate, status).Find(&orders).Error
-	return orders, err
-}
+// settleReservation closes the active reservation of an order. Releasing
+// returns the units, and any serial numbers picked, to available stock;
+// fulfilling also books an issue movement by userID that takes them out of
+// on-hand stock. Orders without an active reservation are left untouched.
+func settleReservation(ctx context.Context, tx repository.Store, orderID uint, status string, userID uint) error {
+	reservation, err := tx.Orders().ActiveReservation(ctx, orderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-// GetOrdersByVendorIDAndProductID returns orders by vendor ID and product ID
-func GetOrdersByVendorIDAndProductID(vendorID string, productID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ? AND product_id = ?", vendorID, productID).Find(&orders).Error
-	return orders, err
-}
+	inventory, err := tx.Inventory().Lock(ctx, reservation.InventoryID)
+	if err != nil {
+		return err
+	}
 
-// GetOrdersByVendorIDAndShipmentID returns orders by vendor ID and shipment ID
-func GetOrdersByVendorIDAndShipmentID(vendorID string, shipmentID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ? AND shipment_id = ?", vendorID, shipmentID).Find(&orders).Error
-	return orders, err
-}
+	if err := tx.Inventory().AdjustReserved(ctx, inventory.ID, -reservation.Quantity); err != nil {
+		return err
+	}
 
-// GetOrdersByProductIDAndShipmentID returns orders by product ID and shipment ID
-func GetOrdersByProductIDAndShipmentID(productID string, shipmentID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("product_id = ? AND shipment_id = ?", productID, shipmentID).Find(&orders).Error
-	return orders, err
-}
+	if status == models.ReservationStatusFulfilled {
+		if err := issueForOrder(ctx, tx, reservation, userID); err != nil {
+			return err
+		}
+	} else if err := releaseSerials(ctx, tx, orderID, userID); err != nil {
+		return err
+	}
 
-// GetOrdersByCustomerIDAndProductID returns orders by customer ID and product ID
-func GetOrdersByCustomerIDAndProductID(customerID string, productID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND product_id = ?", customerID, productID).Find(&orders).Error
-	return orders, err
-}
+	return tx.Orders().UpdateReservationStatus(ctx, reservation.ID, status)
+}
+
+// issueForOrder books the issue movements that take a shipped reservation out
+// of stock. Units are allocated first-expired-first-out: from the lots that
+// expire soonest, then from stock without an expiry date, largest balances
+// first; expired lots are never shipped, and an order that would need them
+// fails with ErrStockExpired. Orders naming a warehouse are
+// picked from it; otherwise the units come from active warehouses and lots
+// outside a warehouse, then from stock that has neither. Serial-controlled
+// SKUs ship the serial numbers picked for the order instead, from wherever
+// they are held.
+func issueForOrder(ctx context.Context, tx repository.Store, reservation *models.StockReservation, userID uint) error {
+	order, err := tx.Orders().Get(ctx, reservation.OrderID)
+	if err != nil {
+		return err
+	}
+	inventory, err := tx.Inventory().Get(ctx, reservation.InventoryID)
+	if err != nil {
+		return err
+	}
 
-// GetOrdersByCustomerIDAndShipmentID returns orders by customer ID and shipment ID
-func GetOrdersByCustomerIDAndShipmentID(customerID string, shipmentID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND shipment_id = ?", customerID, shipmentID).Find(&orders).Error
-	return orders, err
-}
+	issue := func(warehouseID, binID, lotID uint, quantity int, serials []string) error {
+		return recordOrderMovement(ctx, tx, &models.StockMovement{
+			InventoryID: reservation.InventoryID,
+			WarehouseID: warehouseID,
+			BinID:       binID,
+			LotID:       lotID,
+			Type:        models.MovementTypeIssue,
+			Quantity:    quantity,
+			Serials:     serials,
+			ReasonCode:  ReasonOrderShipped,
+			Reference:   fmt.Sprintf("order:%d", order.ID),
+			UserID:      userID,
+		}, order.ID)
+	}
 
-// GetOrdersByCustomerIDAndProductIDAndShipmentID returns orders by customer ID, product ID, and shipment ID
-func GetOrdersByCustomerIDAndProductIDAndShipmentID(customerID string, productID string, shipmentID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND product_id = ? AND shipment_id = ?", customerID, productID, shipmentID).Find(&orders).Error
-	return orders, err
-}
+	if inventory.SKU.SerialControlled {
+		serials, err := tx.Serials().ReservedFor(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if len(serials) != reservation.Quantity {
+			return fmt.Errorf("%w: order %d needs %d serial numbers picked to ship, %d are", ErrInvalidSerial, order.ID, reservation.Quantity, len(serials))
+		}
+		for _, group := range groupSerials(serials) {
+			if err := issue(group.WarehouseID, group.BinID, group.LotID, len(group.Serials), group.Serials); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 
-// GetOrdersByVendorIDAndProductIDAndShipmentID returns orders by vendor ID, product ID, and shipment ID
-func GetOrdersByVendorIDAndProductIDAndShipmentID(vendorID string, productID string, shipmentID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("vendor_id = ? AND product_id = ? AND shipment_id = ?", vendorID, productID, shipmentID).Find(&orders).Error
-	return orders, err
-}
+	now := time.Now().UTC()
+	balances, err := tx.Stock().IssuableBalances(ctx, reservation.InventoryID, order.WarehouseID, now)
+	if err != nil {
+		return err
+	}
 
-// GetOrdersByCustomerIDAndVendorIDAndProductIDAndShipmentID returns orders by customer ID, vendor ID, product ID, and shipment ID
-func GetOrdersByCustomerIDAndVendorIDAndProductIDAndShipmentID(customerID string, vendorID string, productID string, shipmentID string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND vendor_id = ? AND product_id = ? AND shipment_id = ?", customerID, vendorID, productID, shipmentID).Find(&orders).Error
-	return orders, err
-}
+	remaining := reservation.Quantity
+	for _, balance := range balances {
+		if remaining == 0 {
+			break
+		}
+		quantity := min(remaining, balance.Quantity)
+		if err := issue(balance.WarehouseID, balance.BinID, balance.LotID, quantity, nil); err != nil {
+			return err
+		}
+		remaining -= quantity
+	}
 
-// GetOrdersByStatusAndDateRange returns orders by status and date range
-func GetOrdersByStatusAndDateRange(status string, startDate string, endDate string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("status = ? AND created_at BETWEEN ? AND ?", status, startDate, endDate).Find(&orders).Error
-	return orders, err
-}
+	if remaining == 0 {
+		return nil
+	}
 
-// GetOrdersByCustomerIDAndStatusAndDateRange returns orders by customer ID, status, and date range
-func GetOrdersByCustomerIDAndStatusAndDateRange(customerID string, status string, startDate string, endDate string) ([]models.Order, error) {
-	var orders []models.Order
-	err := db.DB.Where("customer_id = ? AND status = ? AND created_at BETWEEN ? AND ?", customerID, status, startDate, endDate).Find(&orders).Error
-	return orders, err
+	// Stock can expire between reserving and shipping an order. Name that
+	// rather than the shortfall it leaves behind.
+	expired, err := tx.Stock().ExpiredQuantity(ctx, reservation.InventoryID, order.WarehouseID, now)
+	if err != nil {
+		return err
+	}
+	if order.WarehouseID != 0 {
+		if expired > 0 {
+			return fmt.Errorf("%w: warehouse %d is short %d units for order %d, %d are in expired lots", ErrStockExpired, order.WarehouseID, remaining, order.ID, expired)
+		}
+		return fmt.Errorf("%w: warehouse %d is short %d units", ErrInsufficientStock, order.WarehouseID, remaining)
+	}
+	if expired > 0 {
+		inventory, err := tx.Inventory().Get(ctx, reservation.InventoryID)
+		if err != nil {
+			return err
+		}
+		located, err := tx.Stock().LocatedQuantity(ctx, reservation.InventoryID)
+		if err != nil {
+			return err
+		}
+		if inventory.Quantity-located < remaining {
+			return fmt.Errorf("%w: order %d is short %d units, %d are in expired lots", ErrStockExpired, order.ID, remaining, expired)
+		}
+	}
+	return issue(0, 0, 0, remaining, nil)
 }
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/pkg/notifier"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordResetTTL is how long a password reset token can be used
+const PasswordResetTTL = time.Hour
+
+// MinPasswordLength is the shortest password that is accepted
+const MinPasswordLength = 10
+
+var (
+	// ErrWeakPassword is returned when a new password does not meet the strength rules
+	ErrWeakPassword = errors.New("password is too weak")
+	// ErrInvalidResetToken is returned for unknown, expired or already used reset tokens
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+)
+
+// ValidatePassword checks a new password against the strength rules: at
+// least MinPasswordLength characters, with letters and digits, and not
+// containing the user's email name
+func ValidatePassword(password, email string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrWeakPassword, MinPasswordLength)
+	}
+
+	var letters, digits bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letters = true
+		case unicode.IsDigit(r):
+			digits = true
+		}
+	}
+	if !letters || !digits {
+		return fmt.Errorf("%w: use both letters and digits", ErrWeakPassword)
+	}
+
+	if name, _, _ := strings.Cut(strings.ToLower(email), "@"); len(name) >= 3 && strings.Contains(strings.ToLower(password), name) {
+		return fmt.Errorf("%w: do not use your email address", ErrWeakPassword)
+	}
+	return nil
+}
+
+// PasswordService resets forgotten passwords
+type PasswordService struct {
+	store    repository.Store
+	notifier notifier.Notifier
+}
+
+// NewPasswordService returns a PasswordService over store that mails reset
+// tokens through n
+func NewPasswordService(store repository.Store, n notifier.Notifier) *PasswordService {
+	return &PasswordService{store: store, notifier: n}
+}
+
+// RequestPasswordReset sends a single-use reset token to the user with the
+// given email. Unknown emails are ignored so the response does not reveal
+// which accounts exist. Requesting a new token voids earlier ones.
+func (s *PasswordService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.store.Users().GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		now := time.Now()
+		if err := tx.PasswordResets().VoidForUser(ctx, user.ID, now); err != nil {
+			return err
+		}
+		return tx.PasswordResets().Create(ctx, &models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: now.Add(PasswordResetTTL),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    resetMessage(token),
+	})
+}
+
+// ConfirmPasswordReset sets a new password using a token from
+// RequestPasswordReset. The token is used up, and every session of the user
+// is ended so a stolen session does not outlive the reset.
+func (s *PasswordService) ConfirmPasswordReset(ctx context.Context, token, password string) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		reset, err := tx.PasswordResets().LockByHash(ctx, hashToken(token))
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if reset.UsedAt != nil || now.After(reset.ExpiresAt) {
+			return ErrInvalidResetToken
+		}
+
+		user, err := tx.Users().Get(ctx, reset.UserID)
+		if err != nil {
+			return ErrInvalidResetToken
+		}
+		if err := ValidatePassword(password, user.Email); err != nil {
+			return err
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.PasswordResets().MarkUsed(ctx, reset.ID, now); err != nil {
+			return err
+		}
+		if err := tx.Users().Update(ctx, user.ID, map[string]interface{}{"password": string(hashedPassword)}); err != nil {
+			return err
+		}
+		return revokeAllSessions(ctx, tx, user.ID)
+	})
+}
+
+// resetMessage is the body of the reset notification. When
+// PASSWORD_RESET_URL is set the token is appended to it to form a link.
+func resetMessage(token string) string {
+	action := "Use this token to choose a new password: " + token
+	if base := os.Getenv("PASSWORD_RESET_URL"); base != "" {
+		action = "Follow this link to choose a new password: " + base + token
+	}
+	return fmt.Sprintf("Someone asked to reset the password of your account.\n\n%s\n\n"+
+		"The token expires in %s and works once. If you did not ask for this, you can ignore this message.",
+		action, PasswordResetTTL)
+}
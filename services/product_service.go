@@ -1,166 +1,106 @@
 package services
 
 import (
-	"inventory-supply-chain-system/db"
+	"context"
+	"errors"
+	"fmt"
+
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 )
 
-// CreateProduct creates a new product
-func CreateProduct(product models.Product) error {
-	return db.DB.Create(&product).Error
-}
-
-// GetProducts fetches all products from the database
-func GetProducts() ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return products, nil
-}
-
-// GetProductByID fetches a product by its ID
-func GetProductByID(id uint) (*models.Product, error) {
-	var product models.Product
-	result := db.DB.First(&product, id)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return &product, nil
-}
+var (
+	// ErrInvalidProduct is returned when product data is malformed
+	ErrInvalidProduct = errors.New("invalid product")
+	// ErrProductHasSKUs is returned when deleting a product that still has SKUs
+	ErrProductHasSKUs = errors.New("product still has SKUs")
+)
 
-// UpdateProduct updates a product in the database
-func UpdateProduct(product *models.Product) error {
-	return db.DB.Save(product).Error
+// ProductService manages the product master records of the catalog
+type ProductService struct {
+	store repository.Store
 }
 
-// DeleteProduct deletes a product from the database
-func DeleteProduct(id uint) error {
-	return db.DB.Delete(&models.Product{}, id).Error
+// NewProductService returns a ProductService over store
+func NewProductService(store repository.Store) *ProductService {
+	return &ProductService{store: store}
 }
 
-// GetProductsByCategory fetches all products for a given category
-func GetProductsByCategory(category string) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("category = ?", category).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
+// CreateProduct creates a new product, along with any SKUs given with it
+func (s *ProductService) CreateProduct(ctx context.Context, product *models.Product) error {
+	if product.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidProduct)
 	}
-
-	return products, nil
-}
-
-// GetProductsByPriceRange fetches all products within a given price range
-func GetProductsByPriceRange(minPrice, maxPrice float64) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("price >= ? AND price <= ?", minPrice, maxPrice).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
+	for i := range product.SKUs {
+		product.SKUs[i].Product = nil
+		if err := validateSKU(&product.SKUs[i]); err != nil {
+			return err
+		}
 	}
-
-	return products, nil
-}
-
-// GetProductsByStock fetches all products with a given stock level
-func GetProductsByStock(stock int) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("stock = ?", stock).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return products, nil
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := checkProductCategory(ctx, tx, product); err != nil {
+			return err
+		}
+		for i := range product.SKUs {
+			if err := checkSKUUnits(ctx, tx, &product.SKUs[i], ""); err != nil {
+				return err
+			}
+		}
+		return tx.Products().Create(ctx, product)
+	})
 }
 
-// GetProductsByStockRange fetches all products within a given stock range
-func GetProductsByStockRange(minStock, maxStock int) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("stock >= ? AND stock <= ?", minStock, maxStock).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return products, nil
+// GetProducts fetches one page of products
+func (s *ProductService) GetProducts(ctx context.Context, page repository.PageRequest) (repository.Page[models.Product], error) {
+	return s.store.Products().List(ctx, page)
 }
 
-// GetProductsByCategoryAndPriceRange fetches all products for a given category within a given price range
-func GetProductsByCategoryAndPriceRange(category string, minPrice, maxPrice float64) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("category = ? AND price >= ? AND price <= ?", category, minPrice, maxPrice).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return products, nil
+// GetProductByID fetches a product with its category and SKUs by its ID
+func (s *ProductService) GetProductByID(ctx context.Context, id uint) (*models.Product, error) {
+	return s.store.Products().Get(ctx, id)
 }
 
-// GetProductsByCategoryAndStock fetches all products for a given category with a given stock level
-func GetProductsByCategoryAndStock(category string, stock int) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("category = ? AND stock = ?", category, stock).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return products, nil
+// FindProducts fetches every product matching filter
+func (s *ProductService) FindProducts(ctx context.Context, filter repository.ProductFilter) ([]models.Product, error) {
+	return s.store.Products().Find(ctx, filter)
 }
 
-// GetProductsByCategoryAndStockRange fetches all products for a given category within a given stock range
-
-func GetProductsByCategoryAndStockRange(category string, minStock, maxStock int) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("category = ? AND stock >= ? AND stock <= ?", category, minStock, maxStock).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
+// UpdateProduct updates a product's own fields; its SKUs are managed on their own
+func (s *ProductService) UpdateProduct(ctx context.Context, product *models.Product) error {
+	if product.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidProduct)
 	}
-
-	return products, nil
-}
-
-// GetProductsByPriceRangeAndStock fetches all products within a given price range with a given stock level
-func GetProductsByPriceRangeAndStock(minPrice, maxPrice float64, stock int) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("price >= ? AND price <= ? AND stock = ?", minPrice, maxPrice, stock).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := checkProductCategory(ctx, tx, product); err != nil {
+			return err
+		}
+		return tx.Products().Save(ctx, product)
+	})
+}
+
+// DeleteProduct deletes a product that no longer has any SKUs
+func (s *ProductService) DeleteProduct(ctx context.Context, id uint) error {
+	count, err := s.store.Products().CountSKUs(ctx, id)
+	if err != nil {
+		return err
 	}
-
-	return products, nil
-}
-
-// GetProductsByPriceRangeAndStockRange fetches all products within a given price range and stock range
-func GetProductsByPriceRangeAndStockRange(minPrice, maxPrice float64, minStock, maxStock int) ([]models.Product, error) {
-
-	var products []models.Product
-	result := db.DB.Where("price >= ? AND price <= ? AND stock >= ? AND stock <= ?", minPrice, maxPrice, minStock, maxStock).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
+	if count > 0 {
+		return ErrProductHasSKUs
 	}
-
-	return products, nil
+	return s.store.Products().Delete(ctx, id)
 }
 
-// GetProductsByCategoryPriceRangeAndStock fetches all products for a given category within a given price range with a given stock level
-func GetProductsByCategoryPriceRangeAndStock(category string, minPrice, maxPrice float64, stock int) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("category = ? AND price >= ? AND price <= ? AND stock = ?", category, minPrice, maxPrice, stock).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
+// checkProductCategory makes sure the category a product is assigned to exists
+func checkProductCategory(ctx context.Context, tx repository.Store, product *models.Product) error {
+	product.Category = nil
+	if product.CategoryID == nil {
+		return nil
 	}
-
-	return products, nil
-}
-
-// GetProductsByCategoryPriceRangeAndStockRange fetches all products for a given category within a given price range and stock range
-func GetProductsByCategoryPriceRangeAndStockRange(category string, minPrice, maxPrice float64, minStock, maxStock int) ([]models.Product, error) {
-	var products []models.Product
-	result := db.DB.Where("category = ? AND price >= ? AND price <= ? AND stock >= ? AND stock <= ?", category, minPrice, maxPrice, minStock, maxStock).Find(&products)
-	if result.Error != nil {
-		return nil, result.Error
+	if _, err := tx.Categories().Get(ctx, *product.CategoryID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: unknown category %d", ErrInvalidProduct, *product.CategoryID)
+		}
+		return err
 	}
-
-	return products, nil
+	return nil
 }
@@ -0,0 +1,439 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+var (
+	// ErrInvalidSerial is returned when the serial numbers given with a
+	// movement or order are malformed or do not match its quantity
+	ErrInvalidSerial = errors.New("invalid serial numbers")
+	// ErrSerialUnavailable is returned when a serial number is already
+	// recorded, or is not in the status or location a change needs
+	ErrSerialUnavailable = errors.New("serial number is not available")
+)
+
+// SerialTrace is the history of a serial number, with the orders it was
+// picked for and the shipments that carried it
+type SerialTrace struct {
+	Serial    *models.SerialNumber `json:"serial"`
+	SKU       *models.SKU          `json:"sku"`
+	Events    []models.SerialEvent `json:"events"`
+	Orders    []models.Order       `json:"orders"`
+	Shipments []models.Shipment    `json:"shipments"`
+}
+
+// SerialService traces the serial numbers of serial-controlled SKUs
+type SerialService struct {
+	store repository.Store
+}
+
+// NewSerialService returns a SerialService over store
+func NewSerialService(store repository.Store) *SerialService {
+	return &SerialService{store: store}
+}
+
+// GetSerialTrace fetches a serial number with its full history. Orders and
+// shipments that have since been deleted are left out.
+func (s *SerialService) GetSerialTrace(ctx context.Context, number string) (*SerialTrace, error) {
+	serial, err := s.store.Serials().GetBySerial(ctx, strings.TrimSpace(number))
+	if err != nil {
+		return nil, err
+	}
+	sku, err := s.store.SKUs().Get(ctx, serial.SKUID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.store.Serials().Events(ctx, serial.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := &SerialTrace{
+		Serial:    serial,
+		SKU:       sku,
+		Events:    events,
+		Orders:    []models.Order{},
+		Shipments: []models.Shipment{},
+	}
+	seenOrders := make(map[uint]bool)
+	seenShipments := make(map[uint]bool)
+	for _, event := range events {
+		if event.OrderID != 0 && !seenOrders[event.OrderID] {
+			seenOrders[event.OrderID] = true
+			order, err := s.store.Orders().Get(ctx, event.OrderID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				trace.Orders = append(trace.Orders, *order)
+			}
+		}
+
+		if event.ShipmentID != 0 && !seenShipments[event.ShipmentID] {
+			seenShipments[event.ShipmentID] = true
+			shipment, err := s.store.Shipments().Get(ctx, event.ShipmentID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				trace.Shipments = append(trace.Shipments, *shipment)
+			}
+		}
+	}
+	return trace, nil
+}
+
+// recordShipmentSerials records on the serial numbers of an order which
+// shipment carries them, appending an event naming the shipment to each.
+// A shipment listing serials carries those, which must have been picked for
+// its order and not be on another shipment; one listing none carries every
+// serial of its order that no shipment carries yet. shipment.Serials is set
+// to the serials recorded.
+func recordShipmentSerials(ctx context.Context, tx repository.Store, shipment *models.Shipment, userID uint) error {
+	if shipment.OrderID == 0 {
+		if len(shipment.Serials) > 0 {
+			return fmt.Errorf("%w: only shipments for an order carry serial numbers", ErrInvalidSerial)
+		}
+		return nil
+	}
+
+	picked, err := tx.Serials().PickedFor(ctx, shipment.OrderID)
+	if err != nil {
+		return err
+	}
+	var carried []*models.SerialNumber
+	if len(shipment.Serials) > 0 {
+		numbers, err := normalizeSerials(shipment.Serials)
+		if err != nil {
+			return err
+		}
+		byNumber := make(map[string]*models.SerialNumber, len(picked))
+		for i := range picked {
+			byNumber[picked[i].Serial] = &picked[i]
+		}
+		for _, number := range numbers {
+			serial, ok := byNumber[number]
+			if !ok {
+				return fmt.Errorf("%w: serial %s is not picked for order %d", ErrSerialUnavailable, number, shipment.OrderID)
+			}
+			if serial.ShipmentID != 0 && serial.ShipmentID != shipment.ID {
+				return fmt.Errorf("%w: serial %s is on shipment %d", ErrSerialUnavailable, number, serial.ShipmentID)
+			}
+			carried = append(carried, serial)
+		}
+	} else {
+		for i := range picked {
+			if picked[i].ShipmentID == 0 {
+				carried = append(carried, &picked[i])
+			}
+		}
+	}
+
+	shipment.Serials = make([]string, 0, len(carried))
+	for _, serial := range carried {
+		shipment.Serials = append(shipment.Serials, serial.Serial)
+		if serial.ShipmentID == shipment.ID {
+			continue
+		}
+		serial.ShipmentID = shipment.ID
+		err := saveSerial(ctx, tx, serial, models.SerialEvent{
+			OrderID:     shipment.OrderID,
+			ShipmentID:  shipment.ID,
+			WarehouseID: serial.WarehouseID,
+			BinID:       serial.BinID,
+			LotID:       serial.LotID,
+			Reference:   fmt.Sprintf("shipment:%d", shipment.ID),
+			UserID:      userID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// captureSerials books the serial numbers listed with a movement of a
+// serial-controlled SKU, one for each base unit moved. Receipts and
+// adjustments that add stock record new serials, or bring back scrapped
+// ones; returns take back shipped serials, and transfers place serials a
+// transfer took out of their warehouse. Stock taken out must name serials
+// on hand at the movement's location, or reserved for orderID: issues ship
+// them, adjustments scrap them and transfers take them out of the
+// warehouse.
+func captureSerials(ctx context.Context, tx repository.Store, sku *models.SKU, movement *models.StockMovement, orderID uint) error {
+	if !sku.SerialControlled {
+		if len(movement.Serials) > 0 {
+			return fmt.Errorf("%w: SKU %s is not serial-controlled", ErrInvalidSerial, sku.Code)
+		}
+		return nil
+	}
+
+	serials, err := normalizeSerials(movement.Serials)
+	if err != nil {
+		return err
+	}
+	units := max(movement.Quantity, -movement.Quantity)
+	if len(serials) != units {
+		return fmt.Errorf("%w: SKU %s is serial-controlled, so %d serial numbers are needed, %d given", ErrInvalidSerial, sku.Code, units, len(serials))
+	}
+	movement.Serials = serials
+
+	for _, number := range serials {
+		serial, err := tx.Serials().GetBySerial(ctx, number)
+		if errors.Is(err, repository.ErrNotFound) {
+			serial = nil
+		} else if err != nil {
+			return err
+		} else if serial.SKUID != sku.ID {
+			return fmt.Errorf("%w: serial %s is not a unit of SKU %s", ErrSerialUnavailable, number, sku.Code)
+		}
+
+		if movement.Quantity > 0 {
+			serial, err = placeSerial(serial, sku, number, movement)
+		} else {
+			err = takeSerial(serial, number, movement, orderID)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = saveSerial(ctx, tx, serial, models.SerialEvent{
+			MovementID:  movement.ID,
+			OrderID:     orderID,
+			WarehouseID: movement.WarehouseID,
+			BinID:       movement.BinID,
+			LotID:       movement.LotID,
+			Reference:   movement.Reference,
+			UserID:      movement.UserID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// placeSerial puts a serial number, which is nil if it is not recorded yet,
+// in stock at the location a movement adds stock to
+func placeSerial(serial *models.SerialNumber, sku *models.SKU, number string, movement *models.StockMovement) (*models.SerialNumber, error) {
+	status := models.SerialStatusInStock
+	switch {
+	case serial == nil && (movement.Type == models.MovementTypeReceipt || movement.Type == models.MovementTypeAdjustment):
+		serial = &models.SerialNumber{Serial: number, SKUID: sku.ID, InventoryID: movement.InventoryID}
+	case serial == nil:
+		return nil, fmt.Errorf("%w: serial %s is not recorded", ErrSerialUnavailable, number)
+	case movement.Type == models.MovementTypeAdjustment && serial.Status == models.SerialStatusScrapped:
+	case movement.Type == models.MovementTypeReturn && serial.Status == models.SerialStatusShipped:
+		status = models.SerialStatusReturned
+	case movement.Type == models.MovementTypeTransfer && serial.OnHand() && serial.WarehouseID == 0:
+		status = serial.Status
+	default:
+		return nil, fmt.Errorf("%w: serial %s is already recorded as %s", ErrSerialUnavailable, number, serial.Status)
+	}
+
+	serial.Status = status
+	serial.WarehouseID, serial.BinID, serial.LotID = movement.WarehouseID, movement.BinID, movement.LotID
+	return serial, nil
+}
+
+// takeSerial takes a serial number out of the location a movement removes
+// stock from
+func takeSerial(serial *models.SerialNumber, number string, movement *models.StockMovement, orderID uint) error {
+	if serial == nil {
+		return fmt.Errorf("%w: serial %s is not recorded", ErrSerialUnavailable, number)
+	}
+	picked := orderID != 0 && serial.Status == models.SerialStatusReserved && serial.OrderID == orderID
+	if !serial.OnHand() && !picked {
+		return fmt.Errorf("%w: serial %s is %s", ErrSerialUnavailable, number, serial.Status)
+	}
+	if serial.WarehouseID != movement.WarehouseID || serial.BinID != movement.BinID || serial.LotID != movement.LotID {
+		return fmt.Errorf("%w: serial %s is held in warehouse %d, bin %d, lot %d", ErrSerialUnavailable, number, serial.WarehouseID, serial.BinID, serial.LotID)
+	}
+
+	switch movement.Type {
+	case models.MovementTypeIssue:
+		serial.Status = models.SerialStatusShipped
+		if orderID != 0 {
+			serial.OrderID = orderID
+		}
+	case models.MovementTypeAdjustment:
+		serial.Status = models.SerialStatusScrapped
+	case models.MovementTypeTransfer:
+		serial.WarehouseID, serial.BinID = 0, 0
+		return nil
+	}
+	serial.WarehouseID, serial.BinID, serial.LotID = 0, 0, 0
+	return nil
+}
+
+// pickSerials reserves the serial numbers picked for an order: one for each
+// unit ordered, on hand and in the order's warehouse when it names one.
+// Serials picked for the order earlier and not picked again go back in
+// stock.
+func pickSerials(ctx context.Context, tx repository.Store, order *models.Order, numbers []string, userID uint) error {
+	inventory, err := tx.Inventory().Lock(ctx, order.InventoryID)
+	if err != nil {
+		return err
+	}
+	sku := inventory.SKU
+	if !sku.SerialControlled {
+		return fmt.Errorf("%w: SKU %s is not serial-controlled", ErrInvalidSerial, sku.Code)
+	}
+
+	serials, err := normalizeSerials(numbers)
+	if err != nil {
+		return err
+	}
+	if len(serials) != order.Quantity {
+		return fmt.Errorf("%w: order %d is for %d units, %d serial numbers given", ErrInvalidSerial, order.ID, order.Quantity, len(serials))
+	}
+
+	event := models.SerialEvent{
+		OrderID:   order.ID,
+		Reference: fmt.Sprintf("order:%d", order.ID),
+		UserID:    userID,
+	}
+	picked := make(map[string]bool, len(serials))
+	for _, number := range serials {
+		picked[number] = true
+	}
+	reserved, err := tx.Serials().ReservedFor(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	for i := range reserved {
+		if picked[reserved[i].Serial] {
+			continue
+		}
+		reserved[i].Status = models.SerialStatusInStock
+		reserved[i].ShipmentID = 0
+		if err := saveSerial(ctx, tx, &reserved[i], event); err != nil {
+			return err
+		}
+	}
+
+	for _, number := range serials {
+		serial, err := tx.Serials().GetBySerial(ctx, number)
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: serial %s is not recorded", ErrSerialUnavailable, number)
+		}
+		if err != nil {
+			return err
+		}
+		if serial.SKUID != sku.ID {
+			return fmt.Errorf("%w: serial %s is not a unit of SKU %s", ErrSerialUnavailable, number, sku.Code)
+		}
+		if serial.Status == models.SerialStatusReserved && serial.OrderID == order.ID {
+			continue
+		}
+		if !serial.OnHand() {
+			return fmt.Errorf("%w: serial %s is %s", ErrSerialUnavailable, number, serial.Status)
+		}
+		if order.WarehouseID != 0 && serial.WarehouseID != order.WarehouseID {
+			return fmt.Errorf("%w: serial %s is not in warehouse %d", ErrSerialUnavailable, number, order.WarehouseID)
+		}
+
+		serial.Status = models.SerialStatusReserved
+		serial.OrderID = order.ID
+		serial.ShipmentID = 0
+		event.WarehouseID, event.BinID, event.LotID = serial.WarehouseID, serial.BinID, serial.LotID
+		if err := saveSerial(ctx, tx, serial, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseSerials puts the serial numbers reserved for an order back in stock
+func releaseSerials(ctx context.Context, tx repository.Store, orderID, userID uint) error {
+	reserved, err := tx.Serials().ReservedFor(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for i := range reserved {
+		reserved[i].Status = models.SerialStatusInStock
+		reserved[i].ShipmentID = 0
+		err := saveSerial(ctx, tx, &reserved[i], models.SerialEvent{
+			OrderID:     orderID,
+			WarehouseID: reserved[i].WarehouseID,
+			BinID:       reserved[i].BinID,
+			LotID:       reserved[i].LotID,
+			Reference:   fmt.Sprintf("order:%d", orderID),
+			UserID:      userID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveSerial stores a serial number and appends event, stamped with its new
+// status, to its history
+func saveSerial(ctx context.Context, tx repository.Store, serial *models.SerialNumber, event models.SerialEvent) error {
+	var err error
+	if serial.ID == 0 {
+		err = tx.Serials().Create(ctx, serial)
+	} else {
+		err = tx.Serials().Save(ctx, serial)
+	}
+	if errors.Is(err, repository.ErrDuplicate) {
+		return fmt.Errorf("%w: serial %s is already recorded", ErrSerialUnavailable, serial.Serial)
+	}
+	if err != nil {
+		return err
+	}
+
+	event.SerialID = serial.ID
+	event.Status = serial.Status
+	return tx.Serials().CreateEvent(ctx, &event)
+}
+
+// serialGroup is a set of serial numbers held at the same location
+type serialGroup struct {
+	WarehouseID, BinID, LotID uint
+	Serials                   []string
+}
+
+// groupSerials groups serial numbers by the location they are held in, in
+// the order the locations first appear
+func groupSerials(serials []models.SerialNumber) []serialGroup {
+	var groups []serialGroup
+	for _, serial := range serials {
+		i := 0
+		for i < len(groups) && (groups[i].WarehouseID != serial.WarehouseID || groups[i].BinID != serial.BinID || groups[i].LotID != serial.LotID) {
+			i++
+		}
+		if i == len(groups) {
+			groups = append(groups, serialGroup{WarehouseID: serial.WarehouseID, BinID: serial.BinID, LotID: serial.LotID})
+		}
+		groups[i].Serials = append(groups[i].Serials, serial.Serial)
+	}
+	return groups
+}
+
+// normalizeSerials trims the serial numbers given with a movement or order
+// and checks that none is blank or listed twice
+func normalizeSerials(numbers []string) ([]string, error) {
+	serials := make([]string, 0, len(numbers))
+	seen := make(map[string]bool, len(numbers))
+	for _, number := range numbers {
+		number = strings.TrimSpace(number)
+		if number == "" {
+			return nil, fmt.Errorf("%w: serial numbers cannot be blank", ErrInvalidSerial)
+		}
+		if seen[number] {
+			return nil, fmt.Errorf("%w: serial %s is listed more than once", ErrInvalidSerial, number)
+		}
+		seen[number] = true
+		serials = append(serials, number)
+	}
+	return serials, nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+)
+
+// RefreshTokenTTL is how long a session can go unused before the user has to log in again
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or reused refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrSessionRevoked is returned when an access token's session has ended
+	// or its user's tokens were invalidated
+	ErrSessionRevoked = errors.New("session revoked")
+)
+
+// TokenPair is what a successful login or refresh returns to the client
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+
+	// MFAEnrollmentRequired is set when the user's role requires MFA and
+	// they have not enrolled; their token carries no permissions until then
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
+}
+
+// SessionService opens, renews and revokes sessions
+type SessionService struct {
+	store  repository.Store
+	policy *AccountPolicy
+}
+
+// NewSessionService returns a SessionService over store that applies policy
+// to every token it issues
+func NewSessionService(store repository.Store, policy *AccountPolicy) *SessionService {
+	return &SessionService{store: store, policy: policy}
+}
+
+// StartSession opens a session for a user who has just authenticated and
+// returns its first token pair
+func (s *SessionService) StartSession(ctx context.Context, user models.User, userAgent, ip string) (*TokenPair, error) {
+	if err := s.policy.checkVerified(&user); err != nil {
+		return nil, err
+	}
+
+	sessionID, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	var pair *TokenPair
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		now := time.Now()
+		session := models.Session{
+			ID:         sessionID,
+			UserID:     user.ID,
+			UserAgent:  userAgent,
+			IP:         ip,
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(RefreshTokenTTL),
+		}
+		if err := tx.Sessions().Create(ctx, &session); err != nil {
+			return err
+		}
+
+		pair, err = s.issueTokens(ctx, tx, &session, &user)
+		return err
+	})
+	return pair, err
+}
+
+// RefreshSession exchanges a refresh token for a new token pair. Each refresh
+// token works once; presenting one that was already used means it leaked,
+// so the whole session is revoked.
+func (s *SessionService) RefreshSession(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	var pair *TokenPair
+	var reused bool
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		token, err := tx.Sessions().GetRefreshToken(ctx, hashToken(refreshToken))
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		session, err := tx.Sessions().Get(ctx, token.SessionID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if token.UsedAt != nil {
+			reused = true
+			return tx.Sessions().Revoke(ctx, session.UserID, session.ID)
+		}
+		if session.RevokedAt != nil || now.After(token.ExpiresAt) || now.After(session.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		user, err := tx.Users().Get(ctx, session.UserID)
+		if err != nil {
+			return ErrInvalidRefreshToken
+		}
+		if err := s.policy.checkVerified(user); err != nil {
+			return err
+		}
+
+		if err := tx.Sessions().UseRefreshToken(ctx, token.ID, now); err != nil {
+			return err
+		}
+		session.LastUsedAt = now
+		session.ExpiresAt = now.Add(RefreshTokenTTL)
+		if err := tx.Sessions().Touch(ctx, session); err != nil {
+			return err
+		}
+
+		pair, err = s.issueTokens(ctx, tx, session, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrInvalidRefreshToken
+	}
+	return pair, nil
+}
+
+// RevokeSession ends one of a user's sessions
+func (s *SessionService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	return s.store.Sessions().Revoke(ctx, userID, sessionID)
+}
+
+// RevokeAllSessions ends every session of a user and invalidates every
+// access token already issued to them
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		return revokeAllSessions(ctx, tx, userID)
+	})
+}
+
+// CheckTokenRevocation is the utils.RevocationCheck used by ValidateToken.
+// It rejects tokens whose session was revoked or whose user has since had
+// their token version bumped or been deleted.
+func (s *SessionService) CheckTokenRevocation(claims *utils.JWTClaims) error {
+	state, err := s.store.Sessions().TokenState(context.Background(), claims.UserID, claims.SessionID)
+	if err != nil {
+		return err
+	}
+	if state == nil || state.RevokedAt != nil || state.TokenVersion != claims.TokenVersion {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// issueTokens signs an access token for the session and stores a fresh refresh token
+func (s *SessionService) issueTokens(ctx context.Context, tx repository.Store, session *models.Session, user *models.User) (*TokenPair, error) {
+	refreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Sessions().CreateRefreshToken(ctx, &models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: session.ExpiresAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateToken(utils.JWTClaims{
+		UserID:       user.ID,
+		Role:         user.Role,
+		Permissions:  s.policy.sessionPermissions(user),
+		SessionID:    session.ID,
+		TokenVersion: user.TokenVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(utils.AccessTokenTTL.Seconds()),
+
+		MFAEnrollmentRequired: s.policy.MFARequired(user) && !user.MFAEnrolled(),
+	}, nil
+}
+
+// revokeAllSessions ends every session of a user and invalidates every
+// access token issued to them so far, as part of tx
+func revokeAllSessions(ctx context.Context, tx repository.Store, userID uint) error {
+	if err := tx.Sessions().RevokeAll(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Users().BumpTokenVersion(ctx, userID)
+}
+
+// hashToken is the form in which opaque tokens are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+var (
+	// ErrInvalidSKU is returned when SKU data is malformed or names an unknown product
+	ErrInvalidSKU = errors.New("invalid SKU")
+	// ErrSKUStocked is returned when deleting a SKU that still has a stock record
+	ErrSKUStocked = errors.New("SKU still has a stock record")
+)
+
+// SKUService manages the sellable variants of products
+type SKUService struct {
+	store repository.Store
+}
+
+// NewSKUService returns a SKUService over store
+func NewSKUService(store repository.Store) *SKUService {
+	return &SKUService{store: store}
+}
+
+// CreateSKU adds a variant to an existing product, with its unit conversions
+func (s *SKUService) CreateSKU(ctx context.Context, sku *models.SKU) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := checkSKU(ctx, tx, sku, ""); err != nil {
+			return err
+		}
+		if err := tx.SKUs().Create(ctx, sku); err != nil {
+			return err
+		}
+		return tx.SKUs().SetUnits(ctx, sku.ID, sku.Units)
+	})
+}
+
+// GetSKUs fetches one page of SKUs
+func (s *SKUService) GetSKUs(ctx context.Context, page repository.PageRequest) (repository.Page[models.SKU], error) {
+	return s.store.SKUs().List(ctx, page)
+}
+
+// GetSKUByID fetches a SKU with its product by its ID
+func (s *SKUService) GetSKUByID(ctx context.Context, id uint) (*models.SKU, error) {
+	return s.store.SKUs().Get(ctx, id)
+}
+
+// GetSKULots fetches the lots a SKU has been received in, earliest expiry first
+func (s *SKUService) GetSKULots(ctx context.Context, id uint) ([]models.Lot, error) {
+	if _, err := s.store.SKUs().Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.store.Lots().ListBySKU(ctx, id)
+}
+
+// GetSKUSerials fetches the serial numbers of a SKU, optionally only those
+// with status
+func (s *SKUService) GetSKUSerials(ctx context.Context, id uint, status string) ([]models.SerialNumber, error) {
+	if _, err := s.store.SKUs().Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.store.Serials().ListBySKU(ctx, id, status)
+}
+
+// FindSKUs fetches every SKU matching filter
+func (s *SKUService) FindSKUs(ctx context.Context, filter repository.SKUFilter) ([]models.SKU, error) {
+	return s.store.SKUs().Find(ctx, filter)
+}
+
+// UpdateSKU updates a SKU, which may also move it to another product, and
+// replaces its unit conversions. Stock is counted in the base unit, so that
+// is fixed once the SKU has a stock record.
+func (s *SKUService) UpdateSKU(ctx context.Context, sku *models.SKU) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		current, err := tx.SKUs().Get(ctx, sku.ID)
+		if err != nil {
+			return err
+		}
+		// SKUs given a larger base unit before it had to be the smallest
+		// keep it, as their stock is counted in it
+		if err := checkSKU(ctx, tx, sku, current.BaseUnit); err != nil {
+			return err
+		}
+		if current.BaseUnit != sku.BaseUnit {
+			_, err := tx.Inventory().GetBySKU(ctx, sku.ID)
+			if err == nil {
+				return fmt.Errorf("%w: base unit cannot change while the SKU has a stock record", ErrInvalidSKU)
+			}
+			if !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+		}
+		if current.SerialControlled != sku.SerialControlled {
+			inventory, err := tx.Inventory().GetBySKU(ctx, sku.ID)
+			if err == nil && inventory.Quantity != 0 {
+				return fmt.Errorf("%w: serial control cannot change while the SKU has stock on hand", ErrInvalidSKU)
+			}
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+		}
+
+		if err := tx.SKUs().Save(ctx, sku); err != nil {
+			return err
+		}
+		return tx.SKUs().SetUnits(ctx, sku.ID, sku.Units)
+	})
+}
+
+// DeleteSKU deletes a SKU that no longer has a stock record
+func (s *SKUService) DeleteSKU(ctx context.Context, id uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		_, err := tx.Inventory().GetBySKU(ctx, id)
+		if err == nil {
+			return ErrSKUStocked
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return tx.SKUs().Delete(ctx, id)
+	})
+}
+
+// checkSKU validates a SKU and makes sure its product and units exist.
+// keptBase is the base unit the SKU already has, if any.
+func checkSKU(ctx context.Context, tx repository.Store, sku *models.SKU, keptBase string) error {
+	if err := validateSKU(sku); err != nil {
+		return err
+	}
+	if err := checkSKUUnits(ctx, tx, sku, keptBase); err != nil {
+		return err
+	}
+	if _, err := tx.Products().Get(ctx, sku.ProductID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%w: unknown product %d", ErrInvalidSKU, sku.ProductID)
+		}
+		return err
+	}
+	sku.Product = nil
+	return nil
+}
+
+// validateSKU checks the required fields of a SKU and defaults its pack
+// size to one unit and its base unit to each
+func validateSKU(sku *models.SKU) error {
+	if sku.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidSKU)
+	}
+	sku.BaseUnit = strings.ToLower(strings.TrimSpace(sku.BaseUnit))
+	if sku.BaseUnit == "" {
+		sku.BaseUnit = "ea"
+	}
+	for i := range sku.Units {
+		sku.Units[i].Unit = strings.ToLower(strings.TrimSpace(sku.Units[i].Unit))
+	}
+	if sku.PackSize == 0 {
+		sku.PackSize = 1
+	}
+	if sku.PackSize < 0 {
+		return fmt.Errorf("%w: pack_size must be positive", ErrInvalidSKU)
+	}
+	if sku.Price < 0 {
+		return fmt.Errorf("%w: price cannot be negative", ErrInvalidSKU)
+	}
+	return nil
+}
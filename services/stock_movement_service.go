@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+// ErrInvalidMovement is returned when a stock movement is malformed
+var ErrInvalidMovement = errors.New("invalid stock movement")
+
+// Reason codes used by movements the system records on its own
+const (
+	ReasonOpeningBalance  = "opening_balance"
+	ReasonInventoryUpdate = "inventory_update"
+	ReasonOrderShipped    = "order_shipped"
+)
+
+// InventoryReconciliation compares an item's on-hand quantity with its ledger
+type InventoryReconciliation struct {
+	InventoryID   uint `json:"inventory_id"`
+	OnHand        int  `json:"on_hand"`
+	LedgerBalance int  `json:"ledger_balance"`
+	Difference    int  `json:"difference"`
+	Movements     int  `json:"movements"`
+}
+
+// RecordStockMovement appends a movement to the ledger and applies it to the
+// inventory's on-hand quantity in one transaction. Receipts, returns and
+// issues take a positive quantity (issues are stored as negative);
+// adjustments take a signed quantity. A transfer takes a positive quantity
+// and moves it from its warehouse and bin to its ToWarehouseID and ToBinID.
+// The quantity is in base units of the SKU, unless a unit_quantity is given
+// in another unit. A movement naming a lot books the stock of that lot; one
+// of a serial-controlled SKU lists the serial number of each unit it moves.
+func (s *InventoryService) RecordStockMovement(ctx context.Context, movement *models.StockMovement) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if movement.Type == models.MovementTypeTransfer {
+			return moveStock(ctx, tx, movement)
+		}
+		return recordMovement(ctx, tx, movement)
+	})
+}
+
+// movementOrigin says which workflow the system records a movement for
+type movementOrigin struct {
+	// orderID is the order whose reserved serial numbers the movement may take
+	orderID uint
+	// transfer marks a leg of a transfer order, which may carry stock that
+	// was picked without a lot
+	transfer bool
+}
+
+// recordMovement is RecordStockMovement inside an existing transaction
+func recordMovement(ctx context.Context, tx repository.Store, movement *models.StockMovement) error {
+	return recordMovementFor(ctx, tx, movement, movementOrigin{})
+}
+
+// recordOrderMovement is recordMovement for a movement made for an order,
+// which may take the serial numbers reserved for it
+func recordOrderMovement(ctx context.Context, tx repository.Store, movement *models.StockMovement, orderID uint) error {
+	return recordMovementFor(ctx, tx, movement, movementOrigin{orderID: orderID})
+}
+
+// recordMovementFor is recordMovement for a movement made by the workflow
+// origin names
+func recordMovementFor(ctx context.Context, tx repository.Store, movement *models.StockMovement, origin movementOrigin) error {
+	// A transfer leg on its own would add or remove stock
+	if movement.Type == models.MovementTypeTransfer && !origin.transfer {
+		return fmt.Errorf("%w: transfers are recorded with both of their legs", ErrInvalidMovement)
+	}
+
+	inventory, err := tx.Inventory().Lock(ctx, movement.InventoryID)
+	if err != nil {
+		return err
+	}
+
+	if err := applyUnit(ctx, tx, inventory.SKU, &movement.Quantity, &movement.Unit, &movement.UnitQuantity); err != nil {
+		return err
+	}
+	if err := normalizeMovement(movement); err != nil {
+		return err
+	}
+	if err := resolveLot(ctx, tx, inventory.SKU, movement, origin.transfer); err != nil {
+		return err
+	}
+
+	balance := inventory.Quantity + movement.Quantity
+	if balance < 0 || balance < inventory.Reserved {
+		return fmt.Errorf("%w: on hand would drop to %d with %d reserved", ErrInsufficientStock, balance, inventory.Reserved)
+	}
+
+	if err := applyToLocation(ctx, tx, movement, balance); err != nil {
+		return err
+	}
+
+	movement.ID = 0
+	movement.SKU = inventory.SKU.Code
+	movement.BalanceAfter = balance
+	if err := tx.Stock().CreateMovement(ctx, movement); err != nil {
+		return err
+	}
+	if err := captureSerials(ctx, tx, inventory.SKU, movement, origin.orderID); err != nil {
+		return err
+	}
+
+	return tx.Inventory().SetQuantity(ctx, inventory.ID, balance)
+}
+
+// applyToLocation books a movement against the stock balance of its
+// warehouse, bin and lot. Movements without a warehouse or lot only touch
+// unlocated stock, so they may not take the item's total below what is held
+// in warehouses and lots.
+func applyToLocation(ctx context.Context, tx repository.Store, movement *models.StockMovement, total int) error {
+	if movement.WarehouseID == 0 && movement.BinID != 0 {
+		return fmt.Errorf("%w: a bin needs a warehouse", ErrInvalidMovement)
+	}
+	if movement.WarehouseID == 0 && movement.LotID == 0 {
+		if movement.Quantity > 0 {
+			return nil
+		}
+
+		located, err := tx.Stock().LocatedQuantity(ctx, movement.InventoryID)
+		if err != nil {
+			return err
+		}
+		if total < located {
+			return fmt.Errorf("%w: %d units are held in warehouses or lots and must be moved from there", ErrInsufficientStock, located)
+		}
+		return nil
+	}
+
+	if movement.WarehouseID != 0 {
+		warehouse, err := tx.Warehouses().Get(ctx, movement.WarehouseID)
+		if err != nil {
+			return err
+		}
+		if !warehouse.Active {
+			return fmt.Errorf("%w: warehouse %s is inactive", ErrLocationUnavailable, warehouse.Code)
+		}
+		if movement.BinID != 0 {
+			bin, err := tx.Warehouses().BinByID(ctx, movement.BinID)
+			if err != nil {
+				return err
+			}
+			if bin.WarehouseID != warehouse.ID {
+				return fmt.Errorf("%w: bin %s is not in warehouse %s", ErrLocationUnavailable, bin.Code, warehouse.Code)
+			}
+		}
+	}
+
+	// The inventory row is locked by the caller, which serialises every
+	// change to this item's balances.
+	balance, err := tx.Stock().Balance(ctx, movement.InventoryID, movement.WarehouseID, movement.BinID, movement.LotID)
+	if err != nil {
+		return err
+	}
+
+	balance.Quantity += movement.Quantity
+	if balance.Quantity < 0 {
+		return fmt.Errorf("%w: location holds %d", ErrInsufficientStock, balance.Quantity-movement.Quantity)
+	}
+	return tx.Stock().SaveBalance(ctx, balance)
+}
+
+// normalizeMovement validates a movement and applies the sign convention of its type
+func normalizeMovement(movement *models.StockMovement) error {
+	switch movement.Type {
+	case models.MovementTypeReceipt, models.MovementTypeReturn:
+		if movement.Quantity <= 0 {
+			return fmt.Errorf("%w: %s quantity must be positive", ErrInvalidMovement, movement.Type)
+		}
+	case models.MovementTypeIssue:
+		if movement.Quantity <= 0 {
+			return fmt.Errorf("%w: issue quantity must be positive", ErrInvalidMovement)
+		}
+		movement.Quantity, movement.UnitQuantity = -movement.Quantity, -movement.UnitQuantity
+	case models.MovementTypeAdjustment, models.MovementTypeTransfer:
+		if movement.Quantity == 0 {
+			return fmt.Errorf("%w: %s quantity must not be zero", ErrInvalidMovement, movement.Type)
+		}
+		if movement.Type == models.MovementTypeAdjustment && movement.ReasonCode == "" {
+			return fmt.Errorf("%w: adjustments need a reason code", ErrInvalidMovement)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidMovement, movement.Type)
+	}
+	return nil
+}
+
+// GetStockMovementsBySKU returns one page of the ledger for a SKU, oldest
+// first by default. Each entry's balance_after is the running balance.
+func (s *InventoryService) GetStockMovementsBySKU(ctx context.Context, sku string, page repository.PageRequest) (repository.Page[models.StockMovement], error) {
+	return s.store.Stock().MovementsBySKU(ctx, sku, page)
+}
+
+// GetInventoryReconciliation compares an item's on-hand quantity with the
+// sum of its ledger without changing anything
+func (s *InventoryService) GetInventoryReconciliation(ctx context.Context, inventoryID uint) (*InventoryReconciliation, error) {
+	inventory, err := s.store.Inventory().Get(ctx, inventoryID)
+	if err != nil {
+		return nil, err
+	}
+	return reconciliation(ctx, s.store, inventory)
+}
+
+// ReconcileInventory makes the ledger the source of truth for an item's
+// on-hand quantity. Items that predate the ledger have no movements yet, so
+// their current quantity is recorded as an opening balance instead of being
+// zeroed; otherwise the on-hand quantity is reset to the ledger balance.
+func (s *InventoryService) ReconcileInventory(ctx context.Context, inventoryID, userID uint) (*InventoryReconciliation, error) {
+	var result *InventoryReconciliation
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		inventory, err := tx.Inventory().Lock(ctx, inventoryID)
+		if err != nil {
+			return err
+		}
+
+		current, err := reconciliation(ctx, tx, inventory)
+		if err != nil {
+			return err
+		}
+
+		if current.Movements == 0 && inventory.Quantity != 0 {
+			opening := &models.StockMovement{
+				InventoryID:  inventory.ID,
+				SKU:          inventory.SKU.Code,
+				Type:         models.MovementTypeAdjustment,
+				Quantity:     inventory.Quantity,
+				Unit:         inventory.SKU.BaseUnit,
+				UnitQuantity: float64(inventory.Quantity),
+				BalanceAfter: inventory.Quantity,
+				ReasonCode:   ReasonOpeningBalance,
+				UserID:       userID,
+			}
+			if err := tx.Stock().CreateMovement(ctx, opening); err != nil {
+				return err
+			}
+		} else if current.Difference != 0 {
+			if err := tx.Inventory().SetQuantity(ctx, inventory.ID, current.LedgerBalance); err != nil {
+				return err
+			}
+			inventory.Quantity = current.LedgerBalance
+		}
+
+		result, err = reconciliation(ctx, tx, inventory)
+		return err
+	})
+	return result, err
+}
+
+// reconciliation sums the ledger of an inventory item
+func reconciliation(ctx context.Context, store repository.Store, inventory *models.Inventory) (*InventoryReconciliation, error) {
+	balance, count, err := store.Stock().LedgerTotals(ctx, inventory.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InventoryReconciliation{
+		InventoryID:   inventory.ID,
+		OnHand:        inventory.Quantity,
+		LedgerBalance: balance,
+		Difference:    inventory.Quantity - balance,
+		Movements:     count,
+	}, nil
+}
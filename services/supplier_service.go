@@ -1,233 +1,79 @@
 package services
 
 import (
-	"inventory-supply-chain-system/db"
+	"context"
+	"errors"
+	"fmt"
+
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 )
 
-// CreateSupplier adds a new supplier to the database.
-func CreateSupplier(supplier models.Supplier) error {
-	result := db.DB.Create(&supplier)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
-}
-
-// GetSuppliers fetches all suppliers from the database.
-func GetSuppliers() ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSupplierByID fetches a supplier by its ID.
-func GetSupplierByID(id uint) (*models.Supplier, error) {
-	var supplier models.Supplier
-	result := db.DB.First(&supplier, id)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return &supplier, nil
-}
-
-// UpdateSupplier updates an existing supplier in the database.
-func UpdateSupplier(supplier *models.Supplier) error {
-	result := db.DB.Save(supplier)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
-}
-
-// DeleteSupplier deletes a supplier from the database using its ID.
-func DeleteSupplier(id uint) error {
-	result := db.DB.Delete(&models.Supplier{}, id)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
+// SupplierService manages suppliers.
+type SupplierService struct {
+	store repository.Store
 }
 
-// GetSuppliersByCategory fetches suppliers based on their category.
-func GetSuppliersByCategory(category string) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("category = ?", category).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
+// NewSupplierService returns a SupplierService over store.
+func NewSupplierService(store repository.Store) *SupplierService {
+	return &SupplierService{store: store}
 }
 
-// GetSuppliersByProductID fetches suppliers based on the products they supply.
-func GetSuppliersByProductID(productID uint) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("product_id = ?", productID).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
+// CreateSupplier adds a new supplier.
+func (s *SupplierService) CreateSupplier(ctx context.Context, supplier *models.Supplier) error {
+	return s.store.Suppliers().Create(ctx, supplier)
 }
 
-// GetSuppliersByLocation fetches suppliers based on their location.
-func GetSuppliersByLocation(location string) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("location = ?", location).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
+// GetSuppliers fetches one page of suppliers.
+func (s *SupplierService) GetSuppliers(ctx context.Context, page repository.PageRequest) (repository.Page[models.Supplier], error) {
+	return s.store.Suppliers().List(ctx, page)
 }
 
-// GetSuppliersByRating fetches suppliers based on their rating.
-func GetSuppliersByRating(rating float32) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("rating = ?", rating).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
+// GetSupplierByID fetches a supplier with its categories by its ID.
+func (s *SupplierService) GetSupplierByID(ctx context.Context, id uint) (*models.Supplier, error) {
+	return s.store.Suppliers().Get(ctx, id)
 }
 
-// GetSuppliersByProductIDAndLocation fetches suppliers based on the products they supply and their location.
-func GetSuppliersByProductIDAndLocation(productID uint, location string) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("product_id = ? AND location = ?", productID, location).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
+// FindSuppliers fetches every supplier matching filter.
+func (s *SupplierService) FindSuppliers(ctx context.Context, filter repository.SupplierFilter) ([]models.Supplier, error) {
+	return s.store.Suppliers().Find(ctx, filter)
 }
 
-// GetSuppliersByProductIDAndRating fetches suppliers based on the products they supply and their rating.
-func GetSuppliersByProductIDAndRating(productID uint, rating float32) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("product_id = ? AND rating = ?", productID, rating).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByLocationAndRating fetches suppliers based on their location and rating.
-func GetSuppliersByLocationAndRating(location string, rating float32) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("location = ? AND rating = ?", location, rating).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByProductIDLocationAndRating fetches suppliers based on the products they supply, their location, and rating.
-func GetSuppliersByProductIDLocationAndRating(productID uint, location string, rating float32) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("product_id = ? AND location = ? AND rating = ?", productID, location, rating).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
+// UpdateSupplier updates an existing supplier; its categories are set on their own.
+func (s *SupplierService) UpdateSupplier(ctx context.Context, supplier *models.Supplier) error {
+	return s.store.Suppliers().Save(ctx, supplier)
 }
 
-// GetSuppliersByCategoryLocationAndRating fetches suppliers based on their category, location, and rating.
-func GetSuppliersByCategoryLocationAndRating(category, location string, rating float32) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("category = ? AND location = ? AND rating = ?", category, location, rating).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
+// SetSupplierCategories replaces the categories a supplier is assigned to.
+func (s *SupplierService) SetSupplierCategories(ctx context.Context, id uint, categoryIDs []uint) (*models.Supplier, error) {
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		if _, err := tx.Suppliers().Get(ctx, id); err != nil {
+			return err
+		}
+		seen := make(map[uint]bool, len(categoryIDs))
+		unique := make([]uint, 0, len(categoryIDs))
+		for _, categoryID := range categoryIDs {
+			if seen[categoryID] {
+				continue
+			}
+			seen[categoryID] = true
+			if _, err := tx.Categories().Get(ctx, categoryID); err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return fmt.Errorf("%w: unknown category %d", ErrInvalidCategory, categoryID)
+				}
+				return err
+			}
+			unique = append(unique, categoryID)
+		}
+		return tx.Suppliers().SetCategories(ctx, id, unique)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return suppliers, nil
+	return s.store.Suppliers().Get(ctx, id)
 }
 
-// GetSuppliersByCategoryLocationRatingAndProductID fetches suppliers based on their category, location, rating, and product ID.
-func GetSuppliersByCategoryLocationRatingAndProductID(category, location string, rating float32, productID uint) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("category = ? AND location = ? AND rating = ? AND product_id = ?", category, location, rating, productID).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByOrderCount fetches suppliers based on the number of orders they've fulfilled.
-func GetSuppliersByOrderCount(minOrders, maxOrders int) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("order_count >= ? AND order_count <= ?", minOrders, maxOrders).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByCategoryAndLocation fetches suppliers based on their category and location.
-func GetSuppliersByCategoryAndLocation(category, location string) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("category = ? AND location = ?", category, location).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByCategoryAndRating fetches suppliers based on their category and rating.
-func GetSuppliersByCategoryAndRating(category string, minRating, maxRating float64) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("category = ? AND rating >= ? AND rating <= ?", category, minRating, maxRating).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByCategoryAndOrderCount fetches suppliers based on their category and the number of orders they've fulfilled.
-func GetSuppliersByCategoryAndOrderCount(category string, minOrders, maxOrders int) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("category = ? AND order_count >= ? AND order_count <= ?", category, minOrders, maxOrders).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByLocationAndOrderCount fetches suppliers based on their location and the number of orders they've fulfilled.
-func GetSuppliersByLocationAndOrderCount(location string, minOrders, maxOrders int) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("location = ? AND order_count >= ? AND order_count <= ?", location, minOrders, maxOrders).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByRatingAndOrderCount fetches suppliers based on their rating and the number of orders they've fulfilled.
-func GetSuppliersByRatingAndOrderCount(minRating, maxRating float64, minOrders, maxOrders int) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("rating >= ? AND rating <= ? AND order_count >= ? AND order_count <= ?", minRating, maxRating, minOrders, maxOrders).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByProductIDAndLocationAndRating fetches suppliers based on the product ID, location, and rating.
-func GetSuppliersByProductIDAndLocationAndRating(productID uint, location string, rating float32) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("product_id = ? AND location = ? AND rating = ?", productID, location, rating).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
-}
-
-// GetSuppliersByCategoryAndLocationAndRating fetches suppliers based on the category, location, and rating.
-func GetSuppliersByCategoryAndLocationAndRating(category string, location string, rating float32) ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := db.DB.Where("category = ? AND location = ? AND rating = ?", category, location, rating).Find(&suppliers)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return suppliers, nil
+// DeleteSupplier deletes a supplier using its ID.
+func (s *SupplierService) DeleteSupplier(ctx context.Context, id uint) error {
+	return s.store.Suppliers().Delete(ctx, id)
 }
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+var (
+	// ErrInvalidTransfer is returned when a transfer order or receipt is malformed
+	ErrInvalidTransfer = errors.New("invalid transfer order")
+	// ErrInvalidTransferStatus is returned when a transfer order is not in the
+	// status the requested step starts from
+	ErrInvalidTransferStatus = errors.New("invalid transfer order status")
+)
+
+// Reason codes of the movements booked by transfer orders
+const (
+	ReasonTransferOut       = "transfer_out"
+	ReasonTransferIn        = "transfer_in"
+	ReasonTransferCancelled = "transfer_cancelled"
+)
+
+// TransferService moves stock between warehouses through transfer orders
+type TransferService struct {
+	store repository.Store
+}
+
+// NewTransferService returns a TransferService over store
+func NewTransferService(store repository.Store) *TransferService {
+	return &TransferService{store: store}
+}
+
+// TransferDispatch carries the carrier details of a transfer's shipment
+type TransferDispatch struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+}
+
+// TransferReceipt is the quantity of one transfer line counted in at the
+// destination, in base units or as a UnitQuantity in Unit. BinID overrides
+// the line's destination bin when set.
+type TransferReceipt struct {
+	LineID       uint    `json:"line_id"`
+	Quantity     int     `json:"quantity"`
+	Unit         string  `json:"unit"`
+	UnitQuantity float64 `json:"unit_quantity"`
+	BinID        uint    `json:"bin_id"`
+}
+
+// CreateTransferOrder records a draft transfer between two active warehouses
+func (s *TransferService) CreateTransferOrder(ctx context.Context, transfer *models.TransferOrder) error {
+	if transfer.SourceWarehouseID == 0 || transfer.DestinationWarehouseID == 0 {
+		return fmt.Errorf("%w: source and destination warehouses are required", ErrInvalidTransfer)
+	}
+	if transfer.SourceWarehouseID == transfer.DestinationWarehouseID {
+		return fmt.Errorf("%w: source and destination must differ", ErrInvalidTransfer)
+	}
+	if len(transfer.Lines) == 0 {
+		return fmt.Errorf("%w: at least one line is required", ErrInvalidTransfer)
+	}
+
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		for _, id := range []uint{transfer.SourceWarehouseID, transfer.DestinationWarehouseID} {
+			warehouse, err := tx.Warehouses().Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			if !warehouse.Active {
+				return fmt.Errorf("%w: warehouse %s is inactive", ErrLocationUnavailable, warehouse.Code)
+			}
+		}
+
+		for i := range transfer.Lines {
+			line := &transfer.Lines[i]
+			inventory, err := tx.Inventory().Get(ctx, line.InventoryID)
+			if err != nil {
+				return err
+			}
+			if err := applyUnit(ctx, tx, inventory.SKU, &line.Quantity, &line.Unit, &line.UnitQuantity); err != nil {
+				return err
+			}
+			if line.Quantity <= 0 {
+				return fmt.Errorf("%w: line quantities must be positive", ErrInvalidTransfer)
+			}
+			if inventory.SKU.SerialControlled {
+				return fmt.Errorf("%w: SKU %s is serial-controlled; move it with a transfer movement that lists its serial numbers", ErrInvalidTransfer, inventory.SKU.Code)
+			}
+			if line.LotID != 0 {
+				lot, err := tx.Lots().Get(ctx, line.LotID)
+				if errors.Is(err, repository.ErrNotFound) || (err == nil && lot.SKUID != inventory.SKUID) {
+					return fmt.Errorf("%w: lot %d is not a lot of SKU %s", ErrInvalidTransfer, line.LotID, inventory.SKU.Code)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if err := ensureBinIn(ctx, tx, line.SourceBinID, transfer.SourceWarehouseID); err != nil {
+				return err
+			}
+			if err := ensureBinIn(ctx, tx, line.DestinationBinID, transfer.DestinationWarehouseID); err != nil {
+				return err
+			}
+			line.ID = 0
+			line.PickedQuantity, line.ReceivedQuantity, line.DiscrepancyQuantity = 0, 0, 0
+		}
+
+		transfer.Status = models.TransferStatusDraft
+		transfer.ShipmentID = 0
+		transfer.DiscrepancyReason = ""
+		return tx.Transfers().Create(ctx, transfer)
+	})
+}
+
+// FindTransferOrders returns one page of the transfer orders matching filter
+func (s *TransferService) FindTransferOrders(ctx context.Context, filter repository.TransferFilter, page repository.PageRequest) (repository.Page[models.TransferOrder], error) {
+	return s.store.Transfers().Find(ctx, filter, page)
+}
+
+// GetTransferOrder fetches a transfer order with its lines
+func (s *TransferService) GetTransferOrder(ctx context.Context, id uint) (*models.TransferOrder, error) {
+	return s.store.Transfers().Get(ctx, id)
+}
+
+// PickTransferOrder takes the stock of every line out of the source
+// warehouse and moves a draft transfer to picked
+func (s *TransferService) PickTransferOrder(ctx context.Context, id, userID uint) (*models.TransferOrder, error) {
+	return s.advanceTransfer(ctx, id, models.TransferStatusDraft, func(tx repository.Store, transfer *models.TransferOrder) error {
+		for i := range transfer.Lines {
+			line := &transfer.Lines[i]
+			if err := recordTransferMovement(ctx, tx, &models.StockMovement{
+				InventoryID: line.InventoryID,
+				WarehouseID: transfer.SourceWarehouseID,
+				BinID:       line.SourceBinID,
+				LotID:       line.LotID,
+				Type:        models.MovementTypeTransfer,
+				Quantity:    -line.Quantity,
+				ReasonCode:  ReasonTransferOut,
+				Reference:   transferReference(transfer.ID),
+				UserID:      userID,
+			}); err != nil {
+				return err
+			}
+			line.PickedQuantity = line.Quantity
+			if err := tx.Transfers().UpdateLine(ctx, line, "picked_quantity"); err != nil {
+				return err
+			}
+		}
+
+		transfer.Status = models.TransferStatusPicked
+		return nil
+	})
+}
+
+// DispatchTransferOrder creates the shipment that carries a picked transfer
+// and moves it to in transit
+func (s *TransferService) DispatchTransferOrder(ctx context.Context, id uint, dispatch TransferDispatch) (*models.TransferOrder, error) {
+	return s.advanceTransfer(ctx, id, models.TransferStatusPicked, func(tx repository.Store, transfer *models.TransferOrder) error {
+		shipment := models.Shipment{
+			TransferOrderID: transfer.ID,
+			WarehouseID:     transfer.SourceWarehouseID,
+			Carrier:         dispatch.Carrier,
+			TrackingNumber:  dispatch.TrackingNumber,
+			ShippingStatus:  "in_transit",
+		}
+		if err := tx.Shipments().Create(ctx, &shipment); err != nil {
+			return err
+		}
+
+		transfer.ShipmentID = shipment.ID
+		transfer.Status = models.TransferStatusInTransit
+		return nil
+	})
+}
+
+// ReceiveTransferOrder credits the destination warehouse with the counted
+// quantities. Receipts may arrive in several parts; the transfer is marked
+// received, and its shipment delivered, once every picked unit has arrived.
+func (s *TransferService) ReceiveTransferOrder(ctx context.Context, id uint, receipts []TransferReceipt, userID uint) (*models.TransferOrder, error) {
+	if len(receipts) == 0 {
+		return nil, fmt.Errorf("%w: nothing to receive", ErrInvalidTransfer)
+	}
+
+	return s.advanceTransfer(ctx, id, models.TransferStatusInTransit, func(tx repository.Store, transfer *models.TransferOrder) error {
+		lines := make(map[uint]*models.TransferOrderLine, len(transfer.Lines))
+		for i := range transfer.Lines {
+			lines[transfer.Lines[i].ID] = &transfer.Lines[i]
+		}
+
+		for _, receipt := range receipts {
+			line, ok := lines[receipt.LineID]
+			if !ok {
+				return fmt.Errorf("%w: line %d is not on this transfer", ErrInvalidTransfer, receipt.LineID)
+			}
+			inventory, err := tx.Inventory().Get(ctx, line.InventoryID)
+			if err != nil {
+				return err
+			}
+			if err := applyUnit(ctx, tx, inventory.SKU, &receipt.Quantity, &receipt.Unit, &receipt.UnitQuantity); err != nil {
+				return err
+			}
+			outstanding := line.PickedQuantity - line.ReceivedQuantity
+			if receipt.Quantity <= 0 || receipt.Quantity > outstanding {
+				return fmt.Errorf("%w: line %d has %d units outstanding", ErrInvalidTransfer, line.ID, outstanding)
+			}
+
+			binID := line.DestinationBinID
+			if receipt.BinID != 0 {
+				if err := ensureBinIn(ctx, tx, receipt.BinID, transfer.DestinationWarehouseID); err != nil {
+					return err
+				}
+				binID = receipt.BinID
+			}
+
+			if err := recordTransferMovement(ctx, tx, &models.StockMovement{
+				InventoryID:  line.InventoryID,
+				WarehouseID:  transfer.DestinationWarehouseID,
+				BinID:        binID,
+				LotID:        line.LotID,
+				Type:         models.MovementTypeTransfer,
+				Quantity:     receipt.Quantity,
+				Unit:         receipt.Unit,
+				UnitQuantity: receipt.UnitQuantity,
+				ReasonCode:   ReasonTransferIn,
+				Reference:    transferReference(transfer.ID),
+				UserID:       userID,
+			}); err != nil {
+				return err
+			}
+
+			line.ReceivedQuantity += receipt.Quantity
+			if err := tx.Transfers().UpdateLine(ctx, line, "received_quantity"); err != nil {
+				return err
+			}
+		}
+
+		for _, line := range transfer.Lines {
+			if line.ReceivedQuantity < line.PickedQuantity {
+				return nil
+			}
+		}
+		return completeTransfer(ctx, tx, transfer)
+	})
+}
+
+// CloseTransferOrder completes an in-transit transfer that will not be
+// received in full. The units still outstanding are recorded on each line as
+// a discrepancy with the given reason; they already left the source
+// warehouse, so the ledger is not touched.
+func (s *TransferService) CloseTransferOrder(ctx context.Context, id uint, reason string) (*models.TransferOrder, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("%w: a discrepancy reason is required", ErrInvalidTransfer)
+	}
+
+	return s.advanceTransfer(ctx, id, models.TransferStatusInTransit, func(tx repository.Store, transfer *models.TransferOrder) error {
+		for i := range transfer.Lines {
+			line := &transfer.Lines[i]
+			line.DiscrepancyQuantity = line.PickedQuantity - line.ReceivedQuantity
+			if err := tx.Transfers().UpdateLine(ctx, line, "discrepancy_quantity"); err != nil {
+				return err
+			}
+		}
+
+		transfer.DiscrepancyReason = reason
+		return completeTransfer(ctx, tx, transfer)
+	})
+}
+
+// CancelTransferOrder cancels a draft or picked transfer. Picked stock is
+// put back where it was taken from.
+func (s *TransferService) CancelTransferOrder(ctx context.Context, id, userID uint) (*models.TransferOrder, error) {
+	var transfer *models.TransferOrder
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		transfer, err = tx.Transfers().Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		switch transfer.Status {
+		case models.TransferStatusDraft:
+		case models.TransferStatusPicked:
+			for _, line := range transfer.Lines {
+				if err := recordTransferMovement(ctx, tx, &models.StockMovement{
+					InventoryID: line.InventoryID,
+					WarehouseID: transfer.SourceWarehouseID,
+					BinID:       line.SourceBinID,
+					LotID:       line.LotID,
+					Type:        models.MovementTypeTransfer,
+					Quantity:    line.PickedQuantity,
+					ReasonCode:  ReasonTransferCancelled,
+					Reference:   transferReference(transfer.ID),
+					UserID:      userID,
+				}); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%w: cannot cancel a transfer that is %s", ErrInvalidTransferStatus, transfer.Status)
+		}
+
+		transfer.Status = models.TransferStatusCancelled
+		return tx.Transfers().Update(ctx, transfer, "status")
+	})
+	return transfer, err
+}
+
+// recordTransferMovement is recordMovement for a leg of a transfer order.
+// These are the only movements that may add stock of a lot-controlled SKU
+// without a lot: they carry on or put back what was picked without one.
+func recordTransferMovement(ctx context.Context, tx repository.Store, movement *models.StockMovement) error {
+	return recordMovementFor(ctx, tx, movement, movementOrigin{transfer: true})
+}
+
+// moveStock records a transfer a client asked for as its two legs: the
+// stock leaves the movement's warehouse and bin and arrives at its
+// ToWarehouseID and ToBinID, with the same lot and serial numbers. movement
+// is left holding the leg into the destination.
+func moveStock(ctx context.Context, tx repository.Store, movement *models.StockMovement) error {
+	if movement.ToWarehouseID == 0 {
+		return fmt.Errorf("%w: a transfer needs a to_warehouse_id", ErrInvalidMovement)
+	}
+	if movement.ToWarehouseID == movement.WarehouseID && movement.ToBinID == movement.BinID {
+		return fmt.Errorf("%w: a transfer must move stock to another location", ErrInvalidMovement)
+	}
+	if movement.Quantity < 0 || movement.UnitQuantity < 0 {
+		return fmt.Errorf("%w: transfer quantity must be positive", ErrInvalidMovement)
+	}
+
+	out := *movement
+	out.Quantity, out.UnitQuantity = -movement.Quantity, -movement.UnitQuantity
+	if err := recordTransferMovement(ctx, tx, &out); err != nil {
+		return err
+	}
+
+	movement.WarehouseID, movement.BinID = movement.ToWarehouseID, movement.ToBinID
+	movement.LotID, movement.Lot = out.LotID, nil
+	movement.Quantity, movement.Unit, movement.UnitQuantity = -out.Quantity, out.Unit, -out.UnitQuantity
+	return recordTransferMovement(ctx, tx, movement)
+}
+
+// advanceTransfer locks a transfer, checks it is in the from status and runs
+// step in the same transaction, saving the transfer's status and shipment
+// afterwards
+func (s *TransferService) advanceTransfer(ctx context.Context, id uint, from string, step func(repository.Store, *models.TransferOrder) error) (*models.TransferOrder, error) {
+	var transfer *models.TransferOrder
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		var err error
+		transfer, err = tx.Transfers().Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != from {
+			return fmt.Errorf("%w: transfer is %s, expected %s", ErrInvalidTransferStatus, transfer.Status, from)
+		}
+
+		if err := step(tx, transfer); err != nil {
+			return err
+		}
+		return tx.Transfers().Update(ctx, transfer, "status", "shipment_id", "discrepancy_reason")
+	})
+	return transfer, err
+}
+
+// completeTransfer marks a transfer received and its shipment delivered
+func completeTransfer(ctx context.Context, tx repository.Store, transfer *models.TransferOrder) error {
+	transfer.Status = models.TransferStatusReceived
+	return tx.Shipments().SetStatus(ctx, transfer.ShipmentID, "delivered")
+}
+
+// ensureBinIn checks that a bin, if given, belongs to the warehouse
+func ensureBinIn(ctx context.Context, tx repository.Store, binID, warehouseID uint) error {
+	if binID == 0 {
+		return nil
+	}
+
+	bin, err := tx.Warehouses().BinByID(ctx, binID)
+	if err != nil {
+		return err
+	}
+	if bin.WarehouseID != warehouseID {
+		return fmt.Errorf("%w: bin %s is not in warehouse %d", ErrLocationUnavailable, bin.Code, warehouseID)
+	}
+	return nil
+}
+
+// transferReference is the movement reference of a transfer order
+func transferReference(id uint) string {
+	return fmt.Sprintf("transfer:%d", id)
+}
@@ -0,0 +1,311 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/services"
+)
+
+// transferFixture moves the warehouse fixture's item from a source warehouse
+// holding 10 units to an empty destination
+type transferFixture struct {
+	*warehouseFixture
+	transfers                 *services.TransferService
+	source, destination       models.Warehouse
+	sourceBin, destinationBin models.Bin
+}
+
+func newTransferFixture(t *testing.T) *transferFixture {
+	t.Helper()
+	f := &transferFixture{warehouseFixture: newWarehouseFixture(t)}
+	f.transfers = services.NewTransferService(f.store)
+	f.source, f.sourceBin = f.warehouse("SOURCE")
+	f.destination, f.destinationBin = f.warehouse("DEST")
+	f.receive(f.source.ID, f.sourceBin.ID, 10)
+	return f
+}
+
+// draft creates a draft transfer of quantity units between the fixture's bins
+func (f *transferFixture) draft(quantity int) *models.TransferOrder {
+	f.t.Helper()
+	transfer := &models.TransferOrder{
+		SourceWarehouseID:      f.source.ID,
+		DestinationWarehouseID: f.destination.ID,
+		Lines: []models.TransferOrderLine{{
+			InventoryID:      f.item.ID,
+			SourceBinID:      f.sourceBin.ID,
+			DestinationBinID: f.destinationBin.ID,
+			Quantity:         quantity,
+		}},
+	}
+	if err := f.transfers.CreateTransferOrder(context.Background(), transfer); err != nil {
+		f.t.Fatalf("CreateTransferOrder: %v", err)
+	}
+	return transfer
+}
+
+// dispatched drafts, picks and dispatches a transfer of quantity units
+func (f *transferFixture) dispatched(quantity int) *models.TransferOrder {
+	f.t.Helper()
+	ctx := context.Background()
+	transfer := f.draft(quantity)
+	if _, err := f.transfers.PickTransferOrder(ctx, transfer.ID, 0); err != nil {
+		f.t.Fatalf("PickTransferOrder: %v", err)
+	}
+	transfer, err := f.transfers.DispatchTransferOrder(ctx, transfer.ID, services.TransferDispatch{Carrier: "DHL"})
+	if err != nil {
+		f.t.Fatalf("DispatchTransferOrder: %v", err)
+	}
+	return transfer
+}
+
+// stock checks what the source and destination bins and the item hold
+func (f *transferFixture) stock(source, destination, total int) {
+	f.t.Helper()
+	if held := f.held(f.source.ID, f.sourceBin.ID); held != source {
+		f.t.Errorf("source bin holds %d, want %d", held, source)
+	}
+	if held := f.held(f.destination.ID, f.destinationBin.ID); held != destination {
+		f.t.Errorf("destination bin holds %d, want %d", held, destination)
+	}
+	item, err := f.store.Inventory().Get(context.Background(), f.item.ID)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	if item.Quantity != total {
+		f.t.Errorf("item quantity = %d, want %d", item.Quantity, total)
+	}
+}
+
+func TestTransferMovesStockThroughEachStatus(t *testing.T) {
+	f := newTransferFixture(t)
+	ctx := context.Background()
+
+	transfer := f.draft(4)
+	if transfer.Status != models.TransferStatusDraft {
+		t.Fatalf("new transfer is %s, want draft", transfer.Status)
+	}
+	f.stock(10, 0, 10)
+
+	transfer, err := f.transfers.PickTransferOrder(ctx, transfer.ID, 0)
+	if err != nil {
+		t.Fatalf("PickTransferOrder: %v", err)
+	}
+	if transfer.Status != models.TransferStatusPicked || transfer.Lines[0].PickedQuantity != 4 {
+		t.Fatalf("picked transfer = %s with %d picked, want picked with 4", transfer.Status, transfer.Lines[0].PickedQuantity)
+	}
+	// Picked stock is in neither warehouse until it is received
+	f.stock(6, 0, 6)
+
+	transfer, err = f.transfers.DispatchTransferOrder(ctx, transfer.ID, services.TransferDispatch{Carrier: "DHL", TrackingNumber: "T-1"})
+	if err != nil {
+		t.Fatalf("DispatchTransferOrder: %v", err)
+	}
+	if transfer.Status != models.TransferStatusInTransit || transfer.ShipmentID == 0 {
+		t.Fatalf("dispatched transfer = %s with shipment %d, want in_transit with a shipment", transfer.Status, transfer.ShipmentID)
+	}
+	shipment, err := f.store.Shipments().Get(ctx, transfer.ShipmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shipment.ShippingStatus != "in_transit" || shipment.TransferOrderID != transfer.ID || shipment.TrackingNumber != "T-1" {
+		t.Errorf("shipment = %+v, want it in transit for the transfer", shipment)
+	}
+
+	transfer, err = f.transfers.ReceiveTransferOrder(ctx, transfer.ID, []services.TransferReceipt{{LineID: transfer.Lines[0].ID, Quantity: 4}}, 0)
+	if err != nil {
+		t.Fatalf("ReceiveTransferOrder: %v", err)
+	}
+	if transfer.Status != models.TransferStatusReceived || transfer.Lines[0].ReceivedQuantity != 4 {
+		t.Fatalf("received transfer = %s with %d received, want received with 4", transfer.Status, transfer.Lines[0].ReceivedQuantity)
+	}
+	f.stock(6, 4, 10)
+	if shipment, err = f.store.Shipments().Get(ctx, transfer.ShipmentID); err != nil || shipment.ShippingStatus != "delivered" {
+		t.Errorf("shipment after the receipt = %+v, %v; want it delivered", shipment, err)
+	}
+}
+
+func TestTransferStepsNeedTheirStartingStatus(t *testing.T) {
+	f := newTransferFixture(t)
+	ctx := context.Background()
+	draft := f.draft(1)
+	inTransit := f.dispatched(1)
+	receipt := []services.TransferReceipt{{LineID: draft.Lines[0].ID, Quantity: 1}}
+
+	steps := map[string]func(id uint) error{
+		"pick": func(id uint) error {
+			_, err := f.transfers.PickTransferOrder(ctx, id, 0)
+			return err
+		},
+		"dispatch": func(id uint) error {
+			_, err := f.transfers.DispatchTransferOrder(ctx, id, services.TransferDispatch{})
+			return err
+		},
+		"receive": func(id uint) error {
+			_, err := f.transfers.ReceiveTransferOrder(ctx, id, receipt, 0)
+			return err
+		},
+		"cancel": func(id uint) error {
+			_, err := f.transfers.CancelTransferOrder(ctx, id, 0)
+			return err
+		},
+	}
+	for _, test := range []struct {
+		step string
+		id   uint
+	}{
+		{"dispatch", draft.ID},
+		{"receive", draft.ID},
+		{"pick", inTransit.ID},
+		{"dispatch", inTransit.ID},
+		{"cancel", inTransit.ID},
+	} {
+		if err := steps[test.step](test.id); !errors.Is(err, services.ErrInvalidTransferStatus) {
+			t.Errorf("%s transfer %d = %v, want ErrInvalidTransferStatus", test.step, test.id, err)
+		}
+	}
+	f.stock(9, 0, 9)
+}
+
+func TestTransferReceivedInParts(t *testing.T) {
+	f := newTransferFixture(t)
+	ctx := context.Background()
+	transfer := f.dispatched(5)
+	line := transfer.Lines[0].ID
+
+	transfer, err := f.transfers.ReceiveTransferOrder(ctx, transfer.ID, []services.TransferReceipt{{LineID: line, Quantity: 2}}, 0)
+	if err != nil {
+		t.Fatalf("first receipt: %v", err)
+	}
+	if transfer.Status != models.TransferStatusInTransit || transfer.Lines[0].ReceivedQuantity != 2 {
+		t.Fatalf("after a partial receipt transfer = %s with %d received, want in_transit with 2", transfer.Status, transfer.Lines[0].ReceivedQuantity)
+	}
+	f.stock(5, 2, 7)
+
+	for _, receipts := range [][]services.TransferReceipt{
+		{{LineID: line, Quantity: 4}},
+		{{LineID: line, Quantity: 0}},
+		{{LineID: line + 100, Quantity: 1}},
+		{{LineID: line, Quantity: 1, BinID: f.sourceBin.ID}},
+		{},
+	} {
+		_, err := f.transfers.ReceiveTransferOrder(ctx, transfer.ID, receipts, 0)
+		if !errors.Is(err, services.ErrInvalidTransfer) && !errors.Is(err, services.ErrLocationUnavailable) {
+			t.Errorf("receiving %+v = %v, want it refused", receipts, err)
+		}
+	}
+	f.stock(5, 2, 7)
+
+	transfer, err = f.transfers.ReceiveTransferOrder(ctx, transfer.ID, []services.TransferReceipt{{LineID: line, Quantity: 3}}, 0)
+	if err != nil {
+		t.Fatalf("last receipt: %v", err)
+	}
+	if transfer.Status != models.TransferStatusReceived || transfer.Lines[0].ReceivedQuantity != 5 {
+		t.Fatalf("after the last receipt transfer = %s with %d received, want received with 5", transfer.Status, transfer.Lines[0].ReceivedQuantity)
+	}
+	f.stock(5, 5, 10)
+}
+
+func TestTransferClosedShort(t *testing.T) {
+	f := newTransferFixture(t)
+	ctx := context.Background()
+	transfer := f.dispatched(5)
+	if _, err := f.transfers.ReceiveTransferOrder(ctx, transfer.ID, []services.TransferReceipt{{LineID: transfer.Lines[0].ID, Quantity: 3}}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.transfers.CloseTransferOrder(ctx, transfer.ID, ""); !errors.Is(err, services.ErrInvalidTransfer) {
+		t.Errorf("closing without a reason = %v, want ErrInvalidTransfer", err)
+	}
+	transfer, err := f.transfers.CloseTransferOrder(ctx, transfer.ID, "damaged in transit")
+	if err != nil {
+		t.Fatalf("CloseTransferOrder: %v", err)
+	}
+	if transfer.Status != models.TransferStatusReceived || transfer.Lines[0].DiscrepancyQuantity != 2 || transfer.DiscrepancyReason != "damaged in transit" {
+		t.Errorf("closed transfer = %s with discrepancy %d (%q), want received with 2", transfer.Status, transfer.Lines[0].DiscrepancyQuantity, transfer.DiscrepancyReason)
+	}
+	// The missing units already left the source and are not put back
+	f.stock(5, 3, 8)
+}
+
+func TestCancelledTransferPutsPickedStockBack(t *testing.T) {
+	f := newTransferFixture(t)
+	ctx := context.Background()
+
+	draft := f.draft(2)
+	picked := f.draft(3)
+	if _, err := f.transfers.PickTransferOrder(ctx, picked.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	f.stock(7, 0, 7)
+
+	for _, transfer := range []*models.TransferOrder{draft, picked} {
+		cancelled, err := f.transfers.CancelTransferOrder(ctx, transfer.ID, 0)
+		if err != nil {
+			t.Fatalf("CancelTransferOrder: %v", err)
+		}
+		if cancelled.Status != models.TransferStatusCancelled {
+			t.Errorf("cancelled transfer is %s", cancelled.Status)
+		}
+	}
+	f.stock(10, 0, 10)
+}
+
+func TestTransfersOutOfInactiveWarehouses(t *testing.T) {
+	f := newTransferFixture(t)
+	ctx := context.Background()
+	other, otherBin := f.warehouse("OTHER")
+
+	// Bins must be in the warehouse their end of the line names
+	transfer := &models.TransferOrder{
+		SourceWarehouseID:      f.source.ID,
+		DestinationWarehouseID: f.destination.ID,
+		Lines:                  []models.TransferOrderLine{{InventoryID: f.item.ID, SourceBinID: otherBin.ID, Quantity: 1}},
+	}
+	if err := f.transfers.CreateTransferOrder(ctx, transfer); !errors.Is(err, services.ErrLocationUnavailable) {
+		t.Errorf("a line from another warehouse's bin = %v, want ErrLocationUnavailable", err)
+	}
+
+	// A transfer drafted before its source closed cannot be picked from it
+	draft := f.draft(2)
+	f.deactivate(f.source)
+	if _, err := f.transfers.PickTransferOrder(ctx, draft.ID, 0); !errors.Is(err, services.ErrLocationUnavailable) {
+		t.Errorf("picking from an inactive warehouse = %v, want ErrLocationUnavailable", err)
+	}
+	if got, err := f.transfers.GetTransferOrder(ctx, draft.ID); err != nil || got.Status != models.TransferStatusDraft || got.Lines[0].PickedQuantity != 0 {
+		t.Errorf("transfer after a refused pick = %+v, %v; want it still a draft", got, err)
+	}
+	f.stock(10, 0, 10)
+
+	// Nor can new transfers start or end at an inactive warehouse
+	for _, ends := range [][2]uint{{f.source.ID, other.ID}, {other.ID, f.source.ID}} {
+		transfer := &models.TransferOrder{
+			SourceWarehouseID:      ends[0],
+			DestinationWarehouseID: ends[1],
+			Lines:                  []models.TransferOrderLine{{InventoryID: f.item.ID, Quantity: 1}},
+		}
+		if err := f.transfers.CreateTransferOrder(ctx, transfer); !errors.Is(err, services.ErrLocationUnavailable) {
+			t.Errorf("transfer from %d to %d = %v, want ErrLocationUnavailable", ends[0], ends[1], err)
+		}
+	}
+}
+
+func TestCreateTransferOrderValidates(t *testing.T) {
+	f := newTransferFixture(t)
+	line := models.TransferOrderLine{InventoryID: f.item.ID, Quantity: 1}
+
+	for name, transfer := range map[string]models.TransferOrder{
+		"no source":         {DestinationWarehouseID: f.destination.ID, Lines: []models.TransferOrderLine{line}},
+		"same warehouse":    {SourceWarehouseID: f.source.ID, DestinationWarehouseID: f.source.ID, Lines: []models.TransferOrderLine{line}},
+		"no lines":          {SourceWarehouseID: f.source.ID, DestinationWarehouseID: f.destination.ID},
+		"no quantity":       {SourceWarehouseID: f.source.ID, DestinationWarehouseID: f.destination.ID, Lines: []models.TransferOrderLine{{InventoryID: f.item.ID}}},
+		"negative quantity": {SourceWarehouseID: f.source.ID, DestinationWarehouseID: f.destination.ID, Lines: []models.TransferOrderLine{{InventoryID: f.item.ID, Quantity: -1}}},
+	} {
+		if err := f.transfers.CreateTransferOrder(context.Background(), &transfer); !errors.Is(err, services.ErrInvalidTransfer) {
+			t.Errorf("%s: CreateTransferOrder = %v, want ErrInvalidTransfer", name, err)
+		}
+	}
+}
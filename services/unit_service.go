@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+var (
+	// ErrInvalidUnit is returned when unit data is malformed, or when a
+	// quantity is given in a unit its SKU cannot be converted from
+	ErrInvalidUnit = errors.New("invalid unit")
+	// ErrUnitInUse is returned when deleting a unit that SKUs still use
+	ErrUnitInUse = errors.New("unit is still used by SKUs")
+)
+
+// unitDimensions lists the dimensions a unit may have
+var unitDimensions = map[string]bool{
+	models.UnitDimensionCount:  true,
+	models.UnitDimensionMass:   true,
+	models.UnitDimensionVolume: true,
+	models.UnitDimensionLength: true,
+}
+
+// UnitService manages the unit of measure catalog
+type UnitService struct {
+	store repository.Store
+}
+
+// NewUnitService returns a UnitService over store
+func NewUnitService(store repository.Store) *UnitService {
+	return &UnitService{store: store}
+}
+
+// CreateUnit adds a unit to the catalog
+func (s *UnitService) CreateUnit(ctx context.Context, unit *models.Unit) error {
+	unit.Code = strings.ToLower(strings.TrimSpace(unit.Code))
+	if unit.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidUnit)
+	}
+	if !unitDimensions[unit.Dimension] {
+		return fmt.Errorf("%w: dimension must be count, mass, volume or length", ErrInvalidUnit)
+	}
+	// Ratios count the smallest unit of the dimension, which has ratio 1
+	if unit.Ratio < 0 || (unit.Ratio > 0 && unit.Ratio < 1) {
+		return fmt.Errorf("%w: ratio must be 0 or at least 1, the size of the smallest unit of the dimension", ErrInvalidUnit)
+	}
+	return s.store.Units().Create(ctx, unit)
+}
+
+// GetUnits fetches the whole catalog
+func (s *UnitService) GetUnits(ctx context.Context) ([]models.Unit, error) {
+	return s.store.Units().All(ctx)
+}
+
+// GetUnit fetches a unit by its code
+func (s *UnitService) GetUnit(ctx context.Context, code string) (*models.Unit, error) {
+	return s.store.Units().GetByCode(ctx, code)
+}
+
+// DeleteUnit removes a unit that no SKU uses
+func (s *UnitService) DeleteUnit(ctx context.Context, code string) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		unit, err := tx.Units().GetByCode(ctx, code)
+		if err != nil {
+			return err
+		}
+		count, err := tx.Units().CountUses(ctx, code)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUnitInUse
+		}
+		return tx.Units().Delete(ctx, unit.ID)
+	})
+}
+
+// checkSKUUnits makes sure a SKU's base unit is in the catalog and that it
+// gives each of its other units, which must be in the catalog too, a factor
+// of at least 1. Stock is kept in whole base units, so the base unit has to
+// be the smallest unit the SKU is counted in: a count unit such as ea, or
+// the smallest unit of a measured dimension, such as g rather than kg.
+// keptBase is the base unit the SKU already has, which is left as it is.
+func checkSKUUnits(ctx context.Context, tx repository.Store, sku *models.SKU, keptBase string) error {
+	base, err := tx.Units().GetByCode(ctx, sku.BaseUnit)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("%w: unknown base unit %q", ErrInvalidSKU, sku.BaseUnit)
+	}
+	if err != nil {
+		return err
+	}
+	if sku.BaseUnit != keptBase && base.Ratio != 1 {
+		return fmt.Errorf("%w: base unit %q is not the smallest unit of its dimension; use a unit with ratio 1, such as ea, g or ml", ErrInvalidSKU, sku.BaseUnit)
+	}
+
+	seen := make(map[string]bool, len(sku.Units))
+	for i := range sku.Units {
+		conversion := &sku.Units[i]
+		if conversion.Unit == sku.BaseUnit {
+			return fmt.Errorf("%w: %q is the base unit and always has factor 1", ErrInvalidSKU, conversion.Unit)
+		}
+		if seen[conversion.Unit] {
+			return fmt.Errorf("%w: unit %q is listed more than once", ErrInvalidSKU, conversion.Unit)
+		}
+		seen[conversion.Unit] = true
+		if conversion.Factor < 1 {
+			return fmt.Errorf("%w: unit %q needs a factor of at least 1, as no unit is smaller than the base unit", ErrInvalidSKU, conversion.Unit)
+		}
+		if _, err := tx.Units().GetByCode(ctx, conversion.Unit); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%w: unknown unit %q", ErrInvalidSKU, conversion.Unit)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// unitFactor is the number of a SKU's base units in one of unit. The SKU's
+// own factor for the unit wins; otherwise units of the same dimension as
+// the base unit convert through their catalog ratios.
+func unitFactor(ctx context.Context, tx repository.Store, sku *models.SKU, unit string) (float64, error) {
+	if unit == sku.BaseUnit {
+		return 1, nil
+	}
+
+	conversion, err := tx.Units().Conversion(ctx, sku.ID, unit)
+	if err == nil {
+		return conversion.Factor, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return 0, err
+	}
+
+	from, err := tx.Units().GetByCode(ctx, unit)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, fmt.Errorf("%w: unknown unit %q", ErrInvalidUnit, unit)
+	}
+	if err != nil {
+		return 0, err
+	}
+	base, err := tx.Units().GetByCode(ctx, sku.BaseUnit)
+	if err != nil {
+		return 0, err
+	}
+	if from.Dimension != base.Dimension || from.Ratio == 0 || base.Ratio == 0 {
+		return 0, fmt.Errorf("%w: SKU %s has no conversion from %s to %s", ErrInvalidUnit, sku.Code, unit, sku.BaseUnit)
+	}
+	return from.Ratio / base.Ratio, nil
+}
+
+// applyUnit sets the base quantity of a line from the quantity it was
+// given in unit. A line without a unit quantity is already in base units,
+// which are recorded as its unit. The unit quantity must come to a whole
+// number of base units.
+func applyUnit(ctx context.Context, tx repository.Store, sku *models.SKU, quantity *int, unit *string, unitQuantity *float64) error {
+	*unit = strings.ToLower(strings.TrimSpace(*unit))
+	if *unit == "" {
+		*unit = sku.BaseUnit
+	}
+	if *unitQuantity == 0 {
+		if *unit != sku.BaseUnit {
+			return fmt.Errorf("%w: unit_quantity is required with unit %s", ErrInvalidUnit, *unit)
+		}
+		*unitQuantity = float64(*quantity)
+		return nil
+	}
+
+	factor, err := unitFactor(ctx, tx, sku, *unit)
+	if err != nil {
+		return err
+	}
+	base := *unitQuantity * factor
+	whole := math.Round(base)
+	if math.Abs(base-whole) > 1e-6 {
+		return fmt.Errorf("%w: %g %s is %g %s, which is not a whole number", ErrInvalidUnit, *unitQuantity, *unit, base, sku.BaseUnit)
+	}
+	*quantity = int(whole)
+	return nil
+}
@@ -1,245 +1,296 @@
 package services
 
 import (
-	"inventory-supply-chain-system/db"
+	"context"
+	"errors"
+	"fmt"
+
+	"inventory-supply-chain-system/internal/authz"
 	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-// CreateUser creates a new user with a hashed password
-func CreateUser(user models.User) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-	user.Password = string(hashedPassword)
-
-	if result := db.DB.Create(&user); result.Error != nil {
-		return result.Error
-	}
+var (
+	// ErrUnknownRole is returned when assigning a role the authz package does not define
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrUnknownPermission is returned when granting a permission no route checks
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrEmailRequired is returned when a user's email would be left blank
+	ErrEmailRequired = errors.New("email is required")
+)
 
-	return nil
+// ProfileChanges holds the profile fields a user asked to change. Fields
+// left nil keep their current value.
+type ProfileChanges struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+	Phone *string `json:"phone"`
 }
 
-// GetUser retrieves a user by ID
-func GetUser(id uint) (models.User, error) {
-	var user models.User
-	if result := db.DB.First(&user, id); result.Error != nil {
-		return user, result.Error
-	}
-
-	return user, nil
+// UserService manages user accounts and what they are allowed to do
+type UserService struct {
+	store repository.Store
 }
 
-// GetUserByEmail retrieves a user by email
-func GetUserByEmail(email string) (models.User, error) {
-	var user models.User
-	if result := db.DB.Where("email = ?", email).First(&user); result.Error != nil {
-		return user, result.Error
-	}
-
-	return user, nil
+// NewUserService returns a UserService over store
+func NewUserService(store repository.Store) *UserService {
+	return &UserService{store: store}
 }
 
-// UpdateUser updates a user's details
-func UpdateUser(user models.User) error {
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
+// CreateUser creates a new user with a hashed password. MFA starts off
+// whatever the caller set; it is only turned on by enrolling.
+func (s *UserService) CreateUser(ctx context.Context, user *models.User) error {
+	user.MFAEnabled = false
+	user.MFASecret = ""
+	user.MFALastCounter = 0
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
 	}
+	user.Password = string(hashedPassword)
 
-	return nil
+	return s.store.Users().Create(ctx, user)
 }
 
-// DeleteUser deletes a user by ID
-func DeleteUser(id uint) error {
-	if result := db.DB.Delete(&models.User{}, id); result.Error != nil {
-		return result.Error
+// GetUser retrieves a user by ID
+func (s *UserService) GetUser(ctx context.Context, id uint) (models.User, error) {
+	user, err := s.store.Users().Get(ctx, id)
+	if err != nil {
+		return models.User{}, err
 	}
 
-	return nil
+	return *user, nil
 }
 
-// ListUsers retrieves all users
-func ListUsers() ([]models.User, error) {
-	var users []models.User
-	if result := db.DB.Find(&users); result.Error != nil {
-		return users, result.Error
+// GetUserByEmail retrieves a user by email
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	user, err := s.store.Users().GetByEmail(ctx, email)
+	if err != nil {
+		return models.User{}, err
 	}
 
-	return users, nil
+	return *user, nil
 }
 
-// LoginUser verifies a user's credentials and logs them in
-func LoginUser(email, password string) (models.User, error) {
-	var user models.User
-	if result := db.DB.Where("email = ?", email).First(&user); result.Error != nil {
-		return user, result.Error
+// UpdateUser updates a user's details. Credentials, role, permissions,
+// verification and MFA have their own operations and are left unchanged,
+// except that a new email address has to be verified again.
+func (s *UserService) UpdateUser(ctx context.Context, user models.User) error {
+	if user.Email == "" {
+		return ErrEmailRequired
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return user, err
-	}
-
-	return user, nil
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		current, err := tx.Users().Get(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Users().Save(ctx, &user, "password", "role", "permissions", "verified", "verification_sent_at",
+			"mfa_enabled", "mfa_secret", "mfa_last_counter", "token_version", "created_at"); err != nil {
+			return err
+		}
+		if user.Email == current.Email {
+			return nil
+		}
+		return unverifyEmail(ctx, tx, user.ID)
+	})
 }
 
-// ChangePassword changes a user's password
-func ChangePassword(id uint, password string) error {
-	var user models.User
-	if result := db.DB.First(&user, id); result.Error != nil {
-		return result.Error
-	}
+// UpdateProfile applies the fields set in changes to a user's profile and
+// returns the updated user. It reports whether the email address changed:
+// the new address is unverified until the user confirms it, and their
+// sessions end as when an administrator unverifies them.
+func (s *UserService) UpdateProfile(ctx context.Context, id uint, changes ProfileChanges) (models.User, bool, error) {
+	if changes.Email != nil && *changes.Email == "" {
+		return models.User{}, false, ErrEmailRequired
+	}
+
+	var user *models.User
+	var emailChanged bool
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		current, err := tx.Users().Get(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-	user.Password = string(hashedPassword)
+		values := map[string]interface{}{}
+		if changes.Name != nil {
+			values["name"] = *changes.Name
+		}
+		if changes.Phone != nil {
+			values["phone"] = *changes.Phone
+		}
+		emailChanged = changes.Email != nil && *changes.Email != current.Email
+		if emailChanged {
+			values["email"] = *changes.Email
+		}
+		if len(values) > 0 {
+			if err := tx.Users().Update(ctx, id, values); err != nil {
+				return err
+			}
+		}
+		if emailChanged {
+			if err := unverifyEmail(ctx, tx, id); err != nil {
+				return err
+			}
+		}
 
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
+		user, err = tx.Users().Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return models.User{}, false, err
 	}
 
-	return nil
+	return *user, emailChanged, nil
 }
 
-// ResetPassword resets a user's password by email
-func ResetPassword(email, password string) error {
-	var user models.User
-	if result := db.DB.Where("email = ?", email).First(&user); result.Error != nil {
-		return result.Error
-	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+// unverifyEmail marks a user's email address as unverified after it
+// changed, so a new link can be sent at once, and ends their sessions
+func unverifyEmail(ctx context.Context, tx repository.Store, id uint) error {
+	if err := tx.Users().Update(ctx, id, map[string]interface{}{"verified": false, "verification_sent_at": nil}); err != nil {
 		return err
 	}
-	user.Password = string(hashedPassword)
-
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
-	}
+	return revokeAllSessions(ctx, tx, id)
+}
 
-	return nil
+// DeleteUser deletes a user by ID and ends their sessions
+func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := revokeAllSessions(ctx, tx, id); err != nil {
+			return err
+		}
+		return tx.Users().Delete(ctx, id)
+	})
 }
 
-// VerifyUser verifies a user's email
-func VerifyUser(email string) error {
-	var user models.User
-	if result := db.DB.Where("email = ?", email).First(&user); result.Error != nil {
-		return result.Error
-	}
+// ListUsers retrieves one page of users
+func (s *UserService) ListUsers(ctx context.Context, page repository.PageRequest) (repository.Page[models.User], error) {
+	return s.store.Users().List(ctx, page)
+}
 
-	user.Verified = true
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
-	}
+// ChangePassword changes a user's password and, as a password reset does,
+// ends every session of the user in the same transaction
+func (s *UserService) ChangePassword(ctx context.Context, id uint, password string) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		user, err := tx.Users().Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := ValidatePassword(password, user.Email); err != nil {
+			return err
+		}
 
-	return nil
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		if err := tx.Users().Update(ctx, user.ID, map[string]interface{}{"password": string(hashedPassword)}); err != nil {
+			return err
+		}
+		return revokeAllSessions(ctx, tx, user.ID)
+	})
 }
 
-// UnverifyUser unverifies a user's email
-func UnverifyUser(email string) error {
-	var user models.User
-	if result := db.DB.Where("email = ?", email).First(&user); result.Error != nil {
-		return result.Error
+// UnverifyUser unverifies a user's email and invalidates their tokens
+func (s *UserService) UnverifyUser(ctx context.Context, email string) error {
+	user, err := s.store.Users().GetByEmail(ctx, email)
+	if err != nil {
+		return err
 	}
 
 	user.Verified = false
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
-	}
-
-	return nil
+	user.TokenVersion++
+	return s.store.Users().Save(ctx, user)
 }
 
 // AddRole adds a role to a user
-func AddRole(id uint, role string) error {
-	var user models.User
-	if result := db.DB.First(&user, id); result.Error != nil {
-		return result.Error
+func (s *UserService) AddRole(ctx context.Context, id uint, role string) error {
+	if !authz.IsRole(role) {
+		return fmt.Errorf("%w: %q", ErrUnknownRole, role)
 	}
 
-	user.Role = role
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
+	user, err := s.store.Users().Get(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	user.Role = role
+	user.TokenVersion++
+	return s.store.Users().Save(ctx, user)
 }
 
-// RemoveRole removes a role from a user
-func RemoveRole(id uint) error {
-	var user models.User
-	if result := db.DB.First(&user, id); result.Error != nil {
-		return result.Error
+// RemoveRole removes a role from a user, invalidating the tokens that carried it
+func (s *UserService) RemoveRole(ctx context.Context, id uint) error {
+	user, err := s.store.Users().Get(ctx, id)
+	if err != nil {
+		return err
 	}
 
 	user.Role = ""
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
-	}
-
-	return nil
+	user.TokenVersion++
+	return s.store.Users().Save(ctx, user)
 }
 
 // AddPermission adds a permission to a user
-func AddPermission(id uint, permission string) error {
-	var user models.User
-	if result := db.DB.First(&user, id); result.Error != nil {
-		return result.Error
+func (s *UserService) AddPermission(ctx context.Context, id uint, permission string) error {
+	if !authz.IsPermission(permission) {
+		return fmt.Errorf("%w: %q", ErrUnknownPermission, permission)
 	}
 
-	user.Permissions = append(user.Permissions, permission)
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
+	user, err := s.store.Users().Get(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	for _, p := range user.Permissions {
+		if p == permission {
+			return nil
+		}
+	}
+
+	user.Permissions = append(user.Permissions, permission)
+	user.TokenVersion++
+	return s.store.Users().Save(ctx, user)
 }
 
 // RemovePermission removes a permission from a user
-func RemovePermission(id uint, permission string) error {
-	var user models.User
-	if result := db.DB.First(&user, id); result.Error != nil {
-		return result.Error
+func (s *UserService) RemovePermission(ctx context.Context, id uint, permission string) error {
+	user, err := s.store.Users().Get(ctx, id)
+	if err != nil {
+		return err
 	}
 
 	for i, p := range user.Permissions {
 		if p == permission {
 			user.Permissions = append(user.Permissions[:i], user.Permissions[i+1:]...)
+			user.TokenVersion++
 			break
 		}
 	}
 
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
-	}
-
-	return nil
+	return s.store.Users().Save(ctx, user)
 }
 
 // AddAddress adds an address to a user
-func AddAddress(id uint, address models.Address) error {
-	var user models.User
-	if result := db.DB.First(&user, id); result.Error != nil {
-		return result.Error
+func (s *UserService) AddAddress(ctx context.Context, id uint, address models.Address) error {
+	user, err := s.store.Users().Get(ctx, id)
+	if err != nil {
+		return err
 	}
 
 	user.Addresses = append(user.Addresses, address)
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
-	}
-
-	return nil
+	return s.store.Users().Save(ctx, user)
 }
 
 // RemoveAddress removes an address from a user
-func RemoveAddress(id uint, address models.Address) error {
-	var user models.User
-	if result := db.DB.First(&user, id); result.Error != nil {
-		return result.Error
+func (s *UserService) RemoveAddress(ctx context.Context, id uint, address models.Address) error {
+	user, err := s.store.Users().Get(ctx, id)
+	if err != nil {
+		return err
 	}
 
 	for i, a := range user.Addresses {
@@ -249,9 +300,28 @@ func RemoveAddress(id uint, address models.Address) error {
 		}
 	}
 
-	if result := db.DB.Save(&user); result.Error != nil {
-		return result.Error
+	return s.store.Users().Save(ctx, user)
+}
+
+// BootstrapAdmin makes sure the user with the given email is an admin,
+// creating the account if it does not exist yet. It is meant to be run at
+// startup so a fresh install has someone who can assign roles; an existing
+// account's password is left unchanged and its email is treated as verified.
+func (s *UserService) BootstrapAdmin(ctx context.Context, email, password string) error {
+	if email == "" || password == "" {
+		return errors.New("admin email and password are required")
 	}
 
-	return nil
+	user, err := s.store.Users().GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.CreateUser(ctx, &models.User{Name: "Administrator", Email: email, Password: password, Role: authz.RoleAdmin, Verified: true})
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.Role == authz.RoleAdmin && user.Verified {
+		return nil
+	}
+	return s.store.Users().Update(ctx, user.ID, map[string]interface{}{"role": authz.RoleAdmin, "verified": true})
 }
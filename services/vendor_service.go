@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+// VendorService manages vendors
+type VendorService struct {
+	vendors repository.VendorRepository
+}
+
+// NewVendorService returns a VendorService over vendors
+func NewVendorService(vendors repository.VendorRepository) *VendorService {
+	return &VendorService{vendors: vendors}
+}
+
+// CreateVendor creates a new vendor
+func (s *VendorService) CreateVendor(ctx context.Context, vendor *models.Vendor) error {
+	return s.vendors.Create(ctx, vendor)
+}
+
+// GetVendors fetches every vendor
+func (s *VendorService) GetVendors(ctx context.Context) ([]models.Vendor, error) {
+	return s.vendors.All(ctx)
+}
+
+// GetVendor fetches a vendor by its ID
+func (s *VendorService) GetVendor(ctx context.Context, id uint) (*models.Vendor, error) {
+	return s.vendors.Get(ctx, id)
+}
+
+// UpdateVendor updates a vendor, provided it still has vendor.Version
+func (s *VendorService) UpdateVendor(ctx context.Context, vendor *models.Vendor) error {
+	return s.vendors.Update(ctx, vendor)
+}
+
+// DeleteVendor deletes a vendor, provided it still has version
+func (s *VendorService) DeleteVendor(ctx context.Context, id uint, version int) error {
+	return s.vendors.Delete(ctx, id, version)
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/pkg/notifier"
+	"inventory-supply-chain-system/pkg/utils"
+	"inventory-supply-chain-system/repository"
+)
+
+const (
+	// VerificationTTL is how long an email verification link can be used
+	VerificationTTL = 48 * time.Hour
+	// VerificationResendInterval is how long a user has to wait between
+	// verification emails
+	VerificationResendInterval = 2 * time.Minute
+
+	verifyEmailPurpose = "verify_email"
+)
+
+// Verification policies decide what unverified users can do
+const (
+	// VerificationBlock refuses to log unverified users in
+	VerificationBlock = "block"
+	// VerificationRestrict logs them in with read permissions only
+	VerificationRestrict = "restrict"
+	// VerificationOff treats them like verified users
+	VerificationOff = "off"
+)
+
+var (
+	// ErrEmailNotVerified is returned when the verification policy keeps an unverified user out
+	ErrEmailNotVerified = errors.New("email address is not verified")
+	// ErrInvalidVerificationToken is returned for malformed, expired or outdated verification links
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	// ErrTooManyRequests is returned when an action is repeated too soon
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// RetryError is returned when a request is refused for now but can be
+// retried after RetryAfter
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v, retry in %s", e.Err, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// VerificationService confirms that users own the email address they
+// registered with
+type VerificationService struct {
+	store    repository.Store
+	notifier notifier.Notifier
+}
+
+// NewVerificationService returns a VerificationService over store that mails
+// verification links through n
+func NewVerificationService(store repository.Store, n notifier.Notifier) *VerificationService {
+	return &VerificationService{store: store, notifier: n}
+}
+
+// SendVerification emails a verification link to a newly registered user
+func (s *VerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	now := time.Now()
+	if err := s.store.Users().Update(ctx, user.ID, map[string]interface{}{"verification_sent_at": now}); err != nil {
+		return err
+	}
+	user.VerificationSentAt = &now
+	return s.sendVerification(user)
+}
+
+// ResendVerification emails a fresh verification link. A user gets at most
+// one every VerificationResendInterval. Unknown and already verified
+// addresses and requests that come too soon are all ignored without an
+// error, so the response does not reveal which accounts exist.
+func (s *VerificationService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.store.Users().GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Verified {
+		return nil
+	}
+
+	now := time.Now()
+	claimed, err := s.store.Users().ClaimVerificationSend(ctx, user.ID, now, now.Add(-VerificationResendInterval))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	return s.sendVerification(user)
+}
+
+// ConfirmEmail marks the user a verification token was issued to as
+// verified. A token only works for the address it was sent to.
+func (s *VerificationService) ConfirmEmail(ctx context.Context, token string) error {
+	claims, err := utils.ValidatePurposeToken(token, verifyEmailPurpose)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := s.store.Users().Get(ctx, uint(userID))
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+	if user.Email != claims.Email {
+		return ErrInvalidVerificationToken
+	}
+	if user.Verified {
+		return nil
+	}
+
+	return s.store.Users().Update(ctx, user.ID, map[string]interface{}{"verified": true})
+}
+
+func (s *VerificationService) sendVerification(user *models.User) error {
+	token, err := utils.GeneratePurposeToken(verifyEmailPurpose, strconv.FormatUint(uint64(user.ID), 10), user.Email, VerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	action := "Use this token to confirm your email address: " + token
+	if base := os.Getenv("VERIFICATION_URL"); base != "" {
+		action = "Follow this link to confirm your email address: " + base + token
+	}
+	return s.notifier.Send(notifier.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body:    fmt.Sprintf("Welcome! %s\n\nThe link expires in %s.", action, VerificationTTL),
+	})
+}
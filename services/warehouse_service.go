@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+)
+
+var (
+	// ErrInvalidWarehouse is returned when warehouse or location data is malformed
+	ErrInvalidWarehouse = errors.New("invalid warehouse")
+	// ErrLocationNotEmpty is returned when deleting a location that still holds stock
+	ErrLocationNotEmpty = errors.New("location still holds stock")
+	// ErrLocationUnavailable is returned when stock is moved to or from an
+	// inactive warehouse or a bin that is not part of the given warehouse
+	ErrLocationUnavailable = errors.New("location unavailable")
+)
+
+// WarehouseService manages warehouses and their zones, aisles and bins
+type WarehouseService struct {
+	store repository.Store
+}
+
+// NewWarehouseService returns a WarehouseService over store
+func NewWarehouseService(store repository.Store) *WarehouseService {
+	return &WarehouseService{store: store}
+}
+
+// CreateWarehouse creates a warehouse after validating its code and timezone
+func (s *WarehouseService) CreateWarehouse(ctx context.Context, warehouse *models.Warehouse) error {
+	if err := validateWarehouse(warehouse); err != nil {
+		return err
+	}
+	warehouse.Zones = nil
+	return s.store.Warehouses().Create(ctx, warehouse)
+}
+
+// GetWarehouses fetches one page of warehouses
+func (s *WarehouseService) GetWarehouses(ctx context.Context, page repository.PageRequest) (repository.Page[models.Warehouse], error) {
+	return s.store.Warehouses().List(ctx, page)
+}
+
+// GetWarehouseByID fetches a warehouse with its zones, aisles and bins
+func (s *WarehouseService) GetWarehouseByID(ctx context.Context, id uint) (*models.Warehouse, error) {
+	return s.store.Warehouses().GetWithLocations(ctx, id)
+}
+
+// UpdateWarehouse updates a warehouse's own fields; its locations are managed separately
+func (s *WarehouseService) UpdateWarehouse(ctx context.Context, warehouse *models.Warehouse) error {
+	if err := validateWarehouse(warehouse); err != nil {
+		return err
+	}
+	return s.store.Warehouses().Save(ctx, warehouse)
+}
+
+// DeleteWarehouse deletes a warehouse and its locations once it holds no stock
+func (s *WarehouseService) DeleteWarehouse(ctx context.Context, id uint) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := ensureNoStock(ctx, tx, repository.Location{WarehouseID: id}); err != nil {
+			return err
+		}
+		return tx.Warehouses().Delete(ctx, id)
+	})
+}
+
+// GetWarehouseStock lists the non-zero stock balances held in a warehouse
+func (s *WarehouseService) GetWarehouseStock(ctx context.Context, warehouseID uint) ([]models.StockBalance, error) {
+	return s.store.Stock().WarehouseBalances(ctx, warehouseID)
+}
+
+// CreateZone adds a zone to a warehouse
+func (s *WarehouseService) CreateZone(ctx context.Context, zone *models.Zone) error {
+	if zone.Code == "" {
+		return fmt.Errorf("%w: zone code is required", ErrInvalidWarehouse)
+	}
+	if _, err := s.store.Warehouses().Get(ctx, zone.WarehouseID); err != nil {
+		return err
+	}
+	zone.Aisles = nil
+	return s.store.Warehouses().CreateZone(ctx, zone)
+}
+
+// GetZones lists the zones of a warehouse
+func (s *WarehouseService) GetZones(ctx context.Context, warehouseID uint) ([]models.Zone, error) {
+	return s.store.Warehouses().Zones(ctx, warehouseID)
+}
+
+// GetZone fetches a zone of a warehouse
+func (s *WarehouseService) GetZone(ctx context.Context, warehouseID, zoneID uint) (*models.Zone, error) {
+	return s.store.Warehouses().GetZone(ctx, warehouseID, zoneID)
+}
+
+// UpdateZone renames a zone; it cannot be moved to another warehouse
+func (s *WarehouseService) UpdateZone(ctx context.Context, zone *models.Zone) error {
+	if zone.Code == "" {
+		return fmt.Errorf("%w: zone code is required", ErrInvalidWarehouse)
+	}
+	return s.store.Warehouses().UpdateZone(ctx, zone)
+}
+
+// DeleteZone deletes a zone and its aisles and bins once none of them hold stock
+func (s *WarehouseService) DeleteZone(ctx context.Context, zone *models.Zone) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := ensureNoStock(ctx, tx, repository.Location{ZoneID: zone.ID}); err != nil {
+			return err
+		}
+		return tx.Warehouses().DeleteZone(ctx, zone)
+	})
+}
+
+// CreateAisle adds an aisle to a zone
+func (s *WarehouseService) CreateAisle(ctx context.Context, aisle *models.Aisle) error {
+	if aisle.Code == "" {
+		return fmt.Errorf("%w: aisle code is required", ErrInvalidWarehouse)
+	}
+	aisle.Bins = nil
+	return s.store.Warehouses().CreateAisle(ctx, aisle)
+}
+
+// GetAisles lists the aisles of a zone
+func (s *WarehouseService) GetAisles(ctx context.Context, zoneID uint) ([]models.Aisle, error) {
+	return s.store.Warehouses().Aisles(ctx, zoneID)
+}
+
+// GetAisle fetches an aisle of a zone
+func (s *WarehouseService) GetAisle(ctx context.Context, zoneID, aisleID uint) (*models.Aisle, error) {
+	return s.store.Warehouses().GetAisle(ctx, zoneID, aisleID)
+}
+
+// UpdateAisle renames an aisle; it cannot be moved to another zone
+func (s *WarehouseService) UpdateAisle(ctx context.Context, aisle *models.Aisle) error {
+	if aisle.Code == "" {
+		return fmt.Errorf("%w: aisle code is required", ErrInvalidWarehouse)
+	}
+	return s.store.Warehouses().UpdateAisle(ctx, aisle)
+}
+
+// DeleteAisle deletes an aisle and its bins once none of them hold stock
+func (s *WarehouseService) DeleteAisle(ctx context.Context, aisle *models.Aisle) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := ensureNoStock(ctx, tx, repository.Location{AisleID: aisle.ID}); err != nil {
+			return err
+		}
+		return tx.Warehouses().DeleteAisle(ctx, aisle)
+	})
+}
+
+// CreateBin adds a bin to an aisle, recording the warehouse the aisle belongs to
+func (s *WarehouseService) CreateBin(ctx context.Context, bin *models.Bin) error {
+	if bin.Code == "" {
+		return fmt.Errorf("%w: bin code is required", ErrInvalidWarehouse)
+	}
+
+	zone, err := s.store.Warehouses().ZoneOfAisle(ctx, bin.AisleID)
+	if err != nil {
+		return err
+	}
+	bin.WarehouseID = zone.WarehouseID
+
+	return s.store.Warehouses().CreateBin(ctx, bin)
+}
+
+// GetBins lists the bins of an aisle
+func (s *WarehouseService) GetBins(ctx context.Context, aisleID uint) ([]models.Bin, error) {
+	return s.store.Warehouses().Bins(ctx, aisleID)
+}
+
+// GetBin fetches a bin of an aisle
+func (s *WarehouseService) GetBin(ctx context.Context, aisleID, binID uint) (*models.Bin, error) {
+	return s.store.Warehouses().GetBin(ctx, aisleID, binID)
+}
+
+// UpdateBin renames a bin; it cannot be moved to another aisle
+func (s *WarehouseService) UpdateBin(ctx context.Context, bin *models.Bin) error {
+	if bin.Code == "" {
+		return fmt.Errorf("%w: bin code is required", ErrInvalidWarehouse)
+	}
+	return s.store.Warehouses().UpdateBin(ctx, bin)
+}
+
+// DeleteBin deletes a bin once it holds no stock
+func (s *WarehouseService) DeleteBin(ctx context.Context, bin *models.Bin) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := ensureNoStock(ctx, tx, repository.Location{BinID: bin.ID}); err != nil {
+			return err
+		}
+		return tx.Warehouses().DeleteBin(ctx, bin)
+	})
+}
+
+// validateWarehouse checks the required fields of a warehouse and defaults its timezone to UTC
+func validateWarehouse(warehouse *models.Warehouse) error {
+	if warehouse.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidWarehouse)
+	}
+	if warehouse.Timezone == "" {
+		warehouse.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(warehouse.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidWarehouse, warehouse.Timezone)
+	}
+	return nil
+}
+
+// ensureNoStock fails with ErrLocationNotEmpty if anything at or below location holds stock
+func ensureNoStock(ctx context.Context, tx repository.Store, location repository.Location) error {
+	held, err := tx.Stock().HoldsStock(ctx, location)
+	if err != nil {
+		return err
+	}
+	if held {
+		return ErrLocationNotEmpty
+	}
+	return nil
+}
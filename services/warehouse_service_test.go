@@ -0,0 +1,221 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"inventory-supply-chain-system/models"
+	"inventory-supply-chain-system/repository"
+	"inventory-supply-chain-system/services"
+)
+
+// warehouseFixture is a store with one product whose SKU has an inventory
+// item, for services that move its stock between locations
+type warehouseFixture struct {
+	t          *testing.T
+	store      repository.Store
+	warehouses *services.WarehouseService
+	inventory  *services.InventoryService
+	item       models.Inventory
+}
+
+func newWarehouseFixture(t *testing.T) *warehouseFixture {
+	t.Helper()
+	store := newStore(t)
+	f := &warehouseFixture{
+		t:          t,
+		store:      store,
+		warehouses: services.NewWarehouseService(store),
+		inventory:  services.NewInventoryService(store),
+	}
+
+	product := models.Product{Name: "Widget", SKUs: []models.SKU{{Code: "W-1", Price: 1}}}
+	if err := services.NewProductService(store).CreateProduct(context.Background(), &product); err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	f.item = models.Inventory{SKUID: product.SKUs[0].ID}
+	if err := f.inventory.CreateInventoryItem(context.Background(), &f.item, 0); err != nil {
+		t.Fatalf("CreateInventoryItem: %v", err)
+	}
+	return f
+}
+
+// warehouse creates an active warehouse with one zone, aisle and bin
+func (f *warehouseFixture) warehouse(code string) (models.Warehouse, models.Bin) {
+	f.t.Helper()
+	ctx := context.Background()
+	warehouse := models.Warehouse{Code: code, Active: true}
+	if err := f.warehouses.CreateWarehouse(ctx, &warehouse); err != nil {
+		f.t.Fatalf("CreateWarehouse %s: %v", code, err)
+	}
+	zone := models.Zone{WarehouseID: warehouse.ID, Code: "Z"}
+	if err := f.warehouses.CreateZone(ctx, &zone); err != nil {
+		f.t.Fatalf("CreateZone: %v", err)
+	}
+	aisle := models.Aisle{ZoneID: zone.ID, Code: "A"}
+	if err := f.warehouses.CreateAisle(ctx, &aisle); err != nil {
+		f.t.Fatalf("CreateAisle: %v", err)
+	}
+	bin := models.Bin{AisleID: aisle.ID, Code: "B"}
+	if err := f.warehouses.CreateBin(ctx, &bin); err != nil {
+		f.t.Fatalf("CreateBin: %v", err)
+	}
+	return warehouse, bin
+}
+
+// receive books quantity units of the item into a warehouse and bin
+func (f *warehouseFixture) receive(warehouseID, binID uint, quantity int) {
+	f.t.Helper()
+	err := f.inventory.RecordStockMovement(context.Background(), &models.StockMovement{
+		InventoryID: f.item.ID,
+		WarehouseID: warehouseID,
+		BinID:       binID,
+		Type:        models.MovementTypeReceipt,
+		Quantity:    quantity,
+	})
+	if err != nil {
+		f.t.Fatalf("receiving %d units: %v", quantity, err)
+	}
+}
+
+// held returns the quantity of the item held at a warehouse and bin
+func (f *warehouseFixture) held(warehouseID, binID uint) int {
+	f.t.Helper()
+	balance, err := f.store.Stock().Balance(context.Background(), f.item.ID, warehouseID, binID, 0)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return balance.Quantity
+}
+
+// deactivate marks a warehouse inactive
+func (f *warehouseFixture) deactivate(warehouse models.Warehouse) {
+	f.t.Helper()
+	warehouse.Active = false
+	warehouse.Zones = nil
+	if err := f.warehouses.UpdateWarehouse(context.Background(), &warehouse); err != nil {
+		f.t.Fatalf("UpdateWarehouse: %v", err)
+	}
+}
+
+func TestCreateWarehouseValidates(t *testing.T) {
+	f := newWarehouseFixture(t)
+	ctx := context.Background()
+
+	for _, warehouse := range []models.Warehouse{
+		{},
+		{Code: "WH-X", Timezone: "Mars/Olympus"},
+	} {
+		if err := f.warehouses.CreateWarehouse(ctx, &warehouse); !errors.Is(err, services.ErrInvalidWarehouse) {
+			t.Errorf("CreateWarehouse(%+v) = %v, want ErrInvalidWarehouse", warehouse, err)
+		}
+	}
+
+	warehouse := models.Warehouse{Code: "WH-1"}
+	if err := f.warehouses.CreateWarehouse(ctx, &warehouse); err != nil {
+		t.Fatal(err)
+	}
+	if warehouse.Timezone != "UTC" {
+		t.Errorf("timezone = %q, want it to default to UTC", warehouse.Timezone)
+	}
+	duplicate := models.Warehouse{Code: "WH-1"}
+	if err := f.warehouses.CreateWarehouse(ctx, &duplicate); !errors.Is(err, repository.ErrDuplicate) {
+		t.Errorf("CreateWarehouse with a taken code = %v, want ErrDuplicate", err)
+	}
+	if err := f.warehouses.CreateZone(ctx, &models.Zone{WarehouseID: warehouse.ID}); !errors.Is(err, services.ErrInvalidWarehouse) {
+		t.Errorf("CreateZone without a code = %v, want ErrInvalidWarehouse", err)
+	}
+	if err := f.warehouses.CreateZone(ctx, &models.Zone{WarehouseID: warehouse.ID + 100, Code: "Z"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("CreateZone in an unknown warehouse = %v, want ErrNotFound", err)
+	}
+}
+
+func TestBinsBelongToTheirAislesWarehouse(t *testing.T) {
+	f := newWarehouseFixture(t)
+	north, northBin := f.warehouse("NORTH")
+	south, _ := f.warehouse("SOUTH")
+
+	if northBin.WarehouseID != north.ID {
+		t.Fatalf("bin warehouse = %d, want %d", northBin.WarehouseID, north.ID)
+	}
+	got, err := f.warehouses.GetWarehouseByID(context.Background(), north.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Zones) != 1 || len(got.Zones[0].Aisles) != 1 || len(got.Zones[0].Aisles[0].Bins) != 1 {
+		t.Fatalf("warehouse locations = %+v, want one zone, aisle and bin", got.Zones)
+	}
+
+	// Stock can go into a warehouse's own bin, not another's
+	err = f.inventory.RecordStockMovement(context.Background(), &models.StockMovement{
+		InventoryID: f.item.ID, WarehouseID: south.ID, BinID: northBin.ID, Type: models.MovementTypeReceipt, Quantity: 1,
+	})
+	if !errors.Is(err, services.ErrLocationUnavailable) {
+		t.Errorf("receiving into another warehouse's bin = %v, want ErrLocationUnavailable", err)
+	}
+	f.receive(north.ID, northBin.ID, 4)
+	if held := f.held(north.ID, northBin.ID); held != 4 {
+		t.Errorf("bin holds %d, want 4", held)
+	}
+}
+
+func TestLocationsHoldingStockAreNotDeleted(t *testing.T) {
+	f := newWarehouseFixture(t)
+	ctx := context.Background()
+	warehouse, bin := f.warehouse("NORTH")
+	located, err := f.warehouses.GetWarehouseByID(ctx, warehouse.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zone, aisle := located.Zones[0], located.Zones[0].Aisles[0]
+	f.receive(warehouse.ID, bin.ID, 3)
+
+	deletes := map[string]func() error{
+		"bin":       func() error { return f.warehouses.DeleteBin(ctx, &bin) },
+		"aisle":     func() error { return f.warehouses.DeleteAisle(ctx, &aisle) },
+		"zone":      func() error { return f.warehouses.DeleteZone(ctx, &zone) },
+		"warehouse": func() error { return f.warehouses.DeleteWarehouse(ctx, warehouse.ID) },
+	}
+	for name, del := range deletes {
+		if err := del(); !errors.Is(err, services.ErrLocationNotEmpty) {
+			t.Errorf("deleting a %s holding stock = %v, want ErrLocationNotEmpty", name, err)
+		}
+	}
+
+	err = f.inventory.RecordStockMovement(ctx, &models.StockMovement{
+		InventoryID: f.item.ID, WarehouseID: warehouse.ID, BinID: bin.ID, Type: models.MovementTypeIssue, Quantity: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.warehouses.DeleteBin(ctx, &bin); err != nil {
+		t.Errorf("deleting an empty bin: %v", err)
+	}
+	if err := f.warehouses.DeleteWarehouse(ctx, warehouse.ID); err != nil {
+		t.Errorf("deleting an empty warehouse: %v", err)
+	}
+	if _, err := f.warehouses.GetWarehouseByID(ctx, warehouse.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetWarehouseByID after the delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestInactiveWarehousesTakeNoMovements(t *testing.T) {
+	f := newWarehouseFixture(t)
+	warehouse, bin := f.warehouse("NORTH")
+	f.receive(warehouse.ID, bin.ID, 5)
+	f.deactivate(warehouse)
+
+	for _, movement := range []models.StockMovement{
+		{Type: models.MovementTypeReceipt, Quantity: 1},
+		{Type: models.MovementTypeIssue, Quantity: 1},
+	} {
+		movement.InventoryID, movement.WarehouseID, movement.BinID = f.item.ID, warehouse.ID, bin.ID
+		if err := f.inventory.RecordStockMovement(context.Background(), &movement); !errors.Is(err, services.ErrLocationUnavailable) {
+			t.Errorf("%s at an inactive warehouse = %v, want ErrLocationUnavailable", movement.Type, err)
+		}
+	}
+	if held := f.held(warehouse.ID, bin.ID); held != 5 {
+		t.Errorf("bin holds %d after refused movements, want 5", held)
+	}
+}